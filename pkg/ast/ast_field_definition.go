@@ -150,3 +150,12 @@ func (d *Document) RemoveFieldDefinitionsFromObjectTypeDefinition(fieldDefinitio
 	}
 	d.ObjectTypeDefinitions[objectTypeDefinitionRef].HasFieldDefinitions = len(d.ObjectTypeDefinitions[objectTypeDefinitionRef].FieldsDefinition.Refs) > 0
 }
+
+func (d *Document) RemoveFieldDefinitionsFromInterfaceTypeDefinition(fieldDefinitionRefs []int, interfaceTypeDefinitionRef int) {
+	for _, fieldRef := range fieldDefinitionRefs {
+		if i, ok := indexOf(d.InterfaceTypeDefinitions[interfaceTypeDefinitionRef].FieldsDefinition.Refs, fieldRef); ok {
+			deleteRef(&d.InterfaceTypeDefinitions[interfaceTypeDefinitionRef].FieldsDefinition.Refs, i)
+		}
+	}
+	d.InterfaceTypeDefinitions[interfaceTypeDefinitionRef].HasFieldDefinitions = len(d.InterfaceTypeDefinitions[interfaceTypeDefinitionRef].FieldsDefinition.Refs) > 0
+}
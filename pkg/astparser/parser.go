@@ -1600,7 +1600,10 @@ func (p *Parser) parseSchemaExtension(extend position.Position) {
 		schemaDefinition.Directives = p.parseDirectiveList()
 		schemaDefinition.HasDirectives = len(schemaDefinition.Directives.Refs) > 0
 	}
-	p.parseRootOperationTypeDefinitionList(&schemaDefinition.RootOperationTypeDefinitions)
+	// a schema extension with directives might omit the root operation types, e.g. extend schema @link(url: "...")
+	if !schemaDefinition.HasDirectives || p.peekEquals(keyword.LBRACE) {
+		p.parseRootOperationTypeDefinitionList(&schemaDefinition.RootOperationTypeDefinitions)
+	}
 
 	schemaExtension := ast.SchemaExtension{
 		ExtendLiteral:    extend,
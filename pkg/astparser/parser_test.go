@@ -436,6 +436,26 @@ func TestParser_Parse(t *testing.T) {
 					}
 				})
 		})
+		t.Run("with directives only", func(t *testing.T) {
+			run(`extend schema @link(url: "https://specs.apollo.dev/federation/v2.0")
+					type Query { hello: String }`, parse, false,
+				func(doc *ast.Document, extra interface{}) {
+					schema := doc.SchemaExtensions[0]
+					if len(schema.RootOperationTypeDefinitions.Refs) != 0 {
+						panic("want no root operation types")
+					}
+					if doc.DirectiveNameString(schema.Directives.Refs[0]) != "link" {
+						panic("want directive link")
+					}
+					if len(doc.ObjectTypeDefinitions) != 1 {
+						panic("want object type definition Query")
+					}
+				})
+		})
+		t.Run("without directives and root operation types", func(t *testing.T) {
+			run(`extend schema
+					type Query { hello: String }`, parse, true)
+		})
 	})
 	t.Run("object type extension", func(t *testing.T) {
 		t.Run("complex", func(t *testing.T) {
@@ -174,6 +174,10 @@ func (p *printVisitor) LeaveDirective(ref int) {
 		ast.NodeKindFieldDefinition,
 		ast.NodeKindInputValueDefinition:
 		return
+	case ast.NodeKindSchemaExtension:
+		if len(p.document.SchemaExtensions[ancestor.Ref].RootOperationTypeDefinitions.Refs) != 0 {
+			p.write(literal.SPACE)
+		}
 	default:
 		p.write(literal.SPACE)
 	}
@@ -883,6 +887,11 @@ func (p *printVisitor) EnterDirectiveLocation(location ast.DirectiveLocation) {
 
 	if p.isFirstDirectiveLocation {
 		p.isFirstDirectiveLocation = false
+		if ancestor := p.Ancestors[len(p.Ancestors)-1]; ancestor.Kind == ast.NodeKindDirectiveDefinition &&
+			p.document.DirectiveDefinitions[ancestor.Ref].Repeatable.IsRepeatable {
+			p.write(literal.SPACE)
+			p.write(literal.REPEATABLE)
+		}
 		p.write(literal.SPACE)
 		p.write(literal.ON)
 		p.write(literal.SPACE)
@@ -966,10 +975,12 @@ func (p *printVisitor) EnterSchemaExtension(ref int) {
 }
 
 func (p *printVisitor) LeaveSchemaExtension(ref int) {
-	if p.indent != nil {
-		p.write(literal.LINETERMINATOR)
+	if len(p.document.SchemaExtensions[ref].RootOperationTypeDefinitions.Refs) != 0 {
+		if p.indent != nil {
+			p.write(literal.LINETERMINATOR)
+		}
+		p.write(literal.RBRACE)
 	}
-	p.write(literal.RBRACE)
 	if !p.document.NodeIsLastRootNode(ast.Node{Kind: ast.NodeKindSchemaExtension, Ref: ref}) {
 		if p.indent != nil {
 			p.write(literal.LINETERMINATOR)
@@ -126,6 +126,10 @@ Examples: 'jwt.sub', 'jwt.team' to vary the cache key based on 'sub' or 'team' f
 """
 vary: [String]! = []) on QUERY`)
 	})
+	t.Run("repeatable directive definition", func(t *testing.T) {
+		run(t, `directive @tag(name: String!) repeatable on OBJECT | FIELD_DEFINITION`,
+			`directive @tag(name: String!) repeatable on OBJECT | FIELD_DEFINITION`)
+	})
 	t.Run("anonymous query", func(t *testing.T) {
 		run(t, `	{
 						dog {
@@ -189,6 +193,13 @@ vary: [String]! = []) on QUERY`)
 					subscription: Subscription
 				}`, `extend schema @foo {query: Query mutation: Mutation subscription: Subscription}`)
 	})
+	t.Run("schema extension with directives only", func(t *testing.T) {
+		run(t, `
+				extend schema @foo(bar: "baz")
+				type Query {
+					field: String
+				}`, `extend schema @foo(bar: "baz") type Query {field: String}`)
+	})
 	t.Run("object type definition", func(t *testing.T) {
 		run(t, `
 				type Foo {
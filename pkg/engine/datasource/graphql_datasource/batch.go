@@ -3,6 +3,7 @@ package graphql_datasource
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 
 	"github.com/buger/jsonparser"
 
@@ -12,7 +13,10 @@ import (
 	"github.com/pvormste/graphql-go-tools/pkg/pool"
 )
 
-var representationPath = []string{"body", "variables", "representations"}
+var (
+	representationPath  = []string{"body", "variables", "representations"}
+	entitiesPathElement = []byte("_entities")
+)
 
 type Batch struct {
 	resultedInput    *fastbuffer.FastBuffer
@@ -170,8 +174,65 @@ func (b *Batch) demultiplexBatch(responsePair *resolve.BufPair, responseMappings
 	}
 
 	if responsePair.HasErrors() {
-		resultBufPairs[0].Errors.WriteBytes(responsePair.Errors.Bytes())
+		b.demultiplexErrors(responsePair, responseMappings, resultBufPairs)
 	}
 
 	return
 }
+
+// demultiplexErrors assigns errors with an _entities path to the buffers of the entity they belong to.
+// The entity index of the path is replaced by the position of the entity in the buffer.
+// All other errors are assigned to the first buffer.
+func (b *Batch) demultiplexErrors(responsePair *resolve.BufPair, responseMappings []inputResponseBufferMappings, resultBufPairs []*resolve.BufPair) {
+	// entityPositions holds for each response index the position of the entity in each assigned buffer
+	entityPositions := make([][]int, len(responseMappings))
+	bufferLengths := make([]int, len(resultBufPairs))
+	for i := range responseMappings {
+		for _, index := range responseMappings[i].assignedBufferIndices {
+			entityPositions[i] = append(entityPositions[i], bufferLengths[index])
+			bufferLengths[index]++
+		}
+	}
+
+	errorsJson := make([]byte, 0, responsePair.Errors.Len()+2)
+	errorsJson = append(errorsJson, literal.LBRACK...)
+	errorsJson = append(errorsJson, responsePair.Errors.Bytes()...)
+	errorsJson = append(errorsJson, literal.RBRACK...)
+
+	_, _ = jsonparser.ArrayEach(errorsJson, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		responseIndex, ok := entityIndex(value)
+		if !ok || responseIndex >= len(responseMappings) {
+			writeBatchError(resultBufPairs[0], value)
+			return
+		}
+
+		for i, index := range responseMappings[responseIndex].assignedBufferIndices {
+			position := strconv.Itoa(entityPositions[responseIndex][i])
+			entityError, err := jsonparser.Set(value, []byte(position), "path", "[1]")
+			if err != nil {
+				entityError = value
+			}
+			writeBatchError(resultBufPairs[index], entityError)
+		}
+	})
+}
+
+// entityIndex returns the index of the entity an error belongs to, if the path of the error starts with _entities.
+func entityIndex(responseError []byte) (int, bool) {
+	element, _, _, err := jsonparser.Get(responseError, "path", "[0]")
+	if err != nil || !bytes.Equal(element, entitiesPathElement) {
+		return 0, false
+	}
+	index, err := jsonparser.GetInt(responseError, "path", "[1]")
+	if err != nil || index < 0 {
+		return 0, false
+	}
+	return int(index), true
+}
+
+func writeBatchError(bufPair *resolve.BufPair, responseError []byte) {
+	if bufPair.HasErrors() {
+		bufPair.Errors.WriteBytes(literal.COMMA)
+	}
+	bufPair.Errors.WriteBytes(responseError)
+}
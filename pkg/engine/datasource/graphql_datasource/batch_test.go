@@ -215,4 +215,20 @@ func TestBatch_Demultiplex(t *testing.T) {
 			},
 		)
 	})
+	t.Run("demultiplex entity errors to the buffers of the entities", func(t *testing.T) {
+		runTestDemultiplex(
+			t,
+			[]string{
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-1","__typename":"Product"}]}}}`,
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-2","__typename":"Product"}]}}}`,
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-2","__typename":"Product"}]}}}`,
+			},
+			newBufPair(`[{"name":"Name 1", "price": 1.01, "__typename":"Product"},{"name":"Name 2", "price": null, "__typename":"Product"}]`, `{"message":"no price","path":["_entities",1,"price"]},{"message":"errorMessage"}`),
+			[]*resolve.BufPair{
+				newBufPair(`{"name":"Name 1", "price": 1.01, "__typename":"Product"}`, `{"message":"errorMessage"}`),
+				newBufPair(`{"name":"Name 2", "price": null, "__typename":"Product"}`, `{"message":"no price","path":["_entities",0,"price"]}`),
+				newBufPair(`{"name":"Name 2", "price": null, "__typename":"Product"}`, `{"message":"no price","path":["_entities",0,"price"]}`),
+			},
+		)
+	})
 }
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/buger/jsonparser"
 	"github.com/pvormste/graphql-go-tools/pkg/asttransform"
@@ -38,8 +40,9 @@ type Planner struct {
 	hasFederationRoot                  bool
 	extractEntities                    bool
 	fetchClient                        *http.Client
-	subscriptionClient                 GraphQLSubscriptionClient
+	subscriptionClients                subscriptionClients
 	isNested                           bool   // isNested - flags that datasource is nested e.g. field with datasource is not on a query type
+	isPollingSubscription              bool   // isPollingSubscription - flags that a subscription is resolved by polling the origin with a query
 	rootTypeName                       string // rootTypeName - holds name of top level type
 	rootFieldName                      string // rootFieldName - holds name of root type field
 	rootFieldRef                       int    // rootFieldRef - holds ref of root type field
@@ -198,6 +201,11 @@ type Configuration struct {
 	Subscription   SubscriptionConfiguration
 	Federation     FederationConfiguration
 	UpstreamSchema string
+	// Errors configures how the errors of upstream responses are added to the response.
+	Errors ErrorsConfiguration
+	// ServiceName is the name of the upstream, e.g. the name of the subgraph. It's used by resolve.UpstreamErrorPolicyWrap,
+	// in execution traces and referenced by the from argument of @override in other federation v2 subgraphs.
+	ServiceName string
 }
 
 func ConfigJson(config Configuration) json.RawMessage {
@@ -205,6 +213,12 @@ func ConfigJson(config Configuration) json.RawMessage {
 	return out
 }
 
+type ErrorsConfiguration struct {
+	// Policy defines how upstream errors are added to the response, it defaults to resolve.UpstreamErrorPolicyPassThrough.
+	// Error paths are always rewritten to the paths of the response.
+	Policy resolve.UpstreamErrorPolicy
+}
+
 type FederationConfiguration struct {
 	Enabled    bool
 	ServiceSDL string
@@ -212,12 +226,53 @@ type FederationConfiguration struct {
 
 type SubscriptionConfiguration struct {
 	URL string
+	// Protocol selects how subscriptions are sent to the origin, it defaults to ProtocolGraphQLWS.
+	Protocol SubscriptionProtocol
+	// Polling configures subscriptions using ProtocolPolling.
+	Polling httpclient.PollingConfiguration `json:"Polling,omitempty"`
+}
+
+// SubscriptionProtocol is the transport protocol which is used to subscribe to an origin.
+type SubscriptionProtocol string
+
+const (
+	// ProtocolGraphQLWS is the legacy WebSocket protocol of subscriptions-transport-ws.
+	ProtocolGraphQLWS SubscriptionProtocol = "graphql-ws"
+	// ProtocolGraphQLTransportWS is the WebSocket protocol of graphql-ws.
+	ProtocolGraphQLTransportWS SubscriptionProtocol = "graphql-transport-ws"
+	// ProtocolSSE is GraphQL over Server-Sent Events.
+	ProtocolSSE SubscriptionProtocol = "sse"
+	// ProtocolPolling resolves subscriptions by repeatedly sending the subscription as a query to the origin.
+	// It is meant for origins without subscription support, the URL defaults to the fetch URL.
+	ProtocolPolling SubscriptionProtocol = "polling"
+)
+
+// UnmarshalJSON rejects unknown protocols, so a typo in the configuration doesn't silently fall back to ProtocolGraphQLWS.
+func (p *SubscriptionProtocol) UnmarshalJSON(data []byte) error {
+	var protocol string
+	if err := json.Unmarshal(data, &protocol); err != nil {
+		return err
+	}
+	switch SubscriptionProtocol(protocol) {
+	case "", ProtocolGraphQLWS, ProtocolGraphQLTransportWS, ProtocolSSE, ProtocolPolling:
+		*p = SubscriptionProtocol(protocol)
+		return nil
+	default:
+		return fmt.Errorf("unknown subscription protocol %q, supported protocols are %q, %q, %q and %q",
+			protocol, ProtocolGraphQLWS, ProtocolGraphQLTransportWS, ProtocolSSE, ProtocolPolling)
+	}
 }
 
 type FetchConfiguration struct {
 	URL    string
 	Method string
 	Header http.Header
+	// SuccessStatusCodes are the status codes of successful responses, defaults to every 2xx status code.
+	// Unsuccessful responses carrying GraphQL errors are resolved as usual,
+	// any other unsuccessful response results in a GraphQL error with the status code in its extensions.
+	SuccessStatusCodes []int `json:"SuccessStatusCodes,omitempty"`
+	// IncludeErrorBody adds the body of unsuccessful responses to the extensions of the GraphQL error.
+	IncludeErrorBody bool `json:"IncludeErrorBody,omitempty"`
 }
 
 func (c *Configuration) ApplyDefaults() {
@@ -261,6 +316,8 @@ func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
 
 	input = httpclient.SetInputURL(input, []byte(p.config.Fetch.URL))
 	input = httpclient.SetInputMethod(input, []byte(p.config.Fetch.Method))
+	input = httpclient.SetInputSuccessStatusCodes(input, p.config.Fetch.SuccessStatusCodes)
+	input = httpclient.SetInputIncludeErrorBody(input, p.config.Fetch.IncludeErrorBody)
 
 	var batchConfig plan.BatchConfig
 	// Allow batch query for fetching entities.
@@ -281,12 +338,18 @@ func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
 		ProcessResponseConfig: resolve.ProcessResponseConfig{
 			ExtractGraphqlResponse:    true,
 			ExtractFederationEntities: p.extractEntities,
+			UpstreamErrorPolicy:       p.config.Errors.Policy,
+			UpstreamServiceName:       p.config.ServiceName,
 		},
 		BatchConfig: batchConfig,
 	}
 }
 
 func (p *Planner) ConfigureSubscription() plan.SubscriptionConfiguration {
+	if p.config.Subscription.Protocol == ProtocolPolling {
+		return p.configurePollingSubscription()
+	}
+
 	input := httpclient.SetInputBodyWithPath(nil, p.upstreamVariables, "variables")
 	input = httpclient.SetInputBodyWithPath(input, p.printOperation(), "query")
 	input = httpclient.SetInputURL(input, []byte(p.config.Subscription.URL))
@@ -299,14 +362,42 @@ func (p *Planner) ConfigureSubscription() plan.SubscriptionConfiguration {
 	return plan.SubscriptionConfiguration{
 		Input: string(input),
 		DataSource: &SubscriptionSource{
-			client: p.subscriptionClient,
+			client: p.subscriptionClients.forProtocol(p.config.Subscription.Protocol),
 		},
 		Variables: p.variables,
 	}
 }
 
+func (p *Planner) configurePollingSubscription() plan.SubscriptionConfiguration {
+	input := httpclient.SetInputBodyWithPath(nil, p.upstreamVariables, "variables")
+	input = httpclient.SetInputBodyWithPath(input, p.printOperation(), "query")
+
+	header, err := json.Marshal(p.config.Fetch.Header)
+	if err == nil && len(header) != 0 && !bytes.Equal(header, literal.NULL) {
+		input = httpclient.SetInputHeader(input, header)
+	}
+
+	url := p.config.Subscription.URL
+	if url == "" {
+		url = p.config.Fetch.URL
+	}
+	input = httpclient.SetInputURL(input, []byte(url))
+	input = httpclient.SetInputMethod(input, []byte(p.config.Fetch.Method))
+	input = httpclient.SetInputSuccessStatusCodes(input, p.config.Fetch.SuccessStatusCodes)
+
+	return plan.SubscriptionConfiguration{
+		Input:      string(httpclient.SetPollingInput(input, p.config.Subscription.Polling, false)),
+		DataSource: httpclient.NewPollingSubscriptionSource(p.fetchClient),
+		Variables:  p.variables,
+	}
+}
+
 func (p *Planner) EnterOperationDefinition(ref int) {
 	operationType := p.visitor.Operation.OperationDefinitions[ref].OperationType
+	if operationType == ast.OperationTypeSubscription && p.config.Subscription.Protocol == ProtocolPolling {
+		p.isPollingSubscription = true
+		operationType = ast.OperationTypeQuery
+	}
 	if p.isNested {
 		operationType = ast.OperationTypeQuery
 	}
@@ -427,7 +518,7 @@ func (p *Planner) EnterField(ref int) {
 	// Note: federated fields always have a field configuration because at
 	// least the federation key for the type the field lives on is required
 	// (and required fields are specified in the configuration).
-	p.handleFederation(fieldConfiguration)
+	p.handleFederation(ref, fieldConfiguration)
 	p.addField(ref)
 
 	upstreamFieldRef := p.nodes[len(p.nodes)-1].Ref
@@ -459,6 +550,7 @@ func (p *Planner) EnterDocument(operation, definition *ast.Document) {
 	p.disallowSingleFlight = false
 	p.hasFederationRoot = false
 	p.extractEntities = false
+	p.isPollingSubscription = false
 
 	// reset information about root type
 	p.rootTypeName = ""
@@ -492,7 +584,7 @@ func (p *Planner) EnterDocument(operation, definition *ast.Document) {
 func (p *Planner) LeaveDocument(operation, definition *ast.Document) {
 }
 
-func (p *Planner) handleFederation(fieldConfig *plan.FieldConfiguration) {
+func (p *Planner) handleFederation(fieldRef int, fieldConfig *plan.FieldConfiguration) {
 	if !p.config.Federation.Enabled { // federation must be enabled
 		return
 	}
@@ -511,7 +603,7 @@ func (p *Planner) handleFederation(fieldConfig *plan.FieldConfiguration) {
 		// LeaveDocument, but ConfigureFetch is called before this visitor's
 		// LeaveDocument is called. (Updating the visitor logic to call
 		// LeaveDocument in reverse registration order would fix this issue.)
-		p.updateRepresentationsVariable(fieldConfig)
+		p.updateRepresentationsVariable(fieldRef, fieldConfig)
 		return
 	}
 	p.hasFederationRoot = true
@@ -519,10 +611,10 @@ func (p *Planner) handleFederation(fieldConfig *plan.FieldConfiguration) {
 	p.addRepresentationsVariableDefinition()     // $representations: [_Any!]!
 	p.addEntitiesSelectionSet()                  // {_entities(representations: $representations)
 	p.addOneTypeInlineFragment()                 // ... on Product
-	p.updateRepresentationsVariable(fieldConfig) // "variables\":{\"representations\":[{\"upc\":\"$$0$$\",\"__typename\":\"Product\"}]}}
+	p.updateRepresentationsVariable(fieldRef, fieldConfig) // "variables\":{\"representations\":[{\"upc\":\"$$0$$\",\"__typename\":\"Product\"}]}}
 }
 
-func (p *Planner) updateRepresentationsVariable(fieldConfig *plan.FieldConfiguration) {
+func (p *Planner) updateRepresentationsVariable(fieldRef int, fieldConfig *plan.FieldConfiguration) {
 	// "variables\":{\"representations\":[{\"upc\":\$$0$$\,\"__typename\":\"Product\"}]}}
 	parser := astparser.NewParser()
 	doc := ast.NewDocument()
@@ -536,7 +628,8 @@ func (p *Planner) updateRepresentationsVariable(fieldConfig *plan.FieldConfigura
 
 	// RequiresFields includes `@requires` fields as well as federation keys
 	// for the type containing the field currently being visited.
-	fields := fieldConfig.RequiresFields
+	// For an entity with several keys, the planner picks the key.
+	fields := p.visitor.RequiredFields(fieldRef, fieldConfig)
 	if len(fields) == 0 {
 		return
 	}
@@ -544,33 +637,66 @@ func (p *Planner) updateRepresentationsVariable(fieldConfig *plan.FieldConfigura
 	onTypeName := p.visitor.Config.Types.RenameTypeNameOnMatchStr(p.lastFieldEnclosingTypeName)
 
 	if len(p.representationsJson) == 0 {
-		p.representationsJson, _ = sjson.SetRawBytes(nil, "__typename", []byte("\""+onTypeName+"\""))
+		p.representationsJson, _ = sjson.SetRawBytes(nil, "__typename", p.representationTypeName(onTypeName))
 	}
 
 	for i := range fields {
-		objectVariable := &resolve.ObjectVariable{
-			Path: []string{fields[i]},
-		}
-		fieldDef := p.fieldDefinition(fields[i], p.lastFieldEnclosingTypeName)
-		if fieldDef == nil {
-			continue
-		}
-		renderer, err := resolve.NewJSONVariableRendererWithValidationFromTypeRef(p.visitor.Definition, p.visitor.Definition, fieldDef.Type)
-		if err != nil {
-			continue
-		}
-		objectVariable.Renderer = renderer
-		variable, exists := p.variables.AddVariable(objectVariable)
-		if exists {
-			continue
+		// a field of a compound key like "organization { id }" is rendered as nested object {"organization":{"id":...}}
+		for _, path := range p.requiredFieldPaths(fields[i]) {
+			objectVariable := &resolve.ObjectVariable{
+				Path: path,
+			}
+			fieldDef := p.fieldDefinitionByPath(path, p.lastFieldEnclosingTypeName)
+			if fieldDef == nil {
+				continue
+			}
+			renderer, err := resolve.NewJSONVariableRendererWithValidationFromTypeRef(p.visitor.Definition, p.visitor.Definition, fieldDef.Type)
+			if err != nil {
+				continue
+			}
+			objectVariable.Renderer = renderer
+			variable, exists := p.variables.AddVariable(objectVariable)
+			if exists {
+				continue
+			}
+			p.representationsJson, _ = sjson.SetRawBytes(p.representationsJson, strings.Join(path, "."), []byte(variable))
 		}
-		p.representationsJson, _ = sjson.SetRawBytes(p.representationsJson, fields[i], []byte(variable))
 	}
 	representationsJson := append([]byte("["), append(p.representationsJson, []byte("]")...)...)
 	p.upstreamVariables, _ = sjson.SetRawBytes(p.upstreamVariables, "representations", representationsJson)
 	p.extractEntities = true
 }
 
+// representationTypeName returns the __typename of the representation.
+// The representation of an entity interface carries the __typename of the concrete type from the response of the parent.
+func (p *Planner) representationTypeName(onTypeName string) []byte {
+	node, exists := p.visitor.Definition.Index.FirstNodeByNameStr(p.lastFieldEnclosingTypeName)
+	if !exists || node.Kind != ast.NodeKindInterfaceTypeDefinition {
+		return []byte("\"" + onTypeName + "\"")
+	}
+	variable, _ := p.variables.AddVariable(&resolve.ObjectVariable{
+		Path:     []string{"__typename"},
+		Renderer: resolve.NewJSONVariableRenderer(),
+	})
+	return []byte(variable)
+}
+
+func (p *Planner) requiredFieldPaths(requiredField string) [][]string {
+	if !strings.Contains(requiredField, "{") {
+		return [][]string{{requiredField}}
+	}
+	return plan.FieldSetLeafPaths(requiredField)
+}
+
+// fieldDefinitionByPath returns the definition of the last field of a path starting at the type typeName.
+func (p *Planner) fieldDefinitionByPath(path []string, typeName string) *ast.FieldDefinition {
+	fieldDef := p.fieldDefinition(path[0], typeName)
+	for i := 1; i < len(path) && fieldDef != nil; i++ {
+		fieldDef = p.fieldDefinition(path[i], p.visitor.Definition.ResolveTypeNameString(fieldDef.Type))
+	}
+	return fieldDef
+}
+
 func (p *Planner) fieldDefinition(fieldName, typeName string) *ast.FieldDefinition {
 	node, ok := p.visitor.Definition.Index.FirstNodeByNameStr(typeName)
 	if !ok {
@@ -585,7 +711,7 @@ func (p *Planner) fieldDefinition(fieldName, typeName string) *ast.FieldDefiniti
 
 func (p *Planner) addOneTypeInlineFragment() {
 	selectionSet := p.upstreamOperation.AddSelectionSet()
-	onTypeName := p.visitor.Config.Types.RenameTypeNameOnMatchBytes([]byte(p.lastFieldEnclosingTypeName))
+	onTypeName := p.visitor.Config.Types.RenameTypeNameOnMatchBytes(p.entityTypeName())
 	typeRef := p.upstreamOperation.AddNamedType(onTypeName)
 	inlineFragment := p.upstreamOperation.AddInlineFragment(ast.InlineFragment{
 		HasSelections: true,
@@ -601,6 +727,41 @@ func (p *Planner) addOneTypeInlineFragment() {
 	p.nodes = append(p.nodes, selectionSet)
 }
 
+// entityTypeName returns the type name of the inline fragment in the _entities selection set.
+// A subgraph extending an entity interface doesn't know the object types implementing it,
+// so the fragment for a field of such an object type is on the interface instead.
+func (p *Planner) entityTypeName() []byte {
+	typeName := []byte(p.lastFieldEnclosingTypeName)
+	serviceDefinition, report := astparser.ParseGraphqlDocumentString(p.config.Federation.ServiceSDL)
+	if report.HasErrors() || hasTypeNamed(&serviceDefinition, typeName) {
+		return typeName
+	}
+	node, exists := p.visitor.Definition.Index.FirstNodeByNameBytes(typeName)
+	if !exists || node.Kind != ast.NodeKindObjectTypeDefinition {
+		return typeName
+	}
+	for _, ref := range p.visitor.Definition.ObjectTypeDefinitions[node.Ref].ImplementsInterfaces.Refs {
+		interfaceName := p.visitor.Definition.TypeNameBytes(ref)
+		if hasTypeNamed(&serviceDefinition, interfaceName) {
+			return interfaceName
+		}
+	}
+	return typeName
+}
+
+func hasTypeNamed(document *ast.Document, typeName []byte) bool {
+	for _, node := range document.RootNodes {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition, ast.NodeKindObjectTypeExtension,
+			ast.NodeKindInterfaceTypeDefinition, ast.NodeKindInterfaceTypeExtension:
+			if bytes.Equal(document.NodeNameBytes(node), typeName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (p *Planner) addEntitiesSelectionSet() {
 	// $representations
 	representationsLiteral := p.upstreamOperation.Input.AppendInputString("representations")
@@ -987,7 +1148,7 @@ func (p *Planner) stopWithError(msg string, args ...interface{}) {
 replaceQueryType - sets definition query type to a current root type.
 Helps to do a normalization of the upstream query for a nested datasource.
 Skips replace when:
-1. datasource is not nested;
+1. datasource is not nested and does not poll a subscription;
 2. federation is enabled;
 3. query type contains an operation field;
 
@@ -1049,7 +1210,7 @@ it might be the case that no FieldDefinition exists for the rewritten root field
 In that case, we transform the schema so that normalization and printing of the upstream Query succeeds.
 */
 func (p *Planner) replaceQueryType(definition *ast.Document) {
-	if !p.isNested || p.config.Federation.Enabled {
+	if (!p.isNested && !p.isPollingSubscription) || p.config.Federation.Enabled {
 		return
 	}
 
@@ -1136,19 +1297,43 @@ func (p *Planner) addField(ref int) {
 }
 
 type Factory struct {
-	BatchFactory resolve.DataSourceBatchFactory
-	HTTPClient   *http.Client
-	wsClient     *WebSocketGraphQLSubscriptionClient
+	BatchFactory        resolve.DataSourceBatchFactory
+	HTTPClient          *http.Client
+	subscriptionClients *subscriptionClients
 }
 
 func (f *Factory) Planner(ctx context.Context) plan.DataSourcePlanner {
-	if f.wsClient == nil {
-		f.wsClient = NewWebSocketGraphQLSubscriptionClient(f.HTTPClient, ctx)
+	if f.subscriptionClients == nil {
+		f.subscriptionClients = &subscriptionClients{
+			graphQLWS:          NewWebSocketGraphQLSubscriptionClient(f.HTTPClient, ctx),
+			graphQLTransportWS: NewWebSocketGraphQLSubscriptionClient(f.HTTPClient, ctx, WithProtocol(ProtocolGraphQLTransportWS)),
+			sse:                NewSSEGraphQLSubscriptionClient(f.HTTPClient),
+		}
 	}
 	return &Planner{
-		batchFactory:       f.BatchFactory,
-		fetchClient:        f.HTTPClient,
-		subscriptionClient: f.wsClient,
+		batchFactory:        f.BatchFactory,
+		fetchClient:         f.HTTPClient,
+		subscriptionClients: *f.subscriptionClients,
+	}
+}
+
+// subscriptionClients holds one client per subscription protocol.
+// WebSocket connections are de-duplicated per client, so each protocol keeps its own set of connections.
+type subscriptionClients struct {
+	graphQLWS          GraphQLSubscriptionClient
+	graphQLTransportWS GraphQLSubscriptionClient
+	sse                GraphQLSubscriptionClient
+}
+
+// forProtocol returns the client of a protocol which has been validated by SubscriptionProtocol.UnmarshalJSON.
+func (s subscriptionClients) forProtocol(protocol SubscriptionProtocol) GraphQLSubscriptionClient {
+	switch protocol {
+	case ProtocolGraphQLTransportWS:
+		return s.graphQLTransportWS
+	case ProtocolSSE:
+		return s.sse
+	default:
+		return s.graphQLWS
 	}
 }
 
@@ -1215,7 +1400,20 @@ func (s *Source) unNullVariables(input []byte) ([]byte, bool) {
 
 func (s *Source) Load(ctx context.Context, input []byte, writer io.Writer) (err error) {
 	input = s.compactAndUnNullVariables(input)
-	return httpclient.Do(s.httpClient, ctx, input, writer)
+	err = httpclient.Do(s.httpClient, ctx, input, writer)
+
+	var statusCodeErr *httpclient.StatusCodeError
+	if errors.As(err, &statusCodeErr) && isGraphQLErrorResponse(statusCodeErr.Body) {
+		// GraphQL servers may answer requests they can't execute with a 4xx status code and a regular error response,
+		// those errors describe the problem better than the status code
+		_, err = writer.Write(statusCodeErr.Body)
+	}
+	return err
+}
+
+func isGraphQLErrorResponse(body []byte) bool {
+	value, dataType, _, err := jsonparser.Get(body, "errors")
+	return err == nil && dataType == jsonparser.Array && len(value) > 2
 }
 
 type GraphQLSubscriptionClient interface {
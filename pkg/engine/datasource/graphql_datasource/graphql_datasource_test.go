@@ -0,0 +1,181 @@
+package graphql_datasource
+
+import (
+	"testing"
+
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/httpclient"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasourcetesting"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
+)
+
+const pollingSchema = `
+	schema {
+		query: Query
+		subscription: Subscription
+	}
+
+	type Query {
+		hello: String
+	}
+
+	type Subscription {
+		price(symbol: String!): Price!
+	}
+
+	type Price {
+		symbol: String!
+		amount: Float!
+	}
+`
+
+func TestGraphQLDataSource_PollingSubscription(t *testing.T) {
+	t.Run("subscription is sent as query to the fetch url", datasourcetesting.RunTest(pollingSchema, `
+		subscription Price($symbol: String!) {
+			price(symbol: $symbol) {
+				symbol
+				amount
+			}
+		}
+	`, "Price", &plan.SubscriptionResponsePlan{
+		Response: &resolve.GraphQLSubscription{
+			Trigger: resolve.GraphQLSubscriptionTrigger{
+				Input: []byte(`{"interval":500,"error_delay":2000,"skip_publish_same_response":true,"max_polls":0,"wrap_response_in_data":false,"request_input":{"method":"POST","url":"https://example.com/graphql","body":{"query":"query($symbol: String!){price(symbol: $symbol){symbol amount}}","variables":{"symbol":$$0$$}}}}`),
+				Variables: resolve.NewVariables(
+					&resolve.ContextVariable{
+						Path:     []string{"symbol"},
+						Renderer: resolve.NewJSONVariableRendererWithValidation(`{"type":["string"]}`),
+					},
+				),
+				Source: httpclient.NewPollingSubscriptionSource(nil),
+			},
+			Response: &resolve.GraphQLResponse{
+				Data: &resolve.Object{
+					Fields: []*resolve.Field{
+						{
+							Name: []byte("price"),
+							Value: &resolve.Object{
+								Path: []string{"price"},
+								Fields: []*resolve.Field{
+									{
+										Name: []byte("symbol"),
+										Value: &resolve.String{
+											Path: []string{"symbol"},
+										},
+									},
+									{
+										Name: []byte("amount"),
+										Value: &resolve.Float{
+											Path: []string{"amount"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, plan.Configuration{
+		DataSources: []plan.DataSourceConfiguration{
+			{
+				RootNodes: []plan.TypeField{
+					{
+						TypeName:   "Subscription",
+						FieldNames: []string{"price"},
+					},
+				},
+				ChildNodes: []plan.TypeField{
+					{
+						TypeName:   "Price",
+						FieldNames: []string{"symbol", "amount"},
+					},
+				},
+				Custom: ConfigJson(Configuration{
+					Fetch: FetchConfiguration{
+						URL: "https://example.com/graphql",
+					},
+					Subscription: SubscriptionConfiguration{
+						Protocol: ProtocolPolling,
+						Polling: httpclient.PollingConfiguration{
+							IntervalMillis:          500,
+							ErrorDelayMillis:        2000,
+							SkipPublishSameResponse: true,
+						},
+					},
+				}),
+				Factory: &Factory{},
+			},
+		},
+		Fields: []plan.FieldConfiguration{
+			{
+				TypeName:  "Subscription",
+				FieldName: "price",
+				Arguments: []plan.ArgumentConfiguration{
+					{
+						Name:       "symbol",
+						SourceType: plan.FieldArgumentSource,
+					},
+				},
+			},
+		},
+		DisableResolveFieldPositions: true,
+	}))
+}
+
+func TestGraphQLDataSource_UpstreamErrors(t *testing.T) {
+	t.Run("errors configuration is added to the fetch", datasourcetesting.RunTest(pollingSchema, `
+		query Hello {
+			hello
+		}
+	`, "Hello", &plan.SynchronousResponsePlan{
+		Response: &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fetch: &resolve.SingleFetch{
+					BufferId:             0,
+					Input:                `{"method":"POST","url":"https://example.com/graphql","body":{"query":"{hello}"}}`,
+					DataSource:           &Source{},
+					DataSourceIdentifier: []byte("graphql_datasource.Source"),
+					ProcessResponseConfig: resolve.ProcessResponseConfig{
+						ExtractGraphqlResponse: true,
+						UpstreamErrorPolicy:    resolve.UpstreamErrorPolicyWrap,
+						UpstreamServiceName:    "hello",
+					},
+				},
+				Fields: []*resolve.Field{
+					{
+						BufferID:  0,
+						HasBuffer: true,
+						Name:      []byte("hello"),
+						Value: &resolve.String{
+							Path:     []string{"hello"},
+							Nullable: true,
+						},
+					},
+				},
+			},
+		},
+	}, plan.Configuration{
+		DataSources: []plan.DataSourceConfiguration{
+			{
+				RootNodes: []plan.TypeField{
+					{
+						TypeName:   "Query",
+						FieldNames: []string{"hello"},
+					},
+				},
+				Custom: ConfigJson(Configuration{
+					Fetch: FetchConfiguration{
+						URL: "https://example.com/graphql",
+					},
+					Errors: ErrorsConfiguration{
+						Policy: resolve.UpstreamErrorPolicyWrap,
+					},
+					ServiceName: "hello",
+				}),
+				Factory: &Factory{},
+			},
+		},
+		DisableResolveFieldPositions: true,
+	}))
+}
@@ -0,0 +1,371 @@
+package graphql_datasource
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+var (
+	sseEventPrefix = []byte("event:")
+	sseDataPrefix  = []byte("data:")
+)
+
+const (
+	sseEventNext     = "next"
+	sseEventComplete = "complete"
+
+	// sseSubscriptionBufferSize is the number of events buffered for a subscription.
+	// A subscription whose buffer is full when the next event arrives can't keep up with the origin and gets completed.
+	sseSubscriptionBufferSize = 64
+)
+
+// SSEGraphQLSubscriptionClient is a client for origins implementing GraphQL over Server-Sent Events
+// Every "next" event (or unnamed event of legacy servers) is emitted as one message, a "complete" event ends the subscription
+// It takes care of de-duplicating streaming HTTP requests to the same origin
+// If Hash(URL,Body,Headers) result in the same result, an existing stream is shared
+type SSEGraphQLSubscriptionClient struct {
+	httpClient *http.Client
+	hashPool   sync.Pool
+	streams    map[uint64]*sseStream
+	streamsMu  sync.Mutex
+}
+
+func NewSSEGraphQLSubscriptionClient(httpClient *http.Client) *SSEGraphQLSubscriptionClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	// the timeout of the client would terminate long living streams
+	streamingClient := *httpClient
+	streamingClient.Timeout = 0
+	return &SSEGraphQLSubscriptionClient{
+		httpClient: &streamingClient,
+		streams:    map[uint64]*sseStream{},
+		hashPool: sync.Pool{
+			New: func() interface{} {
+				return xxhash.New()
+			},
+		},
+	}
+}
+
+// Subscribe sends the subscription to the origin and starts reading the event stream
+// If a stream with the same ID (Hash) exists, the subscription joins it instead of sending another request
+// The next channel gets closed when the origin completes the subscription or the context is done
+func (c *SSEGraphQLSubscriptionClient) Subscribe(ctx context.Context, options GraphQLSubscriptionOptions, next chan<- []byte) error {
+	body, err := json.Marshal(options.Body)
+	if err != nil {
+		return err
+	}
+
+	streamID, err := c.generateStreamIDHash(options, body)
+	if err != nil {
+		return err
+	}
+
+	sub := &sseSubscription{
+		ctx:    ctx,
+		next:   next,
+		events: make(chan []byte, sseSubscriptionBufferSize),
+	}
+
+	for {
+		// the stream is reserved while holding the lock, the request to the origin is sent without it
+		c.streamsMu.Lock()
+		stream, exists := c.streams[streamID]
+		if !exists {
+			stream = newSSEStream()
+			c.streams[streamID] = stream
+		}
+		c.streamsMu.Unlock()
+
+		if !exists {
+			return c.startStream(streamID, stream, options, body, sub)
+		}
+
+		joined, err := stream.join(sub)
+		if joined || err != nil {
+			return err
+		}
+		// the stream has ended before the subscription could join it, a new one gets started
+		c.removeStream(streamID, stream)
+	}
+}
+
+// startStream sends the request of the reserved stream to the origin and starts reading the event stream
+func (c *SSEGraphQLSubscriptionClient) startStream(streamID uint64, stream *sseStream, options GraphQLSubscriptionOptions, body []byte, sub *sseSubscription) (err error) {
+	defer close(stream.ready)
+
+	// the stream outlives the context of the subscription which started it, it ends with its last subscription
+	streamCtx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if !stream.streaming {
+			cancel()
+			stream.startErr = err
+			c.removeStream(streamID, stream)
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodPost, options.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range options.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return fmt.Errorf("unexpected status code from origin: %d", resp.StatusCode)
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		// the origin answered with a single response, e.g. because the operation is invalid
+		data, err := ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer close(sub.next)
+			select {
+			case sub.next <- data:
+			case <-sub.ctx.Done():
+			}
+		}()
+		return nil
+	}
+
+	stream.cancel = cancel
+	stream.streaming = true
+
+	go func() {
+		stream.startBlocking(streamCtx, resp.Body, sub)
+		c.removeStream(streamID, stream)
+	}()
+
+	return nil
+}
+
+func (c *SSEGraphQLSubscriptionClient) removeStream(streamID uint64, stream *sseStream) {
+	c.streamsMu.Lock()
+	defer c.streamsMu.Unlock()
+	if c.streams[streamID] == stream {
+		delete(c.streams, streamID)
+	}
+}
+
+// generateStreamIDHash generates a Hash based on: URL, Body and Headers to uniquely identify streaming requests
+func (c *SSEGraphQLSubscriptionClient) generateStreamIDHash(options GraphQLSubscriptionOptions, body []byte) (uint64, error) {
+	xxh := c.hashPool.Get().(*xxhash.Digest)
+	defer c.hashPool.Put(xxh)
+	xxh.Reset()
+
+	if _, err := xxh.WriteString(options.URL); err != nil {
+		return 0, err
+	}
+	if _, err := xxh.Write(body); err != nil {
+		return 0, err
+	}
+	if err := options.Header.Write(xxh); err != nil {
+		return 0, err
+	}
+
+	return xxh.Sum64(), nil
+}
+
+type sseSubscription struct {
+	ctx  context.Context
+	next chan<- []byte
+	// events buffers the events of the stream, so a slow subscription doesn't hold back the others
+	events chan []byte
+}
+
+// forward sends the buffered events to next until the stream closes events or the context is done, then it closes next
+func (s *sseSubscription) forward() {
+	defer close(s.next)
+	for data := range s.events {
+		select {
+		case s.next <- data:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// sseStream is a streaming request to the origin which is shared by all subscriptions with the same ID
+// if all subscriptions are cancelled the request is cancelled, if the origin ends the stream all subscriptions complete
+type sseStream struct {
+	cancel        context.CancelFunc
+	subscribeCh   chan *sseSubscription
+	unsubscribeCh chan *sseSubscription
+	subscriptions map[*sseSubscription]struct{}
+	// ready is closed once the request to the origin has been sent, streaming is set if the origin answered with an event stream
+	ready     chan struct{}
+	streaming bool
+	startErr  error
+	// done is closed when the stream has ended and accepts no more subscriptions
+	done chan struct{}
+}
+
+func newSSEStream() *sseStream {
+	return &sseStream{
+		subscribeCh:   make(chan *sseSubscription),
+		unsubscribeCh: make(chan *sseSubscription),
+		subscriptions: map[*sseSubscription]struct{}{},
+		ready:         make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// join adds the subscription to the stream once it has been started
+// it returns false if the stream has ended or the origin didn't answer with an event stream
+func (s *sseStream) join(sub *sseSubscription) (bool, error) {
+	select {
+	case <-s.ready:
+	case <-sub.ctx.Done():
+		return true, nil
+	}
+	if s.startErr != nil {
+		return false, s.startErr
+	}
+	if !s.streaming {
+		return false, nil
+	}
+
+	select {
+	case s.subscribeCh <- sub:
+		return true, nil
+	case <-s.done:
+		return false, nil
+	case <-sub.ctx.Done():
+		return true, nil
+	}
+}
+
+// startBlocking starts the single threaded event loop of the stream which sends every event to all subscriptions
+// it stops when the origin ends the stream or the last subscription is gone
+func (s *sseStream) startBlocking(ctx context.Context, body io.ReadCloser, sub *sseSubscription) {
+	defer func() {
+		close(s.done)
+		s.cancel()
+		for sub := range s.subscriptions {
+			close(sub.events)
+		}
+	}()
+
+	s.subscribe(sub)
+	dataCh := make(chan []byte)
+	go s.readBlocking(ctx, body, dataCh)
+
+	for len(s.subscriptions) != 0 {
+		select {
+		case sub = <-s.subscribeCh:
+			s.subscribe(sub)
+		case sub = <-s.unsubscribeCh:
+			s.unsubscribe(sub)
+		case data, ok := <-dataCh:
+			if !ok {
+				return
+			}
+			for sub := range s.subscriptions {
+				select {
+				case sub.events <- data:
+				default:
+					// the subscription can't keep up with the origin, it gets completed so it doesn't hold back the others
+					s.unsubscribe(sub)
+				}
+			}
+		}
+	}
+}
+
+// subscribe adds the subscription and removes it again as soon as its context is done
+func (s *sseStream) subscribe(sub *sseSubscription) {
+	s.subscriptions[sub] = struct{}{}
+	go sub.forward()
+	go func() {
+		select {
+		case <-sub.ctx.Done():
+		case <-s.done:
+			return
+		}
+		select {
+		case s.unsubscribeCh <- sub:
+		case <-s.done:
+		}
+	}()
+}
+
+func (s *sseStream) unsubscribe(sub *sseSubscription) {
+	if _, ok := s.subscriptions[sub]; !ok {
+		return
+	}
+	close(sub.events)
+	delete(s.subscriptions, sub)
+}
+
+// readBlocking reads events from the stream until it ends, the subscription completes or the context is done
+func (s *sseStream) readBlocking(ctx context.Context, body io.ReadCloser, next chan<- []byte) {
+	defer func() {
+		_ = body.Close()
+		close(next)
+	}()
+
+	reader := bufio.NewReader(body)
+	event := ""
+	data := &bytes.Buffer{}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		switch {
+		case len(line) == 0:
+			// an empty line dispatches the event
+			if event == sseEventComplete {
+				return
+			}
+			if data.Len() != 0 && (event == "" || event == sseEventNext) {
+				message := make([]byte, data.Len())
+				copy(message, data.Bytes())
+				select {
+				case next <- message:
+				case <-ctx.Done():
+					return
+				}
+			}
+			event = ""
+			data.Reset()
+		case line[0] == ':':
+			// comments are used as keep alive
+		case bytes.HasPrefix(line, sseEventPrefix):
+			event = string(bytes.TrimSpace(line[len(sseEventPrefix):]))
+		case bytes.HasPrefix(line, sseDataPrefix):
+			if data.Len() != 0 {
+				data.WriteByte('\n')
+			}
+			data.Write(bytes.TrimPrefix(line[len(sseDataPrefix):], []byte(" ")))
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package graphql_datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEGraphQLSubscriptionClient(t *testing.T) {
+	t.Run("should emit next events until complete", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+			assert.Equal(t, "Bearer 123", r.Header.Get("Authorization"))
+			body, err := ioutil.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, `{"query":"subscription {messageAdded(roomName: \"room\"){text}}"}`, string(body))
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			flusher := w.(http.Flusher)
+			_, _ = fmt.Fprint(w, ":\n\n")
+			_, _ = fmt.Fprint(w, "event: next\ndata: {\"data\":{\"messageAdded\":{\"text\":\"first\"}}}\n\n")
+			flusher.Flush()
+			_, _ = fmt.Fprint(w, "data: {\"data\":{\"messageAdded\":{\"text\":\"second\"}}}\n\n")
+			flusher.Flush()
+			_, _ = fmt.Fprint(w, "event: complete\n\n")
+			flusher.Flush()
+		}))
+		defer server.Close()
+
+		client := NewSSEGraphQLSubscriptionClient(&http.Client{Timeout: time.Millisecond})
+		next := make(chan []byte)
+		err := client.Subscribe(context.Background(), GraphQLSubscriptionOptions{
+			URL:    server.URL,
+			Header: http.Header{"Authorization": []string{"Bearer 123"}},
+			Body: GraphQLBody{
+				Query: `subscription {messageAdded(roomName: "room"){text}}`,
+			},
+		}, next)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"data":{"messageAdded":{"text":"first"}}}`, string(<-next))
+		assert.Equal(t, `{"data":{"messageAdded":{"text":"second"}}}`, string(<-next))
+		_, ok := <-next
+		assert.False(t, ok)
+	})
+
+	t.Run("should stop reading when the context is done", func(t *testing.T) {
+		serverDone := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			_, _ = fmt.Fprint(w, "event: next\ndata: {\"data\":{\"counter\":1}}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			close(serverDone)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		client := NewSSEGraphQLSubscriptionClient(http.DefaultClient)
+		next := make(chan []byte)
+		err := client.Subscribe(ctx, GraphQLSubscriptionOptions{
+			URL:  server.URL,
+			Body: GraphQLBody{Query: `subscription {counter}`},
+		}, next)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"data":{"counter":1}}`, string(<-next))
+		cancel()
+		_, ok := <-next
+		assert.False(t, ok)
+		assert.Eventually(t, func() bool {
+			<-serverDone
+			return true
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("should share the stream between subscriptions with the same options", func(t *testing.T) {
+		var requests int32
+		events := make(chan string)
+		serverDone := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case event := <-events:
+					_, _ = fmt.Fprintf(w, "event: next\ndata: %s\n\n", event)
+					w.(http.Flusher).Flush()
+				case <-r.Context().Done():
+					close(serverDone)
+					return
+				}
+			}
+		}))
+		defer server.Close()
+
+		client := NewSSEGraphQLSubscriptionClient(http.DefaultClient)
+		options := GraphQLSubscriptionOptions{
+			URL:  server.URL,
+			Body: GraphQLBody{Query: `subscription {counter}`},
+		}
+
+		firstCtx, firstCancel := context.WithCancel(context.Background())
+		first := make(chan []byte)
+		require.NoError(t, client.Subscribe(firstCtx, options, first))
+		secondCtx, secondCancel := context.WithCancel(context.Background())
+		second := make(chan []byte)
+		require.NoError(t, client.Subscribe(secondCtx, options, second))
+
+		events <- `{"data":{"counter":1}}`
+		assert.Equal(t, `{"data":{"counter":1}}`, string(<-first))
+		assert.Equal(t, `{"data":{"counter":1}}`, string(<-second))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+		firstCancel()
+		_, ok := <-first
+		assert.False(t, ok)
+
+		events <- `{"data":{"counter":2}}`
+		assert.Equal(t, `{"data":{"counter":2}}`, string(<-second))
+
+		secondCancel()
+		_, ok = <-second
+		assert.False(t, ok)
+		select {
+		case <-serverDone:
+		case <-time.After(time.Second):
+			t.Fatal("stream has not been closed after the last subscription")
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("should complete a slow subscription without holding back the others", func(t *testing.T) {
+		eventCount := sseSubscriptionBufferSize + 8
+		events := make(chan string)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			for event := range events {
+				_, _ = fmt.Fprintf(w, "data: %s\n\n", event)
+				w.(http.Flusher).Flush()
+			}
+		}))
+		defer server.Close()
+
+		client := NewSSEGraphQLSubscriptionClient(http.DefaultClient)
+		options := GraphQLSubscriptionOptions{
+			URL:  server.URL,
+			Body: GraphQLBody{Query: `subscription {counter}`},
+		}
+
+		slow := make(chan []byte)
+		require.NoError(t, client.Subscribe(context.Background(), options, slow))
+		fast := make(chan []byte)
+		require.NoError(t, client.Subscribe(context.Background(), options, fast))
+
+		for i := 0; i < eventCount; i++ {
+			events <- fmt.Sprintf(`{"data":{"counter":%d}}`, i)
+			assert.Equal(t, fmt.Sprintf(`{"data":{"counter":%d}}`, i), string(<-fast))
+		}
+
+		received := 0
+		for range slow {
+			received++
+		}
+		assert.Less(t, received, eventCount)
+
+		events <- `{"data":{"counter":-1}}`
+		assert.Equal(t, `{"data":{"counter":-1}}`, string(<-fast))
+		close(events)
+		_, ok := <-fast
+		assert.False(t, ok)
+	})
+
+	t.Run("should not block other subscriptions while connecting to the origin", func(t *testing.T) {
+		connecting := make(chan struct{})
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/hanging" {
+				close(connecting)
+				<-release
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			_, _ = fmt.Fprint(w, "data: {\"data\":{\"counter\":1}}\n\nevent: complete\n\n")
+			w.(http.Flusher).Flush()
+		}))
+		defer server.Close()
+		defer close(release)
+
+		client := NewSSEGraphQLSubscriptionClient(http.DefaultClient)
+
+		hanging := make(chan []byte)
+		go func() {
+			_ = client.Subscribe(context.Background(), GraphQLSubscriptionOptions{
+				URL:  server.URL + "/hanging",
+				Body: GraphQLBody{Query: `subscription {counter}`},
+			}, hanging)
+		}()
+		<-connecting
+
+		subscribed := make(chan error)
+		next := make(chan []byte)
+		go func() {
+			subscribed <- client.Subscribe(context.Background(), GraphQLSubscriptionOptions{
+				URL:  server.URL,
+				Body: GraphQLBody{Query: `subscription {counter}`},
+			}, next)
+		}()
+
+		select {
+		case err := <-subscribed:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("subscription has been blocked by the connection to another stream")
+		}
+		assert.Equal(t, `{"data":{"counter":1}}`, string(<-next))
+		_, ok := <-next
+		assert.False(t, ok)
+	})
+
+	t.Run("should emit single json response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprint(w, `{"errors":[{"message":"invalid"}]}`)
+		}))
+		defer server.Close()
+
+		client := NewSSEGraphQLSubscriptionClient(nil)
+		next := make(chan []byte)
+		err := client.Subscribe(context.Background(), GraphQLSubscriptionOptions{
+			URL:  server.URL,
+			Body: GraphQLBody{Query: `subscription {counter}`},
+		}, next)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"errors":[{"message":"invalid"}]}`, string(<-next))
+		_, ok := <-next
+		assert.False(t, ok)
+	})
+
+	t.Run("should return error on unexpected status code", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		client := NewSSEGraphQLSubscriptionClient(nil)
+		err := client.Subscribe(context.Background(), GraphQLSubscriptionOptions{
+			URL:  server.URL,
+			Body: GraphQLBody{Query: `subscription {counter}`},
+		}, make(chan []byte))
+		assert.EqualError(t, err, "unexpected status code from origin: 502")
+	})
+}
+
+func TestSubscriptionClients_ForProtocol(t *testing.T) {
+	graphQLWS := NewWebSocketGraphQLSubscriptionClient(nil, context.Background())
+	graphQLTransportWS := NewWebSocketGraphQLSubscriptionClient(nil, context.Background(), WithProtocol(ProtocolGraphQLTransportWS))
+	sse := NewSSEGraphQLSubscriptionClient(nil)
+	clients := subscriptionClients{
+		graphQLWS:          graphQLWS,
+		graphQLTransportWS: graphQLTransportWS,
+		sse:                sse,
+	}
+
+	assert.Equal(t, graphQLWS, clients.forProtocol(""))
+	assert.Equal(t, graphQLWS, clients.forProtocol(ProtocolGraphQLWS))
+	assert.Equal(t, graphQLTransportWS, clients.forProtocol(ProtocolGraphQLTransportWS))
+	assert.Equal(t, sse, clients.forProtocol(ProtocolSSE))
+}
+
+func TestSubscriptionProtocol_UnmarshalJSON(t *testing.T) {
+	for _, protocol := range []SubscriptionProtocol{"", ProtocolGraphQLWS, ProtocolGraphQLTransportWS, ProtocolSSE, ProtocolPolling} {
+		var config Configuration
+		err := json.Unmarshal(ConfigJson(Configuration{Subscription: SubscriptionConfiguration{Protocol: protocol}}), &config)
+		require.NoError(t, err)
+		assert.Equal(t, protocol, config.Subscription.Protocol)
+	}
+
+	var config Configuration
+	err := json.Unmarshal([]byte(`{"Subscription":{"Protocol":"graphql_ws"}}`), &config)
+	assert.EqualError(t, err, `unknown subscription protocol "graphql_ws", supported protocols are "graphql-ws", "graphql-transport-ws", "sse" and "polling"`)
+}
@@ -24,6 +24,10 @@ const (
 	stopMessage     = `{"type":"stop","id":"%s"}`
 	internalError   = `{"errors":[{"message":"connection error"}]}`
 	connectionError = `{"errors":[{"message":"connection error"}]}`
+
+	subscribeMessage = `{"type":"subscribe","id":"%s","payload":%s}`
+	completeMessage  = `{"type":"complete","id":"%s"}`
+	pongMessage      = `{"type":"pong"}`
 )
 
 // WebSocketGraphQLSubscriptionClient is a WebSocket client that allows running multiple subscriptions via the same WebSocket Connection
@@ -38,6 +42,7 @@ type WebSocketGraphQLSubscriptionClient struct {
 	handlersMu sync.Mutex
 
 	readTimeout time.Duration
+	protocol    SubscriptionProtocol
 }
 
 type Options func(options *opts)
@@ -54,15 +59,25 @@ func WithReadTimeout(timeout time.Duration) Options {
 	}
 }
 
+// WithProtocol sets the WebSocket sub-protocol which is spoken with the origin.
+// Supported protocols are ProtocolGraphQLWS (default) and ProtocolGraphQLTransportWS.
+func WithProtocol(protocol SubscriptionProtocol) Options {
+	return func(options *opts) {
+		options.protocol = protocol
+	}
+}
+
 type opts struct {
 	readTimeout time.Duration
 	log         abstractlogger.Logger
+	protocol    SubscriptionProtocol
 }
 
 func NewWebSocketGraphQLSubscriptionClient(httpClient *http.Client, ctx context.Context, options ...Options) *WebSocketGraphQLSubscriptionClient {
 	op := &opts{
 		readTimeout: time.Second,
 		log:         abstractlogger.NoopLogger,
+		protocol:    ProtocolGraphQLWS,
 	}
 	for _, option := range options {
 		option(op)
@@ -73,6 +88,7 @@ func NewWebSocketGraphQLSubscriptionClient(httpClient *http.Client, ctx context.
 		handlers:    map[uint64]*connectionHandler{},
 		log:         op.log,
 		readTimeout: op.readTimeout,
+		protocol:    op.protocol,
 		hashPool: sync.Pool{
 			New: func() interface{} {
 				return xxhash.New()
@@ -112,14 +128,14 @@ func (c *WebSocketGraphQLSubscriptionClient) Subscribe(ctx context.Context, opti
 	if options.Header == nil {
 		options.Header = http.Header{}
 	}
-	options.Header.Set("Sec-WebSocket-Protocol", "graphql-ws")
+	options.Header.Set("Sec-WebSocket-Protocol", string(c.protocol))
 	options.Header.Set("Sec-WebSocket-Version", "13")
 
 	conn, upgradeResponse, err := websocket.Dial(ctx, options.URL, &websocket.DialOptions{
 		HTTPClient:      c.httpClient,
 		HTTPHeader:      options.Header,
 		CompressionMode: websocket.CompressionDisabled,
-		Subprotocols:    []string{"graphql-ws"},
+		Subprotocols:    []string{string(c.protocol)},
 	})
 	if err != nil {
 		return err
@@ -132,22 +148,12 @@ func (c *WebSocketGraphQLSubscriptionClient) Subscribe(ctx context.Context, opti
 	if err != nil {
 		return err
 	}
-	msgType, connectionAckMsg, err := conn.Read(ctx)
-	if err != nil {
-		return err
-	}
-	if msgType != websocket.MessageText {
-		return fmt.Errorf("unexpected msg type")
-	}
-	connectionAck, err := jsonparser.GetString(connectionAckMsg, "type")
+	err = c.awaitConnectionAck(ctx, conn)
 	if err != nil {
 		return err
 	}
-	if connectionAck != "connection_ack" {
-		return fmt.Errorf("expected connection_ack, got: %s", connectionAck)
-	}
 
-	handler = newConnectionHandler(c.ctx, conn, c.readTimeout, c.log)
+	handler = newConnectionHandler(c.ctx, conn, c.readTimeout, c.log, c.protocol)
 	c.handlers[handlerID] = handler
 
 	go func(handlerID uint64) {
@@ -160,6 +166,34 @@ func (c *WebSocketGraphQLSubscriptionClient) Subscribe(ctx context.Context, opti
 	return nil
 }
 
+// awaitConnectionAck reads from the connection until the origin acknowledges the connection.
+// graphql-transport-ws origins might send pings before the acknowledgement, which get answered with pongs.
+func (c *WebSocketGraphQLSubscriptionClient) awaitConnectionAck(ctx context.Context, conn *websocket.Conn) error {
+	for {
+		msgType, connectionAckMsg, err := conn.Read(ctx)
+		if err != nil {
+			return err
+		}
+		if msgType != websocket.MessageText {
+			return fmt.Errorf("unexpected msg type")
+		}
+		connectionAck, err := jsonparser.GetString(connectionAckMsg, "type")
+		if err != nil {
+			return err
+		}
+		if c.protocol == ProtocolGraphQLTransportWS && connectionAck == "ping" {
+			if err = conn.Write(ctx, websocket.MessageText, []byte(pongMessage)); err != nil {
+				return err
+			}
+			continue
+		}
+		if connectionAck != "connection_ack" {
+			return fmt.Errorf("expected connection_ack, got: %s", connectionAck)
+		}
+		return nil
+	}
+}
+
 // generateHandlerIDHash generates a Hash based on: URL and Headers to uniquely identify Upgrade Requests
 func (c *WebSocketGraphQLSubscriptionClient) generateHandlerIDHash(options GraphQLSubscriptionOptions) (uint64, error) {
 	var (
@@ -181,11 +215,12 @@ func (c *WebSocketGraphQLSubscriptionClient) generateHandlerIDHash(options Graph
 	return xxh.Sum64(), nil
 }
 
-func newConnectionHandler(ctx context.Context, conn *websocket.Conn, readTimeout time.Duration, log abstractlogger.Logger) *connectionHandler {
+func newConnectionHandler(ctx context.Context, conn *websocket.Conn, readTimeout time.Duration, log abstractlogger.Logger, protocol SubscriptionProtocol) *connectionHandler {
 	return &connectionHandler{
 		conn:               conn,
 		ctx:                ctx,
 		log:                log,
+		protocol:           protocol,
 		subscribeCh:        make(chan subscription),
 		nextSubscriptionID: 0,
 		subscriptions:      map[string]subscription{},
@@ -200,6 +235,7 @@ type connectionHandler struct {
 	conn               *websocket.Conn
 	ctx                context.Context
 	log                abstractlogger.Logger
+	protocol           SubscriptionProtocol
 	subscribeCh        chan subscription
 	nextSubscriptionID int
 	subscriptions      map[string]subscription
@@ -242,7 +278,7 @@ func (h *connectionHandler) startBlocking(sub subscription) {
 				continue
 			}
 			switch messageType {
-			case "data":
+			case "data", "next":
 				h.handleMessageTypeData(data)
 			case "complete":
 				h.handleMessageTypeComplete(data)
@@ -252,6 +288,8 @@ func (h *connectionHandler) startBlocking(sub subscription) {
 			case "error":
 				h.handleMessageTypeError(data)
 				continue
+			case "ping":
+				h.handleMessageTypePing()
 			default:
 				continue
 			}
@@ -300,7 +338,7 @@ func (h *connectionHandler) subscribe(sub subscription) {
 
 	subscriptionID := strconv.Itoa(h.nextSubscriptionID)
 
-	startRequest := fmt.Sprintf(startMessage, subscriptionID, string(graphQLBody))
+	startRequest := fmt.Sprintf(h.startMessageTemplate(), subscriptionID, string(graphQLBody))
 	err = h.conn.Write(h.ctx, websocket.MessageText, []byte(startRequest))
 	if err != nil {
 		return
@@ -309,6 +347,27 @@ func (h *connectionHandler) subscribe(sub subscription) {
 	h.subscriptions[subscriptionID] = sub
 }
 
+func (h *connectionHandler) startMessageTemplate() string {
+	if h.protocol == ProtocolGraphQLTransportWS {
+		return subscribeMessage
+	}
+	return startMessage
+}
+
+func (h *connectionHandler) stopMessageTemplate() string {
+	if h.protocol == ProtocolGraphQLTransportWS {
+		return completeMessage
+	}
+	return stopMessage
+}
+
+func (h *connectionHandler) handleMessageTypePing() {
+	if h.protocol != ProtocolGraphQLTransportWS {
+		return
+	}
+	_ = h.conn.Write(h.ctx, websocket.MessageText, []byte(pongMessage))
+}
+
 func (h *connectionHandler) handleMessageTypeData(data []byte) {
 	id, err := jsonparser.GetString(data, "id")
 	if err != nil {
@@ -368,6 +427,13 @@ func (h *connectionHandler) handleMessageTypeError(data []byte) {
 	if !ok {
 		return
 	}
+	if h.protocol == ProtocolGraphQLTransportWS {
+		// an error message completes the operation in the graphql-transport-ws protocol
+		defer func() {
+			close(sub.next)
+			delete(h.subscriptions, id)
+		}()
+	}
 	value, valueType, _, err := jsonparser.Get(data, "payload")
 	if err != nil {
 		sub.next <- []byte(internalError)
@@ -402,7 +468,7 @@ func (h *connectionHandler) unsubscribe(subscriptionID string) {
 	}
 	close(sub.next)
 	delete(h.subscriptions, subscriptionID)
-	stopRequest := fmt.Sprintf(stopMessage, subscriptionID)
+	stopRequest := fmt.Sprintf(h.stopMessageTemplate(), subscriptionID)
 	_ = h.conn.Write(h.ctx, websocket.MessageText, []byte(stopRequest))
 }
 
@@ -420,3 +420,120 @@ func TestWebsocketSubscriptionClientDeDuplication(t *testing.T) {
 		return connectedClients.Load() == 0
 	}, time.Second, time.Millisecond, "clients not 0")
 }
+
+func TestWebsocketSubscriptionClientGraphQLTransportWS(t *testing.T) {
+	serverDone := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+			Subprotocols: []string{"graphql-transport-ws"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "graphql-transport-ws", conn.Subprotocol())
+		ctx := context.Background()
+		msgType, data, err := conn.Read(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, websocket.MessageText, msgType)
+		assert.Equal(t, `{"type":"connection_init"}`, string(data))
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"ping"}`))
+		assert.NoError(t, err)
+		_, data, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"pong"}`, string(data))
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"connection_ack"}`))
+		assert.NoError(t, err)
+		msgType, data, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, websocket.MessageText, msgType)
+		assert.Equal(t, `{"type":"subscribe","id":"1","payload":{"query":"subscription {messageAdded(roomName: \"room\"){text}}"}}`, string(data))
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"next","id":"1","payload":{"data":{"messageAdded":{"text":"first"}}}}`))
+		assert.NoError(t, err)
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"ping"}`))
+		assert.NoError(t, err)
+		_, data, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"pong"}`, string(data))
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"next","id":"1","payload":{"data":{"messageAdded":{"text":"second"}}}}`))
+		assert.NoError(t, err)
+
+		msgType, data, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, websocket.MessageText, msgType)
+		assert.Equal(t, `{"type":"complete","id":"1"}`, string(data))
+		close(serverDone)
+	}))
+	defer server.Close()
+	ctx, clientCancel := context.WithCancel(context.Background())
+
+	serverCtx, serverCancel := context.WithCancel(context.Background())
+	defer serverCancel()
+
+	client := NewWebSocketGraphQLSubscriptionClient(http.DefaultClient, serverCtx,
+		WithReadTimeout(time.Millisecond),
+		WithLogger(logger()),
+		WithProtocol(ProtocolGraphQLTransportWS),
+	)
+	next := make(chan []byte)
+	err := client.Subscribe(ctx, GraphQLSubscriptionOptions{
+		URL: strings.Replace(server.URL, "http", "ws", -1),
+		Body: GraphQLBody{
+			Query: `subscription {messageAdded(roomName: "room"){text}}`,
+		},
+	}, next)
+	assert.NoError(t, err)
+	first := <-next
+	second := <-next
+	assert.Equal(t, `{"data":{"messageAdded":{"text":"first"}}}`, string(first))
+	assert.Equal(t, `{"data":{"messageAdded":{"text":"second"}}}`, string(second))
+	clientCancel()
+	assert.Eventuallyf(t, func() bool {
+		<-serverDone
+		return true
+	}, time.Second, time.Millisecond*10, "server did not close")
+	serverCancel()
+	assert.Eventuallyf(t, func() bool {
+		client.handlersMu.Lock()
+		defer client.handlersMu.Unlock()
+		return len(client.handlers) == 0
+	}, time.Second, time.Millisecond, "client handlers not 0")
+}
+
+func TestWebsocketSubscriptionClientGraphQLTransportWSErrorCompletes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+			Subprotocols: []string{"graphql-transport-ws"},
+		})
+		assert.NoError(t, err)
+		ctx := context.Background()
+		_, _, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"connection_ack"}`))
+		assert.NoError(t, err)
+		_, _, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"error","id":"1","payload":[{"message":"error"}]}`))
+		assert.NoError(t, err)
+		_, _, _ = conn.Read(ctx)
+	}))
+	defer server.Close()
+
+	serverCtx, serverCancel := context.WithCancel(context.Background())
+	defer serverCancel()
+
+	client := NewWebSocketGraphQLSubscriptionClient(http.DefaultClient, serverCtx,
+		WithReadTimeout(time.Millisecond),
+		WithLogger(logger()),
+		WithProtocol(ProtocolGraphQLTransportWS),
+	)
+	next := make(chan []byte)
+	err := client.Subscribe(context.Background(), GraphQLSubscriptionOptions{
+		URL: strings.Replace(server.URL, "http", "ws", -1),
+		Body: GraphQLBody{
+			Query: `subscription {messageAdded(roomNam: "room"){text}}`,
+		},
+	}, next)
+	assert.NoError(t, err)
+	message := <-next
+	assert.Equal(t, `{"errors":[{"message":"error"}]}`, string(message))
+	_, ok := <-next
+	assert.False(t, ok)
+}
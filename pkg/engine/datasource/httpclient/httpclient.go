@@ -24,6 +24,9 @@ const (
 	HEADER        = "header"
 	QUERYPARAMS   = "query_params"
 
+	SUCCESSSTATUSCODES = "success_status_codes"
+	INCLUDEERRORBODY   = "include_error_body"
+
 	SCHEME = "scheme"
 	HOST   = "host"
 )
@@ -35,6 +38,8 @@ var (
 		{BODY},
 		{HEADER},
 		{QUERYPARAMS},
+		{SUCCESSSTATUSCODES},
+		{INCLUDEERRORBODY},
 	}
 	subscriptionInputPaths = [][]string{
 		{URL},
@@ -146,6 +151,25 @@ func SetInputQueryParams(input, queryParams []byte) []byte {
 	return out
 }
 
+// SetInputSuccessStatusCodes sets the status codes which are considered successful.
+// Without explicit status codes every 2xx status code is considered successful.
+func SetInputSuccessStatusCodes(input []byte, statusCodes []int) []byte {
+	if len(statusCodes) == 0 {
+		return input
+	}
+	out, _ := sjson.SetBytes(input, SUCCESSSTATUSCODES, statusCodes)
+	return out
+}
+
+// SetInputIncludeErrorBody adds the body of unsuccessful responses to the extensions of the resulting error.
+func SetInputIncludeErrorBody(input []byte, includeErrorBody bool) []byte {
+	if !includeErrorBody {
+		return input
+	}
+	out, _ := sjson.SetRawBytes(input, INCLUDEERRORBODY, []byte("true"))
+	return out
+}
+
 func SetInputScheme(input, scheme []byte) []byte {
 	if len(scheme) == 0 {
 		return input
@@ -170,7 +194,7 @@ func SetInputPath(input, path []byte) []byte {
 	return out
 }
 
-func requestInputParams(input []byte) (url, method, body, headers, queryParams []byte) {
+func requestInputParams(input []byte) (url, method, body, headers, queryParams, successStatusCodes, includeErrorBody []byte) {
 	jsonparser.EachKey(input, func(i int, bytes []byte, valueType jsonparser.ValueType, err error) {
 		switch i {
 		case 0:
@@ -183,6 +207,10 @@ func requestInputParams(input []byte) (url, method, body, headers, queryParams [
 			headers = bytes
 		case 4:
 			queryParams = bytes
+		case 5:
+			successStatusCodes = bytes
+		case 6:
+			includeErrorBody = bytes
 		}
 	}, inputPaths...)
 	return
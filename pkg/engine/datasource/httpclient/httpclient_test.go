@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/pvormste/graphql-go-tools/internal/pkg/quotes"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/tracing"
 	"github.com/pvormste/graphql-go-tools/pkg/lexer/literal"
 )
 
@@ -73,6 +74,18 @@ func TestHttpClient(t *testing.T) {
 
 	in = SetInputBodyWithPath(nil, []byte(`{"bar":$$0$$}`), "variables.foo")
 	assert.Equal(t, `{"body":{"variables":{"foo":{"bar":$$0$$}}}}`, string(in))
+
+	in = SetInputSuccessStatusCodes(nil, []int{200, 404})
+	assert.Equal(t, `{"success_status_codes":[200,404]}`, string(in))
+
+	in = SetInputSuccessStatusCodes(nil, nil)
+	assert.Nil(t, in)
+
+	in = SetInputIncludeErrorBody(nil, true)
+	assert.Equal(t, `{"include_error_body":true}`, string(in))
+
+	in = SetInputIncludeErrorBody(nil, false)
+	assert.Nil(t, in)
 }
 
 func TestHttpClientDo(t *testing.T) {
@@ -157,6 +170,20 @@ func TestHttpClientDo(t *testing.T) {
 		t.Run("net", runTest(background, input, `ok`))
 	})
 
+	t.Run("propagates the trace context", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", r.Header.Get("traceparent"))
+			_, err := w.Write([]byte("ok"))
+			assert.NoError(t, err)
+		}))
+		defer server.Close()
+		var input []byte
+		input = SetInputMethod(input, []byte("GET"))
+		input = SetInputURL(input, []byte(server.URL))
+		ctx, _ := tracing.Start(background, fakeTracer{}, tracing.SpanFetch)
+		t.Run("net", runTest(ctx, input, `ok`))
+	})
+
 	t.Run("post", func(t *testing.T) {
 		body := []byte(`{"foo":"bar"}`)
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -195,4 +222,82 @@ func TestHttpClientDo(t *testing.T) {
 		input = SetInputURL(input, []byte(server.URL))
 		t.Run("net", runTest(background, input, `ok`))
 	})
+
+	t.Run("status codes", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/not-found":
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"reason":"unknown id"}`))
+			case "/error":
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`<html>error</html>`))
+			default:
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`ok`))
+			}
+		}))
+		defer server.Close()
+
+		input := func(path string) []byte {
+			var input []byte
+			input = SetInputMethod(input, []byte("GET"))
+			return SetInputURL(input, []byte(server.URL+path))
+		}
+
+		t.Run("should write body of 2xx responses", runTest(background, input("/"), `ok`))
+
+		t.Run("should return error for non 2xx responses", func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := Do(http.DefaultClient, background, input("/error"), out)
+			assert.Equal(t, 0, out.Len())
+
+			statusCodeErr, ok := err.(*StatusCodeError)
+			if assert.True(t, ok) {
+				assert.Equal(t, http.StatusInternalServerError, statusCodeErr.StatusCode)
+				assert.Equal(t, `<html>error</html>`, string(statusCodeErr.Body))
+				assert.Equal(t, "unexpected status code from origin: 500", statusCodeErr.Error())
+				assert.Equal(t, `{"statusCode":500}`, string(statusCodeErr.Extensions()))
+			}
+		})
+
+		t.Run("should include error body in extensions", func(t *testing.T) {
+			err := Do(http.DefaultClient, background, SetInputIncludeErrorBody(input("/error"), true), &bytes.Buffer{})
+			statusCodeErr, ok := err.(*StatusCodeError)
+			if assert.True(t, ok) {
+				assert.Equal(t, `{"statusCode":500,"response":"<html>error</html>"}`, string(statusCodeErr.Extensions()))
+			}
+
+			err = Do(http.DefaultClient, background, SetInputIncludeErrorBody(input("/not-found"), true), &bytes.Buffer{})
+			statusCodeErr, ok = err.(*StatusCodeError)
+			if assert.True(t, ok) {
+				assert.Equal(t, `{"statusCode":404,"response":{"reason":"unknown id"}}`, string(statusCodeErr.Extensions()))
+			}
+		})
+
+		t.Run("should write body of configured success status codes", runTest(background, SetInputSuccessStatusCodes(input("/not-found"), []int{200, 404}), `{"reason":"unknown id"}`))
+
+		t.Run("should return error for 2xx responses which are not configured as success", func(t *testing.T) {
+			err := Do(http.DefaultClient, background, SetInputSuccessStatusCodes(input("/"), []int{200}), &bytes.Buffer{})
+			assert.EqualError(t, err, "unexpected status code from origin: 201")
+		})
+	})
+}
+
+type fakeTracer struct{}
+
+func (fakeTracer) Start(ctx context.Context, _ string, _ ...tracing.Attribute) (context.Context, tracing.Span) {
+	return ctx, fakeSpan{}
+}
+
+type fakeSpan struct{}
+
+func (fakeSpan) SetAttributes(_ ...tracing.Attribute) {}
+
+func (fakeSpan) RecordError(_ error) {}
+
+func (fakeSpan) End() {}
+
+func (fakeSpan) InjectHeader(header http.Header) {
+	header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
 }
@@ -5,12 +5,17 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/buger/jsonparser"
 
+	"github.com/pvormste/graphql-go-tools/pkg/engine/tracing"
 	"github.com/pvormste/graphql-go-tools/pkg/lexer/literal"
 )
 
@@ -35,7 +40,7 @@ var (
 
 func Do(client *http.Client, ctx context.Context, requestInput []byte, out io.Writer) (err error) {
 
-	url, method, body, headers, queryParams := requestInputParams(requestInput)
+	url, method, body, headers, queryParams, successStatusCodes, includeErrorBody := requestInputParams(requestInput)
 
 	request, err := http.NewRequestWithContext(ctx, string(method), string(url), bytes.NewReader(body))
 	if err != nil {
@@ -92,6 +97,7 @@ func Do(client *http.Client, ctx context.Context, requestInput []byte, out io.Wr
 
 	request.Header.Add("accept", "application/json")
 	request.Header.Add("content-type", "application/json")
+	tracing.InjectHeader(ctx, request.Header)
 
 	response, err := client.Do(request)
 	if err != nil {
@@ -104,10 +110,76 @@ func Do(client *http.Client, ctx context.Context, requestInput []byte, out io.Wr
 		return err
 	}
 
+	if !isSuccessStatusCode(response.StatusCode, successStatusCodes) {
+		return newStatusCodeError(response.StatusCode, respReader, bytes.Equal(includeErrorBody, literal.TRUE))
+	}
+
 	_, err = io.Copy(out, respReader)
 	return
 }
 
+func isSuccessStatusCode(statusCode int, successStatusCodes []byte) bool {
+	if len(successStatusCodes) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+
+	success := false
+	_, _ = jsonparser.ArrayEach(successStatusCodes, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		code, parseErr := jsonparser.ParseInt(value)
+		if parseErr == nil && int(code) == statusCode {
+			success = true
+		}
+	})
+	return success
+}
+
+// maxErrorBodySize limits how much of an unsuccessful response is kept for the error.
+const maxErrorBodySize = 64 * 1024
+
+// StatusCodeError is returned by Do if the origin responded with a status code which is not considered successful.
+// The body of the response is not written to the output, so it doesn't get resolved as data.
+type StatusCodeError struct {
+	StatusCode int
+	// Body contains the (possibly truncated) response body.
+	Body             []byte
+	includeErrorBody bool
+}
+
+func newStatusCodeError(statusCode int, body io.Reader, includeErrorBody bool) *StatusCodeError {
+	data, _ := ioutil.ReadAll(io.LimitReader(body, maxErrorBodySize))
+	return &StatusCodeError{
+		StatusCode:       statusCode,
+		Body:             data,
+		includeErrorBody: includeErrorBody,
+	}
+}
+
+func (e *StatusCodeError) Error() string {
+	return fmt.Sprintf("unexpected status code from origin: %d", e.StatusCode)
+}
+
+// Extensions returns the extensions of the GraphQL error which describes the failed fetch.
+// They contain the status code and, if configured, the response body.
+// A JSON body is embedded as it is, any other body as string.
+func (e *StatusCodeError) Extensions() []byte {
+	extensions := bytes.NewBuffer(make([]byte, 0, 32+len(e.Body)))
+	extensions.WriteString(`{"statusCode":`)
+	extensions.WriteString(strconv.Itoa(e.StatusCode))
+	if e.includeErrorBody && len(e.Body) != 0 {
+		extensions.WriteString(`,"response":`)
+		if json.Valid(e.Body) {
+			_ = json.Compact(extensions, e.Body)
+		} else {
+			encoder := json.NewEncoder(extensions)
+			encoder.SetEscapeHTML(false)
+			_ = encoder.Encode(string(e.Body))
+			extensions.Truncate(extensions.Len() - 1) // Encode terminates the value with a newline
+		}
+	}
+	extensions.WriteByte('}')
+	return extensions.Bytes()
+}
+
 func respBodyReader(req *http.Request, resp *http.Response) (io.ReadCloser, error) {
 	if req.Header.Get(AcceptEncodingHeader) == "" {
 		return resp.Body, nil
@@ -0,0 +1,194 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	POLLINTERVAL                = "interval"
+	POLLERRORDELAY              = "error_delay"
+	POLLSKIPPUBLISHSAMERESPONSE = "skip_publish_same_response"
+	POLLMAXPOLLS                = "max_polls"
+	POLLWRAPRESPONSEINDATA      = "wrap_response_in_data"
+	POLLREQUEST                 = "request_input"
+
+	DefaultPollingIntervalMillis = 1000
+)
+
+var pollingInputPaths = [][]string{
+	{POLLINTERVAL},
+	{POLLERRORDELAY},
+	{POLLSKIPPUBLISHSAMERESPONSE},
+	{POLLMAXPOLLS},
+	{POLLWRAPRESPONSEINDATA},
+	{POLLREQUEST},
+}
+
+// PollingConfiguration configures subscriptions which poll an origin without push support.
+type PollingConfiguration struct {
+	// IntervalMillis is the time between two polls, it defaults to DefaultPollingIntervalMillis.
+	IntervalMillis int `json:"interval"`
+	// ErrorDelayMillis is the time to wait after a failed poll, it defaults to IntervalMillis.
+	ErrorDelayMillis int `json:"error_delay"`
+	// SkipPublishSameResponse publishes a response only if it differs from the previously published one.
+	SkipPublishSameResponse bool `json:"skip_publish_same_response"`
+	// MaxPolls ends the subscription after the given number of polls, 0 polls until the subscription is stopped.
+	MaxPolls int `json:"max_polls"`
+}
+
+// SetPollingInput wraps the request input with the polling configuration to create the input of a PollingSubscriptionSource.
+// If wrapResponseInData is true, responses are published as {"data":response}, which is needed for origins
+// that don't respond with GraphQL responses.
+func SetPollingInput(requestInput []byte, config PollingConfiguration, wrapResponseInData bool) []byte {
+	if len(requestInput) == 0 {
+		requestInput = []byte(`{}`)
+	}
+	return []byte(fmt.Sprintf(`{"%s":%d,"%s":%d,"%s":%t,"%s":%d,"%s":%t,"%s":%s}`,
+		POLLINTERVAL, config.IntervalMillis,
+		POLLERRORDELAY, config.ErrorDelayMillis,
+		POLLSKIPPUBLISHSAMERESPONSE, config.SkipPublishSameResponse,
+		POLLMAXPOLLS, config.MaxPolls,
+		POLLWRAPRESPONSEINDATA, wrapResponseInData,
+		POLLREQUEST, requestInput,
+	))
+}
+
+// PollingSubscriptionSource turns an http origin into a subscription by polling it.
+// The origin is requested right away and then once per interval until the subscription context is done
+// or the maximum number of polls is reached. Failed polls are not published, the next poll happens after the error delay.
+type PollingSubscriptionSource struct {
+	client *http.Client
+}
+
+func NewPollingSubscriptionSource(client *http.Client) *PollingSubscriptionSource {
+	if client == nil {
+		client = DefaultNetHttpClient
+	}
+	return &PollingSubscriptionSource{
+		client: client,
+	}
+}
+
+type pollingOptions struct {
+	interval                time.Duration
+	errorDelay              time.Duration
+	skipPublishSameResponse bool
+	maxPolls                int64
+	wrapResponseInData      bool
+	requestInput            []byte
+}
+
+func parsePollingOptions(input []byte) (options pollingOptions, err error) {
+	var interval, errorDelay int64
+	jsonparser.EachKey(input, func(i int, value []byte, valueType jsonparser.ValueType, _ error) {
+		switch i {
+		case 0:
+			interval, _ = jsonparser.ParseInt(value)
+		case 1:
+			errorDelay, _ = jsonparser.ParseInt(value)
+		case 2:
+			options.skipPublishSameResponse, _ = jsonparser.ParseBoolean(value)
+		case 3:
+			options.maxPolls, _ = jsonparser.ParseInt(value)
+		case 4:
+			options.wrapResponseInData, _ = jsonparser.ParseBoolean(value)
+		case 5:
+			if valueType == jsonparser.Object {
+				options.requestInput = value
+			}
+		}
+	}, pollingInputPaths...)
+
+	if options.requestInput == nil {
+		return options, fmt.Errorf("%s must be an object", POLLREQUEST)
+	}
+	if interval <= 0 {
+		interval = DefaultPollingIntervalMillis
+	}
+	if errorDelay <= 0 {
+		errorDelay = interval
+	}
+	options.interval = time.Duration(interval) * time.Millisecond
+	options.errorDelay = time.Duration(errorDelay) * time.Millisecond
+	return options, nil
+}
+
+func (s *PollingSubscriptionSource) Start(ctx context.Context, input []byte, next chan<- []byte) error {
+	options, err := parsePollingOptions(input)
+	if err != nil {
+		return err
+	}
+
+	go s.poll(ctx, options, next)
+	return nil
+}
+
+func (s *PollingSubscriptionSource) poll(ctx context.Context, options pollingOptions, next chan<- []byte) {
+	defer close(next)
+
+	var (
+		lastHash  uint64
+		published bool
+		polls     int64
+	)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	buf := &bytes.Buffer{}
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return
+		}
+
+		buf.Reset()
+		err := Do(s.client, ctx, options.requestInput, buf)
+		if ctx.Err() != nil {
+			return
+		}
+		polls++
+
+		delay := options.interval
+		if err != nil {
+			delay = options.errorDelay
+		} else {
+			hash := xxhash.Sum64(buf.Bytes())
+			if !options.skipPublishSameResponse || !published || hash != lastHash {
+				lastHash = hash
+				published = true
+
+				select {
+				case next <- s.event(buf.Bytes(), options.wrapResponseInData):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		if options.maxPolls > 0 && polls >= options.maxPolls {
+			return
+		}
+		timer.Reset(delay)
+	}
+}
+
+func (s *PollingSubscriptionSource) event(response []byte, wrapResponseInData bool) []byte {
+	if !wrapResponseInData {
+		event := make([]byte, len(response))
+		copy(event, response)
+		return event
+	}
+	event := make([]byte, 0, len(response)+9)
+	event = append(event, `{"data":`...)
+	event = append(event, response...)
+	return append(event, '}')
+}
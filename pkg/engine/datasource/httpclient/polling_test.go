@@ -0,0 +1,170 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPollingInput(t *testing.T) {
+	in := SetPollingInput([]byte(`{"method":"GET","url":"foo.bar.com"}`), PollingConfiguration{
+		IntervalMillis:          100,
+		ErrorDelayMillis:        200,
+		SkipPublishSameResponse: true,
+		MaxPolls:                3,
+	}, true)
+	assert.Equal(t, `{"interval":100,"error_delay":200,"skip_publish_same_response":true,"max_polls":3,"wrap_response_in_data":true,"request_input":{"method":"GET","url":"foo.bar.com"}}`, string(in))
+
+	in = SetPollingInput(nil, PollingConfiguration{}, false)
+	assert.Equal(t, `{"interval":0,"error_delay":0,"skip_publish_same_response":false,"max_polls":0,"wrap_response_in_data":false,"request_input":{}}`, string(in))
+}
+
+func TestPollingSubscriptionSource(t *testing.T) {
+	start := func(t *testing.T, ctx context.Context, serverURL string, config PollingConfiguration, wrapResponseInData bool) chan []byte {
+		t.Helper()
+		source := NewPollingSubscriptionSource(http.DefaultClient)
+		input := SetPollingInput([]byte(fmt.Sprintf(`{"method":"GET","url":"%s"}`, serverURL)), config, wrapResponseInData)
+		next := make(chan []byte)
+		require.NoError(t, source.Start(ctx, input, next))
+		return next
+	}
+
+	receive := func(t *testing.T, next <-chan []byte) string {
+		t.Helper()
+		select {
+		case message, ok := <-next:
+			require.True(t, ok, "subscription closed")
+			return string(message)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timed out waiting for message")
+			return ""
+		}
+	}
+
+	assertClosed := func(t *testing.T, next <-chan []byte) {
+		t.Helper()
+		select {
+		case _, ok := <-next:
+			assert.False(t, ok)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "subscription not closed")
+		}
+	}
+
+	t.Run("should publish every response", func(t *testing.T) {
+		var counter int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodGet, r.Method)
+			_, _ = fmt.Fprintf(w, `{"counter":%d}`, atomic.AddInt32(&counter, 1)/2)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		next := start(t, ctx, server.URL, PollingConfiguration{IntervalMillis: 1}, false)
+		assert.Equal(t, `{"counter":0}`, receive(t, next))
+		assert.Equal(t, `{"counter":1}`, receive(t, next))
+		assert.Equal(t, `{"counter":1}`, receive(t, next))
+		assert.Equal(t, `{"counter":2}`, receive(t, next))
+	})
+
+	t.Run("should skip publishing the same response", func(t *testing.T) {
+		var counter int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"counter":%d}`, atomic.AddInt32(&counter, 1)/2)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		next := start(t, ctx, server.URL, PollingConfiguration{IntervalMillis: 1, SkipPublishSameResponse: true}, false)
+		assert.Equal(t, `{"counter":0}`, receive(t, next))
+		assert.Equal(t, `{"counter":1}`, receive(t, next))
+		assert.Equal(t, `{"counter":2}`, receive(t, next))
+	})
+
+	t.Run("should wrap responses in data", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"counter":0}`))
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		next := start(t, ctx, server.URL, PollingConfiguration{IntervalMillis: 1}, true)
+		assert.Equal(t, `{"data":{"counter":0}}`, receive(t, next))
+	})
+
+	t.Run("should not publish failed polls", func(t *testing.T) {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) <= 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"error":"unavailable"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"counter":0}`))
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		next := start(t, ctx, server.URL, PollingConfiguration{IntervalMillis: 1000, ErrorDelayMillis: 1}, false)
+		assert.Equal(t, `{"counter":0}`, receive(t, next))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("should stop after max polls", func(t *testing.T) {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"counter":%d}`, atomic.AddInt32(&requests, 1))
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		next := start(t, ctx, server.URL, PollingConfiguration{IntervalMillis: 1, MaxPolls: 2}, false)
+		assert.Equal(t, `{"counter":1}`, receive(t, next))
+		assert.Equal(t, `{"counter":2}`, receive(t, next))
+		assertClosed(t, next)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("should stop polling when the context is done", func(t *testing.T) {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			_, _ = w.Write([]byte(`{"counter":0}`))
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		next := start(t, ctx, server.URL, PollingConfiguration{IntervalMillis: 1, SkipPublishSameResponse: true}, false)
+		assert.Equal(t, `{"counter":0}`, receive(t, next))
+
+		cancel()
+		assertClosed(t, next)
+
+		requestsAfterCancel := atomic.LoadInt32(&requests)
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, requestsAfterCancel, atomic.LoadInt32(&requests))
+	})
+
+	t.Run("should return error for invalid input", func(t *testing.T) {
+		source := NewPollingSubscriptionSource(nil)
+		err := source.Start(context.Background(), []byte(`{"interval":1}`), make(chan []byte))
+		assert.EqualError(t, err, "request_input must be an object")
+	})
+}
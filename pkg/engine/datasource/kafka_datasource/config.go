@@ -1,6 +1,7 @@
 package kafka_datasource
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/Shopify/sarama"
@@ -124,6 +125,77 @@ func (g *GraphQLSubscriptionOptions) Validate() error {
 	return nil
 }
 
+// newSaramaConfig creates the sarama config which is shared by consumers and producers.
+func newSaramaConfig(clientID, kafkaVersion string, sasl SASL) *sarama.Config {
+	sc := sarama.NewConfig()
+	sc.Version = SaramaSupportedKafkaVersions[kafkaVersion]
+	sc.ClientID = clientID
+
+	// SASL based authentication with broker. While there are multiple SASL authentication methods
+	// the current implementation is limited to plaintext (SASL/PLAIN) authentication
+	if sasl.Enable {
+		sc.Net.SASL.Enable = true
+		sc.Net.SASL.User = sasl.User
+		sc.Net.SASL.Password = sasl.Password
+	}
+
+	return sc
+}
+
+type PublishOptions struct {
+	BrokerAddr   string `json:"broker_addr"`
+	Topic        string `json:"topic"`
+	ClientID     string `json:"client_id"`
+	KafkaVersion string `json:"kafka_version"`
+	SASL         SASL   `json:"sasl"`
+	Key          string `json:"key"`
+	// Message is the rendered message, a JSON string is published without quotes.
+	Message json.RawMessage `json:"message"`
+}
+
+func (p *PublishOptions) Sanitize() {
+	if p.KafkaVersion == "" {
+		p.KafkaVersion = DefaultKafkaVersion
+	}
+}
+
+func (p *PublishOptions) Validate() error {
+	switch {
+	case p.BrokerAddr == "":
+		return fmt.Errorf("broker_addr cannot be empty")
+	case p.Topic == "":
+		return fmt.Errorf("topic cannot be empty")
+	case p.ClientID == "":
+		return fmt.Errorf("client_id cannot be empty")
+	case len(p.Message) == 0 || string(p.Message) == "null":
+		return fmt.Errorf("message cannot be empty")
+	}
+
+	if _, ok := SaramaSupportedKafkaVersions[p.KafkaVersion]; !ok {
+		return fmt.Errorf("kafka_version is invalid: %s", p.KafkaVersion)
+	}
+
+	if p.SASL.Enable {
+		switch {
+		case p.SASL.User == "":
+			return fmt.Errorf("sasl.user cannot be empty")
+		case p.SASL.Password == "":
+			return fmt.Errorf("sasl.password cannot be empty")
+		}
+	}
+
+	return nil
+}
+
+// messageValue returns the bytes which are published to the topic.
+func (p *PublishOptions) messageValue() []byte {
+	var value string
+	if err := json.Unmarshal(p.Message, &value); err == nil {
+		return []byte(value)
+	}
+	return p.Message
+}
+
 type SubscriptionConfiguration struct {
 	BrokerAddr           string `json:"broker_addr"`
 	Topic                string `json:"topic"`
@@ -136,6 +208,21 @@ type SubscriptionConfiguration struct {
 	SASL                 SASL   `json:"sasl"`
 }
 
+// PublishConfiguration configures mutation fields which publish a message to a topic.
+// Message and Key support templates, e.g. {"name":"{{ .arguments.name }}"}. A message which is no JSON object or array
+// must be a JSON string, e.g. "{{ .arguments.text }}". The field resolves to {"partition":0,"offset":1},
+// so it should be configured with DisableDefaultMapping.
+type PublishConfiguration struct {
+	BrokerAddr   string `json:"broker_addr"`
+	Topic        string `json:"topic"`
+	ClientID     string `json:"client_id"`
+	KafkaVersion string `json:"kafka_version"`
+	SASL         SASL   `json:"sasl"`
+	Key          string `json:"key"`
+	Message      string `json:"message"`
+}
+
 type Configuration struct {
 	Subscription SubscriptionConfiguration
+	Publish      PublishConfiguration
 }
@@ -146,3 +146,62 @@ func TestConfig_GraphQLSubscriptionOptions(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestConfig_PublishOptions(t *testing.T) {
+	t.Run("Set default Kafka version", func(t *testing.T) {
+		p := &PublishOptions{}
+		p.Sanitize()
+		require.Equal(t, DefaultKafkaVersion, p.KafkaVersion)
+	})
+
+	t.Run("Empty broker_addr not allowed", func(t *testing.T) {
+		p := &PublishOptions{
+			Topic:    "foobar",
+			ClientID: "clientid",
+			Message:  []byte(`"message"`),
+		}
+		p.Sanitize()
+		err := p.Validate()
+		require.Equal(t, err.Error(), "broker_addr cannot be empty")
+	})
+
+	t.Run("Empty message not allowed", func(t *testing.T) {
+		p := &PublishOptions{
+			BrokerAddr: "localhost:9092",
+			Topic:      "foobar",
+			ClientID:   "clientid",
+			Message:    []byte(`null`),
+		}
+		p.Sanitize()
+		err := p.Validate()
+		require.Equal(t, err.Error(), "message cannot be empty")
+	})
+
+	t.Run("Invalid SASL configuration - password cannot be empty", func(t *testing.T) {
+		p := &PublishOptions{
+			BrokerAddr: "localhost:9092",
+			Topic:      "foobar",
+			ClientID:   "clientid",
+			Message:    []byte(`"message"`),
+			SASL: SASL{
+				Enable: true,
+				User:   "foobar",
+			},
+		}
+		p.Sanitize()
+		err := p.Validate()
+		require.Equal(t, err.Error(), "sasl.password cannot be empty")
+	})
+
+	t.Run("Valid configuration", func(t *testing.T) {
+		p := &PublishOptions{
+			BrokerAddr: "localhost:9092",
+			Topic:      "foobar",
+			ClientID:   "clientid",
+			Message:    []byte(`{"foo":"bar"}`),
+		}
+		p.Sanitize()
+		err := p.Validate()
+		require.NoError(t, err)
+	})
+}
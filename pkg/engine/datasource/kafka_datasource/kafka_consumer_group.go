@@ -178,9 +178,7 @@ func NewKafkaConsumerGroupBridge(ctx context.Context, logger log.Logger) *KafkaC
 }
 
 func (c *KafkaConsumerGroupBridge) prepareSaramaConfig(options *GraphQLSubscriptionOptions) (*sarama.Config, error) {
-	sc := sarama.NewConfig()
-	sc.Version = SaramaSupportedKafkaVersions[options.KafkaVersion]
-	sc.ClientID = options.ClientID
+	sc := newSaramaConfig(options.ClientID, options.KafkaVersion, options.SASL)
 
 	// Strategy for allocating topic partitions to members (default BalanceStrategyRange)
 	// See this: https://chrzaszcz.dev/2021/09/kafka-assignors/
@@ -209,14 +207,6 @@ func (c *KafkaConsumerGroupBridge) prepareSaramaConfig(options *GraphQLSubscript
 		sc.Consumer.IsolationLevel = sarama.ReadUncommitted
 	}
 
-	// SASL based authentication with broker. While there are multiple SASL authentication methods
-	// the current implementation is limited to plaintext (SASL/PLAIN) authentication
-	if options.SASL.Enable {
-		sc.Net.SASL.Enable = true
-		sc.Net.SASL.User = options.SASL.User
-		sc.Net.SASL.Password = options.SASL.Password
-	}
-
 	return sc, nil
 }
 
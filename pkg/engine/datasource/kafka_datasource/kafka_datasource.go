@@ -3,14 +3,19 @@ package kafka_datasource
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 
 	"github.com/jensneuse/abstractlogger"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
+	"github.com/tidwall/sjson"
 )
 
 type Planner struct {
-	ctx    context.Context
-	config Configuration
+	ctx      context.Context
+	config   Configuration
+	producer Producer
 }
 
 func (p *Planner) Register(_ *plan.Visitor, configuration plan.DataSourceConfiguration, _ bool) error {
@@ -18,7 +23,27 @@ func (p *Planner) Register(_ *plan.Visitor, configuration plan.DataSourceConfigu
 }
 
 func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
-	return plan.FetchConfiguration{}
+	input, _ := json.Marshal(PublishOptions{
+		BrokerAddr:   p.config.Publish.BrokerAddr,
+		Topic:        p.config.Publish.Topic,
+		ClientID:     p.config.Publish.ClientID,
+		KafkaVersion: p.config.Publish.KafkaVersion,
+		SASL:         p.config.Publish.SASL,
+		Key:          p.config.Publish.Key,
+		Message:      json.RawMessage(`null`),
+	})
+	if p.config.Publish.Message != "" {
+		// the message is set as raw template so that it gets rendered in place
+		input, _ = sjson.SetRawBytes(input, "message", []byte(p.config.Publish.Message))
+	}
+	return plan.FetchConfiguration{
+		Input: string(input),
+		DataSource: &Source{
+			producer: p.producer,
+		},
+		DisallowSingleFlight: true,
+		DisableDataLoader:    true,
+	}
 }
 
 func (p *Planner) ConfigureSubscription() plan.SubscriptionConfiguration {
@@ -40,11 +65,17 @@ func (p *Planner) DataSourcePlanningBehavior() plan.DataSourcePlanningBehavior {
 
 func (p *Planner) DownstreamResponseFieldAlias(_ int) (alias string, exists bool) { return }
 
-type Factory struct{}
+type Factory struct {
+	producer *KafkaProducerBridge
+}
 
 func (f *Factory) Planner(ctx context.Context) plan.DataSourcePlanner {
+	if f.producer == nil {
+		f.producer = NewKafkaProducerBridge(ctx, abstractlogger.NoopLogger)
+	}
 	return &Planner{
-		ctx: ctx,
+		ctx:      ctx,
+		producer: f.producer,
 	}
 }
 
@@ -70,5 +101,28 @@ func (s *SubscriptionSource) Start(ctx context.Context, input []byte, next chan<
 	return s.client.Subscribe(ctx, options, next)
 }
 
+type Producer interface {
+	Publish(ctx context.Context, options PublishOptions) (partition int32, offset int64, err error)
+}
+
+// Source publishes the rendered message and responds with the partition and offset of the stored message.
+type Source struct {
+	producer Producer
+}
+
+func (s *Source) Load(ctx context.Context, input []byte, writer io.Writer) (err error) {
+	var options PublishOptions
+	if err = json.Unmarshal(input, &options); err != nil {
+		return err
+	}
+	partition, offset, err := s.producer.Publish(ctx, options)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(writer, `{"partition":%d,"offset":%d}`, partition, offset)
+	return err
+}
+
 var _ plan.PlannerFactory = (*Factory)(nil)
 var _ plan.DataSourcePlanner = (*Planner)(nil)
+var _ resolve.DataSource = (*Source)(nil)
@@ -1,6 +1,7 @@
 package kafka_datasource
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -312,3 +313,169 @@ func TestKafkaConsumerGroupBridge_Subscribe(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expectedMsg, value)
 }
+
+func TestKafkaDataSource_Mutation(t *testing.T) {
+	t.Run("mutation publishes message", datasourcetesting.RunTest(`
+		schema {
+			query: Query
+			mutation: Mutation
+		}
+
+		type Query {
+			hello: String
+		}
+
+		type Mutation {
+			sendMessage(id: ID!, text: String!): PublishResult!
+		}
+
+		type PublishResult {
+			partition: Int!
+			offset: Int!
+		}
+`, `
+		mutation SendMessage($id: ID!, $text: String!) {
+			sendMessage(id: $id, text: $text) {
+				partition
+				offset
+			}
+		}
+	`, "SendMessage", &plan.SynchronousResponsePlan{
+		Response: &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fetch: &resolve.SingleFetch{
+					BufferId: 0,
+					Input: fmt.Sprintf(`{"broker_addr":"localhost:9092","topic":"test.topic","client_id":"test.client.id","kafka_version":"%s","sasl":{"enable":false,"user":"","password":""},"key":"$$0$$","message":{"text":"$$1$$"}}`,
+						testMockKafkaVersion,
+					),
+					Variables: resolve.NewVariables(
+						&resolve.ContextVariable{
+							Path:     []string{"id"},
+							Renderer: resolve.NewPlainVariableRendererWithValidation(`{"type":["string","integer"]}`),
+						},
+						&resolve.ContextVariable{
+							Path:     []string{"text"},
+							Renderer: resolve.NewPlainVariableRendererWithValidation(`{"type":["string"]}`),
+						},
+					),
+					DataSource:           &Source{},
+					DataSourceIdentifier: []byte("kafka_datasource.Source"),
+					DisallowSingleFlight: true,
+					DisableDataLoader:    true,
+				},
+				Fields: []*resolve.Field{
+					{
+						BufferID:  0,
+						HasBuffer: true,
+						Name:      []byte("sendMessage"),
+						Value: &resolve.Object{
+							Fields: []*resolve.Field{
+								{
+									Name: []byte("partition"),
+									Value: &resolve.Integer{
+										Path: []string{"partition"},
+									},
+								},
+								{
+									Name: []byte("offset"),
+									Value: &resolve.Integer{
+										Path: []string{"offset"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, plan.Configuration{
+		DataSources: []plan.DataSourceConfiguration{
+			{
+				RootNodes: []plan.TypeField{
+					{
+						TypeName:   "Mutation",
+						FieldNames: []string{"sendMessage"},
+					},
+				},
+				ChildNodes: []plan.TypeField{
+					{
+						TypeName:   "PublishResult",
+						FieldNames: []string{"partition", "offset"},
+					},
+				},
+				Custom: ConfigJSON(Configuration{
+					Publish: PublishConfiguration{
+						BrokerAddr:   "localhost:9092",
+						Topic:        "test.topic",
+						ClientID:     "test.client.id",
+						KafkaVersion: testMockKafkaVersion,
+						Key:          "{{ .arguments.id }}",
+						Message:      `{"text":"{{ .arguments.text }}"}`,
+					},
+				}),
+				Factory: &Factory{},
+			},
+		},
+		Fields: []plan.FieldConfiguration{
+			{
+				TypeName:              "Mutation",
+				FieldName:             "sendMessage",
+				DisableDefaultMapping: true,
+				Arguments: []plan.ArgumentConfiguration{
+					{
+						Name:       "id",
+						SourceType: plan.FieldArgumentSource,
+					},
+					{
+						Name:       "text",
+						SourceType: plan.FieldArgumentSource,
+					},
+				},
+			},
+		},
+		DisableResolveFieldPositions: true,
+	}))
+}
+
+type testProducer struct {
+	options PublishOptions
+	err     error
+}
+
+func (t *testProducer) Publish(_ context.Context, options PublishOptions) (partition int32, offset int64, err error) {
+	t.options = options
+	return 1, 42, t.err
+}
+
+func TestKafkaDataSource_Source_Load(t *testing.T) {
+	t.Run("should write partition and offset", func(t *testing.T) {
+		producer := &testProducer{}
+		source := &Source{producer: producer}
+
+		buf := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"broker_addr":"localhost:9092","topic":"test.topic","client_id":"test.client.id","key":"1","message":{"text":"hello"}}`), buf)
+		require.NoError(t, err)
+		assert.Equal(t, `{"partition":1,"offset":42}`, buf.String())
+		assert.Equal(t, "test.topic", producer.options.Topic)
+		assert.Equal(t, "1", producer.options.Key)
+		assert.Equal(t, `{"text":"hello"}`, string(producer.options.messageValue()))
+	})
+
+	t.Run("should publish string messages without quotes", func(t *testing.T) {
+		producer := &testProducer{}
+		source := &Source{producer: producer}
+
+		err := source.Load(context.Background(), []byte(`{"broker_addr":"localhost:9092","topic":"test.topic","client_id":"test.client.id","message":"hello"}`), &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(producer.options.messageValue()))
+	})
+
+	t.Run("should return producer error", func(t *testing.T) {
+		source := &Source{producer: &testProducer{err: sarama.ErrNotLeaderForPartition}}
+
+		buf := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"broker_addr":"localhost:9092","topic":"test.topic","client_id":"test.client.id","message":"hello"}`), buf)
+		assert.ErrorIs(t, err, sarama.ErrNotLeaderForPartition)
+		assert.Equal(t, 0, buf.Len())
+	})
+}
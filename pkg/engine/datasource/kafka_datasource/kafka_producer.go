@@ -0,0 +1,156 @@
+package kafka_datasource
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	log "github.com/jensneuse/abstractlogger"
+)
+
+// KafkaProducerBridge publishes messages to Kafka topics.
+// Producers are shared between requests with the same connection options and closed when the gateway context is done.
+type KafkaProducerBridge struct {
+	log log.Logger
+	ctx context.Context
+
+	mu        sync.Mutex
+	producers map[producerKey]sarama.SyncProducer
+	closed    bool
+}
+
+func NewKafkaProducerBridge(ctx context.Context, logger log.Logger) *KafkaProducerBridge {
+	if logger == nil {
+		logger = log.NoopLogger
+	}
+	p := &KafkaProducerBridge{
+		ctx:       ctx,
+		log:       logger,
+		producers: map[producerKey]sarama.SyncProducer{},
+	}
+	go func() {
+		<-ctx.Done()
+		p.close()
+	}()
+	return p
+}
+
+func (p *KafkaProducerBridge) prepareSaramaConfig(options *PublishOptions) *sarama.Config {
+	sc := newSaramaConfig(options.ClientID, options.KafkaVersion, options.SASL)
+	// SyncProducer requires both to be enabled.
+	sc.Producer.Return.Successes = true
+	sc.Producer.Return.Errors = true
+	sc.Producer.RequiredAcks = sarama.WaitForAll
+	return sc
+}
+
+// producerKey identifies the connection options of a producer.
+// The SASL password is only kept as hash, so the map of producers holds no secrets.
+type producerKey struct {
+	brokerAddr       string
+	clientID         string
+	kafkaVersion     string
+	saslEnable       bool
+	saslUser         string
+	saslPasswordHash [sha256.Size]byte
+}
+
+func newProducerKey(options *PublishOptions) producerKey {
+	return producerKey{
+		brokerAddr:       options.BrokerAddr,
+		clientID:         options.ClientID,
+		kafkaVersion:     options.KafkaVersion,
+		saslEnable:       options.SASL.Enable,
+		saslUser:         options.SASL.User,
+		saslPasswordHash: sha256.Sum256([]byte(options.SASL.Password)),
+	}
+}
+
+// producer returns the shared producer for the connection options or creates a new one.
+// Creating a producer dials the broker, so it happens without holding the lock.
+func (p *KafkaProducerBridge) producer(options *PublishOptions) (sarama.SyncProducer, error) {
+	key := newProducerKey(options)
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, context.Canceled
+	}
+	if producer, ok := p.producers[key]; ok {
+		p.mu.Unlock()
+		return producer, nil
+	}
+	p.mu.Unlock()
+
+	producer, err := sarama.NewSyncProducer([]string{options.BrokerAddr}, p.prepareSaramaConfig(options))
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		p.closeProducer(producer)
+		return nil, context.Canceled
+	}
+	if existing, ok := p.producers[key]; ok {
+		// another request created a producer concurrently, its producer is shared
+		p.closeProducer(producer)
+		return existing, nil
+	}
+	p.producers[key] = producer
+	return producer, nil
+}
+
+// Publish sends the message to the configured topic and returns the partition and offset it has been stored at.
+// The context is checked before the message is sent. Sarama can't cancel a message in flight,
+// so sending is bounded by the timeouts of the sarama config instead of the context.
+func (p *KafkaProducerBridge) Publish(ctx context.Context, options PublishOptions) (partition int32, offset int64, err error) {
+	options.Sanitize()
+	if err = options.Validate(); err != nil {
+		return 0, 0, err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	producer, err := p.producer(&options)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// creating the producer might have taken until the context is done
+	if err = ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	message := &sarama.ProducerMessage{
+		Topic: options.Topic,
+		Value: sarama.ByteEncoder(options.messageValue()),
+	}
+	if options.Key != "" {
+		message.Key = sarama.StringEncoder(options.Key)
+	}
+
+	return producer.SendMessage(message)
+}
+
+func (p *KafkaProducerBridge) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for key, producer := range p.producers {
+		p.closeProducer(producer)
+		delete(p.producers, key)
+	}
+}
+
+func (p *KafkaProducerBridge) closeProducer(producer sarama.SyncProducer) {
+	if err := producer.Close(); err != nil {
+		p.log.Error("KafkaProducerBridge.close", log.Error(err))
+	}
+}
@@ -0,0 +1,194 @@
+package kafka_datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockProducerBroker(t *testing.T, topic string, kerror sarama.KError) *sarama.MockBroker {
+	mockBroker := sarama.NewMockBroker(t, 0)
+	mockBroker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(mockBroker.Addr(), mockBroker.BrokerID()).
+			SetLeader(topic, defaultPartition, mockBroker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).
+			SetError(topic, defaultPartition, kerror).
+			SetVersion(2),
+	})
+	return mockBroker
+}
+
+func TestNewProducerKey(t *testing.T) {
+	options := PublishOptions{
+		BrokerAddr: "localhost:9092",
+		ClientID:   "graphql-go-tools-test",
+		SASL:       SASL{Enable: true, User: "user", Password: "secret"},
+	}
+	key := newProducerKey(&options)
+	assert.NotContains(t, fmt.Sprintf("%+v", key), "secret")
+	assert.Equal(t, key, newProducerKey(&options))
+
+	options.SASL.Password = "other"
+	assert.NotEqual(t, key, newProducerKey(&options))
+}
+
+func TestKafkaProducerBridge_Publish(t *testing.T) {
+	topic := "test.topic"
+
+	t.Run("should publish message", func(t *testing.T) {
+		mockBroker := newMockProducerBroker(t, topic, sarama.ErrNoError)
+		defer mockBroker.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		producer := NewKafkaProducerBridge(ctx, logger())
+		partition, offset, err := producer.Publish(context.Background(), PublishOptions{
+			BrokerAddr:   mockBroker.Addr(),
+			Topic:        topic,
+			ClientID:     "graphql-go-tools-test",
+			KafkaVersion: testMockKafkaVersion,
+			Key:          "test.message.key",
+			Message:      json.RawMessage(`{"stock":[{"name":"Trilby","price":293,"inStock":2}]}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(defaultPartition), partition)
+		assert.Equal(t, int64(0), offset)
+
+		var produceRequest *sarama.ProduceRequest
+		for _, request := range mockBroker.History() {
+			if r, ok := request.Request.(*sarama.ProduceRequest); ok {
+				produceRequest = r
+			}
+		}
+		require.NotNil(t, produceRequest)
+	})
+
+	t.Run("should reuse producer for same connection options", func(t *testing.T) {
+		mockBroker := newMockProducerBroker(t, topic, sarama.ErrNoError)
+		defer mockBroker.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		producer := NewKafkaProducerBridge(ctx, logger())
+		options := PublishOptions{
+			BrokerAddr:   mockBroker.Addr(),
+			Topic:        topic,
+			ClientID:     "graphql-go-tools-test",
+			KafkaVersion: testMockKafkaVersion,
+			Message:      json.RawMessage(`"hello"`),
+		}
+		for i := 0; i < 2; i++ {
+			_, _, err := producer.Publish(context.Background(), options)
+			require.NoError(t, err)
+		}
+		assert.Len(t, producer.producers, 1)
+	})
+
+	t.Run("should share the producer between concurrent requests", func(t *testing.T) {
+		mockBroker := newMockProducerBroker(t, topic, sarama.ErrNoError)
+		defer mockBroker.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		producer := NewKafkaProducerBridge(ctx, logger())
+		options := &PublishOptions{
+			BrokerAddr:   mockBroker.Addr(),
+			Topic:        topic,
+			ClientID:     "graphql-go-tools-test",
+			KafkaVersion: testMockKafkaVersion,
+		}
+		producers := make([]sarama.SyncProducer, 4)
+		wg := sync.WaitGroup{}
+		for i := range producers {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				syncProducer, err := producer.producer(options)
+				assert.NoError(t, err)
+				producers[i] = syncProducer
+			}(i)
+		}
+		wg.Wait()
+
+		for _, syncProducer := range producers {
+			assert.Equal(t, producers[0], syncProducer)
+		}
+		assert.Len(t, producer.producers, 1)
+	})
+
+	t.Run("should return error of done request context", func(t *testing.T) {
+		producer := NewKafkaProducerBridge(context.Background(), logger())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, _, err := producer.Publish(ctx, PublishOptions{
+			BrokerAddr: "localhost:9092",
+			Topic:      topic,
+			ClientID:   "graphql-go-tools-test",
+			Message:    json.RawMessage(`"hello"`),
+		})
+		assert.Equal(t, context.Canceled, err)
+		assert.Len(t, producer.producers, 0)
+	})
+
+	t.Run("should return broker error", func(t *testing.T) {
+		mockBroker := newMockProducerBroker(t, topic, sarama.ErrMessageSizeTooLarge)
+		defer mockBroker.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		producer := NewKafkaProducerBridge(ctx, logger())
+		_, _, err := producer.Publish(context.Background(), PublishOptions{
+			BrokerAddr:   mockBroker.Addr(),
+			Topic:        topic,
+			ClientID:     "graphql-go-tools-test",
+			KafkaVersion: testMockKafkaVersion,
+			Message:      json.RawMessage(`"hello"`),
+		})
+		assert.ErrorIs(t, err, sarama.ErrMessageSizeTooLarge)
+	})
+
+	t.Run("should return validation error", func(t *testing.T) {
+		producer := NewKafkaProducerBridge(context.Background(), logger())
+		_, _, err := producer.Publish(context.Background(), PublishOptions{
+			BrokerAddr: "localhost:9092",
+			Topic:      topic,
+			ClientID:   "graphql-go-tools-test",
+		})
+		assert.EqualError(t, err, "message cannot be empty")
+	})
+
+	t.Run("should close producers when gateway context is done", func(t *testing.T) {
+		mockBroker := newMockProducerBroker(t, topic, sarama.ErrNoError)
+		defer mockBroker.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		producer := NewKafkaProducerBridge(ctx, logger())
+		options := PublishOptions{
+			BrokerAddr:   mockBroker.Addr(),
+			Topic:        topic,
+			ClientID:     "graphql-go-tools-test",
+			KafkaVersion: testMockKafkaVersion,
+			Message:      json.RawMessage(`"hello"`),
+		}
+		_, _, err := producer.Publish(context.Background(), options)
+		require.NoError(t, err)
+
+		cancel()
+		assert.Eventually(t, func() bool {
+			_, _, err := producer.Publish(context.Background(), options)
+			return err == context.Canceled
+		}, time.Second, 10*time.Millisecond)
+	})
+}
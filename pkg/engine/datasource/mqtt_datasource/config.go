@@ -0,0 +1,100 @@
+package mqtt_datasource
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type GraphQLSubscriptionOptions struct {
+	BrokerAddr string `json:"broker_addr"`
+	Topic      string `json:"topic"`
+	ClientID   string `json:"client_id"`
+	QoS        byte   `json:"qos"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+}
+
+func (g *GraphQLSubscriptionOptions) Validate() error {
+	switch {
+	case g.BrokerAddr == "":
+		return fmt.Errorf("broker_addr cannot be empty")
+	case g.Topic == "":
+		return fmt.Errorf("topic cannot be empty")
+	case g.ClientID == "":
+		return fmt.Errorf("client_id cannot be empty")
+	}
+
+	return validateQoS(g.QoS)
+}
+
+type PublishOptions struct {
+	BrokerAddr string `json:"broker_addr"`
+	Topic      string `json:"topic"`
+	ClientID   string `json:"client_id"`
+	QoS        byte   `json:"qos"`
+	Retained   bool   `json:"retained"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	// Message is the rendered message, a JSON string is published without quotes.
+	Message json.RawMessage `json:"message"`
+}
+
+func (p *PublishOptions) Validate() error {
+	switch {
+	case p.BrokerAddr == "":
+		return fmt.Errorf("broker_addr cannot be empty")
+	case p.Topic == "":
+		return fmt.Errorf("topic cannot be empty")
+	case p.ClientID == "":
+		return fmt.Errorf("client_id cannot be empty")
+	case len(p.Message) == 0 || string(p.Message) == "null":
+		return fmt.Errorf("message cannot be empty")
+	}
+
+	return validateQoS(p.QoS)
+}
+
+// messageValue returns the bytes which are published to the topic.
+func (p *PublishOptions) messageValue() []byte {
+	var value string
+	if err := json.Unmarshal(p.Message, &value); err == nil {
+		return []byte(value)
+	}
+	return p.Message
+}
+
+func validateQoS(qos byte) error {
+	if qos > 2 {
+		return fmt.Errorf("qos is invalid: %d", qos)
+	}
+	return nil
+}
+
+type SubscriptionConfiguration struct {
+	BrokerAddr string `json:"broker_addr"`
+	Topic      string `json:"topic"`
+	ClientID   string `json:"client_id"`
+	QoS        byte   `json:"qos"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+}
+
+// PublishConfiguration configures mutation fields which publish a message to a topic.
+// Topic and Message support templates, e.g. {"name":"{{ .arguments.name }}"}. A message which is no JSON object or array
+// must be a JSON string, e.g. "{{ .arguments.text }}". The field resolves to {"success":true},
+// so it should be configured with DisableDefaultMapping.
+type PublishConfiguration struct {
+	BrokerAddr string `json:"broker_addr"`
+	Topic      string `json:"topic"`
+	ClientID   string `json:"client_id"`
+	QoS        byte   `json:"qos"`
+	Retained   bool   `json:"retained"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	Message    string `json:"message"`
+}
+
+type Configuration struct {
+	Subscription SubscriptionConfiguration
+	Publish      PublishConfiguration
+}
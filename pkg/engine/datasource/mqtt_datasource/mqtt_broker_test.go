@@ -0,0 +1,161 @@
+package mqtt_datasource
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/stretchr/testify/require"
+)
+
+// testBroker is a minimal in-process MQTT 3.1.1 broker.
+// It supports exact topic matches and forwards all messages with QoS 0.
+type testBroker struct {
+	listener net.Listener
+
+	mu            sync.Mutex
+	subscriptions map[*testBrokerConn]map[string]struct{}
+	connects      int
+}
+
+type testBrokerConn struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+func (c *testBrokerConn) write(packet packets.ControlPacket) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return packet.Write(c.conn)
+}
+
+func newTestBroker(t *testing.T) *testBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	b := &testBroker{
+		listener:      listener,
+		subscriptions: map[*testBrokerConn]map[string]struct{}{},
+	}
+	go b.serve()
+	t.Cleanup(b.close)
+	return b
+}
+
+func (b *testBroker) addr() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *testBroker) connectCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connects
+}
+
+func (b *testBroker) subscriberCount(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	count := 0
+	for _, topics := range b.subscriptions {
+		if _, ok := topics[topic]; ok {
+			count++
+		}
+	}
+	return count
+}
+
+func (b *testBroker) close() {
+	_ = b.listener.Close()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for conn := range b.subscriptions {
+		_ = conn.conn.Close()
+	}
+}
+
+func (b *testBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(&testBrokerConn{conn: conn})
+	}
+}
+
+func (b *testBroker) handle(conn *testBrokerConn) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.subscriptions, conn)
+		b.mu.Unlock()
+		_ = conn.conn.Close()
+	}()
+
+	for {
+		packet, err := packets.ReadPacket(conn.conn)
+		if err != nil {
+			return
+		}
+
+		switch p := packet.(type) {
+		case *packets.ConnectPacket:
+			b.mu.Lock()
+			b.connects++
+			b.subscriptions[conn] = map[string]struct{}{}
+			b.mu.Unlock()
+			err = conn.write(packets.NewControlPacket(packets.Connack))
+		case *packets.SubscribePacket:
+			b.mu.Lock()
+			for _, topic := range p.Topics {
+				b.subscriptions[conn][topic] = struct{}{}
+			}
+			b.mu.Unlock()
+			suback := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			suback.MessageID = p.MessageID
+			suback.ReturnCodes = make([]byte, len(p.Topics))
+			err = conn.write(suback)
+		case *packets.UnsubscribePacket:
+			b.mu.Lock()
+			for _, topic := range p.Topics {
+				delete(b.subscriptions[conn], topic)
+			}
+			b.mu.Unlock()
+			unsuback := packets.NewControlPacket(packets.Unsuback).(*packets.UnsubackPacket)
+			unsuback.MessageID = p.MessageID
+			err = conn.write(unsuback)
+		case *packets.PublishPacket:
+			b.forward(p)
+			if p.Qos > 0 {
+				puback := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				puback.MessageID = p.MessageID
+				err = conn.write(puback)
+			}
+		case *packets.PingreqPacket:
+			err = conn.write(packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (b *testBroker) forward(publish *packets.PublishPacket) {
+	b.mu.Lock()
+	receivers := make([]*testBrokerConn, 0, len(b.subscriptions))
+	for conn, topics := range b.subscriptions {
+		if _, ok := topics[publish.TopicName]; ok {
+			receivers = append(receivers, conn)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, conn := range receivers {
+		message := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+		message.TopicName = publish.TopicName
+		message.Payload = publish.Payload
+		_ = conn.write(message)
+	}
+}
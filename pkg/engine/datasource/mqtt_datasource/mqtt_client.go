@@ -0,0 +1,250 @@
+package mqtt_datasource
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/buger/jsonparser"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	log "github.com/jensneuse/abstractlogger"
+)
+
+const (
+	connectTimeout    = 10 * time.Second
+	disconnectQuiesce = 250
+)
+
+var errTimeout = errors.New("timeout while waiting for the mqtt broker")
+
+// MQTTClientBridge subscribes to and publishes on MQTT topics.
+// Every subscription uses its own connection, connections used for publishing are shared between requests
+// with the same connection options. All connections are closed when the gateway context is done.
+type MQTTClientBridge struct {
+	log log.Logger
+	ctx context.Context
+
+	mu         sync.Mutex
+	publishers map[publisherKey]mqtt.Client
+	closed     bool
+}
+
+func NewMQTTClientBridge(ctx context.Context, logger log.Logger) *MQTTClientBridge {
+	if logger == nil {
+		logger = log.NoopLogger
+	}
+	b := &MQTTClientBridge{
+		ctx:        ctx,
+		log:        logger,
+		publishers: map[publisherKey]mqtt.Client{},
+	}
+	go func() {
+		<-ctx.Done()
+		b.close()
+	}()
+	return b
+}
+
+// connect connects a new client to the broker.
+// A broker allows a single connection per client ID, so the configured client ID gets a random suffix.
+func (b *MQTTClientBridge) connect(brokerAddr, clientID, username, password string) (mqtt.Client, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(brokerAddr).
+		SetClientID(fmt.Sprintf("%s-%s", clientID, hex.EncodeToString(suffix))).
+		SetUsername(username).
+		SetPassword(password).
+		SetKeepAlive(5 * time.Second).
+		SetPingTimeout(5 * time.Second).
+		SetConnectTimeout(connectTimeout).
+		SetResumeSubs(true).
+		SetAutoReconnect(true)
+
+	client := mqtt.NewClient(opts)
+	if err := waitForToken(client.Connect()); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// waitForToken waits until the token is completed and returns its error.
+// Token.WaitTimeout holds a lock which prevents the client from setting an error, so it can't be used here.
+func waitForToken(token mqtt.Token) error {
+	completed := make(chan struct{})
+	go func() {
+		token.Wait()
+		close(completed)
+	}()
+
+	timer := time.NewTimer(connectTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-completed:
+		return token.Error()
+	case <-timer.C:
+		return errTimeout
+	}
+}
+
+// Subscribe subscribes to the topic and streams every message via next channel.
+func (b *MQTTClientBridge) Subscribe(ctx context.Context, options GraphQLSubscriptionOptions, next chan<- []byte) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	client, err := b.connect(options.BrokerAddr, options.ClientID, options.Username, options.Password)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	messages := make(chan []byte)
+	token := client.Subscribe(options.Topic, options.QoS, func(_ mqtt.Client, message mqtt.Message) {
+		select {
+		case messages <- message.Payload():
+		case <-done:
+		}
+	})
+	if err = waitForToken(token); err != nil {
+		client.Disconnect(disconnectQuiesce)
+		return err
+	}
+
+	go func() {
+		defer func() {
+			close(done)
+			if err := waitForToken(client.Unsubscribe(options.Topic)); err != nil {
+				b.log.Error("MQTTClientBridge.Subscribe.Unsubscribe",
+					log.String("topic", options.Topic),
+					log.Error(err),
+				)
+			}
+			client.Disconnect(disconnectQuiesce)
+			close(next)
+		}()
+
+		for {
+			select {
+			case <-b.ctx.Done():
+				// Gateway context
+				return
+			case <-ctx.Done():
+				// Request context
+				return
+			case payload := <-messages:
+				// The "data" field contains the result of your GraphQL request.
+				result, err := jsonparser.Set([]byte(`{}`), payload, "data")
+				if err != nil {
+					b.log.Error("MQTTClientBridge.Subscribe",
+						log.String("topic", options.Topic),
+						log.Error(err),
+					)
+					continue
+				}
+				select {
+				case next <- result:
+				case <-b.ctx.Done():
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// publisherKey identifies the connection options of a publisher.
+// The password is only kept as hash, so the map of publishers holds no secrets.
+type publisherKey struct {
+	brokerAddr   string
+	clientID     string
+	username     string
+	passwordHash [sha256.Size]byte
+}
+
+func newPublisherKey(options *PublishOptions) publisherKey {
+	return publisherKey{
+		brokerAddr:   options.BrokerAddr,
+		clientID:     options.ClientID,
+		username:     options.Username,
+		passwordHash: sha256.Sum256([]byte(options.Password)),
+	}
+}
+
+// publisher returns the shared client for the connection options or connects a new one.
+// Connecting might take until the connect timeout, so it happens without holding the lock.
+func (b *MQTTClientBridge) publisher(options *PublishOptions) (mqtt.Client, error) {
+	key := newPublisherKey(options)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, context.Canceled
+	}
+	if client, ok := b.publishers[key]; ok {
+		b.mu.Unlock()
+		return client, nil
+	}
+	b.mu.Unlock()
+
+	client, err := b.connect(options.BrokerAddr, options.ClientID, options.Username, options.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		client.Disconnect(disconnectQuiesce)
+		return nil, context.Canceled
+	}
+	if existing, ok := b.publishers[key]; ok {
+		// another request connected concurrently, its client is shared
+		client.Disconnect(disconnectQuiesce)
+		return existing, nil
+	}
+	b.publishers[key] = client
+	return client, nil
+}
+
+// Publish sends the message to the configured topic and waits until it has been delivered according to its QoS.
+func (b *MQTTClientBridge) Publish(ctx context.Context, options PublishOptions) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	client, err := b.publisher(&options)
+	if err != nil {
+		return err
+	}
+
+	return waitForToken(client.Publish(options.Topic, options.QoS, options.Retained, options.messageValue()))
+}
+
+func (b *MQTTClientBridge) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for key, client := range b.publishers {
+		client.Disconnect(disconnectQuiesce)
+		delete(b.publishers, key)
+	}
+}
@@ -0,0 +1,150 @@
+package mqtt_datasource
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveMessage(t *testing.T, next <-chan []byte) string {
+	t.Helper()
+	select {
+	case message := <-next:
+		return string(message)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for message")
+		return ""
+	}
+}
+
+func TestMQTTClientBridge(t *testing.T) {
+	t.Run("should publish and receive messages", func(t *testing.T) {
+		broker := newTestBroker(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		bridge := NewMQTTClientBridge(ctx, abstractlogger.NoopLogger)
+
+		next := make(chan []byte)
+		subscriptionCtx, cancelSubscription := context.WithCancel(context.Background())
+		err := bridge.Subscribe(subscriptionCtx, GraphQLSubscriptionOptions{
+			BrokerAddr: broker.addr(),
+			Topic:      "test.topic",
+			ClientID:   "test.client.id",
+		}, next)
+		require.NoError(t, err)
+
+		publish := func(message string) {
+			err := bridge.Publish(context.Background(), PublishOptions{
+				BrokerAddr: broker.addr(),
+				Topic:      "test.topic",
+				ClientID:   "test.client.id",
+				QoS:        1,
+				Message:    []byte(message),
+			})
+			require.NoError(t, err)
+		}
+
+		go publish(`{"text":"hello"}`)
+		assert.Equal(t, `{"data":{"text":"hello"}}`, receiveMessage(t, next))
+
+		go publish(`"{\"text\":\"world\"}"`)
+		assert.Equal(t, `{"data":{"text":"world"}}`, receiveMessage(t, next))
+
+		// subscription and publisher connection
+		assert.Equal(t, 2, broker.connectCount())
+
+		cancelSubscription()
+		_, ok := <-next
+		assert.False(t, ok)
+		assert.Eventually(t, func() bool {
+			return broker.subscriberCount("test.topic") == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should return validation errors", func(t *testing.T) {
+		bridge := NewMQTTClientBridge(context.Background(), abstractlogger.NoopLogger)
+
+		err := bridge.Subscribe(context.Background(), GraphQLSubscriptionOptions{BrokerAddr: "tcp://localhost:1883", ClientID: "client"}, make(chan []byte))
+		assert.EqualError(t, err, "topic cannot be empty")
+
+		err = bridge.Publish(context.Background(), PublishOptions{BrokerAddr: "tcp://localhost:1883", Topic: "topic", ClientID: "client", Message: []byte(`null`)})
+		assert.EqualError(t, err, "message cannot be empty")
+
+		err = bridge.Publish(context.Background(), PublishOptions{BrokerAddr: "tcp://localhost:1883", Topic: "topic", ClientID: "client", QoS: 3, Message: []byte(`"hello"`)})
+		assert.EqualError(t, err, "qos is invalid: 3")
+	})
+
+	t.Run("should return error when the broker is not reachable", func(t *testing.T) {
+		broker := newTestBroker(t)
+		addr := broker.addr()
+		broker.close()
+
+		bridge := NewMQTTClientBridge(context.Background(), abstractlogger.NoopLogger)
+		err := bridge.Subscribe(context.Background(), GraphQLSubscriptionOptions{BrokerAddr: addr, Topic: "topic", ClientID: "client"}, make(chan []byte))
+		assert.Error(t, err)
+	})
+
+	t.Run("should not block publishing while another broker is connecting", func(t *testing.T) {
+		// the stalled broker accepts connections but never acknowledges them
+		stalled, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer stalled.Close()
+		go func() {
+			for {
+				conn, err := stalled.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}()
+
+		broker := newTestBroker(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		bridge := NewMQTTClientBridge(ctx, abstractlogger.NoopLogger)
+
+		go func() {
+			_ = bridge.Publish(context.Background(), PublishOptions{BrokerAddr: "tcp://" + stalled.Addr().String(), Topic: "topic", ClientID: "client", Message: []byte(`"hello"`)})
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		published := make(chan error)
+		go func() {
+			published <- bridge.Publish(context.Background(), PublishOptions{BrokerAddr: broker.addr(), Topic: "topic", ClientID: "client", Message: []byte(`"hello"`)})
+		}()
+		select {
+		case err := <-published:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "publishing has been blocked by the connecting publisher")
+		}
+	})
+
+	t.Run("should key publishers without the password", func(t *testing.T) {
+		options := PublishOptions{BrokerAddr: "tcp://localhost:1883", ClientID: "client", Username: "user", Password: "secret"}
+		key := newPublisherKey(&options)
+		assert.NotContains(t, fmt.Sprintf("%+v", key), "secret")
+
+		options.Password = "other"
+		assert.NotEqual(t, key, newPublisherKey(&options))
+	})
+
+	t.Run("should not publish after the gateway context is done", func(t *testing.T) {
+		broker := newTestBroker(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		bridge := NewMQTTClientBridge(ctx, abstractlogger.NoopLogger)
+
+		cancel()
+		assert.Eventually(t, func() bool {
+			err := bridge.Publish(context.Background(), PublishOptions{BrokerAddr: broker.addr(), Topic: "topic", ClientID: "client", Message: []byte(`"hello"`)})
+			return err == context.Canceled
+		}, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,291 @@
+package mqtt_datasource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasourcetesting"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDefinition = `
+	schema {
+		query: Query
+		mutation: Mutation
+		subscription: Subscription
+	}
+
+	type Query {
+		hello: String
+	}
+
+	type Mutation {
+		sendMessage(room: String!, text: String!): PublishResult!
+	}
+
+	type PublishResult {
+		success: Boolean!
+	}
+
+	type Subscription {
+		messages(room: String!): Message!
+	}
+
+	type Message {
+		text: String!
+	}
+`
+
+func TestMQTTDataSource(t *testing.T) {
+	t.Run("subscription", datasourcetesting.RunTest(testDefinition, `
+		subscription Messages($room: String!) {
+			messages(room: $room) {
+				text
+			}
+		}
+	`, "Messages", &plan.SubscriptionResponsePlan{
+		Response: &resolve.GraphQLSubscription{
+			Trigger: resolve.GraphQLSubscriptionTrigger{
+				Input: []byte(`{"broker_addr":"tcp://localhost:1883","topic":"rooms/$$0$$","client_id":"test.client.id","qos":1,"username":"","password":""}`),
+				Variables: resolve.NewVariables(
+					&resolve.ContextVariable{
+						Path:     []string{"room"},
+						Renderer: resolve.NewPlainVariableRendererWithValidation(`{"type":["string"]}`),
+					},
+				),
+				Source: &SubscriptionSource{},
+			},
+			Response: &resolve.GraphQLResponse{
+				Data: &resolve.Object{
+					Fields: []*resolve.Field{
+						{
+							Name: []byte("messages"),
+							Value: &resolve.Object{
+								Path: []string{"messages"},
+								Fields: []*resolve.Field{
+									{
+										Name: []byte("text"),
+										Value: &resolve.String{
+											Path: []string{"text"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, plan.Configuration{
+		DataSources: []plan.DataSourceConfiguration{
+			{
+				RootNodes: []plan.TypeField{
+					{
+						TypeName:   "Subscription",
+						FieldNames: []string{"messages"},
+					},
+				},
+				ChildNodes: []plan.TypeField{
+					{
+						TypeName:   "Message",
+						FieldNames: []string{"text"},
+					},
+				},
+				Custom: ConfigJSON(Configuration{
+					Subscription: SubscriptionConfiguration{
+						BrokerAddr: "tcp://localhost:1883",
+						Topic:      "rooms/{{ .arguments.room }}",
+						ClientID:   "test.client.id",
+						QoS:        1,
+					},
+				}),
+				Factory: &Factory{},
+			},
+		},
+		Fields: []plan.FieldConfiguration{
+			{
+				TypeName:  "Subscription",
+				FieldName: "messages",
+				Arguments: []plan.ArgumentConfiguration{
+					{
+						Name:       "room",
+						SourceType: plan.FieldArgumentSource,
+					},
+				},
+			},
+		},
+		DisableResolveFieldPositions: true,
+	}))
+
+	t.Run("mutation publishes message", datasourcetesting.RunTest(testDefinition, `
+		mutation SendMessage($room: String!, $text: String!) {
+			sendMessage(room: $room, text: $text) {
+				success
+			}
+		}
+	`, "SendMessage", &plan.SynchronousResponsePlan{
+		Response: &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fetch: &resolve.SingleFetch{
+					BufferId: 0,
+					Input:    `{"broker_addr":"tcp://localhost:1883","topic":"rooms/$$0$$","client_id":"test.client.id","qos":1,"retained":false,"username":"","password":"","message":{"text":"$$1$$"}}`,
+					Variables: resolve.NewVariables(
+						&resolve.ContextVariable{
+							Path:     []string{"room"},
+							Renderer: resolve.NewPlainVariableRendererWithValidation(`{"type":["string"]}`),
+						},
+						&resolve.ContextVariable{
+							Path:     []string{"text"},
+							Renderer: resolve.NewPlainVariableRendererWithValidation(`{"type":["string"]}`),
+						},
+					),
+					DataSource:           &Source{},
+					DataSourceIdentifier: []byte("mqtt_datasource.Source"),
+					DisallowSingleFlight: true,
+					DisableDataLoader:    true,
+				},
+				Fields: []*resolve.Field{
+					{
+						BufferID:  0,
+						HasBuffer: true,
+						Name:      []byte("sendMessage"),
+						Value: &resolve.Object{
+							Fields: []*resolve.Field{
+								{
+									Name: []byte("success"),
+									Value: &resolve.Boolean{
+										Path: []string{"success"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, plan.Configuration{
+		DataSources: []plan.DataSourceConfiguration{
+			{
+				RootNodes: []plan.TypeField{
+					{
+						TypeName:   "Mutation",
+						FieldNames: []string{"sendMessage"},
+					},
+				},
+				ChildNodes: []plan.TypeField{
+					{
+						TypeName:   "PublishResult",
+						FieldNames: []string{"success"},
+					},
+				},
+				Custom: ConfigJSON(Configuration{
+					Publish: PublishConfiguration{
+						BrokerAddr: "tcp://localhost:1883",
+						Topic:      "rooms/{{ .arguments.room }}",
+						ClientID:   "test.client.id",
+						QoS:        1,
+						Message:    `{"text":"{{ .arguments.text }}"}`,
+					},
+				}),
+				Factory: &Factory{},
+			},
+		},
+		Fields: []plan.FieldConfiguration{
+			{
+				TypeName:              "Mutation",
+				FieldName:             "sendMessage",
+				DisableDefaultMapping: true,
+				Arguments: []plan.ArgumentConfiguration{
+					{
+						Name:       "room",
+						SourceType: plan.FieldArgumentSource,
+					},
+					{
+						Name:       "text",
+						SourceType: plan.FieldArgumentSource,
+					},
+				},
+			},
+		},
+		DisableResolveFieldPositions: true,
+	}))
+}
+
+type testPublisher struct {
+	options PublishOptions
+	err     error
+}
+
+func (t *testPublisher) Publish(_ context.Context, options PublishOptions) error {
+	t.options = options
+	return t.err
+}
+
+func TestMQTTDataSource_Source_Load(t *testing.T) {
+	t.Run("should publish message", func(t *testing.T) {
+		publisher := &testPublisher{}
+		source := &Source{publisher: publisher}
+
+		buf := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"broker_addr":"tcp://localhost:1883","topic":"rooms/1","client_id":"test.client.id","qos":1,"message":{"text":"hello"}}`), buf)
+		require.NoError(t, err)
+		assert.Equal(t, `{"success":true}`, buf.String())
+		assert.Equal(t, "rooms/1", publisher.options.Topic)
+		assert.Equal(t, byte(1), publisher.options.QoS)
+		assert.Equal(t, `{"text":"hello"}`, string(publisher.options.messageValue()))
+	})
+
+	t.Run("should return publisher error", func(t *testing.T) {
+		source := &Source{publisher: &testPublisher{err: errors.New("not connected")}}
+
+		buf := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"broker_addr":"tcp://localhost:1883","topic":"rooms/1","client_id":"test.client.id","message":"hello"}`), buf)
+		assert.EqualError(t, err, "not connected")
+		assert.Equal(t, 0, buf.Len())
+	})
+}
+
+func TestMQTTDataSource_Subscription_Start(t *testing.T) {
+	broker := newTestBroker(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bridge := NewMQTTClientBridge(ctx, abstractlogger.NoopLogger)
+
+	t.Run("should return error when input is invalid", func(t *testing.T) {
+		source := SubscriptionSource{client: bridge}
+		err := source.Start(context.Background(), []byte(`{"broker_addr":"",topic":""}`), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should receive published messages, then cancel subscription", func(t *testing.T) {
+		source := SubscriptionSource{client: bridge}
+		subscriptionCtx, cancelSubscription := context.WithCancel(context.Background())
+
+		next := make(chan []byte)
+		err := source.Start(subscriptionCtx, []byte(`{"broker_addr":"`+broker.addr()+`","topic":"rooms/1","client_id":"test.client.id"}`), next)
+		require.NoError(t, err)
+
+		publishSource := Source{publisher: bridge}
+		go func() {
+			err := publishSource.Load(context.Background(), []byte(`{"broker_addr":"`+broker.addr()+`","topic":"rooms/1","client_id":"test.client.id","qos":1,"message":{"text":"hello"}}`), &bytes.Buffer{})
+			assert.NoError(t, err)
+		}()
+
+		assert.Equal(t, `{"data":{"text":"hello"}}`, receiveMessage(t, next))
+
+		cancelSubscription()
+		select {
+		case _, ok := <-next:
+			assert.False(t, ok)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "subscription not closed")
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package nats_datasource
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+type GraphQLSubscriptionOptions struct {
+	Addr  string `json:"addr"`
+	Topic string `json:"topic"`
+}
+
+func (g *GraphQLSubscriptionOptions) Sanitize() {
+	if g.Addr == "" {
+		g.Addr = nats.DefaultURL
+	}
+}
+
+func (g *GraphQLSubscriptionOptions) Validate() error {
+	if g.Topic == "" {
+		return fmt.Errorf("topic cannot be empty")
+	}
+	return nil
+}
+
+type PublishOptions struct {
+	Addr  string `json:"addr"`
+	Topic string `json:"topic"`
+	// Message is the rendered message, a JSON string is published without quotes.
+	Message json.RawMessage `json:"message"`
+}
+
+func (p *PublishOptions) Sanitize() {
+	if p.Addr == "" {
+		p.Addr = nats.DefaultURL
+	}
+}
+
+func (p *PublishOptions) Validate() error {
+	switch {
+	case p.Topic == "":
+		return fmt.Errorf("topic cannot be empty")
+	case len(p.Message) == 0 || string(p.Message) == "null":
+		return fmt.Errorf("message cannot be empty")
+	}
+	return nil
+}
+
+// messageValue returns the bytes which are published to the topic.
+func (p *PublishOptions) messageValue() []byte {
+	var value string
+	if err := json.Unmarshal(p.Message, &value); err == nil {
+		return []byte(value)
+	}
+	return p.Message
+}
+
+// SubscriptionConfiguration configures subscription fields which stream the messages of a topic (subject).
+// Addr defaults to nats.DefaultURL.
+type SubscriptionConfiguration struct {
+	Addr  string `json:"addr"`
+	Topic string `json:"topic"`
+}
+
+// PublishConfiguration configures mutation fields which publish a message to a topic (subject).
+// Topic and Message support templates, e.g. {"name":"{{ .arguments.name }}"}. A message which is no JSON object or array
+// must be a JSON string, e.g. "{{ .arguments.text }}". The field resolves to {"success":true},
+// so it should be configured with DisableDefaultMapping.
+type PublishConfiguration struct {
+	Addr    string `json:"addr"`
+	Topic   string `json:"topic"`
+	Message string `json:"message"`
+}
+
+type Configuration struct {
+	Subscription SubscriptionConfiguration
+	Publish      PublishConfiguration
+}
@@ -0,0 +1,180 @@
+package nats_datasource
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/buger/jsonparser"
+	log "github.com/jensneuse/abstractlogger"
+	"github.com/nats-io/nats.go"
+)
+
+const flushTimeout = 10 * time.Second
+
+// NatsClientBridge subscribes to and publishes on NATS topics (subjects).
+// A single connection per server address is shared by all subscriptions and publishers,
+// connections are closed when the gateway context is done.
+type NatsClientBridge struct {
+	log log.Logger
+	ctx context.Context
+
+	mu     sync.Mutex
+	conns  map[string]*nats.Conn
+	closed bool
+}
+
+func NewNatsClientBridge(ctx context.Context, logger log.Logger) *NatsClientBridge {
+	if logger == nil {
+		logger = log.NoopLogger
+	}
+	b := &NatsClientBridge{
+		ctx:   ctx,
+		log:   logger,
+		conns: map[string]*nats.Conn{},
+	}
+	go func() {
+		<-ctx.Done()
+		b.close()
+	}()
+	return b
+}
+
+// conn returns the shared connection to the server or connects a new one.
+// Connecting might take until the connect timeout, so it happens without holding the lock.
+func (b *NatsClientBridge) conn(addr string) (*nats.Conn, error) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, context.Canceled
+	}
+	if conn, ok := b.conns[addr]; ok && !conn.IsClosed() {
+		b.mu.Unlock()
+		return conn, nil
+	}
+	b.mu.Unlock()
+
+	conn, err := nats.Connect(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		conn.Close()
+		return nil, context.Canceled
+	}
+	if existing, ok := b.conns[addr]; ok && !existing.IsClosed() {
+		// another request connected concurrently, its connection is shared
+		conn.Close()
+		return existing, nil
+	}
+	b.conns[addr] = conn
+	return conn, nil
+}
+
+// Subscribe subscribes to the topic and streams every message via next channel.
+func (b *NatsClientBridge) Subscribe(ctx context.Context, options GraphQLSubscriptionOptions, next chan<- []byte) error {
+	options.Sanitize()
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	conn, err := b.conn(options.Addr)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	messages := make(chan []byte)
+	sub, err := conn.Subscribe(options.Topic, func(msg *nats.Msg) {
+		select {
+		case messages <- msg.Data:
+		case <-done:
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer func() {
+			close(done)
+			if err := sub.Unsubscribe(); err != nil && err != nats.ErrConnectionClosed {
+				b.log.Error("NatsClientBridge.Subscribe.Unsubscribe",
+					log.String("topic", options.Topic),
+					log.Error(err),
+				)
+			}
+			close(next)
+		}()
+
+		for {
+			select {
+			case <-b.ctx.Done():
+				// Gateway context
+				return
+			case <-ctx.Done():
+				// Request context
+				return
+			case data := <-messages:
+				// The "data" field contains the result of your GraphQL request.
+				result, err := jsonparser.Set([]byte(`{}`), data, "data")
+				if err != nil {
+					b.log.Error("NatsClientBridge.Subscribe",
+						log.String("topic", options.Topic),
+						log.Error(err),
+					)
+					continue
+				}
+				select {
+				case next <- result:
+				case <-b.ctx.Done():
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Publish sends the message to the configured topic and waits until the server has processed it.
+func (b *NatsClientBridge) Publish(ctx context.Context, options PublishOptions) error {
+	options.Sanitize()
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	conn, err := b.conn(options.Addr)
+	if err != nil {
+		return err
+	}
+
+	if err = conn.Publish(options.Topic, options.messageValue()); err != nil {
+		return err
+	}
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		return conn.FlushWithContext(ctx)
+	}
+	return conn.FlushTimeout(flushTimeout)
+}
+
+func (b *NatsClientBridge) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for addr, conn := range b.conns {
+		conn.Close()
+		delete(b.conns, addr)
+	}
+}
@@ -0,0 +1,142 @@
+package nats_datasource
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runTestServer(t *testing.T) *server.Server {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	s := natstest.RunServer(&opts)
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func receiveMessage(t *testing.T, next <-chan []byte) string {
+	t.Helper()
+	select {
+	case message := <-next:
+		return string(message)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for message")
+		return ""
+	}
+}
+
+func TestNatsClientBridge(t *testing.T) {
+	t.Run("should publish and receive messages", func(t *testing.T) {
+		s := runTestServer(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		bridge := NewNatsClientBridge(ctx, abstractlogger.NoopLogger)
+
+		next := make(chan []byte)
+		subscriptionCtx, cancelSubscription := context.WithCancel(context.Background())
+		err := bridge.Subscribe(subscriptionCtx, GraphQLSubscriptionOptions{
+			Addr:  s.ClientURL(),
+			Topic: "test.topic",
+		}, next)
+		require.NoError(t, err)
+
+		publish := func(message string) {
+			err := bridge.Publish(context.Background(), PublishOptions{
+				Addr:    s.ClientURL(),
+				Topic:   "test.topic",
+				Message: []byte(message),
+			})
+			require.NoError(t, err)
+		}
+
+		go publish(`{"text":"hello"}`)
+		assert.Equal(t, `{"data":{"text":"hello"}}`, receiveMessage(t, next))
+
+		go publish(`"{\"text\":\"world\"}"`)
+		assert.Equal(t, `{"data":{"text":"world"}}`, receiveMessage(t, next))
+
+		// subscription and publisher share the connection
+		assert.Equal(t, 1, s.NumClients())
+
+		cancelSubscription()
+		_, ok := <-next
+		assert.False(t, ok)
+		assert.Eventually(t, func() bool {
+			return s.NumSubscriptions() == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should return validation errors", func(t *testing.T) {
+		bridge := NewNatsClientBridge(context.Background(), abstractlogger.NoopLogger)
+
+		err := bridge.Subscribe(context.Background(), GraphQLSubscriptionOptions{}, make(chan []byte))
+		assert.EqualError(t, err, "topic cannot be empty")
+
+		err = bridge.Publish(context.Background(), PublishOptions{Topic: "topic", Message: []byte(`null`)})
+		assert.EqualError(t, err, "message cannot be empty")
+	})
+
+	t.Run("should return error when the server is not reachable", func(t *testing.T) {
+		s := runTestServer(t)
+		addr := s.ClientURL()
+		s.Shutdown()
+
+		bridge := NewNatsClientBridge(context.Background(), abstractlogger.NoopLogger)
+		err := bridge.Subscribe(context.Background(), GraphQLSubscriptionOptions{Addr: addr, Topic: "topic"}, make(chan []byte))
+		assert.Error(t, err)
+	})
+
+	t.Run("should share the connection between concurrent requests", func(t *testing.T) {
+		s := runTestServer(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		bridge := NewNatsClientBridge(ctx, abstractlogger.NoopLogger)
+
+		conns := make([]*nats.Conn, 8)
+		wg := sync.WaitGroup{}
+		for i := range conns {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				conn, err := bridge.conn(s.ClientURL())
+				assert.NoError(t, err)
+				conns[i] = conn
+			}(i)
+		}
+		wg.Wait()
+
+		for _, conn := range conns {
+			assert.Same(t, conns[0], conn)
+		}
+		// connections of requests which lost the race are closed
+		assert.Eventually(t, func() bool {
+			return s.NumClients() == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should close connections when the gateway context is done", func(t *testing.T) {
+		s := runTestServer(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		bridge := NewNatsClientBridge(ctx, abstractlogger.NoopLogger)
+
+		next := make(chan []byte)
+		err := bridge.Subscribe(context.Background(), GraphQLSubscriptionOptions{Addr: s.ClientURL(), Topic: "topic"}, next)
+		require.NoError(t, err)
+
+		cancel()
+		_, ok := <-next
+		assert.False(t, ok)
+		assert.Eventually(t, func() bool {
+			err := bridge.Publish(context.Background(), PublishOptions{Addr: s.ClientURL(), Topic: "topic", Message: []byte(`"hello"`)})
+			return err == context.Canceled && s.NumClients() == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,121 @@
+package nats_datasource
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
+	"github.com/tidwall/sjson"
+)
+
+type Planner struct {
+	config Configuration
+	client *NatsClientBridge
+}
+
+func (p *Planner) Register(_ *plan.Visitor, configuration plan.DataSourceConfiguration, _ bool) error {
+	return json.Unmarshal(configuration.Custom, &p.config)
+}
+
+func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
+	input, _ := json.Marshal(PublishOptions{
+		Addr:    p.config.Publish.Addr,
+		Topic:   p.config.Publish.Topic,
+		Message: json.RawMessage(`null`),
+	})
+	if p.config.Publish.Message != "" {
+		// the message is set as raw template so that it gets rendered in place
+		input, _ = sjson.SetRawBytes(input, "message", []byte(p.config.Publish.Message))
+	}
+	return plan.FetchConfiguration{
+		Input: string(input),
+		DataSource: &Source{
+			publisher: p.client,
+		},
+		DisallowSingleFlight: true,
+		DisableDataLoader:    true,
+	}
+}
+
+func (p *Planner) ConfigureSubscription() plan.SubscriptionConfiguration {
+	input, _ := json.Marshal(p.config.Subscription)
+	return plan.SubscriptionConfiguration{
+		Input: string(input),
+		DataSource: &SubscriptionSource{
+			client: p.client,
+		},
+	}
+}
+
+func (p *Planner) DataSourcePlanningBehavior() plan.DataSourcePlanningBehavior {
+	return plan.DataSourcePlanningBehavior{
+		MergeAliasedRootNodes:      false,
+		OverrideFieldPathFromAlias: false,
+	}
+}
+
+func (p *Planner) DownstreamResponseFieldAlias(_ int) (alias string, exists bool) { return }
+
+type Factory struct {
+	client *NatsClientBridge
+}
+
+func (f *Factory) Planner(ctx context.Context) plan.DataSourcePlanner {
+	if f.client == nil {
+		f.client = NewNatsClientBridge(ctx, abstractlogger.NoopLogger)
+	}
+	return &Planner{
+		client: f.client,
+	}
+}
+
+func ConfigJSON(config Configuration) json.RawMessage {
+	out, _ := json.Marshal(config)
+	return out
+}
+
+type GraphQLSubscriptionClient interface {
+	Subscribe(ctx context.Context, options GraphQLSubscriptionOptions, next chan<- []byte) error
+}
+
+type SubscriptionSource struct {
+	client GraphQLSubscriptionClient
+}
+
+func (s *SubscriptionSource) Start(ctx context.Context, input []byte, next chan<- []byte) error {
+	var options GraphQLSubscriptionOptions
+	err := json.Unmarshal(input, &options)
+	if err != nil {
+		return err
+	}
+	return s.client.Subscribe(ctx, options, next)
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, options PublishOptions) error
+}
+
+// Source publishes the rendered message and responds with {"success":true} once the server has processed it.
+type Source struct {
+	publisher Publisher
+}
+
+func (s *Source) Load(ctx context.Context, input []byte, writer io.Writer) (err error) {
+	var options PublishOptions
+	if err = json.Unmarshal(input, &options); err != nil {
+		return err
+	}
+	if err = s.publisher.Publish(ctx, options); err != nil {
+		return err
+	}
+	_, err = writer.Write([]byte(`{"success":true}`))
+	return err
+}
+
+var _ plan.PlannerFactory = (*Factory)(nil)
+var _ plan.DataSourcePlanner = (*Planner)(nil)
+var _ resolve.SubscriptionDataSource = (*SubscriptionSource)(nil)
+var _ resolve.DataSource = (*Source)(nil)
@@ -59,17 +59,30 @@ func ConfigJSON(config Configuration) json.RawMessage {
 	return out
 }
 
+// SubscriptionConfiguration configures subscriptions which poll the endpoint of the FetchConfiguration.
 type SubscriptionConfiguration struct {
-	PollingIntervalMillis   int64
+	// PollingIntervalMillis defaults to httpclient.DefaultPollingIntervalMillis.
+	PollingIntervalMillis int64
+	// ErrorDelayMillis is the delay after a failed request, it defaults to the polling interval.
+	ErrorDelayMillis int64
+	// SkipPublishSameResponse publishes a response only if it differs from the previous one.
 	SkipPublishSameResponse bool
+	// MaxPolls ends the subscription after the given number of requests, 0 polls until the client unsubscribes.
+	MaxPolls int64
 }
 
 type FetchConfiguration struct {
-	URL           string
-	Method        string
-	Header        http.Header
-	Query         []QueryConfiguration
-	Body          string
+	URL    string
+	Method string
+	Header http.Header
+	Query  []QueryConfiguration
+	Body   string
+	// SuccessStatusCodes are the status codes of successful responses, defaults to every 2xx status code.
+	// Any other response results in a GraphQL error with the status code in its extensions
+	// and the fields depending on the response resolve to null.
+	SuccessStatusCodes []int `json:"SuccessStatusCodes,omitempty"`
+	// IncludeErrorBody adds the body of unsuccessful responses to the extensions of the GraphQL error.
+	IncludeErrorBody bool `json:"IncludeErrorBody,omitempty"`
 }
 
 type QueryConfiguration struct {
@@ -93,6 +106,8 @@ func (p *Planner) configureInput() []byte {
 	input := httpclient.SetInputURL(nil, []byte(p.config.Fetch.URL))
 	input = httpclient.SetInputMethod(input, []byte(p.config.Fetch.Method))
 	input = httpclient.SetInputBody(input, []byte(p.config.Fetch.Body))
+	input = httpclient.SetInputSuccessStatusCodes(input, p.config.Fetch.SuccessStatusCodes)
+	input = httpclient.SetInputIncludeErrorBody(input, p.config.Fetch.IncludeErrorBody)
 
 	header, err := json.Marshal(p.config.Fetch.Header)
 	if err == nil && len(header) != 0 && !bytes.Equal(header, literal.NULL) {
@@ -120,7 +135,16 @@ func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
 }
 
 func (p *Planner) ConfigureSubscription() plan.SubscriptionConfiguration {
-	return plan.SubscriptionConfiguration{}
+	input := httpclient.SetPollingInput(p.configureInput(), httpclient.PollingConfiguration{
+		IntervalMillis:          int(p.config.Subscription.PollingIntervalMillis),
+		ErrorDelayMillis:        int(p.config.Subscription.ErrorDelayMillis),
+		SkipPublishSameResponse: p.config.Subscription.SkipPublishSameResponse,
+		MaxPolls:                int(p.config.Subscription.MaxPolls),
+	}, true)
+	return plan.SubscriptionConfiguration{
+		Input:      string(input),
+		DataSource: httpclient.NewPollingSubscriptionSource(p.client),
+	}
 }
 
 var (
@@ -7,13 +7,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/buger/jsonparser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/httpclient"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/datasourcetesting"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
@@ -717,19 +720,22 @@ func TestFastHttpJsonDataSourcePlanning(t *testing.T) {
 			DisableResolveFieldPositions: true,
 		},
 	))
-	/*	t.Run("polling subscription get request with argument", datasourcetesting.RunTest(schema, argumentSubscription, "ArgumentQuery",
+	t.Run("polling subscription get request with argument", datasourcetesting.RunTest(schema, argumentSubscription, "ArgumentQuery",
 		&plan.SubscriptionResponsePlan{
 			Response: &resolve.GraphQLSubscription{
 				Trigger: resolve.GraphQLSubscriptionTrigger{
-					Input: []byte(`{"interval":1000,"request_input":{"method":"GET","url":"https://example.com/$$0$$/$$1$$"},"skip_publish_same_response":true}`),
+					Input: []byte(`{"interval":1000,"error_delay":0,"skip_publish_same_response":true,"max_polls":0,"wrap_response_in_data":true,"request_input":{"method":"GET","url":"https://example.com/$$0$$/$$1$$"}}`),
 					Variables: resolve.NewVariables(
 						&resolve.ContextVariable{
-							Path: []string{"idVariable"},
+							Path:     []string{"idVariable"},
+							Renderer: resolve.NewPlainVariableRendererWithValidation(`{"type":["string"]}`),
 						},
 						&resolve.ContextVariable{
-							Path: []string{"a"},
+							Path:     []string{"a"},
+							Renderer: resolve.NewPlainVariableRendererWithValidation(`{"type":["string","null"]}`),
 						},
 					),
+					Source: httpclient.NewPollingSubscriptionSource(nil),
 				},
 				Response: &resolve.GraphQLResponse{
 					Data: &resolve.Object{
@@ -783,8 +789,9 @@ func TestFastHttpJsonDataSourcePlanning(t *testing.T) {
 					DisableDefaultMapping: true,
 				},
 			},
+			DisableResolveFieldPositions: true,
 		},
-	))*/
+	))
 	t.Run("post request with body", datasourcetesting.RunTest(schema, simpleOperation, "",
 		&plan.SynchronousResponsePlan{
 			Response: &resolve.GraphQLResponse{
@@ -1246,6 +1253,81 @@ func TestHttpJsonDataSource_Load(t *testing.T) {
 	})
 }
 
+func TestHttpJsonDataSource_Subscription(t *testing.T) {
+	start := func(t *testing.T, ctx context.Context, serverURL string, skipPublishSameResponse bool) chan []byte {
+		t.Helper()
+		source := httpclient.NewPollingSubscriptionSource(http.DefaultClient)
+		input := httpclient.SetPollingInput([]byte(fmt.Sprintf(`{"method":"GET","url":"%s"}`, serverURL)), httpclient.PollingConfiguration{
+			IntervalMillis:          1,
+			SkipPublishSameResponse: skipPublishSameResponse,
+		}, true)
+		next := make(chan []byte)
+		require.NoError(t, source.Start(ctx, input, next))
+		return next
+	}
+
+	t.Run("should publish every response", func(t *testing.T) {
+		var counter int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodGet, r.Method)
+			_, _ = fmt.Fprintf(w, `{"counter":%d}`, atomic.AddInt32(&counter, 1)/2)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		next := start(t, ctx, server.URL, false)
+		assert.Equal(t, `{"data":{"counter":0}}`, string(<-next))
+		assert.Equal(t, `{"data":{"counter":1}}`, string(<-next))
+		assert.Equal(t, `{"data":{"counter":1}}`, string(<-next))
+		assert.Equal(t, `{"data":{"counter":2}}`, string(<-next))
+	})
+
+	t.Run("should skip publishing the same response", func(t *testing.T) {
+		var counter int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"counter":%d}`, atomic.AddInt32(&counter, 1)/2)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		next := start(t, ctx, server.URL, true)
+		assert.Equal(t, `{"data":{"counter":0}}`, string(<-next))
+		assert.Equal(t, `{"data":{"counter":1}}`, string(<-next))
+		assert.Equal(t, `{"data":{"counter":2}}`, string(<-next))
+	})
+
+	t.Run("should stop polling when the context is done", func(t *testing.T) {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			_, _ = w.Write([]byte(`{"counter":0}`))
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		next := start(t, ctx, server.URL, true)
+		assert.Equal(t, `{"data":{"counter":0}}`, string(<-next))
+
+		cancel()
+		_, ok := <-next
+		assert.False(t, ok)
+
+		requestsAfterCancel := atomic.LoadInt32(&requests)
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, requestsAfterCancel, atomic.LoadInt32(&requests))
+	})
+
+	t.Run("should return error for invalid input", func(t *testing.T) {
+		source := httpclient.NewPollingSubscriptionSource(http.DefaultClient)
+		err := source.Start(context.Background(), []byte(`{"interval":1}`), make(chan []byte))
+		assert.Error(t, err)
+	})
+}
+
 const authSchema = `
 type Mutation {
   postPasswordlessStart(postPasswordlessStartInput: postPasswordlessStartInput): PostPasswordlessStart
@@ -0,0 +1,134 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/astparser"
+)
+
+// fieldSetField is a field of a federation _FieldSet, e.g. the fields argument of @key or @provides.
+type fieldSetField struct {
+	// typeCondition is the type of the enclosing inline fragment, e.g. "Book" for "... on Book { title }"
+	typeCondition string
+	name          string
+	fields        []fieldSetField
+}
+
+// parseFieldSet parses a _FieldSet like "id organization { id }".
+// Inline fragments are flattened into their fields, which keep the type condition.
+func parseFieldSet(fieldSet string) ([]fieldSetField, bool) {
+	document, report := astparser.ParseGraphqlDocumentString("{" + fieldSet + "}")
+	if report.HasErrors() || len(document.OperationDefinitions) != 1 {
+		return nil, false
+	}
+	operation := document.OperationDefinitions[0]
+	if !operation.HasSelections {
+		return nil, false
+	}
+	return fieldSetFields(&document, operation.SelectionSet, ""), true
+}
+
+func fieldSetFields(document *ast.Document, selectionSet int, typeCondition string) []fieldSetField {
+	var fields []fieldSetField
+	for _, selectionRef := range document.SelectionSets[selectionSet].SelectionRefs {
+		selection := document.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			field := fieldSetField{
+				typeCondition: typeCondition,
+				name:          document.FieldNameString(selection.Ref),
+			}
+			if document.FieldHasSelections(selection.Ref) {
+				field.fields = fieldSetFields(document, document.Fields[selection.Ref].SelectionSet, "")
+			}
+			fields = append(fields, field)
+		case ast.SelectionKindInlineFragment:
+			inlineFragment := document.InlineFragments[selection.Ref]
+			if !inlineFragment.HasSelections {
+				continue
+			}
+			fragmentTypeCondition := typeCondition
+			if document.InlineFragmentHasTypeCondition(selection.Ref) {
+				fragmentTypeCondition = document.InlineFragmentTypeConditionNameString(selection.Ref)
+			}
+			fields = append(fields, fieldSetFields(document, inlineFragment.SelectionSet, fragmentTypeCondition)...)
+		}
+	}
+	return fields
+}
+
+// fieldSetFieldNames returns the names of the top level fields of a _FieldSet.
+func fieldSetFieldNames(fieldSet string) []string {
+	fields, ok := parseFieldSet(fieldSet)
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(fields))
+	for i := range fields {
+		names = append(names, fields[i].name)
+	}
+	return names
+}
+
+// directiveFieldSet returns the fields argument of a federation directive.
+func directiveFieldSet(document *ast.Document, directiveRef int) (fieldSet string, ok bool) {
+	value, exists := document.DirectiveArgumentValueByName(directiveRef, fieldsArgumentNameBytes)
+	if !exists || value.Kind != ast.ValueKindString {
+		return "", false
+	}
+	return document.StringValueContentString(value.Ref), true
+}
+
+// selection renders the field as a selection of a _FieldSet, e.g. "organization { id }".
+func (f fieldSetField) selection() string {
+	if len(f.fields) == 0 {
+		return f.name
+	}
+	selections := make([]string, 0, len(f.fields))
+	for i := range f.fields {
+		selections = append(selections, f.fields[i].selection())
+	}
+	return f.name + " { " + strings.Join(selections, " ") + " }"
+}
+
+// leafPaths appends the paths of the fields without selections, e.g. [organization id] of "organization { id }".
+func (f fieldSetField) leafPaths(parent []string, paths [][]string) [][]string {
+	path := append(parent[:len(parent):len(parent)], f.name)
+	if len(f.fields) == 0 {
+		return append(paths, path)
+	}
+	for i := range f.fields {
+		paths = f.fields[i].leafPaths(path, paths)
+	}
+	return paths
+}
+
+// splitFieldSet returns the top level selections of a _FieldSet,
+// e.g. "id" and "organization { id }" of "id organization { id }".
+func splitFieldSet(fieldSet string) []string {
+	fields, ok := parseFieldSet(fieldSet)
+	if !ok {
+		return nil
+	}
+	selections := make([]string, 0, len(fields))
+	for i := range fields {
+		selections = append(selections, fields[i].selection())
+	}
+	return selections
+}
+
+// FieldSetLeafPaths returns the paths of the fields without selections of a _FieldSet,
+// e.g. [id] and [organization id] of "id organization { id }".
+// It's used to render the representation of an entity with a compound key.
+func FieldSetLeafPaths(fieldSet string) [][]string {
+	fields, ok := parseFieldSet(fieldSet)
+	if !ok {
+		return nil
+	}
+	var paths [][]string
+	for i := range fields {
+		paths = fields[i].leafPaths(nil, paths)
+	}
+	return paths
+}
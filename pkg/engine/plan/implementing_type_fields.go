@@ -0,0 +1,89 @@
+package plan
+
+import (
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+)
+
+// AddImplementingTypeFields adds the fields of the interfaces in typeFields to the object types implementing
+// the interfaces in the definition of the supergraph.
+// A subgraph extending an entity interface, e.g. extend interface Account @key(fields: "id") { reviews: [Review] },
+// doesn't know the object types implementing it, but resolves the fields for them as well,
+// e.g. in a fragment ... on User { reviews }.
+func AddImplementingTypeFields(typeFields []TypeField, definition *ast.Document) []TypeField {
+	numOfTypeFields := len(typeFields)
+	for i := 0; i < numOfTypeFields; i++ {
+		for _, objectTypeRef := range implementingObjectTypes(definition, typeFields[i].TypeName) {
+			typeName := definition.ObjectTypeDefinitionNameString(objectTypeRef)
+			for _, fieldName := range typeFields[i].FieldNames {
+				if !objectTypeHasField(definition, objectTypeRef, fieldName) {
+					continue
+				}
+				typeFields = addTypeFieldName(typeFields, typeName, fieldName)
+			}
+		}
+	}
+	return typeFields
+}
+
+// AddImplementingTypeFieldConfigurations adds the configuration of an interface field to the object types
+// implementing the interface in the definition of the supergraph, unless they have a configuration of their own.
+// This way, the key of an entity interface is required for the fields of the object types as well.
+func AddImplementingTypeFieldConfigurations(fieldConfigs FieldConfigurations, definition *ast.Document) FieldConfigurations {
+	numOfFieldConfigs := len(fieldConfigs)
+	for i := 0; i < numOfFieldConfigs; i++ {
+		for _, objectTypeRef := range implementingObjectTypes(definition, fieldConfigs[i].TypeName) {
+			typeName := definition.ObjectTypeDefinitionNameString(objectTypeRef)
+			if fieldConfigs.ForTypeField(typeName, fieldConfigs[i].FieldName) != nil {
+				continue
+			}
+			fieldConfig := fieldConfigs[i]
+			fieldConfig.TypeName = typeName
+			fieldConfigs = append(fieldConfigs, fieldConfig)
+		}
+	}
+	return fieldConfigs
+}
+
+// implementingObjectTypes returns the object types implementing the interface typeName, if typeName is an interface.
+func implementingObjectTypes(definition *ast.Document, typeName string) (objectTypeRefs []int) {
+	node, exists := definition.Index.FirstNodeByNameStr(typeName)
+	if !exists || node.Kind != ast.NodeKindInterfaceTypeDefinition {
+		return nil
+	}
+	interfaceName := definition.InterfaceTypeDefinitionNameBytes(node.Ref)
+	for _, rootNode := range definition.RootNodes {
+		if rootNode.Kind != ast.NodeKindObjectTypeDefinition || !definition.ObjectTypeDefinitionImplementsInterface(rootNode.Ref, interfaceName) {
+			continue
+		}
+		objectTypeRefs = append(objectTypeRefs, rootNode.Ref)
+	}
+	return objectTypeRefs
+}
+
+func objectTypeHasField(definition *ast.Document, objectTypeRef int, fieldName string) bool {
+	for _, fieldRef := range definition.ObjectTypeDefinitions[objectTypeRef].FieldsDefinition.Refs {
+		if definition.FieldDefinitionNameString(fieldRef) == fieldName {
+			return true
+		}
+	}
+	return false
+}
+
+func addTypeFieldName(typeFields []TypeField, typeName, fieldName string) []TypeField {
+	for i := range typeFields {
+		if typeFields[i].TypeName != typeName {
+			continue
+		}
+		for _, existingFieldName := range typeFields[i].FieldNames {
+			if existingFieldName == fieldName {
+				return typeFields
+			}
+		}
+		typeFields[i].FieldNames = append(typeFields[i].FieldNames, fieldName)
+		return typeFields
+	}
+	return append(typeFields, TypeField{
+		TypeName:   typeName,
+		FieldNames: []string{fieldName},
+	})
+}
@@ -0,0 +1,63 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pvormste/graphql-go-tools/internal/pkg/unsafeparser"
+)
+
+func TestAddImplementingTypeFields(t *testing.T) {
+	definition := unsafeparser.ParseGraphqlDocumentString(`
+		interface Account {
+			id: ID!
+			reviews: [Review]
+		}
+		type User implements Account {
+			id: ID!
+			reviews: [Review]
+		}
+		type Admin implements Account {
+			id: ID!
+			level: Int!
+			reviews: [Review]
+		}
+		type Review {
+			body: String!
+		}
+	`)
+
+	t.Run("fields of an interface are added to the implementing types", func(t *testing.T) {
+		typeFields := []TypeField{
+			{TypeName: "Account", FieldNames: []string{"reviews"}},
+			{TypeName: "Admin", FieldNames: []string{"level"}},
+		}
+		assert.Equal(t, []TypeField{
+			{TypeName: "Account", FieldNames: []string{"reviews"}},
+			{TypeName: "Admin", FieldNames: []string{"level", "reviews"}},
+			{TypeName: "User", FieldNames: []string{"reviews"}},
+		}, AddImplementingTypeFields(typeFields, &definition))
+	})
+
+	t.Run("fields of an object type are kept", func(t *testing.T) {
+		typeFields := []TypeField{
+			{TypeName: "Review", FieldNames: []string{"body"}},
+		}
+		assert.Equal(t, []TypeField{
+			{TypeName: "Review", FieldNames: []string{"body"}},
+		}, AddImplementingTypeFields(typeFields, &definition))
+	})
+
+	t.Run("field configurations of an interface are added to the implementing types", func(t *testing.T) {
+		fieldConfigs := FieldConfigurations{
+			{TypeName: "Account", FieldName: "reviews", RequiresFields: []string{"id"}},
+			{TypeName: "Admin", FieldName: "reviews", RequiresFields: []string{"id", "level"}},
+		}
+		assert.Equal(t, FieldConfigurations{
+			{TypeName: "Account", FieldName: "reviews", RequiresFields: []string{"id"}},
+			{TypeName: "Admin", FieldName: "reviews", RequiresFields: []string{"id", "level"}},
+			{TypeName: "User", FieldName: "reviews", RequiresFields: []string{"id"}},
+		}, AddImplementingTypeFieldConfigurations(fieldConfigs, &definition))
+	})
+}
@@ -19,7 +19,9 @@ const (
 // directive. Child nodes are field types recursively accessible via a root
 // node. Nodes are either object or interface definitions or extensions. Root
 // nodes only include "local" fields; they don't include fields that have the
-// @external directive.
+// @external directive. Child nodes don't include @external fields listed in
+// a @provides directive, unless they are part of a @key, as they are
+// resolvable only below the providing field, see ProvidedFieldExtractor.
 type LocalTypeFieldExtractor struct {
 	document               *ast.Document
 	queryTypeName          string
@@ -28,6 +30,7 @@ type LocalTypeFieldExtractor struct {
 	nodeInfoMap            map[string]*nodeInformation
 	possibleInterfaceTypes map[string][]string
 	rootNodeNames          *rootNodeNamesMap
+	providedFields         map[string]map[string]struct{}
 	childrenSeen           map[string]struct{}
 	childrenToProcess      []string
 	rootNodes              []TypeField
@@ -54,6 +57,7 @@ type nodeInformation struct {
 	localFieldRefs    []int
 	externalFieldRefs []int
 	requiredFields    map[string]struct{}
+	keyFields         map[string]struct{}
 }
 
 type rootNodeNamesMap struct {
@@ -120,6 +124,9 @@ func (e *LocalTypeFieldExtractor) GetAllNodes() ([]TypeField, []TypeField) {
 	// 1. Loop over each node in the document (see description above).
 	e.collectNodeInformation()
 
+	// Record the fields which are provided by @provides directives.
+	e.collectProvidedFields()
+
 	// Record the concrete types for each interface.
 	e.assignConcreteTypesToInterfaces()
 
@@ -202,6 +209,7 @@ func (e *LocalTypeFieldExtractor) getNodeInfo(node ast.Node) *nodeInformation {
 	if ok {
 		// if this node has the key directive, we need to add it to the node information
 		nodeInfo.hasKeyDirective = nodeInfo.hasKeyDirective || e.document.NodeHasDirectiveByNameString(node, FederationKeyDirectiveName)
+		e.collectKeyFields(node, nodeInfo)
 		return nodeInfo
 	}
 
@@ -209,14 +217,32 @@ func (e *LocalTypeFieldExtractor) getNodeInfo(node ast.Node) *nodeInformation {
 		typeName:        typeName,
 		hasKeyDirective: e.document.NodeHasDirectiveByNameString(node, FederationKeyDirectiveName),
 		requiredFields:  make(map[string]struct{}),
+		keyFields:       make(map[string]struct{}),
 	}
+	e.collectKeyFields(node, nodeInfo)
 
 	e.nodeInfoMap[typeName] = nodeInfo
 	return nodeInfo
 }
 
+func (e *LocalTypeFieldExtractor) collectKeyFields(node ast.Node, nodeInfo *nodeInformation) {
+	for _, directiveRef := range e.document.NodeDirectives(node) {
+		if e.document.DirectiveNameString(directiveRef) != FederationKeyDirectiveName {
+			continue
+		}
+		fieldSet, ok := directiveFieldSet(e.document, directiveRef)
+		if !ok {
+			continue
+		}
+		for _, fieldName := range fieldSetFieldNames(fieldSet) {
+			nodeInfo.keyFields[fieldName] = struct{}{}
+		}
+	}
+}
+
 func (e *LocalTypeFieldExtractor) isRootNode(nodeInfo *nodeInformation) bool {
-	isFederationEntity := nodeInfo.hasKeyDirective && !nodeInfo.isInterface
+	// entity interfaces are root nodes as well, so that a subgraph extending them resolves their fields by _entities
+	isFederationEntity := nodeInfo.hasKeyDirective
 	return nodeInfo.typeName == e.queryTypeName ||
 		nodeInfo.typeName == e.mutationTypeName ||
 		nodeInfo.typeName == e.subscriptionTypeName ||
@@ -241,6 +267,27 @@ func (e *LocalTypeFieldExtractor) collectFieldDefinitions(node ast.Node, nodeInf
 	}
 }
 
+func (e *LocalTypeFieldExtractor) collectProvidedFields() {
+	e.providedFields = map[string]map[string]struct{}{}
+
+	var collect func(provides []FieldProvides)
+	collect = func(provides []FieldProvides) {
+		for i := range provides {
+			fields, ok := e.providedFields[provides[i].TypeName]
+			if !ok {
+				fields = map[string]struct{}{}
+				e.providedFields[provides[i].TypeName] = fields
+			}
+			fields[provides[i].FieldName] = struct{}{}
+			collect(provides[i].Provides)
+		}
+	}
+
+	for _, fieldProvides := range NewProvidedFieldExtractor(e.document).GetAllProvidedFields() {
+		collect(fieldProvides.Provides)
+	}
+}
+
 func (e *LocalTypeFieldExtractor) assignConcreteTypesToInterfaces() {
 	for interfaceName, concreteTypeNames := range e.possibleInterfaceTypes {
 		if nodeInfo, ok := e.nodeInfoMap[interfaceName]; ok {
@@ -323,11 +370,14 @@ func (e *LocalTypeFieldExtractor) createChildNodes() {
 			// We assume that a field is marked @external for only three
 			// reasons:
 			// 1) the enclosing type is using it as a @key field
-			// 2) another field in this datasource @provide's it
-			// 3) another field in the enclosing type @require's it
-			// In the first two cases, that means that this datasource
-			// knows the value of the field, and thus we want to include
-			// the field in our ChildNodes.  In the last case, this
+			// 2) another field in this datasource @provides it
+			// 3) another field in the enclosing type @requires it
+			// In the first case, that means that this datasource knows
+			// the value of the field, and thus we want to include the
+			// field in our ChildNodes. In the second case, this
+			// datasource knows the value only below the providing field,
+			// so the planner resolves it using the Provides of the
+			// DataSourceConfiguration instead. In the last case, this
 			// datasource does *not* know the value of the field, so
 			// we don't include it.
 			// (Note it's legal for someone to add an `@external`
@@ -336,8 +386,10 @@ func (e *LocalTypeFieldExtractor) createChildNodes() {
 			// will wrongly say that this datasource can provide its
 			// value.  Hopefully people don't actually do that.)
 			fieldName := e.processFieldRef(ref)
+			_, isKey := nodeInfo.keyFields[fieldName]
+			_, isProvided := e.providedFields[typeName][fieldName]
 			_, isRequired := nodeInfo.requiredFields[fieldName]
-			if !isRequired {
+			if !isRequired && (isKey || !isProvided) {
 				fieldNames = append(fieldNames, fieldName)
 			}
 		}
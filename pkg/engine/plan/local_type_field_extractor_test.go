@@ -242,9 +242,9 @@ func TestLocalTypeFieldExtractor_GetAllNodes(t *testing.T) {
 				user: User
 			}
 
-			# A key directive on an interface is allowed, but it doesn't make
-			# the interface a root node. Entity queries can only be made for
-			# concrete types.
+			# A key directive on an interface makes it an entity interface,
+			# so it's a root node like the concrete entities. Entity queries
+			# for the interface carry the __typename of the concrete type.
 			interface Communication @key(fields: "id") {
 				id: ID!
 				comment: String!
@@ -253,6 +253,7 @@ func TestLocalTypeFieldExtractor_GetAllNodes(t *testing.T) {
 		`,
 			[]TypeField{
 				{TypeName: "Comment", FieldNames: []string{"comment", "id", "user"}},
+				{TypeName: "Communication", FieldNames: []string{"comment", "id", "user"}},
 				{TypeName: "Query", FieldNames: []string{"communication", "me", "user"}},
 				{TypeName: "Review", FieldNames: []string{"comment", "id", "rating", "user"}},
 			},
@@ -299,6 +300,7 @@ func TestLocalTypeFieldExtractor_GetAllNodes(t *testing.T) {
 		`,
 			[]TypeField{
 				{TypeName: "Comment", FieldNames: []string{"comment", "user"}},
+				{TypeName: "Communication", FieldNames: []string{"comment", "user"}},
 				{TypeName: "Query", FieldNames: []string{"communication", "me", "user"}},
 				{TypeName: "Review", FieldNames: []string{"comment", "rating", "user"}},
 			},
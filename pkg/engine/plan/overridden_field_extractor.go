@@ -0,0 +1,86 @@
+package plan
+
+import (
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+)
+
+const federationOverrideDirectiveName = "override"
+
+var fromArgumentNameBytes = []byte("from")
+
+// FieldOverride describes the field TypeName.FieldName, which a subgraph resolves
+// instead of the subgraph From, e.g. inStock: Boolean @override(from: "products").
+type FieldOverride struct {
+	TypeName  string
+	FieldName string
+	From      string
+}
+
+// OverriddenFieldExtractor extracts the @override directives from an ast.Document
+// containing a parsed federation v2 subgraph SDL.
+//
+// The subgraph named in the from argument no longer resolves the overridden field,
+// so it has to be removed from the root and child nodes of that subgraph.
+type OverriddenFieldExtractor struct {
+	document *ast.Document
+}
+
+func NewOverriddenFieldExtractor(document *ast.Document) *OverriddenFieldExtractor {
+	return &OverriddenFieldExtractor{
+		document: document,
+	}
+}
+
+// GetAllOverriddenFields returns a FieldOverride for every field definition with an @override directive.
+func (o *OverriddenFieldExtractor) GetAllOverriddenFields() []FieldOverride {
+	var overrides []FieldOverride
+
+	for _, node := range o.document.RootNodes {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition, ast.NodeKindObjectTypeExtension:
+		default:
+			continue
+		}
+
+		typeName := o.document.NodeNameString(node)
+		for _, fieldDefinitionRef := range o.document.NodeFieldDefinitions(node) {
+			directiveRef, exists := o.document.FieldDefinitionDirectiveByName(fieldDefinitionRef, []byte(federationOverrideDirectiveName))
+			if !exists {
+				continue
+			}
+			value, exists := o.document.DirectiveArgumentValueByName(directiveRef, fromArgumentNameBytes)
+			if !exists || value.Kind != ast.ValueKindString {
+				continue
+			}
+			overrides = append(overrides, FieldOverride{
+				TypeName:  typeName,
+				FieldName: o.document.FieldDefinitionNameString(fieldDefinitionRef),
+				From:      o.document.StringValueContentString(value.Ref),
+			})
+		}
+	}
+
+	return overrides
+}
+
+// RemoveOverriddenFields removes the fields overridden by another subgraph from the type fields of the subgraph serviceName.
+func RemoveOverriddenFields(typeFields []TypeField, overrides []FieldOverride, serviceName string) []TypeField {
+	for _, override := range overrides {
+		if override.From != serviceName {
+			continue
+		}
+		for i := range typeFields {
+			if typeFields[i].TypeName != override.TypeName {
+				continue
+			}
+			fieldNames := make([]string, 0, len(typeFields[i].FieldNames))
+			for _, fieldName := range typeFields[i].FieldNames {
+				if fieldName != override.FieldName {
+					fieldNames = append(fieldNames, fieldName)
+				}
+			}
+			typeFields[i].FieldNames = fieldNames
+		}
+	}
+	return typeFields
+}
@@ -0,0 +1,49 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pvormste/graphql-go-tools/internal/pkg/unsafeparser"
+)
+
+func TestOverriddenFieldExtractor_GetAllOverriddenFields(t *testing.T) {
+	document := unsafeparser.ParseGraphqlDocumentString(`
+		type Product @key(fields: "upc") {
+			upc: String!
+			inStock: Boolean @override(from: "products")
+			name: String @shareable
+		}
+		extend type User @key(fields: "id") {
+			id: ID! @external
+			cart: [Product] @override(from: "accounts")
+		}
+	`)
+
+	overrides := NewOverriddenFieldExtractor(&document).GetAllOverriddenFields()
+	assert.Equal(t, []FieldOverride{
+		{TypeName: "Product", FieldName: "inStock", From: "products"},
+		{TypeName: "User", FieldName: "cart", From: "accounts"},
+	}, overrides)
+
+	t.Run("remove overridden fields of the subgraph", func(t *testing.T) {
+		typeFields := []TypeField{
+			{TypeName: "Query", FieldNames: []string{"topProducts"}},
+			{TypeName: "Product", FieldNames: []string{"upc", "name", "inStock"}},
+		}
+		assert.Equal(t, []TypeField{
+			{TypeName: "Query", FieldNames: []string{"topProducts"}},
+			{TypeName: "Product", FieldNames: []string{"upc", "name"}},
+		}, RemoveOverriddenFields(typeFields, overrides, "products"))
+	})
+
+	t.Run("keep the fields of other subgraphs", func(t *testing.T) {
+		typeFields := []TypeField{
+			{TypeName: "Product", FieldNames: []string{"upc", "name", "inStock"}},
+		}
+		assert.Equal(t, []TypeField{
+			{TypeName: "Product", FieldNames: []string{"upc", "name", "inStock"}},
+		}, RemoveOverriddenFields(typeFields, overrides, "inventory"))
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/pvormste/graphql-go-tools/pkg/ast"
 	"github.com/pvormste/graphql-go-tools/pkg/astimport"
@@ -36,6 +37,10 @@ type Configuration struct {
 	// This setting removes position information from all fields
 	// In production, this should be set to false so that error messages are easier to understand
 	DisableResolveFieldPositions bool
+	// DisableIncludeInfo should be set to true for testing purposes
+	// This setting removes the schema information (resolve.FieldInfo) from all fields
+	// In production, this should be set to false so that error messages name the type and field
+	DisableIncludeInfo bool
 }
 
 type DirectiveConfigurations []DirectiveConfiguration
@@ -113,14 +118,37 @@ type FieldConfiguration struct {
 	// DisableDefaultMapping - instructs planner whether to use path mapping coming from Path field
 	DisableDefaultMapping bool
 	// Path - represents a json path to lookup for a field value in response json
-	Path           []string
-	Arguments      ArgumentsConfigurations
+	Path      []string
+	Arguments ArgumentsConfigurations
+	// RequiresFields - the fields which must be selected to resolve the field, e.g. the federation key fields and the fields of @requires
+	// A field of a compound key is a _FieldSet with selections, e.g. "organization { id }"
 	RequiresFields []string
+	// Keys - the alternative federation keys of an entity with several @key directives
+	// RequiresFields starts with the fields of the first key.
+	// The planner replaces them with the first key which the data source of the parent field is able to resolve.
+	Keys [][]string
 	// UnescapeResponseJson set to true will allow fields (String,List,Object)
 	// to be resolved from an escaped JSON string
 	// e.g. {"response":"{\"foo\":\"bar\"}"} will be returned as {"foo":"bar"} when path is "response"
 	// This way, it is possible to resolve a JSON string as part of the response without extra String encoding of the JSON
 	UnescapeResponseJson bool
+	// CacheControl is the cache hint for the response cache of the ExecutionEngineV2
+	// It takes precedence over a @cacheControl directive on the field definition
+	CacheControl *CacheControl
+}
+
+type CacheControlScope string
+
+const (
+	CacheControlScopePublic  CacheControlScope = "PUBLIC"
+	CacheControlScopePrivate CacheControlScope = "PRIVATE"
+)
+
+// CacheControl describes how long the value of a field may be cached and whether it is specific to a single caller.
+// An empty Scope is treated as CacheControlScopePublic.
+type CacheControl struct {
+	MaxAge time.Duration
+	Scope  CacheControlScope
 }
 
 type ArgumentsConfigurations []ArgumentConfiguration
@@ -162,11 +190,38 @@ type DataSourceConfiguration struct {
 	// They are always required for the Graphql datasources cause each field could have it's own datasource
 	// For any single point datasource like HTTP/REST or GRPC we could not request less fields, as we always get a full response
 	ChildNodes []TypeField
+	// Provides - describes fields which the DataSource resolves only below specific fields,
+	// e.g. the fields of an entity owned by another subgraph listed in a federation @provides directive.
+	// On these paths the fields are treated like child nodes, so no additional fetch is required.
+	Provides   []FieldProvides
 	Directives DirectiveConfigurations
 	Factory    PlannerFactory
 	Custom     json.RawMessage
 }
 
+// ProvidesOfField returns the fields the DataSource resolves below the field typeName.fieldName.
+func (d *DataSourceConfiguration) ProvidesOfField(typeName, fieldName string) []FieldProvides {
+	return fieldProvides(d.Provides, typeName, fieldName)
+}
+
+func fieldProvides(provides []FieldProvides, typeName, fieldName string) []FieldProvides {
+	for i := range provides {
+		if provides[i].TypeName == typeName && provides[i].FieldName == fieldName {
+			return provides[i].Provides
+		}
+	}
+	return nil
+}
+
+func hasFieldProvides(provides []FieldProvides, typeName, fieldName string) bool {
+	for i := range provides {
+		if provides[i].TypeName == typeName && provides[i].FieldName == fieldName {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *DataSourceConfiguration) HasRootNode(typeName, fieldName string) bool {
 	for i := range d.RootNodes {
 		if typeName != d.RootNodes[i].TypeName {
@@ -181,6 +236,32 @@ func (d *DataSourceConfiguration) HasRootNode(typeName, fieldName string) bool {
 	return false
 }
 
+func (d *DataSourceConfiguration) HasChildNode(typeName, fieldName string) bool {
+	for i := range d.ChildNodes {
+		if typeName != d.ChildNodes[i].TypeName {
+			continue
+		}
+		for j := range d.ChildNodes[i].FieldNames {
+			if fieldName == d.ChildNodes[i].FieldNames[j] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolvesFieldSets returns true if the DataSource resolves the top level fields of all field sets on typeName.
+func (d *DataSourceConfiguration) resolvesFieldSets(typeName string, fieldSets []string) bool {
+	for _, fieldSet := range fieldSets {
+		for _, fieldName := range fieldSetFieldNames(fieldSet) {
+			if !d.HasRootNode(typeName, fieldName) && !d.HasChildNode(typeName, fieldName) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 type PlannerFactory interface {
 	// Planner should return the DataSourcePlanner
 	// closer is the closing channel for all stateful DataSources
@@ -196,6 +277,14 @@ type TypeField struct {
 	FieldNames []string
 }
 
+// FieldProvides describes the field TypeName.FieldName and the fields the DataSource resolves below it.
+// Provides are nested for fields returning objects, e.g. @provides(fields: "name address { street }").
+type FieldProvides struct {
+	TypeName  string
+	FieldName string
+	Provides  []FieldProvides
+}
+
 type FieldMapping struct {
 	TypeName              string
 	FieldName             string
@@ -222,7 +311,7 @@ func NewPlanner(ctx context.Context, config Configuration) *Planner {
 
 	requiredFieldsWalker.RegisterEnterDocumentVisitor(requiredFieldsV)
 	requiredFieldsWalker.RegisterEnterOperationVisitor(requiredFieldsV)
-	requiredFieldsWalker.RegisterEnterFieldVisitor(requiredFieldsV)
+	requiredFieldsWalker.RegisterFieldVisitor(requiredFieldsV)
 
 	// configuration
 
@@ -243,6 +332,7 @@ func NewPlanner(ctx context.Context, config Configuration) *Planner {
 		Walker:                       &planningWalker,
 		fieldConfigs:                 map[int]*FieldConfiguration{},
 		disableResolveFieldPositions: config.DisableResolveFieldPositions,
+		disableIncludeInfo:           config.DisableIncludeInfo,
 	}
 
 	p := &Planner{
@@ -291,6 +381,7 @@ func (p *Planner) Plan(operation, definition *ast.Document, operationName string
 	p.planningVisitor.fetchConfigurations = p.configurationVisitor.fetches
 	p.planningVisitor.fieldBuffers = p.configurationVisitor.fieldBuffers
 	p.planningVisitor.skipFieldPaths = p.requiredFieldsVisitor.skipFieldPaths
+	p.planningVisitor.requiredFields = p.requiredFieldsVisitor.requiredFields
 
 	p.planningWalker.ResetVisitors()
 	p.planningWalker.SetVisitorFilter(p.planningVisitor)
@@ -375,10 +466,12 @@ type Visitor struct {
 	fetchConfigurations          []objectFetchConfiguration
 	fieldBuffers                 map[int]int
 	skipFieldPaths               []string
+	requiredFields               map[int][]string
 	fieldConfigs                 map[int]*FieldConfiguration
 	exportedVariables            map[string]struct{}
 	skipIncludeFields            map[int]skipIncludeField
 	disableResolveFieldPositions bool
+	disableIncludeInfo           bool
 }
 
 type skipIncludeField struct {
@@ -526,6 +619,7 @@ func (v *Visitor) EnterField(ref int) {
 			},
 			OnTypeName:              v.resolveOnTypeName(),
 			Position:                v.resolveFieldPosition(ref),
+			Info:                    v.resolveFieldInfo(ref),
 			SkipDirectiveDefined:    skip,
 			SkipVariableName:        skipVariableName,
 			IncludeDirectiveDefined: include,
@@ -572,6 +666,7 @@ func (v *Visitor) EnterField(ref int) {
 		BufferID:                bufferID,
 		OnTypeName:              v.resolveOnTypeName(),
 		Position:                v.resolveFieldPosition(ref),
+		Info:                    v.resolveFieldInfo(ref),
 		SkipDirectiveDefined:    skip,
 		SkipVariableName:        skipVariableName,
 		IncludeDirectiveDefined: include,
@@ -599,6 +694,16 @@ func (v *Visitor) resolveFieldPosition(ref int) resolve.Position {
 	}
 }
 
+func (v *Visitor) resolveFieldInfo(ref int) *resolve.FieldInfo {
+	if v.disableIncludeInfo {
+		return nil
+	}
+	return &resolve.FieldInfo{
+		Name:           v.Operation.FieldNameString(ref),
+		ParentTypeName: v.Walker.EnclosingTypeDefinition.NameString(v.Definition),
+	}
+}
+
 func (v *Visitor) resolveSkipForField(ref int) (bool, string) {
 	skipInclude, ok := v.skipIncludeFields[ref]
 	if ok {
@@ -656,6 +761,9 @@ func (v *Visitor) resolveOnTypeName() []byte {
 }
 
 func (v *Visitor) LeaveField(ref int) {
+	if v.skipField(ref) {
+		return
+	}
 	if v.currentFields[len(v.currentFields)-1].popOnField == ref {
 		v.currentFields = v.currentFields[:len(v.currentFields)-1]
 	}
@@ -670,10 +778,20 @@ func (v *Visitor) LeaveField(ref int) {
 	}
 }
 
+// RequiredFields returns the fields, which must be selected to resolve the field ref of the operation.
+// For an entity with several keys, it contains the key picked by the planner instead of the first key.
+func (v *Visitor) RequiredFields(ref int, fieldConfig *FieldConfiguration) []string {
+	if requiredFields, ok := v.requiredFields[ref]; ok {
+		return requiredFields
+	}
+	return fieldConfig.RequiresFields
+}
+
+// skipField returns true for fields added by the planner and their selections, e.g. federation key fields.
 func (v *Visitor) skipField(ref int) bool {
 	fullPath := v.Walker.Path.DotDelimitedString() + "." + v.Operation.FieldAliasOrNameString(ref)
 	for i := range v.skipFieldPaths {
-		if v.skipFieldPaths[i] == fullPath {
+		if v.skipFieldPaths[i] == fullPath || strings.HasPrefix(fullPath, v.skipFieldPaths[i]+".") {
 			return true
 		}
 	}
@@ -1462,6 +1580,20 @@ func (p *plannerConfiguration) hasChildNode(typeName, fieldName string) bool {
 	return false
 }
 
+// providesChildNode returns true if a field of the path provides the child typeName.fieldName.
+// The returned provides are the fields provided below the child.
+func (p *plannerConfiguration) providesChildNode(path, typeName, fieldName string) (provides []FieldProvides, ok bool) {
+	for i := range p.paths {
+		if p.paths[i].path != path {
+			continue
+		}
+		if hasFieldProvides(p.paths[i].provides, typeName, fieldName) {
+			return fieldProvides(p.paths[i].provides, typeName, fieldName), true
+		}
+	}
+	return nil, false
+}
+
 func (p *plannerConfiguration) hasRootNode(typeName, fieldName string) bool {
 	for i := range p.dataSourceConfiguration.RootNodes {
 		if typeName != p.dataSourceConfiguration.RootNodes[i].TypeName {
@@ -1479,6 +1611,8 @@ func (p *plannerConfiguration) hasRootNode(typeName, fieldName string) bool {
 type pathConfiguration struct {
 	path              string
 	exitPlannerOnNode bool
+	// provides are the fields the data source resolves below the path
+	provides []FieldProvides
 }
 
 func (c *configurationVisitor) EnterOperationDefinition(ref int) {
@@ -1503,13 +1637,28 @@ func (c *configurationVisitor) EnterField(ref int) {
 	for i, planner := range c.planners {
 		if planner.hasParent(parent) && planner.hasRootNode(typeName, fieldName) && planner.planner.DataSourcePlanningBehavior().MergeAliasedRootNodes {
 			// same parent + root node = root sibling
-			c.planners[i].paths = append(c.planners[i].paths, pathConfiguration{path: current})
+			c.planners[i].paths = append(c.planners[i].paths, pathConfiguration{
+				path:     current,
+				provides: planner.dataSourceConfiguration.ProvidesOfField(typeName, fieldName),
+			})
 			c.fieldBuffers[ref] = planner.bufferID
 			return
 		}
 		if planner.hasPath(parent) && planner.hasChildNode(typeName, fieldName) {
 			// has parent path + has child node = child
-			c.planners[i].paths = append(c.planners[i].paths, pathConfiguration{path: current})
+			c.planners[i].paths = append(c.planners[i].paths, pathConfiguration{
+				path:     current,
+				provides: planner.dataSourceConfiguration.ProvidesOfField(typeName, fieldName),
+			})
+			return
+		}
+		if provides, ok := planner.providesChildNode(parent, typeName, fieldName); ok && c.selectionsProvided(planner, ref, provides) {
+			// parent path provides the field and all of its selections = child
+			c.planners[i].paths = append(c.planners[i].paths, pathConfiguration{
+				path: current,
+				// limit the capacity to not append to the provides of the configuration
+				provides: append(provides[:len(provides):len(provides)], planner.dataSourceConfiguration.ProvidesOfField(typeName, fieldName)...),
+			})
 			return
 		}
 	}
@@ -1529,7 +1678,8 @@ func (c *configurationVisitor) EnterField(ref int) {
 				planner:    planner,
 				paths: []pathConfiguration{
 					{
-						path: current,
+						path:     current,
+						provides: config.ProvidesOfField(typeName, fieldName),
 					},
 				},
 				dataSourceConfiguration: config,
@@ -1550,6 +1700,72 @@ func (c *configurationVisitor) EnterField(ref int) {
 	}
 }
 
+// selectionsProvided returns true if the planner resolves all selections of the field,
+// either because they are provided or because they are child nodes of the planner, e.g. the key fields of an entity.
+// Otherwise the field has to be fetched from the subgraph owning it.
+func (c *configurationVisitor) selectionsProvided(planner plannerConfiguration, fieldRef int, provides []FieldProvides) bool {
+	if !c.operation.FieldHasSelections(fieldRef) {
+		return true
+	}
+	fieldDefinition, ok := c.walker.FieldDefinition(fieldRef)
+	if !ok {
+		return false
+	}
+	typeName := c.definition.ResolveTypeNameString(c.definition.FieldDefinitionType(fieldDefinition))
+	return c.selectionSetProvided(planner, c.operation.Fields[fieldRef].SelectionSet, typeName, provides)
+}
+
+func (c *configurationVisitor) selectionSetProvided(planner plannerConfiguration, selectionSet int, typeName string, provides []FieldProvides) bool {
+	for _, selectionRef := range c.operation.SelectionSets[selectionSet].SelectionRefs {
+		selection := c.operation.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			fieldName := c.operation.FieldNameUnsafeString(selection.Ref)
+			if fieldName == "__typename" {
+				continue
+			}
+			if hasFieldProvides(provides, typeName, fieldName) {
+				if !c.operation.FieldHasSelections(selection.Ref) {
+					continue
+				}
+				fieldTypeName, ok := c.fieldTypeName(typeName, fieldName)
+				if !ok || !c.selectionSetProvided(planner, c.operation.Fields[selection.Ref].SelectionSet, fieldTypeName, fieldProvides(provides, typeName, fieldName)) {
+					return false
+				}
+				continue
+			}
+			if c.operation.FieldHasSelections(selection.Ref) || !planner.hasChildNode(typeName, fieldName) {
+				return false
+			}
+		case ast.SelectionKindInlineFragment:
+			fragmentTypeName := typeName
+			if c.operation.InlineFragmentHasTypeCondition(selection.Ref) {
+				fragmentTypeName = c.operation.InlineFragmentTypeConditionNameString(selection.Ref)
+			}
+			if c.operation.InlineFragments[selection.Ref].HasSelections &&
+				!c.selectionSetProvided(planner, c.operation.InlineFragments[selection.Ref].SelectionSet, fragmentTypeName, provides) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// fieldTypeName returns the name of the type of the field typeName.fieldName in the definition.
+func (c *configurationVisitor) fieldTypeName(typeName, fieldName string) (string, bool) {
+	node, ok := c.definition.Index.FirstNodeByNameStr(typeName)
+	if !ok {
+		return "", false
+	}
+	fieldDefinition, ok := c.definition.NodeFieldDefinitionByName(node, []byte(fieldName))
+	if !ok {
+		return "", false
+	}
+	return c.definition.ResolveTypeNameString(c.definition.FieldDefinitionType(fieldDefinition)), true
+}
+
 func (c *configurationVisitor) LeaveField(ref int) {
 	fieldAliasOrName := c.operation.FieldAliasOrNameString(ref)
 	parent := c.walker.Path.DotDelimitedString()
@@ -1598,15 +1814,28 @@ type requiredFieldsVisitor struct {
 	config                *Configuration
 	operationName         string
 	skipFieldPaths        []string
+	// requiredFields are the required fields of the fields of entities with several keys
+	requiredFields  map[int][]string
+	enclosingFields []enclosingField
+}
+
+// enclosingField is a field of the operation, which encloses the currently visited field.
+type enclosingField struct {
+	typeName, fieldName string
 }
 
 func (r *requiredFieldsVisitor) EnterDocument(operation, definition *ast.Document) {
 	r.skipFieldPaths = r.skipFieldPaths[:0]
+	r.requiredFields = map[int][]string{}
+	r.enclosingFields = r.enclosingFields[:0]
 }
 
 func (r *requiredFieldsVisitor) EnterField(ref int) {
 	typeName := r.walker.EnclosingTypeDefinition.NameString(r.definition)
 	fieldName := r.operation.FieldNameUnsafeString(ref)
+	parent, hasParent := r.parentField()
+	r.enclosingFields = append(r.enclosingFields, enclosingField{typeName: typeName, fieldName: fieldName})
+
 	fieldConfig := r.config.Fields.ForTypeField(typeName, fieldName)
 	if fieldConfig == nil {
 		return
@@ -1618,29 +1847,113 @@ func (r *requiredFieldsVisitor) EnterField(ref int) {
 	if selectionSet.Kind != ast.NodeKindSelectionSet {
 		return
 	}
-	for i := range fieldConfig.RequiresFields {
-		r.handleRequiredField(selectionSet.Ref, fieldConfig.RequiresFields[i])
+	requiresFields := fieldConfig.RequiresFields
+	if len(fieldConfig.Keys) > 1 && hasParent {
+		requiresFields = r.requiresFieldsWithResolvableKey(fieldConfig, parent, typeName)
+		r.requiredFields[ref] = requiresFields
+	}
+	for i := range requiresFields {
+		r.handleRequiredField(selectionSet.Ref, requiresFields[i])
+	}
+	if r.walker.EnclosingTypeDefinition.Kind == ast.NodeKindInterfaceTypeDefinition {
+		// the representation of an entity interface carries the __typename of the concrete type
+		r.handleRequiredField(selectionSet.Ref, "__typename")
+	}
+}
+
+func (r *requiredFieldsVisitor) LeaveField(ref int) {
+	r.enclosingFields = r.enclosingFields[:len(r.enclosingFields)-1]
+}
+
+func (r *requiredFieldsVisitor) parentField() (enclosingField, bool) {
+	if len(r.enclosingFields) == 0 {
+		return enclosingField{}, false
+	}
+	return r.enclosingFields[len(r.enclosingFields)-1], true
+}
+
+// requiresFieldsWithResolvableKey returns the RequiresFields of a field of an entity with several keys.
+// The fields of the first key are replaced by the first key, which a data source of the parent field resolves.
+func (r *requiredFieldsVisitor) requiresFieldsWithResolvableKey(fieldConfig *FieldConfiguration, parent enclosingField, typeName string) []string {
+	firstKey := fieldConfig.Keys[0]
+	if len(fieldConfig.RequiresFields) < len(firstKey) {
+		return fieldConfig.RequiresFields
+	}
+	for _, key := range fieldConfig.Keys {
+		if !r.parentResolvesKey(parent, typeName, key) {
+			continue
+		}
+		requiresFields := make([]string, 0, len(key)+len(fieldConfig.RequiresFields)-len(firstKey))
+		requiresFields = append(requiresFields, key...)
+		return append(requiresFields, fieldConfig.RequiresFields[len(firstKey):]...)
+	}
+	return fieldConfig.RequiresFields
+}
+
+// parentResolvesKey returns true if a data source of the parent field resolves all key fields of the entity typeName.
+func (r *requiredFieldsVisitor) parentResolvesKey(parent enclosingField, typeName string, key []string) bool {
+	for i := range r.config.DataSources {
+		dataSource := &r.config.DataSources[i]
+		if !dataSource.HasRootNode(parent.typeName, parent.fieldName) && !dataSource.HasChildNode(parent.typeName, parent.fieldName) {
+			continue
+		}
+		if dataSource.resolvesFieldSets(typeName, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *requiredFieldsVisitor) handleRequiredField(selectionSet int, requiredField string) {
+	if !strings.Contains(requiredField, "{") {
+		r.handleRequiredFieldSetFields(selectionSet, r.walker.Path.DotDelimitedString(), []fieldSetField{{name: requiredField}})
+		return
+	}
+	// a field of a compound key, e.g. "organization { id }"
+	fields, ok := parseFieldSet(requiredField)
+	if !ok {
+		return
+	}
+	r.handleRequiredFieldSetFields(selectionSet, r.walker.Path.DotDelimitedString(), fields)
+}
+
+func (r *requiredFieldsVisitor) handleRequiredFieldSetFields(selectionSet int, path string, fields []fieldSetField) {
+	for i := range fields {
+		fieldRef, exists := r.selectedField(selectionSet, fields[i].name)
+		if !exists {
+			r.addRequiredField(fields[i], selectionSet, path)
+			continue
+		}
+		if len(fields[i].fields) == 0 || !r.operation.FieldHasSelections(fieldRef) {
+			continue
+		}
+		r.handleRequiredFieldSetFields(r.operation.Fields[fieldRef].SelectionSet, path+"."+fields[i].name, fields[i].fields)
 	}
 }
 
-func (r *requiredFieldsVisitor) handleRequiredField(selectionSet int, requiredFieldName string) {
+func (r *requiredFieldsVisitor) selectedField(selectionSet int, fieldName string) (fieldRef int, exists bool) {
 	for _, ref := range r.operation.SelectionSets[selectionSet].SelectionRefs {
 		selection := r.operation.Selections[ref]
 		if selection.Kind != ast.SelectionKindField {
 			continue
 		}
 		name := r.operation.FieldAliasOrNameString(selection.Ref)
-		if name == requiredFieldName {
-			// already exists
-			return
+		if name == fieldName {
+			return selection.Ref, true
 		}
 	}
-	r.addRequiredField(requiredFieldName, selectionSet)
+	return ast.InvalidRef, false
 }
 
-func (r *requiredFieldsVisitor) addRequiredField(fieldName string, selectionSet int) {
+// addRequiredField adds the field and its selections to the selection set.
+// The added field is skipped in the response, as it is not part of the operation.
+func (r *requiredFieldsVisitor) addRequiredField(requiredField fieldSetField, selectionSet int, path string) {
 	field := ast.Field{
-		Name: r.operation.Input.AppendInputString(fieldName),
+		Name: r.operation.Input.AppendInputString(requiredField.name),
+	}
+	if len(requiredField.fields) != 0 {
+		field.HasSelections = true
+		field.SelectionSet = r.operation.AddSelectionSet().Ref
 	}
 	addedField := r.operation.AddField(field)
 	selection := ast.Selection{
@@ -1648,8 +1961,11 @@ func (r *requiredFieldsVisitor) addRequiredField(fieldName string, selectionSet
 		Ref:  addedField.Ref,
 	}
 	r.operation.AddSelection(selectionSet, selection)
-	addedFieldPath := r.walker.Path.DotDelimitedString() + "." + fieldName
+	addedFieldPath := path + "." + requiredField.name
 	r.skipFieldPaths = append(r.skipFieldPaths, addedFieldPath)
+	for i := range requiredField.fields {
+		r.addRequiredField(requiredField.fields[i], field.SelectionSet, addedFieldPath)
+	}
 }
 
 func (r *requiredFieldsVisitor) EnterOperationDefinition(ref int) {
@@ -90,6 +90,10 @@ func TestPlanner_Plan(t *testing.T) {
 				Fields: []*resolve.Field{
 					{
 						Name: []byte("droid"),
+						Info: &resolve.FieldInfo{
+							Name:           "droid",
+							ParentTypeName: "Query",
+						},
 						Position: resolve.Position{
 							Line:   3,
 							Column: 4,
@@ -100,6 +104,10 @@ func TestPlanner_Plan(t *testing.T) {
 							Fields: []*resolve.Field{
 								{
 									Name: []byte("name"),
+									Info: &resolve.FieldInfo{
+										Name:           "name",
+										ParentTypeName: "Droid",
+									},
 									Value: &resolve.String{
 										Path: []string{"name"},
 									},
@@ -110,6 +118,10 @@ func TestPlanner_Plan(t *testing.T) {
 								},
 								{
 									Name: []byte("aliased"),
+									Info: &resolve.FieldInfo{
+										Name:           "name",
+										ParentTypeName: "Droid",
+									},
 									Value: &resolve.String{
 										Path: []string{"name"},
 									},
@@ -120,6 +132,10 @@ func TestPlanner_Plan(t *testing.T) {
 								},
 								{
 									Name: []byte("friends"),
+									Info: &resolve.FieldInfo{
+										Name:           "friends",
+										ParentTypeName: "Droid",
+									},
 									Stream: &resolve.StreamField{
 										InitialBatchSize: 0,
 									},
@@ -135,6 +151,10 @@ func TestPlanner_Plan(t *testing.T) {
 											Fields: []*resolve.Field{
 												{
 													Name: []byte("name"),
+													Info: &resolve.FieldInfo{
+														Name:           "name",
+														ParentTypeName: "Character",
+													},
 													Value: &resolve.String{
 														Path: []string{"name"},
 													},
@@ -149,6 +169,10 @@ func TestPlanner_Plan(t *testing.T) {
 								},
 								{
 									Name: []byte("friendsWithInitialBatch"),
+									Info: &resolve.FieldInfo{
+										Name:           "friends",
+										ParentTypeName: "Droid",
+									},
 									Position: resolve.Position{
 										Line:   9,
 										Column: 5,
@@ -164,6 +188,10 @@ func TestPlanner_Plan(t *testing.T) {
 											Fields: []*resolve.Field{
 												{
 													Name: []byte("name"),
+													Info: &resolve.FieldInfo{
+														Name:           "name",
+														ParentTypeName: "Character",
+													},
 													Value: &resolve.String{
 														Path: []string{"name"},
 													},
@@ -178,6 +206,10 @@ func TestPlanner_Plan(t *testing.T) {
 								},
 								{
 									Name: []byte("primaryFunction"),
+									Info: &resolve.FieldInfo{
+										Name:           "primaryFunction",
+										ParentTypeName: "Droid",
+									},
 									Position: resolve.Position{
 										Line:   12,
 										Column: 5,
@@ -188,6 +220,10 @@ func TestPlanner_Plan(t *testing.T) {
 								},
 								{
 									Name: []byte("favoriteEpisode"),
+									Info: &resolve.FieldInfo{
+										Name:           "favoriteEpisode",
+										ParentTypeName: "Droid",
+									},
 									Position: resolve.Position{
 										Line:   13,
 										Column: 5,
@@ -297,6 +333,10 @@ var expectedMyHeroPlan = &SynchronousResponsePlan{
 			Fields: []*resolve.Field{
 				{
 					Name: []byte("hero"),
+					Info: &resolve.FieldInfo{
+						Name:           "hero",
+						ParentTypeName: "Query",
+					},
 					Position: resolve.Position{
 						Line:   3,
 						Column: 6,
@@ -307,6 +347,10 @@ var expectedMyHeroPlan = &SynchronousResponsePlan{
 						Fields: []*resolve.Field{
 							{
 								Name: []byte("name"),
+								Info: &resolve.FieldInfo{
+									Name:           "name",
+									ParentTypeName: "Character",
+								},
 								Value: &resolve.String{
 									Path: []string{"name"},
 								},
@@ -330,6 +374,10 @@ var expectedMyHeroPlanWithFragment = &SynchronousResponsePlan{
 			Fields: []*resolve.Field{
 				{
 					Name: []byte("hero"),
+					Info: &resolve.FieldInfo{
+						Name:           "hero",
+						ParentTypeName: "Query",
+					},
 					Position: resolve.Position{
 						Line:   6,
 						Column: 6,
@@ -340,6 +388,10 @@ var expectedMyHeroPlanWithFragment = &SynchronousResponsePlan{
 						Fields: []*resolve.Field{
 							{
 								Name: []byte("name"),
+								Info: &resolve.FieldInfo{
+									Name:           "name",
+									ParentTypeName: "Character",
+								},
 								Value: &resolve.String{
 									Path: []string{"name"},
 								},
@@ -426,3 +478,106 @@ type Startship {
     name: String!
     length: Float!
 }`
+
+func TestConfigurationVisitor_SelectionSetProvided(t *testing.T) {
+	definition := `
+		type Query {
+			review: Review
+		}
+
+		type Review {
+			author: User!
+			writer: Author!
+		}
+
+		interface Author {
+			id: ID!
+		}
+
+		type User implements Author {
+			id: ID!
+			username: String!
+			reviewCount: Int!
+			account: Account!
+		}
+
+		type Bot implements Author {
+			id: ID!
+			model: String!
+		}
+
+		type Account {
+			name: String!
+			email: String!
+		}
+	`
+	planner := plannerConfiguration{
+		dataSourceConfiguration: DataSourceConfiguration{
+			ChildNodes: []TypeField{
+				{TypeName: "Author", FieldNames: []string{"id"}},
+				{TypeName: "User", FieldNames: []string{"id"}},
+			},
+		},
+	}
+	// @provides(fields: "username account { name }")
+	authorProvides := []FieldProvides{
+		{TypeName: "User", FieldName: "username"},
+		{TypeName: "User", FieldName: "account", Provides: []FieldProvides{
+			{TypeName: "Account", FieldName: "name"},
+		}},
+	}
+	// @provides(fields: "... on User { username } ... on Bot { model }")
+	writerProvides := []FieldProvides{
+		{TypeName: "User", FieldName: "username"},
+		{TypeName: "Bot", FieldName: "model"},
+	}
+
+	run := func(t *testing.T, operation, fieldName, typeName string, provides []FieldProvides, expected bool) {
+		t.Helper()
+
+		def := unsafeparser.ParseGraphqlDocumentString(definition)
+		op := unsafeparser.ParseGraphqlDocumentString(operation)
+		visitor := &configurationVisitor{operation: &op, definition: &def}
+
+		for ref := range op.Fields {
+			if op.FieldNameString(ref) != fieldName {
+				continue
+			}
+			got := visitor.selectionSetProvided(planner, op.Fields[ref].SelectionSet, typeName, provides)
+			assert.Equal(t, expected, got)
+			return
+		}
+		t.Fatalf("field %s not found in operation", fieldName)
+	}
+
+	t.Run("provided field", func(t *testing.T) {
+		run(t, `{ review { author { username } } }`, "author", "User", authorProvides, true)
+	})
+	t.Run("provided field with key field and __typename", func(t *testing.T) {
+		run(t, `{ review { author { __typename id username } } }`, "author", "User", authorProvides, true)
+	})
+	t.Run("nested provided field", func(t *testing.T) {
+		run(t, `{ review { author { username account { name } } } }`, "author", "User", authorProvides, true)
+	})
+	t.Run("partially provided nested field", func(t *testing.T) {
+		run(t, `{ review { author { username account { name email } } } }`, "author", "User", authorProvides, false)
+	})
+	t.Run("field which is not provided", func(t *testing.T) {
+		run(t, `{ review { author { username reviewCount } } }`, "author", "User", authorProvides, false)
+	})
+	t.Run("nested field without provided selections", func(t *testing.T) {
+		run(t, `{ review { author { id account { email } } } }`, "author", "User", authorProvides, false)
+	})
+	t.Run("inline fragment without type condition", func(t *testing.T) {
+		run(t, `{ review { author { ... { username } } } }`, "author", "User", authorProvides, true)
+	})
+	t.Run("inline fragments with provided fields", func(t *testing.T) {
+		run(t, `{ review { writer { id ... on User { username } ... on Bot { model } } } }`, "writer", "Author", writerProvides, true)
+	})
+	t.Run("inline fragment with field which is not provided", func(t *testing.T) {
+		run(t, `{ review { writer { ... on User { username reviewCount } } } }`, "writer", "Author", writerProvides, false)
+	})
+	t.Run("inline fragment with provides of another type", func(t *testing.T) {
+		run(t, `{ review { writer { ... on Bot { username } } } }`, "writer", "Author", writerProvides, false)
+	})
+}
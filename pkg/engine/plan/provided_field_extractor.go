@@ -0,0 +1,98 @@
+package plan
+
+import (
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+)
+
+const federationProvidesDirectiveName = "provides"
+
+// ProvidedFieldExtractor extracts the @provides directives from an ast.Document
+// containing a parsed federation subgraph SDL.
+//
+// A field with @provides(fields: "...") returns the listed fields of an entity,
+// although the subgraph declares them @external. The subgraph resolves them only
+// below this field, so they are no child nodes of the data source but FieldProvides.
+type ProvidedFieldExtractor struct {
+	document *ast.Document
+}
+
+func NewProvidedFieldExtractor(document *ast.Document) *ProvidedFieldExtractor {
+	return &ProvidedFieldExtractor{
+		document: document,
+	}
+}
+
+// GetAllProvidedFields returns a FieldProvides for every field definition with a @provides directive.
+func (p *ProvidedFieldExtractor) GetAllProvidedFields() []FieldProvides {
+	var provides []FieldProvides
+
+	for _, node := range p.document.RootNodes {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition, ast.NodeKindObjectTypeExtension,
+			ast.NodeKindInterfaceTypeDefinition, ast.NodeKindInterfaceTypeExtension:
+		default:
+			continue
+		}
+
+		typeName := p.document.NodeNameString(node)
+		for _, fieldDefinitionRef := range p.document.NodeFieldDefinitions(node) {
+			directiveRef, exists := p.document.FieldDefinitionDirectiveByName(fieldDefinitionRef, []byte(federationProvidesDirectiveName))
+			if !exists {
+				continue
+			}
+			fieldSet, ok := directiveFieldSet(p.document, directiveRef)
+			if !ok {
+				continue
+			}
+			fields, ok := parseFieldSet(fieldSet)
+			if !ok {
+				continue
+			}
+
+			fieldTypeName := p.document.ResolveTypeNameString(p.document.FieldDefinitionType(fieldDefinitionRef))
+			provides = append(provides, FieldProvides{
+				TypeName:  typeName,
+				FieldName: p.document.FieldDefinitionNameString(fieldDefinitionRef),
+				Provides:  p.providedFields(fieldTypeName, fields),
+			})
+		}
+	}
+
+	return provides
+}
+
+// providedFields returns the fields of the field set, which are selected on the type typeName.
+func (p *ProvidedFieldExtractor) providedFields(typeName string, fields []fieldSetField) []FieldProvides {
+	provides := make([]FieldProvides, 0, len(fields))
+	for _, field := range fields {
+		fieldTypeName := typeName
+		if field.typeCondition != "" {
+			fieldTypeName = field.typeCondition
+		}
+
+		provided := FieldProvides{
+			TypeName:  fieldTypeName,
+			FieldName: field.name,
+		}
+		if len(field.fields) != 0 {
+			provided.Provides = p.providedFields(p.fieldTypeName(fieldTypeName, field.name), field.fields)
+		}
+		provides = append(provides, provided)
+	}
+	return provides
+}
+
+// fieldTypeName returns the name of the type of a field, the field might be defined on any extension of the type.
+func (p *ProvidedFieldExtractor) fieldTypeName(typeName, fieldName string) string {
+	for _, node := range p.document.RootNodes {
+		if p.document.NodeNameString(node) != typeName {
+			continue
+		}
+		fieldDefinitionRef, exists := p.document.NodeFieldDefinitionByName(node, []byte(fieldName))
+		if !exists {
+			continue
+		}
+		return p.document.ResolveTypeNameString(p.document.FieldDefinitionType(fieldDefinitionRef))
+	}
+	return ""
+}
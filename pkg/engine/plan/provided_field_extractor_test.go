@@ -0,0 +1,162 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pvormste/graphql-go-tools/internal/pkg/unsafeparser"
+)
+
+func TestProvidedFieldExtractor_GetAllProvidedFields(t *testing.T) {
+	run := func(t *testing.T, SDL string, expected []FieldProvides) {
+		t.Helper()
+
+		document := unsafeparser.ParseGraphqlDocumentString(SDL)
+		extractor := NewProvidedFieldExtractor(&document)
+		got := extractor.GetAllProvidedFields()
+		assert.Equal(t, expected, got)
+	}
+
+	t.Run("no provides", func(t *testing.T) {
+		run(t, `
+		type Review {
+			body: String!
+			author: User!
+		}
+
+		extend type User @key(fields: "id") {
+			id: ID! @external
+		}
+		`, nil)
+	})
+	t.Run("flat provides", func(t *testing.T) {
+		run(t, `
+		type Review {
+			body: String!
+			author: User! @provides(fields: "username")
+		}
+
+		extend type User @key(fields: "id") {
+			id: ID! @external
+			username: String! @external
+		}
+		`, []FieldProvides{
+			{TypeName: "Review", FieldName: "author", Provides: []FieldProvides{
+				{TypeName: "User", FieldName: "username"},
+			}},
+		})
+	})
+	t.Run("provides on type extension", func(t *testing.T) {
+		run(t, `
+		extend type Query {
+			topReviews: [Review] @provides(fields: "body")
+		}
+
+		extend type Review @key(fields: "id") {
+			id: ID! @external
+			body: String! @external
+		}
+		`, []FieldProvides{
+			{TypeName: "Query", FieldName: "topReviews", Provides: []FieldProvides{
+				{TypeName: "Review", FieldName: "body"},
+			}},
+		})
+	})
+	t.Run("nested provides", func(t *testing.T) {
+		run(t, `
+		type Review {
+			author: User! @provides(fields: "username account { name address { street } }")
+		}
+
+		extend type User @key(fields: "id") {
+			id: ID! @external
+			username: String! @external
+			account: Account! @external
+		}
+
+		type Account {
+			name: String!
+			address: Address!
+		}
+
+		extend type Account {
+			email: String!
+		}
+
+		type Address {
+			street: String!
+		}
+		`, []FieldProvides{
+			{TypeName: "Review", FieldName: "author", Provides: []FieldProvides{
+				{TypeName: "User", FieldName: "username"},
+				{TypeName: "User", FieldName: "account", Provides: []FieldProvides{
+					{TypeName: "Account", FieldName: "name"},
+					{TypeName: "Account", FieldName: "address", Provides: []FieldProvides{
+						{TypeName: "Address", FieldName: "street"},
+					}},
+				}},
+			}},
+		})
+	})
+	t.Run("provides with inline fragments", func(t *testing.T) {
+		run(t, `
+		type Review {
+			author: Author! @provides(fields: "... on User { username } ... on Bot { model { name } }")
+		}
+
+		interface Author {
+			id: ID!
+		}
+
+		extend type User implements Author @key(fields: "id") {
+			id: ID! @external
+			username: String! @external
+		}
+
+		extend type Bot implements Author @key(fields: "id") {
+			id: ID! @external
+			model: Model! @external
+		}
+
+		type Model {
+			name: String!
+		}
+		`, []FieldProvides{
+			{TypeName: "Review", FieldName: "author", Provides: []FieldProvides{
+				{TypeName: "User", FieldName: "username"},
+				{TypeName: "Bot", FieldName: "model", Provides: []FieldProvides{
+					{TypeName: "Model", FieldName: "name"},
+				}},
+			}},
+		})
+	})
+	t.Run("provides on interface field", func(t *testing.T) {
+		run(t, `
+		interface Reviewable {
+			reviews: [Review] @provides(fields: "body")
+		}
+
+		extend type Review @key(fields: "id") {
+			id: ID! @external
+			body: String! @external
+		}
+		`, []FieldProvides{
+			{TypeName: "Reviewable", FieldName: "reviews", Provides: []FieldProvides{
+				{TypeName: "Review", FieldName: "body"},
+			}},
+		})
+	})
+	t.Run("invalid field set", func(t *testing.T) {
+		run(t, `
+		type Review {
+			author: User! @provides(fields: "username {")
+		}
+
+		extend type User @key(fields: "id") {
+			id: ID! @external
+			username: String! @external
+		}
+		`, nil)
+	})
+}
@@ -27,6 +27,8 @@ func (f *RequiredFieldExtractor) GetAllRequiredFields() FieldConfigurations {
 
 	f.addFieldsForObjectExtensionDefinitions(&fieldRequires)
 	f.addFieldsForObjectDefinitions(&fieldRequires)
+	f.addFieldsForInterfaceExtensionDefinitions(&fieldRequires)
+	f.addFieldsForInterfaceDefinitions(&fieldRequires)
 
 	return fieldRequires
 }
@@ -35,63 +37,98 @@ func (f *RequiredFieldExtractor) addFieldsForObjectExtensionDefinitions(fieldReq
 	for _, objectTypeExt := range f.document.ObjectTypeExtensions {
 		objectType := objectTypeExt.ObjectTypeDefinition
 		typeName := f.document.Input.ByteSliceString(objectType.Name)
+		f.addFieldsForTypeExtension(fieldRequires, typeName, objectType.Directives.Refs, objectType.FieldsDefinition.Refs)
+	}
+}
 
-		primaryKeys, exists := f.primaryKeyFieldsIfObjectTypeIsEntity(objectType)
-		if !exists {
+func (f *RequiredFieldExtractor) addFieldsForObjectDefinitions(fieldRequires *FieldConfigurations) {
+	for _, objectType := range f.document.ObjectTypeDefinitions {
+		typeName := f.document.Input.ByteSliceString(objectType.Name)
+		f.addFieldsForTypeDefinition(fieldRequires, typeName, objectType.Directives.Refs, objectType.FieldsDefinition.Refs)
+	}
+}
+
+// addFieldsForInterfaceExtensionDefinitions adds the fields of entity interfaces, e.g. extend interface Account @key(fields: "id").
+func (f *RequiredFieldExtractor) addFieldsForInterfaceExtensionDefinitions(fieldRequires *FieldConfigurations) {
+	for _, interfaceTypeExt := range f.document.InterfaceTypeExtensions {
+		interfaceType := interfaceTypeExt.InterfaceTypeDefinition
+		typeName := f.document.Input.ByteSliceString(interfaceType.Name)
+		f.addFieldsForTypeExtension(fieldRequires, typeName, interfaceType.Directives.Refs, interfaceType.FieldsDefinition.Refs)
+	}
+}
+
+func (f *RequiredFieldExtractor) addFieldsForInterfaceDefinitions(fieldRequires *FieldConfigurations) {
+	for _, interfaceType := range f.document.InterfaceTypeDefinitions {
+		typeName := f.document.Input.ByteSliceString(interfaceType.Name)
+		f.addFieldsForTypeDefinition(fieldRequires, typeName, interfaceType.Directives.Refs, interfaceType.FieldsDefinition.Refs)
+	}
+}
+
+func (f *RequiredFieldExtractor) addFieldsForTypeExtension(fieldRequires *FieldConfigurations, typeName string, directiveRefs, fieldDefinitionRefs []int) {
+	keys, exists := f.primaryKeysIfTypeIsEntity(directiveRefs)
+	if !exists {
+		return
+	}
+	primaryKeys := keys[0]
+
+	for _, fieldDefinitionRef := range fieldDefinitionRefs {
+		if f.document.FieldDefinitionHasNamedDirective(fieldDefinitionRef, federationExternalDirectiveName) {
 			continue
 		}
 
-		for _, fieldDefinitionRef := range objectType.FieldsDefinition.Refs {
-			if f.document.FieldDefinitionHasNamedDirective(fieldDefinitionRef, federationExternalDirectiveName) {
-				continue
-			}
+		fieldName := f.document.FieldDefinitionNameString(fieldDefinitionRef)
 
-			fieldName := f.document.FieldDefinitionNameString(fieldDefinitionRef)
+		requiredFields := make([]string, len(primaryKeys))
+		copy(requiredFields, primaryKeys)
 
-			requiredFields := make([]string, len(primaryKeys))
-			copy(requiredFields, primaryKeys)
+		requiredFieldsByRequiresDirective := requiredFieldsByRequiresDirective(f.document, fieldDefinitionRef)
+		requiredFields = append(requiredFields, requiredFieldsByRequiresDirective...)
 
-			requiredFieldsByRequiresDirective := requiredFieldsByRequiresDirective(f.document, fieldDefinitionRef)
-			requiredFields = append(requiredFields, requiredFieldsByRequiresDirective...)
-
-			*fieldRequires = append(*fieldRequires, FieldConfiguration{
-				TypeName:       typeName,
-				FieldName:      fieldName,
-				RequiresFields: requiredFields,
-			})
-		}
+		*fieldRequires = append(*fieldRequires, FieldConfiguration{
+			TypeName:       typeName,
+			FieldName:      fieldName,
+			RequiresFields: requiredFields,
+			Keys:           alternativeKeys(keys),
+		})
 	}
 }
 
-func (f *RequiredFieldExtractor) addFieldsForObjectDefinitions(fieldRequires *FieldConfigurations) {
-	for _, objectType := range f.document.ObjectTypeDefinitions {
-		typeName := f.document.Input.ByteSliceString(objectType.Name)
+func (f *RequiredFieldExtractor) addFieldsForTypeDefinition(fieldRequires *FieldConfigurations, typeName string, directiveRefs, fieldDefinitionRefs []int) {
+	keys, exists := f.primaryKeysIfTypeIsEntity(directiveRefs)
+	if !exists {
+		return
+	}
+	primaryKeys := keys[0]
 
-		primaryKeys, exists := f.primaryKeyFieldsIfObjectTypeIsEntity(objectType)
-		if !exists {
-			continue
+	primaryKeysSet := make(map[string]struct{})
+	for _, key := range keys {
+		for _, fieldSet := range key {
+			for _, name := range fieldSetFieldNames(fieldSet) {
+				primaryKeysSet[name] = struct{}{}
+			}
 		}
+	}
 
-		primaryKeysSet := make(map[string]struct{}, len(primaryKeys))
-		for _, val := range primaryKeys {
-			primaryKeysSet[val] = struct{}{}
+	for _, fieldRef := range fieldDefinitionRefs {
+		fieldName := f.document.FieldDefinitionNameString(fieldRef)
+		if _, exists := primaryKeysSet[fieldName]; exists { // Field is part of primary key, it couldn't have any required fields
+			continue
+		}
+		// federation v2 entities are defined without extend, but might have @external fields as well
+		if f.document.FieldDefinitionHasNamedDirective(fieldRef, federationExternalDirectiveName) {
+			continue
 		}
 
-		for _, fieldRef := range objectType.FieldsDefinition.Refs {
-			fieldName := f.document.FieldDefinitionNameString(fieldRef)
-			if _, exists := primaryKeysSet[fieldName]; exists { // Field is part of primary key, it couldn't have any required fields
-				continue
-			}
-
-			requiredFields := make([]string, len(primaryKeys))
-			copy(requiredFields, primaryKeys)
+		requiredFields := make([]string, len(primaryKeys))
+		copy(requiredFields, primaryKeys)
+		requiredFields = append(requiredFields, requiredFieldsByRequiresDirective(f.document, fieldRef)...)
 
-			*fieldRequires = append(*fieldRequires, FieldConfiguration{
-				TypeName:       typeName,
-				FieldName:      fieldName,
-				RequiresFields: requiredFields,
-			})
-		}
+		*fieldRequires = append(*fieldRequires, FieldConfiguration{
+			TypeName:       typeName,
+			FieldName:      fieldName,
+			RequiresFields: requiredFields,
+			Keys:           alternativeKeys(keys),
+		})
 	}
 }
 
@@ -117,24 +154,33 @@ func requiredFieldsByRequiresDirective(document *ast.Document, fieldDefinitionRe
 	return nil
 }
 
-func (f *RequiredFieldExtractor) primaryKeyFieldsIfObjectTypeIsEntity(objectType ast.ObjectTypeDefinition) (keyFields []string, ok bool) {
-	for _, directiveRef := range objectType.Directives.Refs {
+// primaryKeysIfTypeIsEntity returns the fields of every @key directive of an entity.
+// A field of a compound key is rendered with its selections, e.g. "organization { id }".
+func (f *RequiredFieldExtractor) primaryKeysIfTypeIsEntity(directiveRefs []int) (keys [][]string, ok bool) {
+	for _, directiveRef := range directiveRefs {
 		if directiveName := f.document.DirectiveNameString(directiveRef); directiveName != FederationKeyDirectiveName {
 			continue
 		}
 
-		value, exists := f.document.DirectiveArgumentValueByName(directiveRef, fieldsArgumentNameBytes)
+		fieldsStr, exists := directiveFieldSet(f.document, directiveRef)
 		if !exists {
 			continue
 		}
-		if value.Kind != ast.ValueKindString {
+
+		keyFields := splitFieldSet(fieldsStr)
+		if len(keyFields) == 0 {
 			continue
 		}
+		keys = append(keys, keyFields)
+	}
 
-		fieldsStr := f.document.StringValueContentString(value.Ref)
+	return keys, len(keys) > 0
+}
 
-		return strings.Split(fieldsStr, " "), true
+// alternativeKeys returns the keys of an entity with several @key directives, otherwise nil.
+func alternativeKeys(keys [][]string) [][]string {
+	if len(keys) < 2 {
+		return nil
 	}
-
-	return nil, false
+	return keys
 }
@@ -36,6 +36,17 @@ func TestRequiredFieldExtractor_GetAllFieldRequires(t *testing.T) {
 		}
 		`, nil)
 	})
+	t.Run("federation v2 entity with external and required fields", func(t *testing.T) {
+		run(t, `
+		type Product @key(fields: "upc"){
+			upc: String!
+			weight: Int @external
+			shippingEstimate: Int @requires(fields: "weight")
+		}
+		`, FieldConfigurations{
+			{TypeName: "Product", FieldName: "shippingEstimate", RequiresFields: []string{"upc", "weight"}},
+		})
+	})
 	t.Run("Entity with simple primary key", func(t *testing.T) {
 		run(t, `
 		type Review @key(fields: "id"){
@@ -61,6 +72,41 @@ func TestRequiredFieldExtractor_GetAllFieldRequires(t *testing.T) {
 			{TypeName: "Review", FieldName: "title", RequiresFields: []string{"id", "author"}},
 		})
 	})
+	t.Run("Entity with nested compound primary key", func(t *testing.T) {
+		run(t, `
+		type User @key(fields: "id organization { id }"){
+			id: ID!
+			organization: Organization!
+			username: String!
+		}
+		`, FieldConfigurations{
+			{TypeName: "User", FieldName: "username", RequiresFields: []string{"id", "organization { id }"}},
+		})
+	})
+	t.Run("Entity with several primary keys", func(t *testing.T) {
+		run(t, `
+		type Product @key(fields: "upc") @key(fields: "sku variation { id }"){
+			upc: String!
+			sku: String!
+			variation: Variation!
+			name: String!
+		}
+		`, FieldConfigurations{
+			{TypeName: "Product", FieldName: "name", RequiresFields: []string{"upc"}, Keys: [][]string{{"upc"}, {"sku", "variation { id }"}}},
+		})
+	})
+	t.Run("Entity object extension with several primary keys and required fields", func(t *testing.T) {
+		run(t, `
+		extend type Product @key(fields: "upc") @key(fields: "sku"){
+			upc: String! @external
+			sku: String! @external
+			weight: Int @external
+			shippingEstimate: Int @requires(fields: "weight")
+		}
+		`, FieldConfigurations{
+			{TypeName: "Product", FieldName: "shippingEstimate", RequiresFields: []string{"upc", "weight"}, Keys: [][]string{{"upc"}, {"sku"}}},
+		})
+	})
 	t.Run("Entity object extension without non-primary external fields", func(t *testing.T) {
 		run(t, `
 		extend type Review @key(fields: "id"){
@@ -83,4 +129,24 @@ func TestRequiredFieldExtractor_GetAllFieldRequires(t *testing.T) {
 			{TypeName: "Review", FieldName: "slug", RequiresFields: []string{"id", "title", "author"}},
 		})
 	})
+	t.Run("Entity interface", func(t *testing.T) {
+		run(t, `
+		interface Account @key(fields: "id"){
+			id: ID!
+			name: String!
+		}
+		`, FieldConfigurations{
+			{TypeName: "Account", FieldName: "name", RequiresFields: []string{"id"}},
+		})
+	})
+	t.Run("Entity interface extension", func(t *testing.T) {
+		run(t, `
+		extend interface Account @key(fields: "id"){
+			id: ID! @external
+			reviews: [Review]
+		}
+		`, FieldConfigurations{
+			{TypeName: "Account", FieldName: "reviews", RequiresFields: []string{"id"}},
+		})
+	})
 }
@@ -1,11 +1,16 @@
 package resolve
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"hash"
 	"sync"
 
+	"github.com/buger/jsonparser"
 	"github.com/cespare/xxhash/v2"
 
+	"github.com/pvormste/graphql-go-tools/pkg/engine/tracing"
 	"github.com/pvormste/graphql-go-tools/pkg/fastbuffer"
 	"github.com/pvormste/graphql-go-tools/pkg/pool"
 )
@@ -48,15 +53,31 @@ func NewFetcher(enableSingleFlightLoader bool) *Fetcher {
 }
 
 func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuffer.FastBuffer, buf *BufPair) (err error) {
+	loadCtx, span := f.startSpan(ctx, fetch, preparedInput.Bytes())
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	dataBuf := pool.BytesBuffer.Get()
 	defer pool.BytesBuffer.Put(dataBuf)
 
+	if ctx.trace != nil {
+		if ctx.trace.Format == TraceFormatFTV1 {
+			loadCtx = tracing.WithFederatedTracing(loadCtx)
+		}
+		fetchTrace := ctx.trace.startFetch(ctx.errorPathElements(), fetch, preparedInput.Bytes())
+		defer func() {
+			ctx.trace.endFetch(fetchTrace, dataBuf.Bytes())
+		}()
+	}
+
 	if ctx.beforeFetchHook != nil {
 		ctx.beforeFetchHook.OnBeforeFetch(f.hookCtx(ctx), preparedInput.Bytes())
 	}
 
 	if !f.EnableSingleFlightLoader || fetch.DisallowSingleFlight {
-		err = fetch.DataSource.Load(ctx.Context, preparedInput.Bytes(), dataBuf)
+		err = fetch.DataSource.Load(loadCtx, preparedInput.Bytes(), dataBuf)
+		err = writeDataSourceError(err, buf)
 		extractResponse(dataBuf.Bytes(), buf, fetch.ProcessResponseConfig)
 
 		if ctx.afterFetchHook != nil {
@@ -81,6 +102,7 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 		inflight.waitFree.Add(1)
 		defer inflight.waitFree.Done()
 		f.inflightFetchMu.Unlock()
+		span.SetAttributes(tracing.Bool(tracing.AttributeSingleFlight, true))
 		inflight.waitLoad.Wait()
 		if inflight.bufPair.HasData() {
 			if ctx.afterFetchHook != nil {
@@ -103,7 +125,8 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 
 	f.inflightFetchMu.Unlock()
 
-	err = fetch.DataSource.Load(ctx.Context, preparedInput.Bytes(), dataBuf)
+	err = fetch.DataSource.Load(loadCtx, preparedInput.Bytes(), dataBuf)
+	err = writeDataSourceError(err, &inflight.bufPair)
 	extractResponse(dataBuf.Bytes(), &inflight.bufPair, fetch.ProcessResponseConfig)
 	inflight.err = err
 
@@ -160,6 +183,35 @@ func (f *Fetcher) FetchBatch(ctx *Context, fetch *BatchFetch, preparedInputs []*
 	return
 }
 
+// writeDataSourceError adds a DataSourceError to the errors of buf, so the fetch results in a GraphQL error.
+// Any other error is returned unchanged.
+func writeDataSourceError(err error, buf *BufPair) error {
+	var dataSourceError DataSourceError
+	if !errors.As(err, &dataSourceError) {
+		return err
+	}
+
+	message, _ := json.Marshal(dataSourceError.Error())
+	buf.WriteErr(message[1:len(message)-1], nil, nil, dataSourceError.Extensions())
+	return nil
+}
+
+// startSpan starts the span of a fetch if the Context has a tracer.
+// The returned context contains the span, it is passed to the data source, so upstream requests can propagate it.
+func (f *Fetcher) startSpan(ctx *Context, fetch *SingleFetch, input []byte) (context.Context, tracing.Span) {
+	if ctx.tracer == nil {
+		return tracing.Start(ctx.Context, nil, tracing.SpanFetch)
+	}
+
+	attributes := []tracing.Attribute{
+		tracing.String(tracing.AttributeDataSourceID, string(fetch.DataSourceIdentifier)),
+	}
+	if url, err := jsonparser.GetString(input, "url"); err == nil {
+		attributes = append(attributes, tracing.String(tracing.AttributeUpstreamURL, url))
+	}
+	return tracing.Start(ctx.Context, ctx.tracer, tracing.SpanFetch, attributes...)
+}
+
 func (f *Fetcher) getBufPair() *BufPair {
 	return f.bufPairPool.Get().(*BufPair)
 }
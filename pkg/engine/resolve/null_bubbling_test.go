@@ -0,0 +1,125 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolver_NullBubbling covers the examples of the "Handling Field Errors" section of the GraphQL spec:
+// a null in a non-null position propagates to the nearest nullable parent only.
+func TestResolver_NullBubbling(t *testing.T) {
+	const upstreamResponse = `{"errors":[{"message":"Name for character with ID 1002 could not be fetched.","locations":[{"line":6,"column":7}],"path":["hero","heroFriends",1,"name"]}],"data":{"hero":{"name":"R2-D2","friends":[{"id":"1000","name":"Luke Skywalker"},{"id":"1002","name":null},{"id":"1003","name":"Leia Organa"}]}}}`
+	const upstreamError = `{"message":"Name for character with ID 1002 could not be fetched.","locations":[{"line":6,"column":7}],"path":["hero","heroFriends",1,"name"]}`
+
+	type nullability struct {
+		hero, heroFriends, friend, name bool
+	}
+
+	heroResponse := func(n nullability, resolveAsynchronous bool) *GraphQLResponse {
+		return &GraphQLResponse{
+			Data: &Object{
+				Fetch: &SingleFetch{
+					BufferId:              0,
+					DataSource:            FakeDataSource(upstreamResponse),
+					ProcessResponseConfig: ProcessResponseConfig{ExtractGraphqlResponse: true},
+				},
+				Fields: []*Field{
+					{
+						BufferID:  0,
+						HasBuffer: true,
+						Name:      []byte("hero"),
+						Info:      &FieldInfo{Name: "hero", ParentTypeName: "Query"},
+						Position:  Position{Line: 2, Column: 3},
+						Value: &Object{
+							Path:     []string{"hero"},
+							Nullable: n.hero,
+							Fields: []*Field{
+								{
+									Name:     []byte("name"),
+									Info:     &FieldInfo{Name: "name", ParentTypeName: "Character"},
+									Position: Position{Line: 3, Column: 5},
+									Value: &String{
+										Path: []string{"name"},
+									},
+								},
+								{
+									Name:     []byte("heroFriends"),
+									Info:     &FieldInfo{Name: "friends", ParentTypeName: "Character"},
+									Position: Position{Line: 4, Column: 5},
+									Value: &Array{
+										Path:                []string{"friends"},
+										Nullable:            n.heroFriends,
+										ResolveAsynchronous: resolveAsynchronous,
+										Item: &Object{
+											Nullable: n.friend,
+											Fields: []*Field{
+												{
+													Name:     []byte("id"),
+													Info:     &FieldInfo{Name: "id", ParentTypeName: "Character"},
+													Position: Position{Line: 5, Column: 7},
+													Value: &String{
+														Path: []string{"id"},
+													},
+												},
+												{
+													Name:     []byte("name"),
+													Info:     &FieldInfo{Name: "name", ParentTypeName: "Character"},
+													Position: Position{Line: 6, Column: 7},
+													Value: &String{
+														Path:     []string{"name"},
+														Nullable: n.name,
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	run := func(n nullability, resolveAsynchronous bool, expectedOutput string) func(t *testing.T) {
+		return func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			resolver := newResolver(ctx, false, false)
+
+			buf := &bytes.Buffer{}
+			err := resolver.ResolveGraphQLResponse(&Context{Context: context.Background()}, heroResponse(n, resolveAsynchronous), nil, buf)
+			require.NoError(t, err)
+			assert.Equal(t, expectedOutput, buf.String())
+		}
+	}
+
+	const nonNullNameError = `{"message":"Cannot return null for non-nullable field Character.name.","locations":[{"line":6,"column":7}],"path":["hero","heroFriends",1,"name"],"extensions":{"fetchId":0}}`
+
+	t.Run("nullable field resolves to null", run(nullability{hero: true, heroFriends: true, friend: true, name: true}, false,
+		`{"errors":[`+upstreamError+`],"data":{"hero":{"name":"R2-D2","heroFriends":[{"id":"1000","name":"Luke Skywalker"},{"id":"1002","name":null},{"id":"1003","name":"Leia Organa"}]}}}`,
+	))
+	t.Run("non-null field nulls the list item", run(nullability{hero: true, heroFriends: true, friend: true}, false,
+		`{"errors":[`+upstreamError+`,`+nonNullNameError+`],"data":{"hero":{"name":"R2-D2","heroFriends":[{"id":"1000","name":"Luke Skywalker"},null,{"id":"1003","name":"Leia Organa"}]}}}`,
+	))
+	t.Run("non-null field nulls the list item of an asynchronous list", run(nullability{hero: true, heroFriends: true, friend: true}, true,
+		`{"errors":[`+upstreamError+`,`+nonNullNameError+`],"data":{"hero":{"name":"R2-D2","heroFriends":[{"id":"1000","name":"Luke Skywalker"},null,{"id":"1003","name":"Leia Organa"}]}}}`,
+	))
+	t.Run("non-null list item nulls the list", run(nullability{hero: true, heroFriends: true}, false,
+		`{"errors":[`+upstreamError+`,`+nonNullNameError+`],"data":{"hero":{"name":"R2-D2","heroFriends":null}}}`,
+	))
+	t.Run("non-null list item nulls the asynchronous list", run(nullability{hero: true, heroFriends: true}, true,
+		`{"errors":[`+upstreamError+`,`+nonNullNameError+`],"data":{"hero":{"name":"R2-D2","heroFriends":null}}}`,
+	))
+	t.Run("non-null list nulls the parent", run(nullability{hero: true}, false,
+		`{"errors":[`+upstreamError+`,`+nonNullNameError+`],"data":{"hero":null}}`,
+	))
+	t.Run("non-null fields up to the root null the data", run(nullability{}, false,
+		`{"errors":[`+upstreamError+`,`+nonNullNameError+`],"data":null}`,
+	))
+}
@@ -17,6 +17,7 @@ import (
 	errors "golang.org/x/xerrors"
 
 	"github.com/pvormste/graphql-go-tools/internal/pkg/unsafebytes"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/tracing"
 	"github.com/pvormste/graphql-go-tools/pkg/fastbuffer"
 	"github.com/pvormste/graphql-go-tools/pkg/lexer/literal"
 	"github.com/pvormste/graphql-go-tools/pkg/pool"
@@ -30,7 +31,6 @@ var (
 	comma             = []byte(",")
 	colon             = []byte(":")
 	quote             = []byte("\"")
-	quotedComma       = []byte(`","`)
 	null              = []byte("null")
 	literalData       = []byte("data")
 	literalErrors     = []byte("errors")
@@ -41,18 +41,38 @@ var (
 	literalPath       = []byte("path")
 	literalExtensions = []byte("extensions")
 
-	unableToResolveMsg = []byte("unable to resolve")
-	emptyArray         = []byte("[]")
+	unableToResolveMsg  = []byte("unable to resolve")
+	nonNullableFieldMsg = []byte("Cannot return null for non-nullable field ")
+	literalFetchID      = []byte("fetchId")
+	literalDataSource   = []byte("dataSource")
+	emptyArray          = []byte("[]")
 )
 
 var (
 	errNonNullableFieldValueIsNull = errors.New("non Nullable field value is null")
-	errTypeNameSkipped             = errors.New("skipped because of __typename condition")
-	errHeaderPathInvalid           = errors.New("invalid header path: header variables must be of this format: .request.header.{{ key }} ")
+	// errNonNullableFieldValueIsNullReported is returned instead of errNonNullableFieldValueIsNull
+	// once the error of the non-null violation has been added to the response.
+	errNonNullableFieldValueIsNullReported error = reportedError{err: errNonNullableFieldValueIsNull}
+	errTypeNameSkipped                           = errors.New("skipped because of __typename condition")
+	errHeaderPathInvalid                         = errors.New("invalid header path: header variables must be of this format: .request.header.{{ key }} ")
 
 	ErrUnableToResolve = errors.New("unable to resolve operation")
 )
 
+// reportedError marks an error which has already been added to the errors of the response.
+// It keeps the message of the error and matches it with errors.Is.
+type reportedError struct {
+	err error
+}
+
+func (e reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e reportedError) Is(target error) bool {
+	return target == e.err
+}
+
 var (
 	responsePaths = [][]string{
 		{"errors"},
@@ -120,6 +140,8 @@ type Context struct {
 	pathElements     [][]byte
 	responseElements []string
 	lastFetchID      int
+	lastFetch        *SingleFetch
+	currentField     *Field
 	patches          []patch
 	usedBuffers      []*bytes.Buffer
 	currentPatch     int
@@ -128,8 +150,12 @@ type Context struct {
 	dataLoader       *dataLoader
 	beforeFetchHook  BeforeFetchHook
 	afterFetchHook   AfterFetchHook
+	tracer           tracing.Tracer
+	trace            *ExecutionTrace
+	traceNode        *TraceNode
 	position         Position
 	RenameTypeNames  []RenameTypeName
+	hasErrors        bool
 }
 
 type Request struct {
@@ -185,7 +211,13 @@ func (c *Context) Clone() Context {
 		pathPrefix:      pathPrefix,
 		beforeFetchHook: c.beforeFetchHook,
 		afterFetchHook:  c.afterFetchHook,
+		tracer:          c.tracer,
+		trace:           c.trace,
+		traceNode:       c.traceNode,
 		position:        c.position,
+		lastFetchID:     c.lastFetchID,
+		lastFetch:       c.lastFetch,
+		currentField:    c.currentField,
 	}
 }
 
@@ -203,10 +235,16 @@ func (c *Context) Free() {
 	c.maxPatch = -1
 	c.beforeFetchHook = nil
 	c.afterFetchHook = nil
+	c.tracer = nil
+	c.trace = nil
+	c.traceNode = nil
 	c.Request.Header = nil
 	c.position = Position{}
+	c.lastFetch = nil
+	c.currentField = nil
 	c.dataLoader = nil
 	c.RenameTypeNames = nil
+	c.hasErrors = false
 }
 
 func (c *Context) SetBeforeFetchHook(hook BeforeFetchHook) {
@@ -217,6 +255,28 @@ func (c *Context) SetAfterFetchHook(hook AfterFetchHook) {
 	c.afterFetchHook = hook
 }
 
+// SetTracer enables a span for every fetch, the span is propagated to the data source through the context of Load.
+func (c *Context) SetTracer(tracer tracing.Tracer) {
+	c.tracer = tracer
+}
+
+// EnableTracing records the timing of every resolved field and fetch.
+// The trace is added to the extensions of the response in the given format.
+func (c *Context) EnableTracing(format TraceFormat) {
+	c.trace = newExecutionTrace(format)
+	c.traceNode = &c.trace.Root
+}
+
+// HasErrors returns true if the last response resolved with the context contains errors.
+func (c *Context) HasErrors() bool {
+	return c.hasErrors
+}
+
+// Trace returns the trace of the response if tracing is enabled.
+func (c *Context) Trace() (trace *ExecutionTrace, ok bool) {
+	return c.trace, c.trace != nil
+}
+
 func (c *Context) setPosition(position Position) {
 	c.position = position
 }
@@ -273,6 +333,54 @@ func (c *Context) path() []byte {
 	return buf.Bytes()
 }
 
+// writeErrorPath writes the response path of the current field as JSON array, list indices are written as integers.
+// It returns false if the path is empty.
+func (c *Context) writeErrorPath(buf *bytes.Buffer) bool {
+	return writePathElements(buf, c.errorPathElements())
+}
+
+// errorPathElements returns the elements of the response path of the current field, including the path prefix of patches.
+func (c *Context) errorPathElements() [][]byte {
+	var elements [][]byte
+	if len(c.pathPrefix) != 0 {
+		for _, element := range bytes.Split(c.pathPrefix, literal.SLASH) {
+			if len(element) == 0 || (len(elements) == 0 && bytes.Equal(element, literal.DATA)) {
+				continue
+			}
+			elements = append(elements, element)
+		}
+	}
+	return append(elements, c.pathElements...)
+}
+
+// writePathElements writes the path elements as JSON array. It returns false if there are no elements.
+func writePathElements(buf *bytes.Buffer, elements [][]byte) bool {
+	if len(elements) == 0 {
+		return false
+	}
+
+	buf.Write(lBrack)
+	for i := range elements {
+		if i != 0 {
+			buf.Write(comma)
+		}
+		if isListIndex(elements[i]) {
+			buf.Write(elements[i])
+			continue
+		}
+		buf.Write(quote)
+		buf.Write(elements[i])
+		buf.Write(quote)
+	}
+	buf.Write(rBrack)
+	return true
+}
+
+// isListIndex reports whether the path element is a list index, GraphQL names can't start with a digit.
+func isListIndex(element []byte) bool {
+	return len(element) != 0 && element[0] >= '0' && element[0] <= '9'
+}
+
 func (c *Context) addPatch(index int, path, extraPath, data []byte) {
 	next := patch{path: path, extraPath: extraPath, data: data, index: index}
 	c.patches = append(c.patches, next)
@@ -311,6 +419,14 @@ type DataSource interface {
 	Load(ctx context.Context, input []byte, w io.Writer) (err error)
 }
 
+// DataSourceError can be returned by DataSource.Load to report a failed fetch as GraphQL error
+// instead of aborting the whole response. The fields depending on the fetch resolve to null.
+type DataSourceError interface {
+	error
+	// Extensions returns the JSON encoded extensions of the GraphQL error.
+	Extensions() []byte
+}
+
 type SubscriptionDataSource interface {
 	Start(ctx context.Context, input []byte, next chan<- []byte) error
 }
@@ -327,6 +443,7 @@ type Resolver struct {
 	hash64Pool        sync.Pool
 	dataloaderFactory *dataLoaderFactory
 	fetcher           *Fetcher
+	triggerManager    *subscriptionTriggerManager
 }
 
 type inflightFetch struct {
@@ -386,6 +503,7 @@ func New(ctx context.Context, fetcher *Fetcher, enableDataLoader bool) *Resolver
 		dataloaderFactory: newDataloaderFactory(fetcher),
 		fetcher:           fetcher,
 		dataLoaderEnabled: enableDataLoader,
+		triggerManager:    newSubscriptionTriggerManager(ctx),
 	}
 }
 
@@ -493,21 +611,36 @@ func (r *Resolver) ResolveGraphQLResponse(ctx *Context, response *GraphQLRespons
 		}()
 	}
 
+	// nulls of non-nullable fields bubble up to the nearest nullable parent,
+	// the data is only ignored if they bubble up to the root of the response
 	ignoreData := false
 	err = r.resolveNode(ctx, response.Data, responseBuf.Data.Bytes(), buf)
 	if err != nil {
 		if !errors.Is(err, errNonNullableFieldValueIsNull) {
 			return
 		}
+		if err == errNonNullableFieldValueIsNull {
+			r.addResolveError(ctx, buf)
+		}
 		ignoreData = true
 	}
 	if responseBuf.Errors.Len() > 0 {
 		r.MergeBufPairErrors(responseBuf, buf)
 	}
+	ctx.hasErrors = buf.Errors.Len() != 0
 
-	return writeGraphqlResponse(buf, writer, ignoreData)
+	if ctx.trace == nil {
+		return writeGraphqlResponse(buf, writer, ignoreData)
+	}
+	extensions, err := ctx.trace.extensions()
+	if err != nil {
+		return err
+	}
+	return writeGraphqlResponseWithExtensions(buf, writer, ignoreData, extensions)
 }
 
+// ResolveGraphQLSubscription resolves every event of the subscription and flushes it to the writer.
+// Subscriptions with the same data source and rendered input share a single upstream subscription.
 func (r *Resolver) ResolveGraphQLSubscription(ctx *Context, subscription *GraphQLSubscription, writer FlushWriter) (err error) {
 
 	buf := r.getBufPair()
@@ -520,32 +653,24 @@ func (r *Resolver) ResolveGraphQLSubscription(ctx *Context, subscription *GraphQ
 	copy(subscriptionInput, rendered)
 	r.freeBufPair(buf)
 
-	c, cancel := context.WithCancel(ctx)
-	defer cancel()
-	resolverDone := r.ctx.Done()
-
-	next := make(chan []byte)
-	err = subscription.Trigger.Source.Start(c, subscriptionInput, next)
+	subscriber, err := r.triggerManager.subscribe(subscription.Trigger.Source, subscriptionInput)
 	if err != nil {
-		if errors.Is(err, ErrUnableToResolve) {
-			_, err = writer.Write([]byte(`{"errors":[{"message":"unable to resolve"}]}`))
-			if err != nil {
-				return err
-			}
-			writer.Flush()
-			return nil
-		}
-		return err
+		return r.writeSubscriptionStartError(err, writer)
 	}
+	defer r.triggerManager.unsubscribe(subscriber)
+
+	resolverDone := r.ctx.Done()
+	requestDone := ctx.Context.Done()
 
 	for {
 		select {
 		case <-resolverDone:
 			return nil
-		default:
-			data, ok := <-next
+		case <-requestDone:
+			return nil
+		case data, ok := <-subscriber.next:
 			if !ok {
-				return nil
+				return subscriber.err
 			}
 			err = r.ResolveGraphQLResponse(ctx, subscription.Response, data, writer)
 			if err != nil {
@@ -556,6 +681,18 @@ func (r *Resolver) ResolveGraphQLSubscription(ctx *Context, subscription *GraphQ
 	}
 }
 
+func (r *Resolver) writeSubscriptionStartError(err error, writer FlushWriter) error {
+	if !errors.Is(err, ErrUnableToResolve) {
+		return err
+	}
+	_, err = writer.Write([]byte(`{"errors":[{"message":"unable to resolve"}]}`))
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return nil
+}
+
 func (r *Resolver) ResolveGraphQLStreamingResponse(ctx *Context, response *GraphQLStreamingResponse, data []byte, writer FlushWriter) (err error) {
 
 	if err := r.validateContext(ctx); err != nil {
@@ -592,7 +729,8 @@ Loop:
 				continue
 			}
 
-			if buf.Len() != 1 {
+			written := buf.Len()
+			if written != 1 {
 				buf.Write(literal.COMMA)
 			}
 
@@ -601,9 +739,13 @@ Loop:
 			if err != nil {
 				return err
 			}
+			if buf.Len() <= written+1 {
+				// the patch has been skipped
+				buf.Truncate(written)
+			}
 
 			now := time.Now()
-			if now.After(nextFlush) {
+			if now.After(nextFlush) && buf.Len() != 1 {
 				buf.Write(literal.RBRACK)
 				_, err = writer.Write(buf.Bytes())
 				if err != nil {
@@ -645,27 +787,30 @@ func (r *Resolver) ResolveGraphQLResponsePatch(ctx *Context, patch *GraphQLRespo
 		}
 		_, ok := set.buffers[0]
 		if ok {
-			r.MergeBufPairErrors(set.buffers[0], buf)
+			r.mergePatchUpstreamErrors(ctx, patch, 0, set.buffers[0], buf)
 			data = set.buffers[0].Data.Bytes()
 		}
 	}
 
 	err = r.resolveNode(ctx, patch.Value, data, buf)
 	if err != nil {
-		return
+		if !errors.Is(err, errNonNullableFieldValueIsNull) {
+			return
+		}
+		// the null can't bubble up into the already flushed parts of the response,
+		// so it stops at the root of the patch, which is sent with a null value and the error
+		if err == errNonNullableFieldValueIsNull {
+			r.addResolveError(ctx, buf)
+		}
+		buf.Data.Reset()
+		r.resolveNull(buf.Data)
+		err = nil
 	}
 
 	hasErrors := buf.Errors.Len() != 0
 	hasData := buf.Data.Len() != 0
 
-	if hasErrors {
-		return
-	}
-
-	if hasData {
-		if hasErrors {
-			err = writeSafe(err, writer, comma)
-		}
+	if hasData || hasErrors {
 		err = writeSafe(err, writer, lBrace)
 		err = writeSafe(err, writer, quote)
 		err = writeSafe(err, writer, literal.OP)
@@ -687,7 +832,21 @@ func (r *Resolver) ResolveGraphQLResponsePatch(ctx *Context, patch *GraphQLRespo
 		err = writeSafe(err, writer, literal.VALUE)
 		err = writeSafe(err, writer, quote)
 		err = writeSafe(err, writer, colon)
-		_, err = writer.Write(buf.Data.Bytes())
+		if hasData {
+			err = writeSafe(err, writer, buf.Data.Bytes())
+		} else {
+			err = writeSafe(err, writer, literal.NULL)
+		}
+		if hasErrors {
+			err = writeSafe(err, writer, comma)
+			err = writeSafe(err, writer, quote)
+			err = writeSafe(err, writer, literalErrors)
+			err = writeSafe(err, writer, quote)
+			err = writeSafe(err, writer, colon)
+			err = writeSafe(err, writer, lBrack)
+			err = writeSafe(err, writer, buf.Errors.Bytes())
+			err = writeSafe(err, writer, rBrack)
+		}
 		err = writeSafe(err, writer, rBrace)
 	}
 
@@ -772,11 +931,24 @@ func (r *Resolver) resolveArraySynchronous(ctx *Context, array *Array, arrayItem
 		}
 
 		ctx.addIntegerPathElement(i)
+		traceParent := ctx.traceNode
+		if ctx.trace != nil && tracesItems(array) {
+			ctx.traceNode = ctx.trace.startListItem(traceParent, i)
+		}
 		err = r.resolveNode(ctx, array.Item, (*arrayItems)[i], itemBuf)
+		if err == errNonNullableFieldValueIsNull {
+			r.addNonNullableFieldError(ctx, ctx.currentField, itemBuf)
+			err = errNonNullableFieldValueIsNullReported
+		}
+		ctx.traceNode = traceParent
 		ctx.removeLastPathElement()
 		if err != nil {
-			if errors.Is(err, errNonNullableFieldValueIsNull) && array.Nullable {
+			if errors.Is(err, errNonNullableFieldValueIsNull) {
 				arrayBuf.Data.Reset()
+				r.MergeBufPairErrors(itemBuf, arrayBuf)
+				if !array.Nullable {
+					return err
+				}
 				r.resolveNull(arrayBuf.Data)
 				return nil
 			}
@@ -817,9 +989,18 @@ func (r *Resolver) resolveArrayAsynchronous(ctx *Context, array *Array, arrayIte
 		*bufSlice = append(*bufSlice, itemBuf)
 		itemData := (*arrayItems)[i]
 		cloned := ctx.Clone()
+		if ctx.trace != nil && tracesItems(array) {
+			// the item nodes are added before resolving concurrently to keep them in order
+			cloned.traceNode = ctx.trace.startListItem(ctx.traceNode, i)
+		}
 		go func(ctx Context, i int) {
-			ctx.addPathElement([]byte(strconv.Itoa(i)))
-			if e := r.resolveNode(&ctx, array.Item, itemData, itemBuf); e != nil && !errors.Is(e, errTypeNameSkipped) {
+			ctx.addIntegerPathElement(i)
+			e := r.resolveNode(&ctx, array.Item, itemData, itemBuf)
+			if e == errNonNullableFieldValueIsNull {
+				r.addNonNullableFieldError(&ctx, ctx.currentField, itemBuf)
+				e = errNonNullableFieldValueIsNullReported
+			}
+			if e != nil && !errors.Is(e, errTypeNameSkipped) {
 				select {
 				case errCh <- e:
 				default:
@@ -838,8 +1019,14 @@ func (r *Resolver) resolveArrayAsynchronous(ctx *Context, array *Array, arrayIte
 	}
 
 	if err != nil {
-		if errors.Is(err, errNonNullableFieldValueIsNull) && array.Nullable {
+		if errors.Is(err, errNonNullableFieldValueIsNull) {
 			arrayBuf.Data.Reset()
+			for i := range *bufSlice {
+				r.MergeBufPairErrors((*bufSlice)[i], arrayBuf)
+			}
+			if !array.Nullable {
+				return err
+			}
 			r.resolveNull(arrayBuf.Data)
 			return nil
 		}
@@ -1004,19 +1191,107 @@ func (r *Resolver) addResolveError(ctx *Context, objectBuf *BufPair) {
 	locations.Write(rBrace)
 	locations.Write(rBrack)
 
-	if len(ctx.pathElements) > 0 {
-		path.Write(lBrack)
-		path.Write(quote)
-		path.Write(bytes.Join(ctx.pathElements, quotedComma))
-		path.Write(quote)
-		path.Write(rBrack)
-
+	if ctx.writeErrorPath(path) {
 		pathBytes = path.Bytes()
 	}
 
 	objectBuf.WriteErr(unableToResolveMsg, locations.Bytes(), pathBytes, nil)
 }
 
+// addNonNullableFieldError adds the error of a null value for the non-nullable field (or list item) at the current path.
+// The extensions identify the fetch which provided the data of the field.
+func (r *Resolver) addNonNullableFieldError(ctx *Context, field *Field, buf *BufPair) {
+	message, locations, path, extensions := pool.BytesBuffer.Get(), pool.BytesBuffer.Get(), pool.BytesBuffer.Get(), pool.BytesBuffer.Get()
+	defer pool.BytesBuffer.Put(message)
+	defer pool.BytesBuffer.Put(locations)
+	defer pool.BytesBuffer.Put(path)
+	defer pool.BytesBuffer.Put(extensions)
+
+	var locationsBytes, pathBytes, extensionsBytes []byte
+
+	message.Write(nonNullableFieldMsg)
+	if field != nil {
+		if field.Info != nil {
+			message.WriteString(field.Info.ParentTypeName)
+			message.WriteString(".")
+			message.WriteString(field.Info.Name)
+		} else {
+			message.Write(field.Name)
+		}
+
+		writeFieldLocations(locations, field)
+		locationsBytes = locations.Bytes()
+	}
+	message.WriteString(".")
+
+	if ctx.writeErrorPath(path) {
+		pathBytes = path.Bytes()
+	}
+
+	if ctx.lastFetch != nil {
+		extensions.Write(lBrace)
+		extensions.Write(quote)
+		extensions.Write(literalFetchID)
+		extensions.Write(quote)
+		extensions.Write(colon)
+		extensions.WriteString(strconv.Itoa(ctx.lastFetch.BufferId))
+		if len(ctx.lastFetch.DataSourceIdentifier) != 0 {
+			extensions.Write(comma)
+			extensions.Write(quote)
+			extensions.Write(literalDataSource)
+			extensions.Write(quote)
+			extensions.Write(colon)
+			extensions.Write(quote)
+			extensions.Write(ctx.lastFetch.DataSourceIdentifier)
+			extensions.Write(quote)
+		}
+		extensions.Write(rBrace)
+		extensionsBytes = extensions.Bytes()
+	}
+
+	buf.WriteErr(message.Bytes(), locationsBytes, pathBytes, extensionsBytes)
+}
+
+// writeFieldLocations writes the position of the field as JSON array of locations.
+func writeFieldLocations(buf *bytes.Buffer, field *Field) {
+	buf.Write(lBrack)
+	buf.Write(lBrace)
+	buf.Write(quote)
+	buf.Write(literalLine)
+	buf.Write(quote)
+	buf.Write(colon)
+	buf.WriteString(strconv.Itoa(int(field.Position.Line)))
+	buf.Write(comma)
+	buf.Write(quote)
+	buf.Write(literalColumn)
+	buf.Write(quote)
+	buf.Write(colon)
+	buf.WriteString(strconv.Itoa(int(field.Position.Column)))
+	buf.Write(rBrace)
+	buf.Write(rBrack)
+}
+
+// singleFetchByBufferID returns the fetch which writes into the buffer with the given id.
+func singleFetchByBufferID(fetch Fetch, bufferID int) *SingleFetch {
+	switch f := fetch.(type) {
+	case *SingleFetch:
+		if f.BufferId == bufferID {
+			return f
+		}
+	case *BatchFetch:
+		if f.Fetch.BufferId == bufferID {
+			return f.Fetch
+		}
+	case *ParallelFetch:
+		for i := range f.Fetches {
+			if single := singleFetchByBufferID(f.Fetches[i], bufferID); single != nil {
+				return single
+			}
+		}
+	}
+	return nil
+}
+
 func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, objectBuf *BufPair) (err error) {
 	if len(object.Path) != 0 {
 		data, _, _, _ = jsonparser.Get(data, object.Path...)
@@ -1027,12 +1302,19 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 				return
 			}
 
-			r.addResolveError(ctx, objectBuf)
 			return errNonNullableFieldValueIsNull
 		}
 
 		ctx.addResponseElements(object.Path)
 		defer ctx.removeResponseLastElements(object.Path)
+	} else if bytes.Equal(data, literal.NULL) {
+		// the object is the value of a fetch, e.g. an entity which doesn't exist
+		if object.Nullable {
+			r.resolveNull(objectBuf.Data)
+			return
+		}
+
+		return errNonNullableFieldValueIsNull
 	}
 
 	if object.UnescapeResponseJson {
@@ -1048,7 +1330,7 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 			return
 		}
 		for i := range set.buffers {
-			r.MergeBufPairErrors(set.buffers[i], objectBuf)
+			r.mergeUpstreamErrors(ctx, object, i, set.buffers[i], objectBuf)
 		}
 	}
 
@@ -1057,6 +1339,8 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 
 	responseElements := ctx.responseElements
 	lastFetchID := ctx.lastFetchID
+	lastFetch := ctx.lastFetch
+	currentField := ctx.currentField
 
 	typeNameSkip := false
 	first := true
@@ -1086,6 +1370,7 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 				fieldData = buffer.Data.Bytes()
 				ctx.resetResponsePathElements()
 				ctx.lastFetchID = object.Fields[i].BufferID
+				ctx.lastFetch = singleFetchByBufferID(object.Fetch, object.Fields[i].BufferID)
 			}
 		} else {
 			fieldData = data
@@ -1098,6 +1383,7 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 				// Restore the response elements that may have been reset above.
 				ctx.responseElements = responseElements
 				ctx.lastFetchID = lastFetchID
+				ctx.lastFetch = lastFetch
 				continue
 			}
 		}
@@ -1114,10 +1400,25 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 		objectBuf.Data.WriteBytes(colon)
 		ctx.addPathElement(object.Fields[i].Name)
 		ctx.setPosition(object.Fields[i].Position)
+		ctx.currentField = object.Fields[i]
+		traceParent := ctx.traceNode
+		if ctx.trace != nil {
+			ctx.traceNode = ctx.trace.startField(traceParent, object.Fields[i])
+		}
 		err = r.resolveNode(ctx, object.Fields[i].Value, fieldData, fieldBuf)
+		if err == errNonNullableFieldValueIsNull {
+			r.addNonNullableFieldError(ctx, object.Fields[i], fieldBuf)
+			err = errNonNullableFieldValueIsNullReported
+		}
+		if ctx.trace != nil {
+			ctx.trace.end(ctx.traceNode)
+			ctx.traceNode = traceParent
+		}
 		ctx.removeLastPathElement()
 		ctx.responseElements = responseElements
 		ctx.lastFetchID = lastFetchID
+		ctx.lastFetch = lastFetch
+		ctx.currentField = currentField
 		if err != nil {
 			if errors.Is(err, errTypeNameSkipped) {
 				objectBuf.Data.Reset()
@@ -1132,11 +1433,6 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 					r.resolveNull(objectBuf.Data)
 					return nil
 				}
-
-				// if fied is of object type than we should not add resolve error here
-				if _, ok := object.Fields[i].Value.(*Object); !ok {
-					r.addResolveError(ctx, objectBuf)
-				}
 			}
 
 			return
@@ -1155,7 +1451,6 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 			return errTypeNameSkipped
 		}
 		if !object.Nullable {
-			r.addResolveError(ctx, objectBuf)
 			return errNonNullableFieldValueIsNull
 		}
 		r.resolveNull(objectBuf.Data)
@@ -1302,6 +1597,7 @@ type Field struct {
 	Name                    []byte
 	Value                   Node
 	Position                Position
+	Info                    *FieldInfo
 	Defer                   *DeferField
 	Stream                  *StreamField
 	HasBuffer               bool
@@ -1313,6 +1609,14 @@ type Field struct {
 	IncludeVariableName     string
 }
 
+// FieldInfo describes the field of the schema which is resolved by a Field.
+type FieldInfo struct {
+	// Name is the name of the field in the schema, Field.Name is the alias if one is defined.
+	Name string
+	// ParentTypeName is the name of the type the field is defined on.
+	ParentTypeName string
+}
+
 type Position struct {
 	Line   uint32
 	Column uint32
@@ -1360,6 +1664,10 @@ type SingleFetch struct {
 type ProcessResponseConfig struct {
 	ExtractGraphqlResponse    bool
 	ExtractFederationEntities bool
+	// UpstreamErrorPolicy defines how the errors of the upstream response are added to the response
+	UpstreamErrorPolicy UpstreamErrorPolicy
+	// UpstreamServiceName is the name of the upstream used in wrapped errors
+	UpstreamServiceName string
 }
 
 func (_ *SingleFetch) FetchKind() FetchKind {
@@ -1631,6 +1939,11 @@ func (r *Resolver) freeWaitGroup(wg *sync.WaitGroup) {
 }
 
 func writeGraphqlResponse(buf *BufPair, writer io.Writer, ignoreData bool) (err error) {
+	return writeGraphqlResponseWithExtensions(buf, writer, ignoreData, nil)
+}
+
+// writeGraphqlResponseWithExtensions writes the response with extensions, if they aren't empty.
+func writeGraphqlResponseWithExtensions(buf *BufPair, writer io.Writer, ignoreData bool, extensions []byte) (err error) {
 	hasErrors := buf.Errors.Len() != 0
 	hasData := buf.Data.Len() != 0 && !ignoreData
 
@@ -1657,6 +1970,15 @@ func writeGraphqlResponse(buf *BufPair, writer io.Writer, ignoreData bool) (err
 	} else {
 		err = writeSafe(err, writer, literal.NULL)
 	}
+
+	if len(extensions) != 0 {
+		err = writeSafe(err, writer, comma)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, literalExtensions)
+		err = writeSafe(err, writer, quote)
+		err = writeSafe(err, writer, colon)
+		err = writeSafe(err, writer, extensions)
+	}
 	err = writeSafe(err, writer, rBrace)
 
 	return err
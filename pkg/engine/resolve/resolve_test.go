@@ -3,6 +3,7 @@ package resolve
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -1727,7 +1728,55 @@ func TestResolver_ResolveGraphQLResponse(t *testing.T) {
 					},
 				},
 			},
-		}, Context{Context: context.Background()}, `{"errors":[{"message":"unable to resolve","locations":[{"line":3,"column":4}],"path":["country"]}],"data":null}`
+		}, Context{Context: context.Background()}, `{"errors":[{"message":"Cannot return null for non-nullable field country.","locations":[{"line":3,"column":4}],"path":["country"]}],"data":null}`
+	}))
+	t.Run("non-nullable field error with field info, aliases and list indices", testFn(false, false, func(t *testing.T, ctrl *gomock.Controller) (node *GraphQLResponse, ctx Context, expectedOutput string) {
+		return &GraphQLResponse{
+			Data: &Object{
+				Fetch: &SingleFetch{
+					BufferId:             0,
+					DataSource:           FakeDataSource(`{"allUsers":[{"name":"Jens"},{"name":null}]}`),
+					DataSourceIdentifier: []byte("graphql_datasource.Source"),
+				},
+				Fields: []*Field{
+					{
+						BufferID:  0,
+						HasBuffer: true,
+						Name:      []byte("users"),
+						Info: &FieldInfo{
+							Name:           "allUsers",
+							ParentTypeName: "Query",
+						},
+						Position: Position{
+							Line:   2,
+							Column: 3,
+						},
+						Value: &Array{
+							Path:     []string{"allUsers"},
+							Nullable: true,
+							Item: &Object{
+								Fields: []*Field{
+									{
+										Name: []byte("name"),
+										Info: &FieldInfo{
+											Name:           "name",
+											ParentTypeName: "User",
+										},
+										Position: Position{
+											Line:   3,
+											Column: 5,
+										},
+										Value: &String{
+											Path: []string{"name"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}, Context{Context: context.Background()}, `{"errors":[{"message":"Cannot return null for non-nullable field User.name.","locations":[{"line":3,"column":5}],"path":["users",1,"name"],"extensions":{"fetchId":0,"dataSource":"graphql_datasource.Source"}}],"data":{"users":null}}`
 	}))
 	t.Run("fetch with simple error", testFn(true, false, func(t *testing.T, ctrl *gomock.Controller) (node *GraphQLResponse, ctx Context, expectedOutput string) {
 		mockDataSource := NewMockDataSource(ctrl)
@@ -1807,7 +1856,7 @@ func TestResolver_ResolveGraphQLResponse(t *testing.T) {
 					},
 				},
 			},
-		}, Context{Context: context.Background()}, `{"errors":[{"message":"errorMessage"},{"message":"unable to resolve","locations":[{"line":0,"column":0}],"path":["nestedObject"]}],"data":null}`
+		}, Context{Context: context.Background()}, `{"errors":[{"message":"errorMessage"},{"message":"Cannot return null for non-nullable field foo.","locations":[{"line":0,"column":0}],"path":["nestedObject","foo"],"extensions":{"fetchId":1}}],"data":null}`
 	}))
 	t.Run("fetch with two Errors", testFn(true, false, func(t *testing.T, ctrl *gomock.Controller) (node *GraphQLResponse, ctx Context, expectedOutput string) {
 		mockDataSource := NewMockDataSource(ctrl)
@@ -2027,7 +2076,7 @@ func TestResolver_ResolveGraphQLResponse(t *testing.T) {
 					},
 				},
 			},
-		}, Context{Context: context.Background()}, `{"errors":[{"message":"unable to resolve","locations":[{"line":0,"column":0}],"path":["objectObject","objectField"]}],"data":{"stringObject":null,"integerObject":null,"floatObject":null,"booleanObject":null,"objectObject":null,"arrayObject":null,"asynchronousArrayObject":null,"nullableArray":null}}`
+		}, Context{Context: context.Background()}, `{"errors":[{"message":"Cannot return null for non-nullable field stringField.","locations":[{"line":0,"column":0}],"path":["stringObject","stringField"],"extensions":{"fetchId":0}},{"message":"Cannot return null for non-nullable field integerField.","locations":[{"line":0,"column":0}],"path":["integerObject","integerField"],"extensions":{"fetchId":0}},{"message":"Cannot return null for non-nullable field floatField.","locations":[{"line":0,"column":0}],"path":["floatObject","floatField"],"extensions":{"fetchId":0}},{"message":"Cannot return null for non-nullable field booleanField.","locations":[{"line":0,"column":0}],"path":["booleanObject","booleanField"],"extensions":{"fetchId":0}},{"message":"Cannot return null for non-nullable field objectField.","locations":[{"line":0,"column":0}],"path":["objectObject","objectField"],"extensions":{"fetchId":0}},{"message":"Cannot return null for non-nullable field arrayField.","locations":[{"line":0,"column":0}],"path":["arrayObject","arrayField",0],"extensions":{"fetchId":0}},{"message":"Cannot return null for non-nullable field arrayField.","locations":[{"line":0,"column":0}],"path":["asynchronousArrayObject","arrayField",0],"extensions":{"fetchId":0}},{"message":"Cannot return null for non-nullable field arrayField.","locations":[{"line":0,"column":0}],"path":["asynchronousArrayObject","arrayField",1],"extensions":{"fetchId":0}},{"message":"Cannot return null for non-nullable field arrayField.","locations":[{"line":0,"column":0}],"path":["asynchronousArrayObject","arrayField",2],"extensions":{"fetchId":0}},{"message":"Cannot return null for non-nullable field nullableArray.","locations":[{"line":0,"column":0}],"path":["nullableArray",0],"extensions":{"fetchId":0}}],"data":{"stringObject":null,"integerObject":null,"floatObject":null,"booleanObject":null,"objectObject":null,"arrayObject":null,"asynchronousArrayObject":null,"nullableArray":null}}`
 	}))
 	t.Run("empty nullable array should resolve correctly", testFn(false, false, func(t *testing.T, ctrl *gomock.Controller) (node *GraphQLResponse, ctx Context, expectedOutput string) {
 		return &GraphQLResponse{
@@ -2144,7 +2193,7 @@ func TestResolver_ResolveGraphQLResponse(t *testing.T) {
 					},
 				},
 			},
-		}, Context{Context: context.Background()}, `{"errors":[{"message":"unable to resolve","locations":[{"line":0,"column":0}]}],"data":null}`
+		}, Context{Context: context.Background()}, `{"errors":[{"message":"Cannot return null for non-nullable field nonNullArray.","locations":[{"line":0,"column":0}],"path":["nonNullArray"],"extensions":{"fetchId":0}}],"data":null}`
 	}))
 	t.Run("when data null and errors present not nullable array should result to null data upsteam error and resolve error", testFn(false, false, func(t *testing.T, ctrl *gomock.Controller) (node *GraphQLResponse, ctx Context, expectedOutput string) {
 		return &GraphQLResponse{
@@ -2182,7 +2231,7 @@ func TestResolver_ResolveGraphQLResponse(t *testing.T) {
 					},
 				},
 			},
-		}, Context{Context: context.Background()}, `{"errors":[{"message":"Could not get a name","locations":[{"line":3,"column":5}],"path":["todos",0,"name"]},{"message":"unable to resolve","locations":[{"line":0,"column":0}]}],"data":null}`
+		}, Context{Context: context.Background()}, `{"errors":[{"message":"Could not get a name","locations":[{"line":3,"column":5}],"path":["todos",0,"name"]},{"message":"Cannot return null for non-nullable field todos.","locations":[{"line":0,"column":0}],"path":["todos"],"extensions":{"fetchId":0}}],"data":null}`
 	}))
 	t.Run("complex GraphQL Server plan", testFn(true, false, func(t *testing.T, ctrl *gomock.Controller) (node *GraphQLResponse, ctx Context, expectedOutput string) {
 		serviceOne := NewMockDataSource(ctrl)
@@ -3025,7 +3074,7 @@ func TestResolver_ResolveGraphQLResponse(t *testing.T) {
 					},
 				},
 			},
-		}, Context{Context: context.Background(), Variables: nil}, `{"errors":[{"message":"errorMessage"},{"message":"unable to resolve","locations":[{"line":0,"column":0}],"path":["me","reviews","0","product"]},{"message":"unable to resolve","locations":[{"line":0,"column":0}],"path":["me","reviews","1","product"]}],"data":{"me":{"id":"1234","username":"Me","reviews":[null,null]}}}`
+		}, Context{Context: context.Background(), Variables: nil}, `{"errors":[{"message":"errorMessage"},{"message":"Cannot return null for non-nullable field name.","locations":[{"line":0,"column":0}],"path":["me","reviews",0,"product","name"],"extensions":{"fetchId":2}},{"message":"Cannot return null for non-nullable field name.","locations":[{"line":0,"column":0}],"path":["me","reviews",1,"product","name"],"extensions":{"fetchId":2}}],"data":{"me":{"id":"1234","username":"Me","reviews":[null,null]}}}`
 	}))
 }
 
@@ -3121,24 +3170,40 @@ func FakeStream(cancelFunc func(), messageFunc func(count int) (message string,
 	}
 }
 
+// FakeCompletingStream closes the next channel after the last message instead of cancelling a context,
+// like an upstream completing the subscription.
+func FakeCompletingStream(messageFunc func(count int) (message string, ok bool)) *_fakeStream {
+	return &_fakeStream{
+		messageFunc: messageFunc,
+	}
+}
+
 type _fakeStream struct {
 	cancel      context.CancelFunc
 	messageFunc func(counter int) (message string, ok bool)
 }
 
+func (f *_fakeStream) end(next chan<- []byte) {
+	if f.cancel != nil {
+		f.cancel()
+		return
+	}
+	close(next)
+}
+
 func (f *_fakeStream) Start(ctx context.Context, input []byte, next chan<- []byte) error {
 	go func() {
 		time.Sleep(time.Millisecond)
 		count := 0
 		for {
 			if count == 3 {
-				f.cancel()
+				f.end(next)
 				return
 			}
 			message, ok := f.messageFunc(count)
 			next <- []byte(message)
 			if !ok {
-				f.cancel()
+				f.end(next)
 				return
 			}
 			count++
@@ -3179,7 +3244,7 @@ func TestResolver_ResolveGraphQLSubscription(t *testing.T) {
 		c, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		fakeStream := FakeStream(cancel, func(count int) (message string, ok bool) {
+		fakeStream := FakeCompletingStream(func(count int) (message string, ok bool) {
 			return `{"errors":[{"message":"Validation error occurred","locations":[{"line":1,"column":1}],"extensions":{"code":"GRAPHQL_VALIDATION_FAILED"}}],"data":null}`, false
 		})
 
@@ -3199,7 +3264,7 @@ func TestResolver_ResolveGraphQLSubscription(t *testing.T) {
 		c, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		fakeStream := FakeStream(cancel, func(count int) (message string, ok bool) {
+		fakeStream := FakeCompletingStream(func(count int) (message string, ok bool) {
 			return fmt.Sprintf(`{"data":{"counter":%d}}`, count), true
 		})
 
@@ -3216,6 +3281,25 @@ func TestResolver_ResolveGraphQLSubscription(t *testing.T) {
 		assert.Equal(t, `{"data":{"counter":1}}`, out.flushed[1])
 		assert.Equal(t, `{"data":{"counter":2}}`, out.flushed[2])
 	})
+
+	t.Run("should stop when the request is done", func(t *testing.T) {
+		c, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		fakeStream := FakeStream(cancel, func(count int) (message string, ok bool) {
+			return fmt.Sprintf(`{"data":{"counter":%d}}`, count), true
+		})
+
+		resolver, plan, out := setup(c, fakeStream)
+
+		ctx := Context{
+			Context: c,
+		}
+
+		err := resolver.ResolveGraphQLSubscription(&ctx, plan, out)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(out.flushed), 3)
+	})
 }
 
 func BenchmarkResolver_ResolveNode(b *testing.B) {
@@ -3689,3 +3773,10 @@ func TestInputTemplate_Render(t *testing.T) {
 		assert.Equal(t, `{"key":null}`, out)
 	})
 }
+
+func TestReportedError(t *testing.T) {
+	assert.True(t, errors.Is(errNonNullableFieldValueIsNullReported, errNonNullableFieldValueIsNull))
+	assert.False(t, errors.Is(errNonNullableFieldValueIsNullReported, errTypeNameSkipped))
+	assert.NotEqual(t, errNonNullableFieldValueIsNull, errNonNullableFieldValueIsNullReported)
+	assert.EqualError(t, errNonNullableFieldValueIsNullReported, errNonNullableFieldValueIsNull.Error())
+}
@@ -263,6 +263,7 @@ func TestArrayStream_InitialBatch_2(t *testing.T) {
 
 	err := resolver.ResolveGraphQLStreamingResponse(ctx, res, nil, writer)
 	assert.NoError(t, err)
+	for _, f := range writer.flushed { t.Log(f) }
 
 	assert.Equal(t, 1, len(writer.flushed))
 
@@ -406,3 +407,78 @@ func TestStreamAndDefer(t *testing.T) {
 	assert.NoError(t, err)
 	assert.JSONEq(t, string(expected), writer.flushed[4])
 }
+
+func TestArrayStream_NonNullableFieldValueIsNull(t *testing.T) {
+
+	controller := gomock.NewController(t)
+
+	userService := fakeService(t, controller, "user", "./testdata/users.json",
+		"")
+
+	res := &GraphQLStreamingResponse{
+		InitialResponse: &GraphQLResponse{
+			Data: &Object{
+				Fetch: &SingleFetch{
+					DataSource: userService,
+					BufferId:   0,
+				},
+				Fields: []*Field{
+					{
+						HasBuffer: true,
+						BufferID:  0,
+						Name:      []byte("users"),
+						Value: &Array{
+							Stream: Stream{
+								Enabled:          true,
+								InitialBatchSize: 0,
+								PatchIndex:       0,
+							},
+						},
+					},
+				},
+			},
+		},
+		Patches: []*GraphQLResponsePatch{
+			{
+				Operation: literal.ADD,
+				Value: &Object{
+					Fields: []*Field{
+						{
+							Name: []byte("id"),
+							Value: &Integer{
+								Path: []string{"id"},
+							},
+						},
+						{
+							Name: []byte("nickname"),
+							Value: &String{
+								Path: []string{"nickname"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	rCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := New(rCtx, NewFetcher(false), false)
+
+	ctx := NewContext(context.Background())
+
+	writer := &TestFlushWriter{}
+
+	err := resolver.ResolveGraphQLStreamingResponse(ctx, res, nil, writer)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, len(writer.flushed))
+
+	expected, err := ioutil.ReadFile("./testdata/stream_1.json")
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(expected), writer.flushed[0])
+	// the null stops at the root of the patch, which is still sent with the error
+	assert.Equal(t, `[{"op":"add","path":"/data/users/0","value":null,"errors":[{"message":"Cannot return null for non-nullable field nickname.","locations":[{"line":0,"column":0}],"path":["users",0,"nickname"]}]}]`, writer.flushed[1])
+	assert.Equal(t, `[{"op":"add","path":"/data/users/1","value":null,"errors":[{"message":"Cannot return null for non-nullable field nickname.","locations":[{"line":0,"column":0}],"path":["users",1,"nickname"]}]}]`, writer.flushed[2])
+}
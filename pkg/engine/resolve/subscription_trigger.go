@@ -0,0 +1,207 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// subscriberBufferSize is the number of events buffered for a subscriber.
+// A subscriber whose buffer is full when the next event arrives can't keep up with the upstream and gets dropped.
+const subscriberBufferSize = 64
+
+// ErrSlowSubscriber is returned by Resolver.ResolveGraphQLSubscription
+// if the client consumed the events slower than the upstream subscription produced them.
+var ErrSlowSubscriber = errors.New("subscription dropped as the client could not keep up with the events")
+
+// subscriptionTriggerID identifies an upstream subscription by the type of its data source and the hash of the rendered input.
+// The input of a subscription contains everything needed to start it, e.g. the url and the upstream query,
+// so two data sources of the same type started with the same input produce the same events.
+type subscriptionTriggerID struct {
+	sourceType reflect.Type
+	inputHash  uint64
+}
+
+// subscriptionTriggerManager deduplicates subscription triggers.
+// Subscribers with the same trigger ID share a single upstream subscription, every event is sent to all of them.
+// Events are buffered per subscriber, so a slow subscriber doesn't hold back the others.
+// The upstream subscription is stopped as soon as the last subscriber is gone.
+type subscriptionTriggerManager struct {
+	ctx      context.Context
+	mu       sync.Mutex
+	triggers map[subscriptionTriggerID]*subscriptionTrigger
+}
+
+func newSubscriptionTriggerManager(ctx context.Context) *subscriptionTriggerManager {
+	return &subscriptionTriggerManager{
+		ctx:      ctx,
+		triggers: map[subscriptionTriggerID]*subscriptionTrigger{},
+	}
+}
+
+type subscriptionTrigger struct {
+	id          subscriptionTriggerID
+	cancel      context.CancelFunc
+	subscribers map[*subscriptionTriggerSubscriber]struct{}
+	// started is closed once the data source has been started, startErr is set if that failed
+	started  chan struct{}
+	startErr error
+}
+
+type subscriptionTriggerSubscriber struct {
+	trigger *subscriptionTrigger
+	// next receives the events of the trigger, it's closed when the upstream subscription ended or the subscriber was dropped
+	next chan []byte
+	// err is set before next gets closed if the subscriber was dropped
+	err error
+}
+
+// subscribe adds a subscriber to the trigger for the data source and input and starts the upstream subscription if it's the first one.
+// Subscribers must call unsubscribe when they are done.
+func (m *subscriptionTriggerManager) subscribe(source SubscriptionDataSource, input []byte) (*subscriptionTriggerSubscriber, error) {
+	id := subscriptionTriggerID{
+		sourceType: reflect.TypeOf(source),
+		inputHash:  xxhash.Sum64(input),
+	}
+	subscriber := &subscriptionTriggerSubscriber{
+		next: make(chan []byte, subscriberBufferSize),
+	}
+
+	m.mu.Lock()
+	trigger, exists := m.triggers[id]
+	if !exists {
+		trigger = &subscriptionTrigger{
+			id:          id,
+			subscribers: map[*subscriptionTriggerSubscriber]struct{}{},
+			started:     make(chan struct{}),
+		}
+		m.triggers[id] = trigger
+	}
+	// the subscriber is added before the upstream subscription is started, so it receives the first events
+	subscriber.trigger = trigger
+	trigger.subscribers[subscriber] = struct{}{}
+	var ctx context.Context
+	if !exists {
+		ctx, trigger.cancel = context.WithCancel(m.ctx)
+	}
+	m.mu.Unlock()
+
+	if !exists {
+		// the data source gets started without holding the lock as it might e.g. dial the upstream
+		next := make(chan []byte)
+		trigger.startErr = source.Start(ctx, input, next)
+		if trigger.startErr != nil {
+			m.removeTrigger(trigger)
+		} else {
+			go m.run(ctx, trigger, next)
+		}
+		close(trigger.started)
+	}
+
+	<-trigger.started
+	if trigger.startErr != nil {
+		m.unsubscribe(subscriber)
+		return nil, trigger.startErr
+	}
+	return subscriber, nil
+}
+
+// unsubscribe removes the subscriber and stops the upstream subscription if it was the last one.
+func (m *subscriptionTriggerManager) unsubscribe(subscriber *subscriptionTriggerSubscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	trigger := subscriber.trigger
+	if _, ok := trigger.subscribers[subscriber]; !ok {
+		return
+	}
+	delete(trigger.subscribers, subscriber)
+
+	if len(trigger.subscribers) == 0 {
+		if m.triggers[trigger.id] == trigger {
+			delete(m.triggers, trigger.id)
+		}
+		trigger.cancel()
+	}
+}
+
+func (m *subscriptionTriggerManager) removeTrigger(trigger *subscriptionTrigger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.triggers[trigger.id] == trigger {
+		delete(m.triggers, trigger.id)
+	}
+}
+
+// run sends every event of the upstream subscription to all subscribers until it ends.
+// run is the only sender on the next channels of the subscribers, so it's the one closing them.
+func (m *subscriptionTriggerManager) run(ctx context.Context, trigger *subscriptionTrigger, next <-chan []byte) {
+	defer func() {
+		m.mu.Lock()
+		if m.triggers[trigger.id] == trigger {
+			delete(m.triggers, trigger.id)
+		}
+		for subscriber := range trigger.subscribers {
+			close(subscriber.next)
+		}
+		m.mu.Unlock()
+		trigger.cancel()
+	}()
+
+	subscribers := make([]*subscriptionTriggerSubscriber, 0, 8)
+	for {
+		var (
+			data []byte
+			ok   bool
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case data, ok = <-next:
+			if !ok {
+				return
+			}
+		}
+
+		m.mu.Lock()
+		subscribers = subscribers[:0]
+		for subscriber := range trigger.subscribers {
+			subscribers = append(subscribers, subscriber)
+		}
+		m.mu.Unlock()
+
+		for _, subscriber := range subscribers {
+			select {
+			case subscriber.next <- data:
+			default:
+				m.drop(subscriber)
+			}
+		}
+	}
+}
+
+// drop removes a subscriber which can't keep up with the events and closes its next channel.
+// The upstream subscription keeps running for the remaining subscribers.
+func (m *subscriptionTriggerManager) drop(subscriber *subscriptionTriggerSubscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	trigger := subscriber.trigger
+	if _, ok := trigger.subscribers[subscriber]; !ok {
+		return
+	}
+	delete(trigger.subscribers, subscriber)
+	subscriber.err = ErrSlowSubscriber
+	close(subscriber.next)
+
+	if len(trigger.subscribers) == 0 {
+		if m.triggers[trigger.id] == trigger {
+			delete(m.triggers, trigger.id)
+		}
+		trigger.cancel()
+	}
+}
@@ -0,0 +1,264 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTriggerSource struct {
+	mu       sync.Mutex
+	starts   map[string]int
+	streams  map[string]chan<- []byte
+	contexts map[string]context.Context
+	startErr error
+}
+
+func newTestTriggerSource() *testTriggerSource {
+	return &testTriggerSource{
+		starts:   map[string]int{},
+		streams:  map[string]chan<- []byte{},
+		contexts: map[string]context.Context{},
+	}
+}
+
+func (s *testTriggerSource) Start(ctx context.Context, input []byte, next chan<- []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.startErr != nil {
+		return s.startErr
+	}
+	s.starts[string(input)]++
+	s.streams[string(input)] = next
+	s.contexts[string(input)] = ctx
+	return nil
+}
+
+func (s *testTriggerSource) startCount(input string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.starts[input]
+}
+
+func (s *testTriggerSource) send(input, data string) {
+	s.mu.Lock()
+	next := s.streams[input]
+	s.mu.Unlock()
+	next <- []byte(data)
+}
+
+func (s *testTriggerSource) context(input string) context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.contexts[input]
+}
+
+// immediateTriggerSource sends a single event and ends the subscription right away, like a single response of an upstream.
+type immediateTriggerSource struct{}
+
+func (immediateTriggerSource) Start(ctx context.Context, input []byte, next chan<- []byte) error {
+	go func() {
+		next <- []byte("event")
+		close(next)
+	}()
+	return nil
+}
+
+func receive(t *testing.T, subscriber *subscriptionTriggerSubscriber) string {
+	t.Helper()
+	select {
+	case data := <-subscriber.next:
+		return string(data)
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for data")
+		return ""
+	}
+}
+
+func TestSubscriptionTriggerManager(t *testing.T) {
+	subscribe := func(t *testing.T, manager *subscriptionTriggerManager, source SubscriptionDataSource, input string) *subscriptionTriggerSubscriber {
+		t.Helper()
+		subscriber, err := manager.subscribe(source, []byte(input))
+		require.NoError(t, err)
+		return subscriber
+	}
+
+	t.Run("should share the upstream subscription between subscribers with the same input", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		manager := newSubscriptionTriggerManager(ctx)
+		source := newTestTriggerSource()
+
+		first := subscribe(t, manager, source, "a")
+		second := subscribe(t, manager, source, "a")
+		assert.Equal(t, 1, source.startCount("a"))
+		assert.Equal(t, first.trigger, second.trigger)
+
+		go source.send("a", "event")
+		assert.Equal(t, "event", receive(t, first))
+		assert.Equal(t, "event", receive(t, second))
+
+		manager.unsubscribe(first)
+		manager.unsubscribe(second)
+	})
+
+	t.Run("should drop subscribers which can't keep up without holding back the others", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		manager := newSubscriptionTriggerManager(ctx)
+		source := newTestTriggerSource()
+
+		slow := subscribe(t, manager, source, "a")
+		fast := subscribe(t, manager, source, "a")
+		upstreamCtx := source.context("a")
+
+		for i := 0; i <= subscriberBufferSize; i++ {
+			source.send("a", "event")
+			assert.Equal(t, "event", receive(t, fast))
+		}
+
+		for i := 0; i < subscriberBufferSize; i++ {
+			assert.Equal(t, "event", receive(t, slow))
+		}
+		_, ok := <-slow.next
+		assert.False(t, ok)
+		assert.Equal(t, ErrSlowSubscriber, slow.err)
+		assert.NoError(t, upstreamCtx.Err())
+
+		go source.send("a", "next event")
+		assert.Equal(t, "next event", receive(t, fast))
+
+		manager.unsubscribe(slow)
+		manager.unsubscribe(fast)
+		assert.Error(t, upstreamCtx.Err())
+	})
+
+	t.Run("should close the subscribers after the remaining events when the upstream subscription ends", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		manager := newSubscriptionTriggerManager(ctx)
+		source := newTestTriggerSource()
+
+		subscriber := subscribe(t, manager, source, "a")
+		source.send("a", "event")
+		source.mu.Lock()
+		close(source.streams["a"])
+		source.mu.Unlock()
+
+		assert.Equal(t, "event", receive(t, subscriber))
+		select {
+		case _, ok := <-subscriber.next:
+			assert.False(t, ok)
+		case <-time.After(time.Second):
+			require.Fail(t, "subscriber not closed")
+		}
+		assert.NoError(t, subscriber.err)
+		manager.unsubscribe(subscriber)
+	})
+
+	t.Run("should start a separate upstream subscription for different input", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		manager := newSubscriptionTriggerManager(ctx)
+		source := newTestTriggerSource()
+
+		first := subscribe(t, manager, source, "a")
+		second := subscribe(t, manager, source, "b")
+		assert.Equal(t, 1, source.startCount("a"))
+		assert.Equal(t, 1, source.startCount("b"))
+		assert.NotEqual(t, first.trigger, second.trigger)
+
+		go source.send("b", "event")
+		assert.Equal(t, "event", receive(t, second))
+
+		manager.unsubscribe(first)
+		manager.unsubscribe(second)
+	})
+
+	t.Run("should stop the upstream subscription when the last subscriber is gone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		manager := newSubscriptionTriggerManager(ctx)
+		source := newTestTriggerSource()
+
+		first := subscribe(t, manager, source, "a")
+		second := subscribe(t, manager, source, "a")
+		upstreamCtx := source.context("a")
+
+		manager.unsubscribe(first)
+		assert.NoError(t, upstreamCtx.Err())
+
+		manager.unsubscribe(second)
+		assert.Error(t, upstreamCtx.Err())
+
+		third := subscribe(t, manager, source, "a")
+		assert.Equal(t, 2, source.startCount("a"))
+		manager.unsubscribe(third)
+	})
+
+	t.Run("should stop the upstream subscription when the resolver is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		manager := newSubscriptionTriggerManager(ctx)
+		source := newTestTriggerSource()
+
+		subscriber := subscribe(t, manager, source, "a")
+		cancel()
+
+		select {
+		case _, ok := <-subscriber.next:
+			assert.False(t, ok)
+		case <-time.After(time.Second):
+			require.Fail(t, "subscriber not closed")
+		}
+		manager.unsubscribe(subscriber)
+	})
+
+	t.Run("should deliver the events of an upstream subscription ending right after the start", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		manager := newSubscriptionTriggerManager(ctx)
+
+		subscriber := subscribe(t, manager, immediateTriggerSource{}, "a")
+		assert.Equal(t, "event", receive(t, subscriber))
+		select {
+		case _, ok := <-subscriber.next:
+			assert.False(t, ok)
+		case <-time.After(time.Second):
+			require.Fail(t, "subscriber not closed")
+		}
+		manager.unsubscribe(subscriber)
+		assert.Len(t, manager.triggers, 0)
+	})
+
+	t.Run("should return the start error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		manager := newSubscriptionTriggerManager(ctx)
+		source := newTestTriggerSource()
+		source.startErr = errors.New("start failed")
+
+		_, err := manager.subscribe(source, []byte("a"))
+		assert.EqualError(t, err, "start failed")
+		assert.Len(t, manager.triggers, 0)
+	})
+
+	t.Run("should share the upstream subscription between data sources of the same type", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		manager := newSubscriptionTriggerManager(ctx)
+		source := newTestTriggerSource()
+
+		first := subscribe(t, manager, source, "a")
+		second := subscribe(t, manager, newTestTriggerSource(), "a")
+		assert.Equal(t, 1, source.startCount("a"))
+		assert.Equal(t, first.trigger, second.trigger)
+
+		manager.unsubscribe(first)
+		manager.unsubscribe(second)
+	})
+}
@@ -0,0 +1,433 @@
+package resolve
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// TraceFormat is the format of the execution trace which is added to the extensions of the response.
+type TraceFormat int
+
+const (
+	// TraceFormatJSON adds a readable trace in the format of Apollo Tracing as extensions.tracing.
+	TraceFormatJSON TraceFormat = iota + 1
+	// TraceFormatFTV1 adds a base64 encoded Apollo Federated Tracing (ftv1) protobuf as extensions.ftv1.
+	// The ftv1 traces of GraphQL upstreams are requested and merged into the query plan of the trace.
+	TraceFormatFTV1
+)
+
+var (
+	literalTracing = []byte("tracing")
+	literalFTV1    = []byte("ftv1")
+)
+
+// ExecutionTrace records the start and end time of every resolved field and every fetch of a response.
+// All times are offsets to StartTime.
+type ExecutionTrace struct {
+	Format    TraceFormat
+	StartTime time.Time
+	EndTime   time.Time
+	// Root holds the root fields as children.
+	Root    TraceNode
+	Fetches []*FetchTrace
+
+	mu sync.Mutex
+}
+
+// TraceNode is a resolved field, or a list item if it has an Index.
+type TraceNode struct {
+	ResponseName string
+	// Index is the position of a list item, it is -1 for fields.
+	Index      int
+	FieldName  string
+	ParentType string
+	StartTime  time.Duration
+	EndTime    time.Duration
+	Children   []*TraceNode
+}
+
+// FetchTrace is a single fetch of a data source.
+type FetchTrace struct {
+	// Path is the response path of the object the fetch was made for.
+	Path         []string
+	DataSourceID string
+	// ServiceName is the name of the upstream, if it is known, or its URL.
+	ServiceName string
+	StartTime   time.Duration
+	EndTime     time.Duration
+	// UpstreamTrace is the ftv1 trace of the upstream response.
+	UpstreamTrace []byte
+	// UpstreamTraceFailed is true if the upstream response contains an ftv1 trace which can't be decoded.
+	UpstreamTraceFailed bool
+}
+
+func newExecutionTrace(format TraceFormat) *ExecutionTrace {
+	return &ExecutionTrace{
+		Format:    format,
+		StartTime: time.Now(),
+		Root:      TraceNode{Index: -1},
+	}
+}
+
+func (t *ExecutionTrace) since() time.Duration {
+	return time.Since(t.StartTime)
+}
+
+// startField adds a field to the children of parent.
+func (t *ExecutionTrace) startField(parent *TraceNode, field *Field) *TraceNode {
+	node := &TraceNode{
+		ResponseName: string(field.Name),
+		Index:        -1,
+		FieldName:    string(field.Name),
+		StartTime:    t.since(),
+	}
+	if field.Info != nil {
+		node.FieldName = field.Info.Name
+		node.ParentType = field.Info.ParentTypeName
+	}
+	t.addChild(parent, node)
+	return node
+}
+
+// startListItem adds a list item to the children of parent.
+func (t *ExecutionTrace) startListItem(parent *TraceNode, index int) *TraceNode {
+	node := &TraceNode{
+		Index:     index,
+		StartTime: t.since(),
+	}
+	t.addChild(parent, node)
+	return node
+}
+
+func (t *ExecutionTrace) addChild(parent, node *TraceNode) {
+	t.mu.Lock()
+	parent.Children = append(parent.Children, node)
+	t.mu.Unlock()
+}
+
+func (t *ExecutionTrace) end(node *TraceNode) {
+	node.EndTime = t.since()
+}
+
+// tracesItems returns true if the items of the array get their own node,
+// which is only the case if they contain fields.
+func tracesItems(array *Array) bool {
+	switch array.Item.(type) {
+	case *Object, *Array:
+		return true
+	default:
+		return false
+	}
+}
+
+// startFetch records the start of a fetch made for the object at path.
+func (t *ExecutionTrace) startFetch(path [][]byte, fetch *SingleFetch, input []byte) *FetchTrace {
+	fetchTrace := &FetchTrace{
+		Path:         make([]string, 0, len(path)),
+		DataSourceID: string(fetch.DataSourceIdentifier),
+		ServiceName:  fetch.ProcessResponseConfig.UpstreamServiceName,
+		StartTime:    t.since(),
+	}
+	for i := range path {
+		fetchTrace.Path = append(fetchTrace.Path, string(path[i]))
+	}
+	if fetchTrace.ServiceName == "" {
+		fetchTrace.ServiceName, _ = jsonparser.GetString(input, "url")
+	}
+
+	t.mu.Lock()
+	t.Fetches = append(t.Fetches, fetchTrace)
+	t.mu.Unlock()
+	return fetchTrace
+}
+
+// endFetch records the end of a fetch and extracts the ftv1 trace of the upstream response.
+func (t *ExecutionTrace) endFetch(fetchTrace *FetchTrace, response []byte) {
+	fetchTrace.EndTime = t.since()
+
+	encoded, err := jsonparser.GetString(response, "extensions", "ftv1")
+	if err != nil {
+		return
+	}
+	upstreamTrace, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		fetchTrace.UpstreamTraceFailed = true
+		return
+	}
+	fetchTrace.UpstreamTrace = upstreamTrace
+}
+
+// extensions returns the trace as value of the response extensions.
+func (t *ExecutionTrace) extensions() ([]byte, error) {
+	t.EndTime = time.Now()
+
+	if t.Format == TraceFormatFTV1 {
+		encoded, err := json.Marshal(base64.StdEncoding.EncodeToString(t.FTV1()))
+		if err != nil {
+			return nil, err
+		}
+		return t.wrap(literalFTV1, encoded), nil
+	}
+
+	encoded, err := json.Marshal(t.apolloTracing())
+	if err != nil {
+		return nil, err
+	}
+	return t.wrap(literalTracing, encoded), nil
+}
+
+func (t *ExecutionTrace) wrap(key, value []byte) []byte {
+	out := make([]byte, 0, len(key)+len(value)+5)
+	out = append(out, lBrace...)
+	out = append(out, quote...)
+	out = append(out, key...)
+	out = append(out, quote...)
+	out = append(out, colon...)
+	out = append(out, value...)
+	return append(out, rBrace...)
+}
+
+type apolloTracing struct {
+	Version   int                    `json:"version"`
+	StartTime string                 `json:"startTime"`
+	EndTime   string                 `json:"endTime"`
+	Duration  int64                  `json:"duration"`
+	Execution apolloTracingExecution `json:"execution"`
+	Fetches   []apolloTracingFetch   `json:"fetches"`
+}
+
+type apolloTracingExecution struct {
+	Resolvers []apolloTracingResolver `json:"resolvers"`
+}
+
+type apolloTracingResolver struct {
+	Path        []interface{} `json:"path"`
+	ParentType  string        `json:"parentType"`
+	FieldName   string        `json:"fieldName"`
+	StartOffset int64         `json:"startOffset"`
+	Duration    int64         `json:"duration"`
+}
+
+type apolloTracingFetch struct {
+	Path         []string `json:"path"`
+	DataSourceID string   `json:"dataSourceId"`
+	ServiceName  string   `json:"serviceName,omitempty"`
+	StartOffset  int64    `json:"startOffset"`
+	Duration     int64    `json:"duration"`
+	FTV1         string   `json:"ftv1,omitempty"`
+}
+
+// apolloTracing returns the trace in the format of Apollo Tracing, extended by the fetches.
+func (t *ExecutionTrace) apolloTracing() apolloTracing {
+	tracing := apolloTracing{
+		Version:   1,
+		StartTime: t.StartTime.UTC().Format(time.RFC3339Nano),
+		EndTime:   t.EndTime.UTC().Format(time.RFC3339Nano),
+		Duration:  int64(t.EndTime.Sub(t.StartTime)),
+		Execution: apolloTracingExecution{
+			Resolvers: []apolloTracingResolver{},
+		},
+		Fetches: make([]apolloTracingFetch, 0, len(t.Fetches)),
+	}
+
+	var walk func(node *TraceNode, path []interface{})
+	walk = func(node *TraceNode, path []interface{}) {
+		for _, child := range node.Children {
+			childPath := make([]interface{}, len(path), len(path)+1)
+			copy(childPath, path)
+			if child.Index >= 0 {
+				walk(child, append(childPath, child.Index))
+				continue
+			}
+			childPath = append(childPath, child.ResponseName)
+			tracing.Execution.Resolvers = append(tracing.Execution.Resolvers, apolloTracingResolver{
+				Path:        childPath,
+				ParentType:  child.ParentType,
+				FieldName:   child.FieldName,
+				StartOffset: int64(child.StartTime),
+				Duration:    int64(child.EndTime - child.StartTime),
+			})
+			walk(child, childPath)
+		}
+	}
+	walk(&t.Root, nil)
+
+	for _, fetch := range t.Fetches {
+		fetchTracing := apolloTracingFetch{
+			Path:         fetch.Path,
+			DataSourceID: fetch.DataSourceID,
+			ServiceName:  fetch.ServiceName,
+			StartOffset:  int64(fetch.StartTime),
+			Duration:     int64(fetch.EndTime - fetch.StartTime),
+		}
+		if len(fetch.UpstreamTrace) != 0 {
+			fetchTracing.FTV1 = base64.StdEncoding.EncodeToString(fetch.UpstreamTrace)
+		}
+		tracing.Fetches = append(tracing.Fetches, fetchTracing)
+	}
+
+	return tracing
+}
+
+// field numbers of the Trace message of Apollo's reports.proto
+const (
+	ftv1TraceEndTime    protowire.Number = 3
+	ftv1TraceStartTime  protowire.Number = 4
+	ftv1TraceDurationNs protowire.Number = 11
+	ftv1TraceRoot       protowire.Number = 14
+	ftv1TraceQueryPlan  protowire.Number = 26
+
+	ftv1NodeResponseName      protowire.Number = 1
+	ftv1NodeIndex             protowire.Number = 2
+	ftv1NodeStartTime         protowire.Number = 8
+	ftv1NodeEndTime           protowire.Number = 9
+	ftv1NodeChild             protowire.Number = 12
+	ftv1NodeParentType        protowire.Number = 13
+	ftv1NodeOriginalFieldName protowire.Number = 14
+
+	ftv1QueryPlanSequence protowire.Number = 1
+	ftv1QueryPlanFetch    protowire.Number = 3
+	ftv1QueryPlanFlatten  protowire.Number = 4
+
+	ftv1SequenceNodes protowire.Number = 1
+
+	ftv1FetchServiceName        protowire.Number = 1
+	ftv1FetchTraceParsingFailed protowire.Number = 2
+	ftv1FetchTrace              protowire.Number = 3
+	ftv1FetchSentTimeOffset     protowire.Number = 4
+	ftv1FetchSentTime           protowire.Number = 5
+	ftv1FetchReceivedTime       protowire.Number = 6
+
+	ftv1FlattenResponsePath protowire.Number = 1
+	ftv1FlattenNode         protowire.Number = 2
+
+	ftv1PathElementFieldName protowire.Number = 1
+	ftv1PathElementIndex     protowire.Number = 2
+
+	ftv1TimestampSeconds protowire.Number = 1
+	ftv1TimestampNanos   protowire.Number = 2
+)
+
+// FTV1 encodes the trace as Trace message of Apollo's reports.proto.
+// The fetches are added as query plan, containing the ftv1 traces of the upstreams.
+func (t *ExecutionTrace) FTV1() []byte {
+	var out []byte
+	out = appendFTV1Timestamp(out, ftv1TraceStartTime, t.StartTime)
+	out = appendFTV1Timestamp(out, ftv1TraceEndTime, t.EndTime)
+	out = protowire.AppendTag(out, ftv1TraceDurationNs, protowire.VarintType)
+	out = protowire.AppendVarint(out, uint64(t.EndTime.Sub(t.StartTime)))
+	out = protowire.AppendTag(out, ftv1TraceRoot, protowire.BytesType)
+	out = protowire.AppendBytes(out, t.ftv1Node(&t.Root))
+
+	if len(t.Fetches) != 0 {
+		out = protowire.AppendTag(out, ftv1TraceQueryPlan, protowire.BytesType)
+		out = protowire.AppendBytes(out, t.ftv1QueryPlan())
+	}
+
+	return out
+}
+
+func (t *ExecutionTrace) ftv1Node(node *TraceNode) []byte {
+	var out []byte
+	if node.Index >= 0 {
+		out = protowire.AppendTag(out, ftv1NodeIndex, protowire.VarintType)
+		out = protowire.AppendVarint(out, uint64(node.Index))
+	} else if node.ResponseName != "" {
+		out = protowire.AppendTag(out, ftv1NodeResponseName, protowire.BytesType)
+		out = protowire.AppendString(out, node.ResponseName)
+		if node.FieldName != node.ResponseName {
+			out = protowire.AppendTag(out, ftv1NodeOriginalFieldName, protowire.BytesType)
+			out = protowire.AppendString(out, node.FieldName)
+		}
+		if node.ParentType != "" {
+			out = protowire.AppendTag(out, ftv1NodeParentType, protowire.BytesType)
+			out = protowire.AppendString(out, node.ParentType)
+		}
+		out = protowire.AppendTag(out, ftv1NodeStartTime, protowire.VarintType)
+		out = protowire.AppendVarint(out, uint64(node.StartTime))
+		out = protowire.AppendTag(out, ftv1NodeEndTime, protowire.VarintType)
+		out = protowire.AppendVarint(out, uint64(node.EndTime))
+	}
+
+	for _, child := range node.Children {
+		out = protowire.AppendTag(out, ftv1NodeChild, protowire.BytesType)
+		out = protowire.AppendBytes(out, t.ftv1Node(child))
+	}
+	return out
+}
+
+// ftv1QueryPlan returns a sequence of the fetches, fetches of nested objects are flattened to their response path.
+func (t *ExecutionTrace) ftv1QueryPlan() []byte {
+	var sequence []byte
+	for _, fetch := range t.Fetches {
+		node := protowire.AppendTag(nil, ftv1QueryPlanFetch, protowire.BytesType)
+		node = protowire.AppendBytes(node, t.ftv1FetchNode(fetch))
+
+		if len(fetch.Path) != 0 {
+			var flatten []byte
+			for _, element := range fetch.Path {
+				flatten = protowire.AppendTag(flatten, ftv1FlattenResponsePath, protowire.BytesType)
+				flatten = protowire.AppendBytes(flatten, ftv1PathElement(element))
+			}
+			flatten = protowire.AppendTag(flatten, ftv1FlattenNode, protowire.BytesType)
+			flatten = protowire.AppendBytes(flatten, node)
+
+			node = protowire.AppendTag(nil, ftv1QueryPlanFlatten, protowire.BytesType)
+			node = protowire.AppendBytes(node, flatten)
+		}
+
+		sequence = protowire.AppendTag(sequence, ftv1SequenceNodes, protowire.BytesType)
+		sequence = protowire.AppendBytes(sequence, node)
+	}
+
+	out := protowire.AppendTag(nil, ftv1QueryPlanSequence, protowire.BytesType)
+	return protowire.AppendBytes(out, sequence)
+}
+
+func (t *ExecutionTrace) ftv1FetchNode(fetch *FetchTrace) []byte {
+	var out []byte
+	if fetch.ServiceName != "" {
+		out = protowire.AppendTag(out, ftv1FetchServiceName, protowire.BytesType)
+		out = protowire.AppendString(out, fetch.ServiceName)
+	}
+	if fetch.UpstreamTraceFailed {
+		out = protowire.AppendTag(out, ftv1FetchTraceParsingFailed, protowire.VarintType)
+		out = protowire.AppendVarint(out, protowire.EncodeBool(true))
+	}
+	if len(fetch.UpstreamTrace) != 0 {
+		// the upstream trace is already an encoded Trace message, so it is embedded as is
+		out = protowire.AppendTag(out, ftv1FetchTrace, protowire.BytesType)
+		out = protowire.AppendBytes(out, fetch.UpstreamTrace)
+	}
+	out = protowire.AppendTag(out, ftv1FetchSentTimeOffset, protowire.VarintType)
+	out = protowire.AppendVarint(out, uint64(fetch.StartTime))
+	out = appendFTV1Timestamp(out, ftv1FetchSentTime, t.StartTime.Add(fetch.StartTime))
+	out = appendFTV1Timestamp(out, ftv1FetchReceivedTime, t.StartTime.Add(fetch.EndTime))
+	return out
+}
+
+func ftv1PathElement(element string) []byte {
+	if index, err := strconv.Atoi(element); err == nil {
+		out := protowire.AppendTag(nil, ftv1PathElementIndex, protowire.VarintType)
+		return protowire.AppendVarint(out, uint64(index))
+	}
+	out := protowire.AppendTag(nil, ftv1PathElementFieldName, protowire.BytesType)
+	return protowire.AppendString(out, element)
+}
+
+func appendFTV1Timestamp(out []byte, number protowire.Number, timestamp time.Time) []byte {
+	var encoded []byte
+	encoded = protowire.AppendTag(encoded, ftv1TimestampSeconds, protowire.VarintType)
+	encoded = protowire.AppendVarint(encoded, uint64(timestamp.Unix()))
+	encoded = protowire.AppendTag(encoded, ftv1TimestampNanos, protowire.VarintType)
+	encoded = protowire.AppendVarint(encoded, uint64(timestamp.Nanosecond()))
+
+	out = protowire.AppendTag(out, number, protowire.BytesType)
+	return protowire.AppendBytes(out, encoded)
+}
@@ -0,0 +1,237 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/pvormste/graphql-go-tools/pkg/engine/tracing"
+)
+
+// headerRecordingDataSource returns data and records the headers which would be injected into upstream requests.
+type headerRecordingDataSource struct {
+	data   string
+	header http.Header
+}
+
+func (h *headerRecordingDataSource) Load(ctx context.Context, input []byte, w io.Writer) (err error) {
+	h.header = http.Header{}
+	tracing.InjectHeader(ctx, h.header)
+	_, err = w.Write([]byte(h.data))
+	return
+}
+
+// ftv1Fields decodes a protobuf message into its fields, nested messages are kept as bytes.
+func ftv1Fields(t *testing.T, message []byte) map[protowire.Number][]interface{} {
+	fields := map[protowire.Number][]interface{}{}
+	for len(message) > 0 {
+		number, typ, n := protowire.ConsumeTag(message)
+		require.GreaterOrEqual(t, n, 0)
+		message = message[n:]
+		switch typ {
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(message)
+			require.GreaterOrEqual(t, n, 0)
+			fields[number] = append(fields[number], value)
+			message = message[n:]
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(message)
+			require.GreaterOrEqual(t, n, 0)
+			fields[number] = append(fields[number], value)
+			message = message[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+	}
+	return fields
+}
+
+func TestResolver_ResolveGraphQLResponse_Tracing(t *testing.T) {
+	upstreamTrace := protowire.AppendVarint(protowire.AppendTag(nil, ftv1TraceDurationNs, protowire.VarintType), 42)
+	upstreamResponse := `{"data":{"hero":{"name":"R2-D2","friends":[{"name":"Luke"},{"name":"Leia"}]}},"extensions":{"ftv1":"` + base64.StdEncoding.EncodeToString(upstreamTrace) + `"}}`
+
+	heroResponse := func(dataSource DataSource, resolveAsynchronous bool) *GraphQLResponse {
+		return &GraphQLResponse{
+			Data: &Object{
+				Fetch: &SingleFetch{
+					BufferId:              0,
+					DataSource:            dataSource,
+					DataSourceIdentifier:  []byte("graphql_datasource.Source"),
+					Input:                 `{"url":"http://heroes.service"}`,
+					InputTemplate:         InputTemplate{Segments: []TemplateSegment{{Data: []byte(`{"url":"http://heroes.service"}`), SegmentType: StaticSegmentType}}},
+					ProcessResponseConfig: ProcessResponseConfig{ExtractGraphqlResponse: true},
+				},
+				Fields: []*Field{
+					{
+						BufferID:  0,
+						HasBuffer: true,
+						Name:      []byte("hero"),
+						Info:      &FieldInfo{Name: "hero", ParentTypeName: "Query"},
+						Value: &Object{
+							Path: []string{"hero"},
+							Fields: []*Field{
+								{
+									Name:  []byte("name"),
+									Info:  &FieldInfo{Name: "name", ParentTypeName: "Character"},
+									Value: &String{Path: []string{"name"}},
+								},
+								{
+									Name: []byte("heroFriends"),
+									Info: &FieldInfo{Name: "friends", ParentTypeName: "Character"},
+									Value: &Array{
+										Path:                []string{"friends"},
+										ResolveAsynchronous: resolveAsynchronous,
+										Item: &Object{
+											Fields: []*Field{
+												{
+													Name:  []byte("name"),
+													Info:  &FieldInfo{Name: "name", ParentTypeName: "Character"},
+													Value: &String{Path: []string{"name"}},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	resolve := func(t *testing.T, format TraceFormat, dataSource DataSource, resolveAsynchronous bool) []byte {
+		ctx := NewContext(context.Background())
+		ctx.EnableTracing(format)
+
+		buf := &bytes.Buffer{}
+		err := newResolver(context.Background(), false, false).ResolveGraphQLResponse(ctx, heroResponse(dataSource, resolveAsynchronous), nil, buf)
+		require.NoError(t, err)
+
+		data, _, _, err := jsonparser.Get(buf.Bytes(), "data")
+		require.NoError(t, err)
+		assert.Equal(t, `{"hero":{"name":"R2-D2","heroFriends":[{"name":"Luke"},{"name":"Leia"}]}}`, string(data))
+		return buf.Bytes()
+	}
+
+	for name, resolveAsynchronous := range map[string]bool{"json": false, "json with asynchronous arrays": true} {
+		resolveAsynchronous := resolveAsynchronous
+		t.Run(name, func(t *testing.T) {
+			response := resolve(t, TraceFormatJSON, FakeDataSource(upstreamResponse), resolveAsynchronous)
+
+			tracingJSON, _, _, err := jsonparser.Get(response, "extensions", "tracing")
+			require.NoError(t, err)
+
+			var trace apolloTracing
+			require.NoError(t, json.Unmarshal(tracingJSON, &trace))
+			assert.Equal(t, 1, trace.Version)
+			assert.Greater(t, trace.Duration, int64(0))
+
+			type resolver struct {
+				Path       []interface{}
+				ParentType string
+				FieldName  string
+			}
+			resolvers := make([]resolver, 0, len(trace.Execution.Resolvers))
+			for _, r := range trace.Execution.Resolvers {
+				assert.GreaterOrEqual(t, r.Duration, int64(0))
+				resolvers = append(resolvers, resolver{Path: r.Path, ParentType: r.ParentType, FieldName: r.FieldName})
+			}
+			assert.Equal(t, []resolver{
+				{Path: []interface{}{"hero"}, ParentType: "Query", FieldName: "hero"},
+				{Path: []interface{}{"hero", "name"}, ParentType: "Character", FieldName: "name"},
+				{Path: []interface{}{"hero", "heroFriends"}, ParentType: "Character", FieldName: "friends"},
+				{Path: []interface{}{"hero", "heroFriends", float64(0), "name"}, ParentType: "Character", FieldName: "name"},
+				{Path: []interface{}{"hero", "heroFriends", float64(1), "name"}, ParentType: "Character", FieldName: "name"},
+			}, resolvers)
+
+			require.Len(t, trace.Fetches, 1)
+			assert.Equal(t, []string{}, trace.Fetches[0].Path)
+			assert.Equal(t, "graphql_datasource.Source", trace.Fetches[0].DataSourceID)
+			assert.Equal(t, "http://heroes.service", trace.Fetches[0].ServiceName)
+			assert.Equal(t, base64.StdEncoding.EncodeToString(upstreamTrace), trace.Fetches[0].FTV1)
+		})
+	}
+
+	t.Run("ftv1", func(t *testing.T) {
+		dataSource := &headerRecordingDataSource{data: upstreamResponse}
+		response := resolve(t, TraceFormatFTV1, dataSource, false)
+		assert.Equal(t, tracing.HeaderIncludeTraceFTV1, dataSource.header.Get(tracing.HeaderIncludeTrace))
+
+		encoded, err := jsonparser.GetString(response, "extensions", "ftv1")
+		require.NoError(t, err)
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		require.NoError(t, err)
+
+		trace := ftv1Fields(t, decoded)
+		require.Len(t, trace[ftv1TraceStartTime], 1)
+		require.Len(t, trace[ftv1TraceEndTime], 1)
+		require.Len(t, trace[ftv1TraceDurationNs], 1)
+
+		root := ftv1Fields(t, trace[ftv1TraceRoot][0].([]byte))
+		require.Len(t, root[ftv1NodeChild], 1)
+		hero := ftv1Fields(t, root[ftv1NodeChild][0].([]byte))
+		assert.Equal(t, []interface{}{[]byte("hero")}, hero[ftv1NodeResponseName])
+		assert.Equal(t, []interface{}{[]byte("Query")}, hero[ftv1NodeParentType])
+		assert.Nil(t, hero[ftv1NodeOriginalFieldName])
+		require.Len(t, hero[ftv1NodeChild], 2)
+
+		heroFriends := ftv1Fields(t, hero[ftv1NodeChild][1].([]byte))
+		assert.Equal(t, []interface{}{[]byte("heroFriends")}, heroFriends[ftv1NodeResponseName])
+		assert.Equal(t, []interface{}{[]byte("friends")}, heroFriends[ftv1NodeOriginalFieldName])
+		require.Len(t, heroFriends[ftv1NodeChild], 2)
+
+		friend := ftv1Fields(t, heroFriends[ftv1NodeChild][1].([]byte))
+		assert.Equal(t, []interface{}{uint64(1)}, friend[ftv1NodeIndex])
+		require.Len(t, friend[ftv1NodeChild], 1)
+
+		queryPlan := ftv1Fields(t, trace[ftv1TraceQueryPlan][0].([]byte))
+		sequence := ftv1Fields(t, queryPlan[ftv1QueryPlanSequence][0].([]byte))
+		require.Len(t, sequence[ftv1SequenceNodes], 1)
+		node := ftv1Fields(t, sequence[ftv1SequenceNodes][0].([]byte))
+		fetch := ftv1Fields(t, node[ftv1QueryPlanFetch][0].([]byte))
+		assert.Equal(t, []interface{}{[]byte("http://heroes.service")}, fetch[ftv1FetchServiceName])
+		assert.Equal(t, []interface{}{upstreamTrace}, fetch[ftv1FetchTrace])
+		assert.Nil(t, fetch[ftv1FetchTraceParsingFailed])
+	})
+
+	t.Run("without tracing", func(t *testing.T) {
+		dataSource := &headerRecordingDataSource{data: upstreamResponse}
+		buf := &bytes.Buffer{}
+		err := newResolver(context.Background(), false, false).ResolveGraphQLResponse(NewContext(context.Background()), heroResponse(dataSource, false), nil, buf)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"hero":{"name":"R2-D2","heroFriends":[{"name":"Luke"},{"name":"Leia"}]}}}`, buf.String())
+		assert.Equal(t, "", dataSource.header.Get(tracing.HeaderIncludeTrace))
+	})
+}
+
+func TestExecutionTrace_FTV1_FlattensNestedFetches(t *testing.T) {
+	trace := newExecutionTrace(TraceFormatFTV1)
+	fetchTrace := trace.startFetch([][]byte{[]byte("users"), []byte("1")}, &SingleFetch{
+		ProcessResponseConfig: ProcessResponseConfig{UpstreamServiceName: "reviews"},
+	}, nil)
+	trace.endFetch(fetchTrace, []byte(`{"data":{},"extensions":{"ftv1":"not base64"}}`))
+	assert.True(t, fetchTrace.UpstreamTraceFailed)
+
+	queryPlan := ftv1Fields(t, trace.ftv1QueryPlan())
+	sequence := ftv1Fields(t, queryPlan[ftv1QueryPlanSequence][0].([]byte))
+	node := ftv1Fields(t, sequence[ftv1SequenceNodes][0].([]byte))
+	flatten := ftv1Fields(t, node[ftv1QueryPlanFlatten][0].([]byte))
+
+	require.Len(t, flatten[ftv1FlattenResponsePath], 2)
+	assert.Equal(t, []interface{}{[]byte("users")}, ftv1Fields(t, flatten[ftv1FlattenResponsePath][0].([]byte))[ftv1PathElementFieldName])
+	assert.Equal(t, []interface{}{uint64(1)}, ftv1Fields(t, flatten[ftv1FlattenResponsePath][1].([]byte))[ftv1PathElementIndex])
+
+	fetch := ftv1Fields(t, ftv1Fields(t, flatten[ftv1FlattenNode][0].([]byte))[ftv1QueryPlanFetch][0].([]byte))
+	assert.Equal(t, []interface{}{[]byte("reviews")}, fetch[ftv1FetchServiceName])
+	assert.Equal(t, []interface{}{uint64(1)}, fetch[ftv1FetchTraceParsingFailed])
+}
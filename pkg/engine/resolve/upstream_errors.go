@@ -0,0 +1,258 @@
+package resolve
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/pvormste/graphql-go-tools/pkg/pool"
+)
+
+// UpstreamErrorPolicy defines how the errors returned by an upstream are added to the response.
+type UpstreamErrorPolicy int
+
+const (
+	// UpstreamErrorPolicyPassThrough adds the upstream errors with their paths rewritten to response paths.
+	UpstreamErrorPolicyPassThrough UpstreamErrorPolicy = iota
+	// UpstreamErrorPolicyWrap adds a single error per fetch naming the upstream service,
+	// the upstream errors are added to its extensions.
+	UpstreamErrorPolicyWrap
+	// UpstreamErrorPolicyMask replaces the messages of the upstream errors and drops their extensions.
+	UpstreamErrorPolicyMask
+)
+
+var (
+	maskedUpstreamErrorMsg = []byte("Internal server error")
+	literalUpstreamErrors  = []byte("errors")
+)
+
+// mergeUpstreamErrors merges the errors of the fetch writing into the buffer with the given id into objectBuf.
+// Upstream error paths refer to the upstream query, so they are rewritten to the response path using the
+// fields of the object resolved from the buffer. This way aliases, list indices and _entities match the
+// operation of the client.
+func (r *Resolver) mergeUpstreamErrors(ctx *Context, object *Object, bufferID int, fetchBuf, objectBuf *BufPair) {
+	if !fetchBuf.HasErrors() {
+		return
+	}
+
+	fetch := singleFetchByBufferID(object.Fetch, bufferID)
+	if fetch == nil {
+		r.MergeBufPairErrors(fetchBuf, objectBuf)
+		return
+	}
+
+	r.mergeFetchUpstreamErrors(ctx, fetch, object, object, bufferID, fetchBuf, objectBuf)
+}
+
+// mergePatchUpstreamErrors merges the errors of the fetch of a patch into buf.
+// The value of the patch is resolved from the fetch response, so upstream error paths are mapped from the value.
+func (r *Resolver) mergePatchUpstreamErrors(ctx *Context, patch *GraphQLResponsePatch, bufferID int, fetchBuf, buf *BufPair) {
+	if !fetchBuf.HasErrors() {
+		return
+	}
+
+	fetch := singleFetchByBufferID(patch.Fetch, bufferID)
+	if fetch == nil {
+		r.MergeBufPairErrors(fetchBuf, buf)
+		return
+	}
+
+	r.mergeFetchUpstreamErrors(ctx, fetch, patch.Value, nil, bufferID, fetchBuf, buf)
+}
+
+// mergeFetchUpstreamErrors applies the upstream error policy of the fetch and merges its errors into objectBuf.
+// Upstream error paths are mapped starting at root, object is the object holding the fetch, it's nil for patches.
+func (r *Resolver) mergeFetchUpstreamErrors(ctx *Context, fetch *SingleFetch, root Node, object *Object, bufferID int, fetchBuf, objectBuf *BufPair) {
+	upstreamErrors := pool.BytesBuffer.Get()
+	defer pool.BytesBuffer.Put(upstreamErrors)
+
+	upstreamErrors.Write(lBrack)
+	upstreamErrors.Write(fetchBuf.Errors.Bytes())
+	upstreamErrors.Write(rBrack)
+	fetchBuf.Errors.Reset()
+
+	rewritten := r.getBufPair()
+	defer r.freeBufPair(rewritten)
+
+	_, _ = jsonparser.ArrayEach(upstreamErrors.Bytes(), func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		var (
+			message, locations, path, extensions []byte
+		)
+		jsonparser.EachKey(value, func(i int, bytes []byte, valueType jsonparser.ValueType, err error) {
+			switch i {
+			case errorsMessagePathIndex:
+				message = bytes
+			case errorsLocationsPathIndex:
+				locations = bytes
+			case errorsPathPathIndex:
+				path = bytes
+			case errorsExtensionsPathIndex:
+				extensions = bytes
+			}
+		}, errorPaths...)
+		if message != nil {
+			r.writeUpstreamError(ctx, root, object, bufferID, fetch.ProcessResponseConfig, message, locations, path, extensions, rewritten)
+		}
+	})
+
+	if fetch.ProcessResponseConfig.UpstreamErrorPolicy == UpstreamErrorPolicyWrap && rewritten.HasErrors() {
+		r.writeWrappedUpstreamErrors(ctx, fetch.ProcessResponseConfig.UpstreamServiceName, rewritten, objectBuf)
+		return
+	}
+
+	r.MergeBufPairErrors(rewritten, objectBuf)
+}
+
+func (r *Resolver) writeUpstreamError(ctx *Context, root Node, object *Object, bufferID int, config ProcessResponseConfig, message, locations, path, extensions []byte, buf *BufPair) {
+	responsePath, responseLocations := pool.BytesBuffer.Get(), pool.BytesBuffer.Get()
+	defer pool.BytesBuffer.Put(responsePath)
+	defer pool.BytesBuffer.Put(responseLocations)
+
+	if path != nil {
+		elements, field := upstreamErrorPathElements(root, object, bufferID, config.ExtractFederationEntities, path)
+		if writePathElements(responsePath, append(ctx.errorPathElements(), elements...)) {
+			path = responsePath.Bytes()
+		} else {
+			path = nil
+		}
+		if field != nil && field.Position.Line != 0 {
+			writeFieldLocations(responseLocations, field)
+			locations = responseLocations.Bytes()
+		}
+	}
+
+	if config.UpstreamErrorPolicy == UpstreamErrorPolicyMask {
+		message = maskedUpstreamErrorMsg
+		extensions = nil
+	}
+
+	buf.WriteErr(message, locations, path, extensions)
+}
+
+// writeWrappedUpstreamErrors writes a single error for all upstream errors, the upstream errors are added to its extensions.
+func (r *Resolver) writeWrappedUpstreamErrors(ctx *Context, serviceName string, upstreamErrors, buf *BufPair) {
+	path, extensions := pool.BytesBuffer.Get(), pool.BytesBuffer.Get()
+	defer pool.BytesBuffer.Put(path)
+	defer pool.BytesBuffer.Put(extensions)
+
+	text := "Failed to fetch from subgraph."
+	if serviceName != "" {
+		text = fmt.Sprintf("Failed to fetch from subgraph '%s'.", serviceName)
+	}
+	message, _ := json.Marshal(text)
+
+	var pathBytes []byte
+	if ctx.writeErrorPath(path) {
+		pathBytes = path.Bytes()
+	}
+
+	extensions.Write(lBrace)
+	extensions.Write(quote)
+	extensions.Write(literalUpstreamErrors)
+	extensions.Write(quote)
+	extensions.Write(colon)
+	extensions.Write(lBrack)
+	extensions.Write(upstreamErrors.Errors.Bytes())
+	extensions.Write(rBrack)
+	extensions.Write(rBrace)
+	upstreamErrors.Errors.Reset()
+
+	buf.WriteErr(message[1:len(message)-1], nil, pathBytes, extensions.Bytes())
+}
+
+// upstreamErrorPathElements maps the elements of an upstream error path to response path elements.
+// Field names are replaced by the response names of the fields resolved from them, the leading _entities
+// element and index of entity fetches are removed as they refer to the object itself.
+// Elements which can't be mapped are kept as is. The returned field is the one the path ends at,
+// it's nil if the path couldn't be mapped completely.
+// The mapping starts at root, which is the object holding the fetch or the value of a patch.
+func upstreamErrorPathElements(root Node, object *Object, bufferID int, entities bool, path []byte) (elements [][]byte, field *Field) {
+	var upstream [][]byte
+	_, _ = jsonparser.ArrayEach(path, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		upstream = append(upstream, value)
+	})
+
+	if entities && len(upstream) >= 2 && string(upstream[0]) == entitiesPath[0] && isListIndex(upstream[1]) {
+		upstream = upstream[2:]
+	}
+
+	if object == nil {
+		// the value of a patch is resolved from its own path of the fetch response
+		if rootPath := nodePath(root); upstreamPathHasPrefix(upstream, rootPath) {
+			upstream = upstream[len(rootPath):]
+		}
+	}
+
+	node := root
+	for len(upstream) != 0 {
+		switch n := node.(type) {
+		case *Object:
+			next := upstreamErrorPathField(n, object != nil && n == object, bufferID, upstream)
+			if next == nil {
+				return append(elements, upstream...), nil
+			}
+			elements = append(elements, next.Name)
+			upstream = upstream[len(nodePath(next.Value)):]
+			node = next.Value
+			field = next
+		case *Array:
+			if !isListIndex(upstream[0]) {
+				return append(elements, upstream...), nil
+			}
+			elements = append(elements, upstream[0])
+			upstream = upstream[1:]
+			node = n.Item
+		default:
+			return append(elements, upstream...), nil
+		}
+	}
+
+	return elements, field
+}
+
+// upstreamErrorPathField returns the field of the object which resolves the value at the start of the upstream path.
+// Fields of the root object must be resolved from the buffer, fields of nested objects must not be resolved from another fetch.
+func upstreamErrorPathField(object *Object, isRoot bool, bufferID int, upstream [][]byte) *Field {
+	for _, field := range object.Fields {
+		if isRoot != field.HasBuffer || (isRoot && field.BufferID != bufferID) {
+			continue
+		}
+		valuePath := nodePath(field.Value)
+		if len(valuePath) != 0 && upstreamPathHasPrefix(upstream, valuePath) {
+			return field
+		}
+	}
+	return nil
+}
+
+func upstreamPathHasPrefix(upstream [][]byte, prefix []string) bool {
+	if len(prefix) > len(upstream) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != string(upstream[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func nodePath(node Node) []string {
+	switch n := node.(type) {
+	case *Object:
+		return n.Path
+	case *Array:
+		return n.Path
+	case *String:
+		return n.Path
+	case *Boolean:
+		return n.Path
+	case *Integer:
+		return n.Path
+	case *Float:
+		return n.Path
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,233 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/pkg/lexer/literal"
+)
+
+func TestResolver_UpstreamErrors(t *testing.T) {
+	// the upstream selects user { friends { firstName } } for the operation { me { friends { name: firstName } } }
+	userResponse := func(upstreamResponse string, config ProcessResponseConfig) *GraphQLResponse {
+		config.ExtractGraphqlResponse = true
+		return &GraphQLResponse{
+			Data: &Object{
+				Fetch: &SingleFetch{
+					BufferId:              0,
+					DataSource:            FakeDataSource(upstreamResponse),
+					ProcessResponseConfig: config,
+				},
+				Fields: []*Field{
+					{
+						BufferID:  0,
+						HasBuffer: true,
+						Name:      []byte("me"),
+						Position:  Position{Line: 2, Column: 3},
+						Value: &Object{
+							Path:     []string{"user"},
+							Nullable: true,
+							Fields: []*Field{
+								{
+									Name:     []byte("friends"),
+									Position: Position{Line: 3, Column: 5},
+									Value: &Array{
+										Path:     []string{"friends"},
+										Nullable: true,
+										Item: &Object{
+											Nullable: true,
+											Fields: []*Field{
+												{
+													Name:     []byte("name"),
+													Position: Position{Line: 4, Column: 7},
+													Value: &String{
+														Path:     []string{"firstName"},
+														Nullable: true,
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	run := func(response *GraphQLResponse, expectedOutput string) func(t *testing.T) {
+		return func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			resolver := newResolver(ctx, false, false)
+
+			buf := &bytes.Buffer{}
+			err := resolver.ResolveGraphQLResponse(&Context{Context: context.Background()}, response, nil, buf)
+			require.NoError(t, err)
+			assert.Equal(t, expectedOutput, buf.String())
+		}
+	}
+
+	const friendsResponse = `{"errors":[{"message":"could not get first name","locations":[{"line":1,"column":24}],"path":["user","friends",1,"firstName"],"extensions":{"code":"NOT_FOUND"}}],"data":{"user":{"friends":[{"firstName":"Jens"},{"firstName":null}]}}}`
+	const friendsData = `"data":{"me":{"friends":[{"name":"Jens"},{"name":null}]}}`
+
+	t.Run("rewrites the upstream path and locations", run(userResponse(friendsResponse, ProcessResponseConfig{}),
+		`{"errors":[{"message":"could not get first name","locations":[{"line":4,"column":7}],"path":["me","friends",1,"name"],"extensions":{"code":"NOT_FOUND"}}],`+friendsData+`}`,
+	))
+	t.Run("keeps path elements which can't be mapped", run(userResponse(`{"errors":[{"message":"unknown","locations":[{"line":1,"column":2}],"path":["user","unknown"]}],"data":{"user":null}}`, ProcessResponseConfig{}),
+		`{"errors":[{"message":"unknown","locations":[{"line":1,"column":2}],"path":["me","unknown"]}],"data":{"me":null}}`,
+	))
+	t.Run("keeps errors without path", run(userResponse(`{"errors":[{"message":"unauthorized"}],"data":null}`, ProcessResponseConfig{}),
+		`{"errors":[{"message":"unauthorized"}],"data":{"me":null}}`,
+	))
+	t.Run("masks upstream errors", run(userResponse(friendsResponse, ProcessResponseConfig{UpstreamErrorPolicy: UpstreamErrorPolicyMask}),
+		`{"errors":[{"message":"Internal server error","locations":[{"line":4,"column":7}],"path":["me","friends",1,"name"]}],`+friendsData+`}`,
+	))
+	t.Run("wraps upstream errors", run(userResponse(friendsResponse, ProcessResponseConfig{UpstreamErrorPolicy: UpstreamErrorPolicyWrap, UpstreamServiceName: "users"}),
+		`{"errors":[{"message":"Failed to fetch from subgraph 'users'.","extensions":{"errors":[{"message":"could not get first name","locations":[{"line":4,"column":7}],"path":["me","friends",1,"name"],"extensions":{"code":"NOT_FOUND"}}]}}],`+friendsData+`}`,
+	))
+	t.Run("doesn't wrap responses without errors", run(userResponse(`{"data":{"user":null}}`, ProcessResponseConfig{UpstreamErrorPolicy: UpstreamErrorPolicyWrap, UpstreamServiceName: "users"}),
+		`{"data":{"me":null}}`,
+	))
+
+	t.Run("applies the upstream error policy to patches", func(t *testing.T) {
+		patch := func(config ProcessResponseConfig) *GraphQLResponsePatch {
+			config.ExtractGraphqlResponse = true
+			return &GraphQLResponsePatch{
+				Operation: literal.REPLACE,
+				Fetch: &SingleFetch{
+					BufferId:              0,
+					DataSource:            FakeDataSource(friendsResponse),
+					ProcessResponseConfig: config,
+				},
+				Value: &Array{
+					Path:     []string{"user", "friends"},
+					Nullable: true,
+					Item: &Object{
+						Nullable: true,
+						Fields: []*Field{
+							{
+								Name:     []byte("name"),
+								Position: Position{Line: 4, Column: 7},
+								Value: &String{
+									Path:     []string{"firstName"},
+									Nullable: true,
+								},
+							},
+						},
+					},
+				},
+			}
+		}
+
+		runPatch := func(t *testing.T, patch *GraphQLResponsePatch, expectedOutput string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			resolver := newResolver(ctx, false, false)
+
+			buf := &bytes.Buffer{}
+			err := resolver.ResolveGraphQLResponsePatch(&Context{Context: context.Background()}, patch, nil, []byte("/data/me/friends"), nil, buf)
+			require.NoError(t, err)
+			assert.Equal(t, expectedOutput, buf.String())
+		}
+
+		const friendsValue = `[{"name":"Jens"},{"name":null}]`
+
+		runPatch(t, patch(ProcessResponseConfig{}),
+			`{"op":"replace","path":"/data/me/friends","value":`+friendsValue+`,"errors":[{"message":"could not get first name","locations":[{"line":4,"column":7}],"path":["me","friends",1,"name"],"extensions":{"code":"NOT_FOUND"}}]}`,
+		)
+		runPatch(t, patch(ProcessResponseConfig{UpstreamErrorPolicy: UpstreamErrorPolicyMask}),
+			`{"op":"replace","path":"/data/me/friends","value":`+friendsValue+`,"errors":[{"message":"Internal server error","locations":[{"line":4,"column":7}],"path":["me","friends",1,"name"]}]}`,
+		)
+		runPatch(t, patch(ProcessResponseConfig{UpstreamErrorPolicy: UpstreamErrorPolicyWrap, UpstreamServiceName: "users"}),
+			`{"op":"replace","path":"/data/me/friends","value":`+friendsValue+`,"errors":[{"message":"Failed to fetch from subgraph 'users'.","path":["me","friends"],"extensions":{"errors":[{"message":"could not get first name","locations":[{"line":4,"column":7}],"path":["me","friends",1,"name"],"extensions":{"code":"NOT_FOUND"}}]}}]}`,
+		)
+	})
+
+	t.Run("rewrites _entities paths to the path of the entity", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		batch := &_fakeDataSourceBatch{
+			resultedInput: NewBufPair().Data,
+			bufPairs:      []*BufPair{NewBufPair()},
+		}
+		batch.bufPairs[0].Data.WriteString(`{"reviews":[{"body":"great"},{"body":null}]}`)
+		batch.bufPairs[0].Errors.WriteString(`{"message":"could not get body","path":["_entities",0,"reviews",1,"body"]}`)
+
+		batchFactory := NewMockDataSourceBatchFactory(ctrl)
+		batchFactory.EXPECT().CreateBatch(gomock.Any()).Return(batch, nil)
+
+		response := &GraphQLResponse{
+			Data: &Object{
+				Fetch: &SingleFetch{
+					BufferId:              0,
+					DataSource:            FakeDataSource(`{"data":{"me":{"id":"1"}}}`),
+					ProcessResponseConfig: ProcessResponseConfig{ExtractGraphqlResponse: true},
+				},
+				Fields: []*Field{
+					{
+						BufferID:  0,
+						HasBuffer: true,
+						Name:      []byte("me"),
+						Value: &Object{
+							Path: []string{"me"},
+							Fetch: &BatchFetch{
+								Fetch: &SingleFetch{
+									BufferId:   1,
+									DataSource: FakeDataSource(`{}`),
+									ProcessResponseConfig: ProcessResponseConfig{
+										ExtractGraphqlResponse:    true,
+										ExtractFederationEntities: true,
+										UpstreamServiceName:       "reviews",
+									},
+								},
+								BatchFactory: batchFactory,
+							},
+							Fields: []*Field{
+								{
+									Name: []byte("id"),
+									Value: &String{
+										Path: []string{"id"},
+									},
+								},
+								{
+									BufferID:  1,
+									HasBuffer: true,
+									Name:      []byte("reviews"),
+									Position:  Position{Line: 4, Column: 5},
+									Value: &Array{
+										Path:     []string{"reviews"},
+										Nullable: true,
+										Item: &Object{
+											Nullable: true,
+											Fields: []*Field{
+												{
+													Name:     []byte("text"),
+													Position: Position{Line: 5, Column: 7},
+													Value: &String{
+														Path:     []string{"body"},
+														Nullable: true,
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		run(response, `{"errors":[{"message":"could not get body","locations":[{"line":5,"column":7}],"path":["me","reviews",1,"text"]}],"data":{"me":{"id":"1","reviews":[{"text":"great"},{"text":null}]}}}`)(t)
+	})
+}
@@ -0,0 +1,95 @@
+// Package opentelemetry adapts OpenTelemetry tracers to tracing.Tracer.
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pvormste/graphql-go-tools/pkg/engine/tracing"
+)
+
+const InstrumentationName = "github.com/pvormste/graphql-go-tools"
+
+// Tracer emits the spans of the ExecutionEngineV2 as OpenTelemetry spans.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+type Option func(tracer *Tracer)
+
+// WithPropagator replaces the W3C trace context propagator used for upstream requests.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(tracer *Tracer) {
+		tracer.propagator = propagator
+	}
+}
+
+// NewTracer returns a Tracer which starts spans with a tracer of provider.
+func NewTracer(provider trace.TracerProvider, opts ...Option) *Tracer {
+	tracer := &Tracer{
+		tracer:     provider.Tracer(InstrumentationName),
+		propagator: propagation.TraceContext{},
+	}
+	for _, opt := range opts {
+		opt(tracer)
+	}
+	return tracer
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, attributes ...tracing.Attribute) (context.Context, tracing.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(convertAttributes(attributes)...))
+	return ctx, &Span{
+		span:       span,
+		ctx:        ctx,
+		propagator: t.propagator,
+	}
+}
+
+// Span wraps an OpenTelemetry span.
+type Span struct {
+	span       trace.Span
+	ctx        context.Context
+	propagator propagation.TextMapPropagator
+}
+
+func (s *Span) SetAttributes(attributes ...tracing.Attribute) {
+	s.span.SetAttributes(convertAttributes(attributes)...)
+}
+
+func (s *Span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *Span) End() {
+	s.span.End()
+}
+
+// InjectHeader adds the traceparent header of the span to the header of an upstream request.
+func (s *Span) InjectHeader(header http.Header) {
+	s.propagator.Inject(s.ctx, propagation.HeaderCarrier(header))
+}
+
+func convertAttributes(attributes []tracing.Attribute) []attribute.KeyValue {
+	converted := make([]attribute.KeyValue, 0, len(attributes))
+	for _, attr := range attributes {
+		switch value := attr.Value.(type) {
+		case string:
+			converted = append(converted, attribute.String(attr.Key, value))
+		case bool:
+			converted = append(converted, attribute.Bool(attr.Key, value))
+		case int:
+			converted = append(converted, attribute.Int(attr.Key, value))
+		default:
+			converted = append(converted, attribute.String(attr.Key, fmt.Sprint(value)))
+		}
+	}
+	return converted
+}
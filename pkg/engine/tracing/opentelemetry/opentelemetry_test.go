@@ -0,0 +1,55 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pvormste/graphql-go-tools/pkg/engine/tracing"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := NewTracer(provider)
+
+	ctx, resolveSpan := tracing.Start(context.Background(), tracer, tracing.SpanResolve, tracing.String(tracing.AttributeOperationName, "Hello"))
+	fetchCtx, fetchSpan := tracing.Start(ctx, tracer, tracing.SpanFetch, tracing.String(tracing.AttributeDataSourceID, "graphql_datasource.Source"))
+	fetchSpan.SetAttributes(tracing.Bool(tracing.AttributeSingleFlight, true), tracing.Int("retries", 1))
+
+	header := http.Header{}
+	tracing.InjectHeader(fetchCtx, header)
+
+	tracing.End(fetchSpan, errors.New("upstream unavailable"))
+	tracing.End(resolveSpan, nil)
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 2)
+
+	fetch, resolve := ended[0], ended[1]
+	assert.Equal(t, tracing.SpanFetch, fetch.Name())
+	assert.Equal(t, resolve.SpanContext().SpanID(), fetch.Parent().SpanID())
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String(tracing.AttributeDataSourceID, "graphql_datasource.Source"),
+		attribute.Bool(tracing.AttributeSingleFlight, true),
+		attribute.Int("retries", 1),
+	}, fetch.Attributes())
+	assert.Equal(t, codes.Error, fetch.Status().Code)
+	assert.Equal(t, "upstream unavailable", fetch.Status().Description)
+	assert.Len(t, fetch.Events(), 1)
+
+	assert.Equal(t, tracing.SpanResolve, resolve.Name())
+	assert.Equal(t, codes.Unset, resolve.Status().Code)
+
+	traceParent := fmt.Sprintf("00-%s-%s-01", fetch.SpanContext().TraceID(), fetch.SpanContext().SpanID())
+	assert.Equal(t, traceParent, header.Get("traceparent"))
+}
@@ -0,0 +1,129 @@
+// Package tracing defines the spans emitted while executing an operation.
+//
+// The ExecutionEngineV2 emits a span for each phase of the execution: parse, normalize, validate, plan and resolve.
+// While resolving, every fetch gets its own span which is propagated to upstream requests of the httpclient.
+// Implement Tracer to connect any tracing system, package opentelemetry contains an adapter for OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	SpanParse     = "graphql.parse"
+	SpanNormalize = "graphql.normalize"
+	SpanValidate  = "graphql.validate"
+	SpanPlan      = "graphql.plan"
+	SpanResolve   = "graphql.resolve"
+	SpanFetch     = "graphql.fetch"
+)
+
+const (
+	AttributeOperationName = "graphql.operation.name"
+	AttributeOperationType = "graphql.operation.type"
+	AttributePlanCacheHit  = "graphql.plan.cache_hit"
+	AttributeDataSourceID  = "graphql.fetch.data_source_id"
+	AttributeUpstreamURL   = "graphql.fetch.upstream_url"
+	AttributeSingleFlight  = "graphql.fetch.single_flight"
+)
+
+const (
+	// HeaderIncludeTrace asks a federated subgraph to add an Apollo Federated Tracing (ftv1) trace to the extensions of its response.
+	HeaderIncludeTrace     = "apollo-federation-include-trace"
+	HeaderIncludeTraceFTV1 = "ftv1"
+)
+
+// Tracer starts spans, implementations must be safe for concurrent use.
+type Tracer interface {
+	// Start starts a span which is a child of the span in ctx, if there is one.
+	// The returned context must contain the new span.
+	Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// HeaderPropagator is implemented by spans which propagate their trace context to upstream requests,
+// e.g. with the W3C traceparent header.
+type HeaderPropagator interface {
+	InjectHeader(header http.Header)
+}
+
+// Attribute is a key value pair describing a span, Value is either a string, bool or int.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+type spanContextKey struct{}
+
+type federatedTracingContextKey struct{}
+
+// Start starts a span with tracer and keeps it in the returned context, so InjectHeader can propagate it.
+// If tracer is nil, the span does nothing.
+func Start(ctx context.Context, tracer Tracer, name string, attributes ...Attribute) (context.Context, Span) {
+	if tracer == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := tracer.Start(ctx, name, attributes...)
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// SpanFromContext returns the span started last with Start.
+func SpanFromContext(ctx context.Context) (span Span, ok bool) {
+	span, ok = ctx.Value(spanContextKey{}).(Span)
+	return span, ok
+}
+
+// WithFederatedTracing makes InjectHeader request an ftv1 trace from the upstream.
+func WithFederatedTracing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, federatedTracingContextKey{}, true)
+}
+
+// InjectHeader adds the trace context of the span in ctx to the header of an upstream request.
+// If ctx has been created with WithFederatedTracing, the header requesting an ftv1 trace is added as well.
+func InjectHeader(ctx context.Context, header http.Header) {
+	if federatedTracing, _ := ctx.Value(federatedTracingContextKey{}).(bool); federatedTracing {
+		header.Set(HeaderIncludeTrace, HeaderIncludeTraceFTV1)
+	}
+
+	span, ok := SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	if propagator, ok := span.(HeaderPropagator); ok {
+		propagator.InjectHeader(header)
+	}
+}
+
+// End records err, if it isn't nil, and ends the span.
+func End(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(_ ...Attribute) {}
+
+func (noopSpan) RecordError(_ error) {}
+
+func (noopSpan) End() {}
@@ -27,7 +27,7 @@ type schemaBuilder struct {
 
 // BuildFederationSchema takes a baseSchema plus the service sdl and turns it into a fully compliant federation schema
 func (s *schemaBuilder) buildFederationSchema(baseSchema, serviceSDL string) (string, error) {
-	unionTypes := s.entityUnionTypes(serviceSDL)
+	unionTypes := s.entityUnionTypes(baseSchema, serviceSDL)
 	if len(unionTypes) == 0 {
 		return baseSchema, nil
 	}
@@ -107,7 +107,10 @@ func (s *schemaBuilder) extendQueryType(doc *ast.Document, ref int) {
 // _entities(representations: [_Any!]!): [_Entity]!
 // _service: _Service!
 
-func (s *schemaBuilder) entityUnionTypes(serviceSDL string) []string {
+// entityUnionTypes returns the entities of the service sdl.
+// The entity interfaces of the service sdl can't be members of the _Entity union,
+// so the object types of the base schema implementing them are added instead.
+func (s *schemaBuilder) entityUnionTypes(baseSchema, serviceSDL string) []string {
 	doc := ast.NewDocument()
 	doc.Input.ResetInputString(serviceSDL)
 	parser := astparser.NewParser()
@@ -116,22 +119,49 @@ func (s *schemaBuilder) entityUnionTypes(serviceSDL string) []string {
 	if report.HasErrors() {
 		return nil
 	}
+	if _, err := sdlmerge.NormalizeFederationV2Directives(doc); err != nil {
+		return nil
+	}
 
 	walker := astvisitor.NewWalker(4)
 	visitor := &schemaBuilderVisitor{}
 	walker.RegisterEnterDocumentVisitor(visitor)
 	walker.RegisterEnterObjectTypeDefinitionVisitor(visitor)
 	walker.RegisterEnterObjectTypeExtensionVisitor(visitor)
+	walker.RegisterEnterInterfaceTypeDefinitionVisitor(visitor)
+	walker.RegisterEnterInterfaceTypeExtensionVisitor(visitor)
 	walker.Walk(doc, nil, report)
 	if report.HasErrors() {
 		return nil
 	}
+	if len(visitor.entityInterfaces) != 0 {
+		s.addImplementingTypes(visitor, baseSchema)
+	}
 	return visitor.entityUnionTypes
 }
 
+func (s *schemaBuilder) addImplementingTypes(visitor *schemaBuilderVisitor, baseSchema string) {
+	doc := ast.NewDocument()
+	doc.Input.ResetInputString(baseSchema)
+	parser := astparser.NewParser()
+	report := &operationreport.Report{}
+	parser.Parse(doc, report)
+	if report.HasErrors() {
+		return
+	}
+	for _, entityInterface := range visitor.entityInterfaces {
+		for ref := range doc.ObjectTypeDefinitions {
+			if doc.ObjectTypeDefinitionImplementsInterface(ref, []byte(entityInterface)) {
+				visitor.addEntity(doc.ObjectTypeDefinitionNameString(ref))
+			}
+		}
+	}
+}
+
 type schemaBuilderVisitor struct {
 	definition       *ast.Document
 	entityUnionTypes []string
+	entityInterfaces []string
 }
 
 func (s *schemaBuilderVisitor) addEntity(entity string) {
@@ -163,6 +193,22 @@ func (s *schemaBuilderVisitor) EnterObjectTypeDefinition(ref int) {
 	}
 }
 
+func (s *schemaBuilderVisitor) EnterInterfaceTypeDefinition(ref int) {
+	for _, i := range s.definition.InterfaceTypeDefinitions[ref].Directives.Refs {
+		if s.definition.DirectiveNameString(i) == "key" {
+			s.entityInterfaces = append(s.entityInterfaces, s.definition.InterfaceTypeDefinitionNameString(ref))
+		}
+	}
+}
+
+func (s *schemaBuilderVisitor) EnterInterfaceTypeExtension(ref int) {
+	for _, i := range s.definition.InterfaceTypeExtensions[ref].Directives.Refs {
+		if s.definition.DirectiveNameString(i) == "key" {
+			s.entityInterfaces = append(s.entityInterfaces, s.definition.InterfaceTypeExtensionNameString(ref))
+		}
+	}
+}
+
 const federationTemplate = `
 
 scalar _Any
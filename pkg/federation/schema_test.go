@@ -12,6 +12,44 @@ func TestSchemaBuilder_BuildFederationSchema(t *testing.T) {
 	assert.Equal(t, federatedSchema, actual)
 }
 
+func TestSchemaBuilder_BuildFederationSchema_EntityInterface(t *testing.T) {
+	actual, err := BuildFederationSchema(entityInterfaceBaseSchema, entityInterfaceServiceSDL)
+	assert.NoError(t, err)
+	assert.Contains(t, actual, "union _Entity = User | Admin")
+	assert.Contains(t, actual, "_entities(representations: [_Any!]!): [_Entity]!")
+}
+
+const entityInterfaceServiceSDL = `type Review { body: String! } extend interface Account @key(fields: "id") { id: ID! @external reviews: [Review] }`
+
+const entityInterfaceBaseSchema = `
+schema {
+	query: Query
+}
+
+type Query {
+  accounts: [Account]
+}
+
+interface Account {
+  id: ID!
+  reviews: [Review]
+}
+
+type User implements Account {
+  id: ID!
+  reviews: [Review]
+}
+
+type Admin implements Account {
+  id: ID!
+  reviews: [Review]
+}
+
+type Review {
+  body: String!
+}
+`
+
 const serviceSDL = `extend type Query {topProducts(first: Int = 5): [Product]}type Product @key(fields: "upc") {upc: String!name: String! price: Int!} extend type Query {me: User} type User @key(fields: "id"){ id: ID! username: String!} type Review { body: String! author: User! @provides(fields: "username") product: Product! } extend type User @key(fields: "id") { id: ID! @external reviews: [Review] } extend type Product @key(fields: "upc") { upc: String! @external reviews: [Review] }`
 
 const baseSchema = `
@@ -0,0 +1,74 @@
+package sdlmerge
+
+import (
+	"bytes"
+
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/astvisitor"
+)
+
+// newAddEntityInterfaceFieldsVisitor adds the fields of an entity interface to the object types implementing it.
+// A subgraph might add fields to an entity interface, e.g.
+// extend interface Account @key(fields: "id") { id: ID! @external reviews: [Review] },
+// without knowing the object types implementing the interface in another subgraph.
+func newAddEntityInterfaceFieldsVisitor(collectedEntities entitySet) *addEntityInterfaceFieldsVisitor {
+	return &addEntityInterfaceFieldsVisitor{
+		collectedEntities: collectedEntities,
+	}
+}
+
+type addEntityInterfaceFieldsVisitor struct {
+	document          *ast.Document
+	collectedEntities entitySet
+}
+
+func (a *addEntityInterfaceFieldsVisitor) Register(walker *astvisitor.Walker) {
+	walker.RegisterEnterDocumentVisitor(a)
+	walker.RegisterLeaveDocumentVisitor(a)
+}
+
+func (a *addEntityInterfaceFieldsVisitor) EnterDocument(operation, _ *ast.Document) {
+	a.document = operation
+}
+
+func (a *addEntityInterfaceFieldsVisitor) LeaveDocument(_, _ *ast.Document) {
+	for _, node := range a.document.RootNodes {
+		if node.Kind != ast.NodeKindInterfaceTypeDefinition {
+			continue
+		}
+		interfaceName := a.document.InterfaceTypeDefinitionNameBytes(node.Ref)
+		if _, isEntity := a.collectedEntities[string(interfaceName)]; !isEntity {
+			continue
+		}
+		for _, objectNode := range a.document.RootNodes {
+			if objectNode.Kind != ast.NodeKindObjectTypeDefinition || !a.document.ObjectTypeDefinitionImplementsInterface(objectNode.Ref, interfaceName) {
+				continue
+			}
+			a.addMissingFields(objectNode.Ref, a.document.InterfaceTypeDefinitions[node.Ref].FieldsDefinition.Refs)
+		}
+	}
+}
+
+func (a *addEntityInterfaceFieldsVisitor) addMissingFields(objectTypeRef int, interfaceFieldRefs []int) {
+	objectType := &a.document.ObjectTypeDefinitions[objectTypeRef]
+	for _, fieldRef := range interfaceFieldRefs {
+		if a.document.FieldDefinitionHasNamedDirective(fieldRef, externalDirectiveName) {
+			continue
+		}
+		fieldName := a.document.FieldDefinitionNameBytes(fieldRef)
+		if a.hasField(objectType.FieldsDefinition.Refs, fieldName) {
+			continue
+		}
+		objectType.FieldsDefinition.Refs = append(objectType.FieldsDefinition.Refs, fieldRef)
+		objectType.HasFieldDefinitions = true
+	}
+}
+
+func (a *addEntityInterfaceFieldsVisitor) hasField(fieldRefs []int, fieldName ast.ByteSlice) bool {
+	for _, ref := range fieldRefs {
+		if bytes.Equal(a.document.FieldDefinitionNameBytes(ref), fieldName) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package sdlmerge
+
+import (
+	"testing"
+)
+
+func TestAddEntityInterfaceFields(t *testing.T) {
+	t.Run("fields of an entity interface are added to the implementing object types", func(t *testing.T) {
+		run(
+			t, newAddEntityInterfaceFieldsVisitor(entitySet{"Mammal": {}}),
+			`
+			interface Mammal {
+				name: String
+				favoriteToy: String
+			}
+			type Cat implements Mammal {
+				name: String
+			}
+			type Dog implements Mammal {
+				name: String
+				favoriteToy: String
+			}
+			`,
+			`
+			interface Mammal {
+				name: String
+				favoriteToy: String
+			}
+			type Cat implements Mammal {
+				name: String
+				favoriteToy: String
+			}
+			type Dog implements Mammal {
+				name: String
+				favoriteToy: String
+			}
+			`)
+	})
+	t.Run("external fields are not added to the implementing object types", func(t *testing.T) {
+		run(
+			t, newAddEntityInterfaceFieldsVisitor(entitySet{"Mammal": {}}),
+			`
+			interface Mammal {
+				name: String @external
+				favoriteToy: String
+			}
+			type Cat implements Mammal {
+				age: Int
+			}
+			`,
+			`
+			interface Mammal {
+				name: String @external
+				favoriteToy: String
+			}
+			type Cat implements Mammal {
+				age: Int
+				favoriteToy: String
+			}
+			`)
+	})
+	t.Run("fields of an interface which is no entity are not added", func(t *testing.T) {
+		run(
+			t, newAddEntityInterfaceFieldsVisitor(entitySet{}),
+			`
+			interface Mammal {
+				name: String
+			}
+			type Cat implements Mammal {
+				age: Int
+			}
+			`,
+			`
+			interface Mammal {
+				name: String
+			}
+			type Cat implements Mammal {
+				age: Int
+			}
+			`)
+	})
+}
@@ -0,0 +1,326 @@
+package sdlmerge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/astparser"
+	"github.com/pvormste/graphql-go-tools/pkg/astprinter"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+)
+
+const (
+	federationV2LinkURLPrefix = "https://specs.apollo.dev/federation/v2"
+	federationV2Namespace     = "federation"
+
+	linkDirectiveName         = "link"
+	extendsDirectiveName      = "extends"
+	externalDirectiveName     = "external"
+	shareableDirectiveName    = "shareable"
+	overrideDirectiveName     = "override"
+	inaccessibleDirectiveName = "inaccessible"
+	tagDirectiveName          = "tag"
+)
+
+// federationV2DirectiveNames are the directives of the federation v2 specification, which might be imported by @link
+var federationV2DirectiveNames = []string{
+	plan.FederationKeyDirectiveName, extendsDirectiveName, externalDirectiveName, "requires", "provides",
+	shareableDirectiveName, overrideDirectiveName, inaccessibleDirectiveName, tagDirectiveName,
+}
+
+var (
+	linkURLArgumentNameBytes    = []byte("url")
+	linkAsArgumentNameBytes     = []byte("as")
+	linkImportArgumentNameBytes = []byte("import")
+	linkImportNameFieldName     = "name"
+	linkImportAsFieldName       = "as"
+)
+
+// NormalizeFederationV2Directives detects a federation v2 subgraph by its
+// @link(url: "https://specs.apollo.dev/federation/v2.x") schema directive.
+// The directives of the federation specification are renamed to their
+// canonical names, e.g. an import {name: "@key", as: "@primaryKey"} turns
+// @primaryKey into @key and the not imported @federation__shareable turns into
+// @shareable. The @link directive itself is removed from the document.
+// It returns false if the document isn't a federation v2 subgraph.
+func NormalizeFederationV2Directives(document *ast.Document) (isFederationV2 bool, err error) {
+	directiveNames := make(map[string]string)
+
+	for _, node := range document.RootNodes {
+		if node.Kind != ast.NodeKindSchemaDefinition && node.Kind != ast.NodeKindSchemaExtension {
+			continue
+		}
+		var linkRefs []int
+		for _, directiveRef := range document.NodeDirectives(node) {
+			if document.DirectiveNameString(directiveRef) != linkDirectiveName {
+				continue
+			}
+			url, ok := directiveStringArgument(document, directiveRef, linkURLArgumentNameBytes)
+			if !ok || !strings.HasPrefix(url, federationV2LinkURLPrefix) {
+				continue
+			}
+			if err = collectLinkedDirectiveNames(document, directiveRef, directiveNames); err != nil {
+				return false, err
+			}
+			linkRefs = append(linkRefs, directiveRef)
+		}
+		if len(linkRefs) == 0 {
+			continue
+		}
+		isFederationV2 = true
+		removeSchemaDirectives(document, node, linkRefs)
+	}
+
+	if !isFederationV2 {
+		return false, nil
+	}
+
+	for ref := range document.Directives {
+		canonicalName, ok := directiveNames[document.DirectiveNameString(ref)]
+		if !ok {
+			continue
+		}
+		document.Directives[ref].Name = document.Input.AppendInputString(canonicalName)
+	}
+
+	removeEmptySchemaExtensions(document)
+	return true, nil
+}
+
+// collectLinkedDirectiveNames maps the names used in the subgraph to the canonical names of the federation directives.
+func collectLinkedDirectiveNames(document *ast.Document, linkRef int, directiveNames map[string]string) error {
+	namespace := federationV2Namespace
+	if as, ok := directiveStringArgument(document, linkRef, linkAsArgumentNameBytes); ok {
+		namespace = as
+	}
+	for _, name := range federationV2DirectiveNames {
+		directiveNames[namespace+"__"+name] = name
+	}
+
+	imports, ok := document.DirectiveArgumentValueByName(linkRef, linkImportArgumentNameBytes)
+	if !ok {
+		return nil
+	}
+	if imports.Kind != ast.ValueKindList {
+		return fmt.Errorf("the import argument of @%s must be a list", linkDirectiveName)
+	}
+	for _, valueRef := range document.ListValues[imports.Ref].Refs {
+		name, as, err := linkImport(document, document.Value(valueRef))
+		if err != nil {
+			return err
+		}
+		// only directives are renamed, imported types like FieldSet are not used by the composition
+		if !strings.HasPrefix(name, "@") {
+			continue
+		}
+		if !isFederationV2Directive(name[1:]) {
+			return fmt.Errorf("the directive %s is not part of the federation v2 specification", name)
+		}
+		directiveNames[strings.TrimPrefix(as, "@")] = name[1:]
+	}
+	return nil
+}
+
+// linkImport returns the imported name and the name it is imported as of an element of the import argument of @link.
+func linkImport(document *ast.Document, value ast.Value) (name, as string, err error) {
+	switch value.Kind {
+	case ast.ValueKindString:
+		name = document.StringValueContentString(value.Ref)
+		return name, name, nil
+	case ast.ValueKindObject:
+		for _, fieldRef := range document.ObjectValues[value.Ref].Refs {
+			fieldValue := document.ObjectFieldValue(fieldRef)
+			if fieldValue.Kind != ast.ValueKindString {
+				continue
+			}
+			switch document.ObjectFieldNameString(fieldRef) {
+			case linkImportNameFieldName:
+				name = document.StringValueContentString(fieldValue.Ref)
+			case linkImportAsFieldName:
+				as = document.StringValueContentString(fieldValue.Ref)
+			}
+		}
+		if name == "" {
+			return "", "", fmt.Errorf("an import of @%s must have a name", linkDirectiveName)
+		}
+		if as == "" {
+			as = name
+		}
+		return name, as, nil
+	default:
+		return "", "", fmt.Errorf("an import of @%s must be a string or an object", linkDirectiveName)
+	}
+}
+
+func isFederationV2Directive(name string) bool {
+	for i := range federationV2DirectiveNames {
+		if federationV2DirectiveNames[i] == name {
+			return true
+		}
+	}
+	return false
+}
+
+func directiveStringArgument(document *ast.Document, directiveRef int, argumentName []byte) (string, bool) {
+	value, ok := document.DirectiveArgumentValueByName(directiveRef, argumentName)
+	if !ok || value.Kind != ast.ValueKindString {
+		return "", false
+	}
+	return document.StringValueContentString(value.Ref), true
+}
+
+func removeSchemaDirectives(document *ast.Document, node ast.Node, directiveRefs []int) {
+	var directives *ast.DirectiveList
+	var hasDirectives *bool
+	switch node.Kind {
+	case ast.NodeKindSchemaDefinition:
+		directives = &document.SchemaDefinitions[node.Ref].Directives
+		hasDirectives = &document.SchemaDefinitions[node.Ref].HasDirectives
+	case ast.NodeKindSchemaExtension:
+		directives = &document.SchemaExtensions[node.Ref].Directives
+		hasDirectives = &document.SchemaExtensions[node.Ref].HasDirectives
+	default:
+		return
+	}
+	refs := directives.Refs[:0]
+	for _, ref := range directives.Refs {
+		if !containsRef(directiveRefs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	directives.Refs = refs
+	*hasDirectives = len(refs) > 0
+}
+
+// removeEmptySchemaExtensions removes schema extensions which neither have directives nor root operation types.
+func removeEmptySchemaExtensions(document *ast.Document) {
+	var nodes []ast.Node
+	for _, node := range document.RootNodes {
+		if node.Kind != ast.NodeKindSchemaExtension {
+			continue
+		}
+		extension := document.SchemaExtensions[node.Ref]
+		if len(extension.Directives.Refs) == 0 && len(extension.RootOperationTypeDefinitions.Refs) == 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	document.DeleteRootNodes(nodes)
+}
+
+// normalizeFederationV2Subgraphs prepares the federation v2 subgraphs for the merge:
+//
+//   - the federation directives are renamed to their canonical names, see NormalizeFederationV2Directives
+//   - @shareable on a type is moved to its fields and the key fields of entities are @shareable implicitly
+//   - types already defined by a previous subgraph become extensions (@extends),
+//     because federation v2 subgraphs define shared types and entities without extend
+func normalizeFederationV2Subgraphs(subgraphs []string) error {
+	definedTypeNames := make(map[string]struct{})
+	for i, subgraph := range subgraphs {
+		doc, report := astparser.ParseGraphqlDocumentString(subgraph)
+		if report.HasErrors() {
+			return fmt.Errorf(parseDocumentError, report.Error())
+		}
+		isFederationV2, err := NormalizeFederationV2Directives(&doc)
+		if err != nil {
+			return fmt.Errorf("normalize federation v2 directives: %s", err.Error())
+		}
+
+		var subgraphTypeNames []string
+		for _, node := range doc.RootNodes {
+			if node.Kind != ast.NodeKindObjectTypeDefinition {
+				continue
+			}
+			name := doc.ObjectTypeDefinitionNameString(node.Ref)
+			if ast.IsRootType([]byte(name)) || doc.ObjectTypeDefinitions[node.Ref].Directives.HasDirectiveByName(&doc, extendsDirectiveName) {
+				continue
+			}
+			subgraphTypeNames = append(subgraphTypeNames, name)
+			if !isFederationV2 {
+				continue
+			}
+			shareFields(&doc, node.Ref)
+			if _, exists := definedTypeNames[name]; exists {
+				extendsRef := doc.ImportDirective(extendsDirectiveName, nil)
+				doc.ObjectTypeDefinitions[node.Ref].Directives.Refs = append(doc.ObjectTypeDefinitions[node.Ref].Directives.Refs, extendsRef)
+				doc.ObjectTypeDefinitions[node.Ref].HasDirectives = true
+			}
+		}
+		for _, name := range subgraphTypeNames {
+			definedTypeNames[name] = struct{}{}
+		}
+
+		if !isFederationV2 {
+			continue
+		}
+		out, err := astprinter.PrintString(&doc, nil)
+		if err != nil {
+			return fmt.Errorf("stringify schema: %s", err.Error())
+		}
+		subgraphs[i] = out
+	}
+	return nil
+}
+
+// shareFields adds @shareable to the fields of a @shareable object type and to the key fields of an entity.
+func shareFields(document *ast.Document, ref int) {
+	objectType := &document.ObjectTypeDefinitions[ref]
+	isShareable := objectType.Directives.HasDirectiveByName(document, shareableDirectiveName)
+	objectType.Directives.RemoveDirectiveByName(document, shareableDirectiveName)
+	objectType.HasDirectives = len(objectType.Directives.Refs) > 0
+
+	keyFields := make(map[string]struct{})
+	for _, directiveRef := range objectType.Directives.Refs {
+		if document.DirectiveNameString(directiveRef) != plan.FederationKeyDirectiveName {
+			continue
+		}
+		fieldSet, ok := directiveStringArgument(document, directiveRef, []byte("fields"))
+		if !ok {
+			continue
+		}
+		for _, name := range topLevelFieldSetFieldNames(fieldSet) {
+			keyFields[name] = struct{}{}
+		}
+	}
+
+	for _, fieldRef := range objectType.FieldsDefinition.Refs {
+		if _, isKeyField := keyFields[document.FieldDefinitionNameString(fieldRef)]; !isShareable && !isKeyField {
+			continue
+		}
+		if document.FieldDefinitionHasNamedDirective(fieldRef, shareableDirectiveName) ||
+			document.FieldDefinitionHasNamedDirective(fieldRef, externalDirectiveName) {
+			continue
+		}
+		shareableRef := document.ImportDirective(shareableDirectiveName, nil)
+		document.FieldDefinitions[fieldRef].Directives.Refs = append(document.FieldDefinitions[fieldRef].Directives.Refs, shareableRef)
+		document.FieldDefinitions[fieldRef].HasDirectives = true
+	}
+}
+
+// topLevelFieldSetFieldNames returns the names of the top level fields of a _FieldSet, e.g. id and organization of "id organization { id }".
+func topLevelFieldSetFieldNames(fieldSet string) []string {
+	var names []string
+	depth := 0
+	for _, token := range strings.Fields(strings.NewReplacer("{", " { ", "}", " } ").Replace(fieldSet)) {
+		switch token {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		default:
+			if depth == 0 {
+				names = append(names, token)
+			}
+		}
+	}
+	return names
+}
+
+func containsRef(refs []int, ref int) bool {
+	for i := range refs {
+		if refs[i] == ref {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package sdlmerge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/pvormste/graphql-go-tools/pkg/astprinter"
+)
+
+func TestNormalizeFederationV2Directives(t *testing.T) {
+	run := func(t *testing.T, sdl string, expectedIsFederationV2 bool, expectedSDL string) {
+		t.Helper()
+
+		doc := unsafeparser.ParseGraphqlDocumentString(sdl)
+		isFederationV2, err := NormalizeFederationV2Directives(&doc)
+		require.NoError(t, err)
+		assert.Equal(t, expectedIsFederationV2, isFederationV2)
+
+		expectedDoc := unsafeparser.ParseGraphqlDocumentString(expectedSDL)
+		assert.Equal(t, mustString(astprinter.PrintString(&expectedDoc, nil)), mustString(astprinter.PrintString(&doc, nil)))
+	}
+
+	t.Run("imported and namespaced directives are renamed", func(t *testing.T) {
+		run(t, `
+			extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: [{name: "@key", as: "@primaryKey"}, "@shareable", "FieldSet"])
+			type Product @primaryKey(fields: "upc") {
+				upc: String!
+				name: String! @shareable @federation__tag(name: "public")
+			}
+		`, true, `
+			type Product @key(fields: "upc") {
+				upc: String!
+				name: String! @shareable @tag(name: "public")
+			}
+		`)
+	})
+
+	t.Run("the namespace of the link is used for not imported directives", func(t *testing.T) {
+		run(t, `
+			extend schema @link(url: "https://specs.apollo.dev/federation/v2.3", as: "fed") @custom
+			type Product @fed__key(fields: "upc") {
+				upc: String!
+				name: String! @federation__shareable
+			}
+		`, true, `
+			extend schema @custom
+			type Product @key(fields: "upc") {
+				upc: String!
+				name: String! @federation__shareable
+			}
+		`)
+	})
+
+	t.Run("federation v1 subgraphs are unchanged", func(t *testing.T) {
+		run(t, `
+			extend schema @link(url: "https://specs.apollo.dev/link/v1.0")
+			type Product @key(fields: "upc") {
+				upc: String!
+				name: String! @federation__shareable
+			}
+		`, false, `
+			extend schema @link(url: "https://specs.apollo.dev/link/v1.0")
+			type Product @key(fields: "upc") {
+				upc: String!
+				name: String! @federation__shareable
+			}
+		`)
+	})
+
+	t.Run("importing an unknown directive returns an error", func(t *testing.T) {
+		doc := unsafeparser.ParseGraphqlDocumentString(`
+			extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@unknown"])
+			type Query { hello: String }
+		`)
+		_, err := NormalizeFederationV2Directives(&doc)
+		assert.EqualError(t, err, "the directive @unknown is not part of the federation v2 specification")
+	})
+}
@@ -0,0 +1,110 @@
+package sdlmerge
+
+import (
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/astvisitor"
+	"github.com/pvormste/graphql-go-tools/pkg/operationreport"
+)
+
+// newMergeSharedFieldDefinitionsVisitor removes the duplicated fields of an object type, which are
+// defined by several federation v2 subgraphs. A field with @override replaces the fields of the
+// other subgraphs, otherwise all duplicates must be @shareable and have identical types.
+// The @tag and @inaccessible directives of the removed fields are kept.
+func newMergeSharedFieldDefinitionsVisitor() *mergeSharedFieldDefinitionsVisitor {
+	return &mergeSharedFieldDefinitionsVisitor{}
+}
+
+type mergeSharedFieldDefinitionsVisitor struct {
+	*astvisitor.Walker
+	document *ast.Document
+}
+
+func (m *mergeSharedFieldDefinitionsVisitor) Register(walker *astvisitor.Walker) {
+	m.Walker = walker
+	walker.RegisterEnterDocumentVisitor(m)
+	walker.RegisterEnterObjectTypeDefinitionVisitor(m)
+}
+
+func (m *mergeSharedFieldDefinitionsVisitor) EnterDocument(operation, _ *ast.Document) {
+	m.document = operation
+}
+
+func (m *mergeSharedFieldDefinitionsVisitor) EnterObjectTypeDefinition(ref int) {
+	fieldRefsByName := make(map[string][]int)
+	var fieldNames []string
+	for _, fieldRef := range m.document.ObjectTypeDefinitions[ref].FieldsDefinition.Refs {
+		name := m.document.FieldDefinitionNameString(fieldRef)
+		if _, exists := fieldRefsByName[name]; !exists {
+			fieldNames = append(fieldNames, name)
+		}
+		fieldRefsByName[name] = append(fieldRefsByName[name], fieldRef)
+	}
+
+	var refsForDeletion []int
+	for _, name := range fieldNames {
+		fieldRefs := fieldRefsByName[name]
+		if len(fieldRefs) == 1 {
+			continue
+		}
+		keptRef, err := m.sharedFieldDefinition(ref, name, fieldRefs)
+		if err != nil {
+			m.StopWithExternalErr(*err)
+			return
+		}
+		for _, fieldRef := range fieldRefs {
+			if fieldRef == keptRef {
+				continue
+			}
+			m.keepDirectives(keptRef, fieldRef)
+			refsForDeletion = append(refsForDeletion, fieldRef)
+		}
+	}
+
+	m.document.RemoveFieldDefinitionsFromObjectTypeDefinition(refsForDeletion, ref)
+}
+
+// sharedFieldDefinition returns the field definition which is kept of all definitions of a field.
+func (m *mergeSharedFieldDefinitionsVisitor) sharedFieldDefinition(ref int, fieldName string, fieldRefs []int) (int, *operationreport.ExternalError) {
+	typeName := m.document.ObjectTypeDefinitionNameString(ref)
+	for _, fieldRef := range fieldRefs {
+		if m.document.FieldDefinitionHasNamedDirective(fieldRef, overrideDirectiveName) {
+			return fieldRef, nil
+		}
+	}
+	for _, fieldRef := range fieldRefs {
+		if !m.document.FieldDefinitionHasNamedDirective(fieldRef, shareableDirectiveName) {
+			err := operationreport.ErrSharedFieldMustBeShareable(typeName, fieldName)
+			return ast.InvalidRef, &err
+		}
+		if !m.document.TypesAreCompatibleDeep(m.document.FieldDefinitions[fieldRefs[0]].Type, m.document.FieldDefinitions[fieldRef].Type) {
+			err := operationreport.ErrSharedTypesMustBeIdenticalToFederate(typeName)
+			return ast.InvalidRef, &err
+		}
+	}
+	return fieldRefs[0], nil
+}
+
+// keepDirectives adds the @tag and @inaccessible directives of a removed field to the kept field.
+func (m *mergeSharedFieldDefinitionsVisitor) keepDirectives(keptRef, removedRef int) {
+	for _, directiveRef := range m.document.FieldDefinitions[removedRef].Directives.Refs {
+		switch m.document.DirectiveNameString(directiveRef) {
+		case tagDirectiveName, inaccessibleDirectiveName:
+		default:
+			continue
+		}
+		if m.hasEqualDirective(keptRef, directiveRef) {
+			continue
+		}
+		m.document.FieldDefinitions[keptRef].Directives.Refs = append(m.document.FieldDefinitions[keptRef].Directives.Refs, directiveRef)
+		m.document.FieldDefinitions[keptRef].HasDirectives = true
+	}
+}
+
+func (m *mergeSharedFieldDefinitionsVisitor) hasEqualDirective(fieldRef, directiveRef int) bool {
+	for _, ref := range m.document.FieldDefinitions[fieldRef].Directives.Refs {
+		if m.document.DirectivesAreEqual(ref, directiveRef) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package sdlmerge
+
+import (
+	"testing"
+)
+
+func TestMergeSharedFieldDefinitions(t *testing.T) {
+	t.Run("shareable fields are merged into a single field", func(t *testing.T) {
+		run(t, newMergeSharedFieldDefinitionsVisitor(), `
+			type Product {
+				upc: String! @shareable
+				name: String! @shareable @tag(name: "public")
+				upc: String! @shareable
+				price: Int!
+				name: String! @shareable @tag(name: "catalog") @tag(name: "public")
+			}
+		`, `
+			type Product {
+				upc: String! @shareable
+				name: String! @shareable @tag(name: "public") @tag(name: "catalog")
+				price: Int!
+			}
+		`)
+	})
+
+	t.Run("an overriding field replaces the overridden field", func(t *testing.T) {
+		run(t, newMergeSharedFieldDefinitionsVisitor(), `
+			type Product {
+				inStock: Boolean @inaccessible
+				inStock: Boolean @override(from: "products")
+			}
+		`, `
+			type Product {
+				inStock: Boolean @override(from: "products") @inaccessible
+			}
+		`)
+	})
+
+	t.Run("fields which are not shareable return an error", func(t *testing.T) {
+		runAndExpectError(t, newMergeSharedFieldDefinitionsVisitor(), `
+			type Product {
+				name: String! @shareable
+				name: String!
+			}
+		`, sharedFieldMustBeShareableErrorMessage("Product", "name"))
+	})
+
+	t.Run("shareable fields with different types return an error", func(t *testing.T) {
+		runAndExpectError(t, newMergeSharedFieldDefinitionsVisitor(), `
+			type Product {
+				name: String! @shareable
+				name: Int! @shareable
+			}
+		`, nonIdenticalSharedTypeErrorMessage("Product"))
+	})
+}
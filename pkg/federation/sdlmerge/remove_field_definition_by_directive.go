@@ -24,6 +24,7 @@ type removeFieldDefinitionByDirective struct {
 func (r *removeFieldDefinitionByDirective) Register(walker *astvisitor.Walker) {
 	walker.RegisterEnterDocumentVisitor(r)
 	walker.RegisterLeaveObjectTypeDefinitionVisitor(r)
+	walker.RegisterLeaveInterfaceTypeDefinitionVisitor(r)
 }
 
 func (r *removeFieldDefinitionByDirective) EnterDocument(operation, _ *ast.Document) {
@@ -31,9 +32,18 @@ func (r *removeFieldDefinitionByDirective) EnterDocument(operation, _ *ast.Docum
 }
 
 func (r *removeFieldDefinitionByDirective) LeaveObjectTypeDefinition(ref int) {
-	var refsForDeletion []int
-	// select fields for deletion
-	for _, fieldRef := range r.operation.ObjectTypeDefinitions[ref].FieldsDefinition.Refs {
+	refsForDeletion := r.fieldRefsForDeletion(r.operation.ObjectTypeDefinitions[ref].FieldsDefinition.Refs)
+	r.operation.RemoveFieldDefinitionsFromObjectTypeDefinition(refsForDeletion, ref)
+}
+
+func (r *removeFieldDefinitionByDirective) LeaveInterfaceTypeDefinition(ref int) {
+	refsForDeletion := r.fieldRefsForDeletion(r.operation.InterfaceTypeDefinitions[ref].FieldsDefinition.Refs)
+	r.operation.RemoveFieldDefinitionsFromInterfaceTypeDefinition(refsForDeletion, ref)
+}
+
+// fieldRefsForDeletion selects the fields with one of the directives for deletion
+func (r *removeFieldDefinitionByDirective) fieldRefsForDeletion(fieldRefs []int) (refsForDeletion []int) {
+	for _, fieldRef := range fieldRefs {
 		for _, directiveRef := range r.operation.FieldDefinitions[fieldRef].Directives.Refs {
 			directiveName := r.operation.DirectiveNameString(directiveRef)
 			if _, ok := r.directives[directiveName]; ok {
@@ -41,6 +51,5 @@ func (r *removeFieldDefinitionByDirective) LeaveObjectTypeDefinition(ref int) {
 			}
 		}
 	}
-	// delete fields
-	r.operation.RemoveFieldDefinitionsFromObjectTypeDefinition(refsForDeletion, ref)
+	return refsForDeletion
 }
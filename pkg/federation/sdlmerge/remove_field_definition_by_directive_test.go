@@ -25,4 +25,19 @@ func TestRemoveFieldDirective(t *testing.T) {
 				}
 			`)
 	})
+	t.Run("remove interface field with specified directive", func(t *testing.T) {
+		run(
+			t, newRemoveFieldDefinitions("forDelete"),
+			`
+				interface Mammal {
+					name: String
+					favoriteToy: String @forDelete
+				}
+			`,
+			`
+				interface Mammal {
+					name: String
+				}
+			`)
+	})
 }
@@ -0,0 +1,156 @@
+package sdlmerge
+
+import (
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/astvisitor"
+	"github.com/pvormste/graphql-go-tools/pkg/operationreport"
+)
+
+// newRemoveInaccessibleVisitor hides everything marked @inaccessible from the merged schema:
+// types, fields of objects, interfaces and input objects and enum values.
+// A field which is still accessible must not reference an @inaccessible type.
+func newRemoveInaccessibleVisitor() *removeInaccessibleVisitor {
+	return &removeInaccessibleVisitor{}
+}
+
+type removeInaccessibleVisitor struct {
+	*astvisitor.Walker
+	document *ast.Document
+}
+
+func (r *removeInaccessibleVisitor) Register(walker *astvisitor.Walker) {
+	r.Walker = walker
+	walker.RegisterEnterDocumentVisitor(r)
+	walker.RegisterLeaveDocumentVisitor(r)
+}
+
+func (r *removeInaccessibleVisitor) EnterDocument(operation, _ *ast.Document) {
+	r.document = operation
+}
+
+func (r *removeInaccessibleVisitor) LeaveDocument(_, _ *ast.Document) {
+	inaccessibleTypeNames := make(map[string]struct{})
+	var rootNodesToRemove []ast.Node
+	for _, node := range r.document.RootNodes {
+		if r.isInaccessible(r.document.NodeDirectives(node)) && node.Kind != ast.NodeKindSchemaDefinition && node.Kind != ast.NodeKindSchemaExtension {
+			inaccessibleTypeNames[r.document.NodeNameString(node)] = struct{}{}
+			rootNodesToRemove = append(rootNodesToRemove, node)
+		}
+	}
+	r.document.DeleteRootNodes(rootNodesToRemove)
+
+	for _, node := range r.document.RootNodes {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition:
+			objectType := &r.document.ObjectTypeDefinitions[node.Ref]
+			objectType.FieldsDefinition.Refs = r.accessibleFieldDefinitions(objectType.FieldsDefinition.Refs)
+			objectType.HasFieldDefinitions = len(objectType.FieldsDefinition.Refs) > 0
+		case ast.NodeKindInterfaceTypeDefinition:
+			interfaceType := &r.document.InterfaceTypeDefinitions[node.Ref]
+			interfaceType.FieldsDefinition.Refs = r.accessibleFieldDefinitions(interfaceType.FieldsDefinition.Refs)
+			interfaceType.HasFieldDefinitions = len(interfaceType.FieldsDefinition.Refs) > 0
+		case ast.NodeKindInputObjectTypeDefinition:
+			inputObjectType := &r.document.InputObjectTypeDefinitions[node.Ref]
+			inputObjectType.InputFieldsDefinition.Refs = r.accessibleInputValueDefinitions(inputObjectType.InputFieldsDefinition.Refs)
+			inputObjectType.HasInputFieldsDefinition = len(inputObjectType.InputFieldsDefinition.Refs) > 0
+		case ast.NodeKindEnumTypeDefinition:
+			enumType := &r.document.EnumTypeDefinitions[node.Ref]
+			refs := enumType.EnumValuesDefinition.Refs[:0]
+			for _, ref := range enumType.EnumValuesDefinition.Refs {
+				if !r.isInaccessible(r.document.EnumValueDefinitions[ref].Directives.Refs) {
+					refs = append(refs, ref)
+				}
+			}
+			enumType.EnumValuesDefinition.Refs = refs
+			enumType.HasEnumValuesDefinition = len(refs) > 0
+		case ast.NodeKindUnionTypeDefinition:
+			unionType := &r.document.UnionTypeDefinitions[node.Ref]
+			refs := unionType.UnionMemberTypes.Refs[:0]
+			for _, ref := range unionType.UnionMemberTypes.Refs {
+				if _, inaccessible := inaccessibleTypeNames[r.document.TypeNameString(ref)]; !inaccessible {
+					refs = append(refs, ref)
+				}
+			}
+			unionType.UnionMemberTypes.Refs = refs
+			unionType.HasUnionMemberTypes = len(refs) > 0
+		}
+	}
+
+	if len(inaccessibleTypeNames) == 0 {
+		return
+	}
+	for _, node := range r.document.RootNodes {
+		if err := r.checkTypeReferences(node, inaccessibleTypeNames); err != nil {
+			r.StopWithExternalErr(*err)
+			return
+		}
+	}
+}
+
+// checkTypeReferences returns an error if an accessible field or argument references an @inaccessible type.
+func (r *removeInaccessibleVisitor) checkTypeReferences(node ast.Node, inaccessibleTypeNames map[string]struct{}) *operationreport.ExternalError {
+	var fieldRefs, inputValueRefs []int
+	switch node.Kind {
+	case ast.NodeKindObjectTypeDefinition:
+		fieldRefs = r.document.ObjectTypeDefinitions[node.Ref].FieldsDefinition.Refs
+	case ast.NodeKindInterfaceTypeDefinition:
+		fieldRefs = r.document.InterfaceTypeDefinitions[node.Ref].FieldsDefinition.Refs
+	case ast.NodeKindInputObjectTypeDefinition:
+		inputValueRefs = r.document.InputObjectTypeDefinitions[node.Ref].InputFieldsDefinition.Refs
+	default:
+		return nil
+	}
+
+	typeName := r.document.NodeNameString(node)
+	for _, fieldRef := range fieldRefs {
+		fieldTypeName := r.document.ResolveTypeNameString(r.document.FieldDefinitions[fieldRef].Type)
+		if _, inaccessible := inaccessibleTypeNames[fieldTypeName]; inaccessible {
+			err := operationreport.ErrInaccessibleTypeMustNotBeReferenced(typeName, r.document.FieldDefinitionNameString(fieldRef), fieldTypeName)
+			return &err
+		}
+		for _, argumentRef := range r.document.FieldDefinitions[fieldRef].ArgumentsDefinition.Refs {
+			argumentTypeName := r.document.ResolveTypeNameString(r.document.InputValueDefinitions[argumentRef].Type)
+			if _, inaccessible := inaccessibleTypeNames[argumentTypeName]; inaccessible {
+				err := operationreport.ErrInaccessibleTypeMustNotBeReferenced(typeName, r.document.FieldDefinitionNameString(fieldRef), argumentTypeName)
+				return &err
+			}
+		}
+	}
+	for _, inputValueRef := range inputValueRefs {
+		inputValueTypeName := r.document.ResolveTypeNameString(r.document.InputValueDefinitions[inputValueRef].Type)
+		if _, inaccessible := inaccessibleTypeNames[inputValueTypeName]; inaccessible {
+			err := operationreport.ErrInaccessibleTypeMustNotBeReferenced(typeName, r.document.InputValueDefinitionNameString(inputValueRef), inputValueTypeName)
+			return &err
+		}
+	}
+	return nil
+}
+
+func (r *removeInaccessibleVisitor) accessibleFieldDefinitions(fieldRefs []int) []int {
+	refs := fieldRefs[:0]
+	for _, ref := range fieldRefs {
+		if !r.isInaccessible(r.document.FieldDefinitions[ref].Directives.Refs) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+func (r *removeInaccessibleVisitor) accessibleInputValueDefinitions(inputValueRefs []int) []int {
+	refs := inputValueRefs[:0]
+	for _, ref := range inputValueRefs {
+		if !r.isInaccessible(r.document.InputValueDefinitions[ref].Directives.Refs) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+func (r *removeInaccessibleVisitor) isInaccessible(directiveRefs []int) bool {
+	for _, directiveRef := range directiveRefs {
+		if r.document.DirectiveNameString(directiveRef) == inaccessibleDirectiveName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package sdlmerge
+
+import (
+	"testing"
+)
+
+func TestRemoveInaccessible(t *testing.T) {
+	t.Run("remove inaccessible types, fields and enum values", func(t *testing.T) {
+		run(t, newRemoveInaccessibleVisitor(), `
+			type Query {
+				products: [Product]
+				secret: Secret @inaccessible
+			}
+
+			type Product {
+				upc: String!
+				cost: Int @inaccessible
+				state: State
+				search: SearchResult
+			}
+
+			type Secret @inaccessible {
+				value: String
+			}
+
+			enum State {
+				AVAILABLE
+				HIDDEN @inaccessible
+			}
+
+			input ProductInput {
+				upc: String!
+				cost: Int @inaccessible
+			}
+
+			union SearchResult = Product | Secret
+		`, `
+			type Query {
+				products: [Product]
+			}
+
+			type Product {
+				upc: String!
+				state: State
+				search: SearchResult
+			}
+
+			enum State {
+				AVAILABLE
+			}
+
+			input ProductInput {
+				upc: String!
+			}
+
+			union SearchResult = Product
+		`)
+	})
+
+	t.Run("accessible fields referencing an inaccessible type return an error", func(t *testing.T) {
+		runAndExpectError(t, newRemoveInaccessibleVisitor(), `
+			type Query {
+				secret(filter: SecretFilter): String
+			}
+
+			input SecretFilter @inaccessible {
+				value: String
+			}
+		`, inaccessibleTypeReferencedErrorMessage("Query", "secret", "SecretFilter"))
+	})
+}
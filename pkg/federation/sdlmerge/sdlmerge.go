@@ -40,6 +40,9 @@ func MergeSDLs(SDLs ...string) (string, error) {
 	rawDocs := make([]string, 0, len(SDLs)+1)
 	rawDocs = append(rawDocs, rootOperationTypeDefinitions)
 	rawDocs = append(rawDocs, SDLs...)
+	if normalizationError := normalizeFederationV2Subgraphs(rawDocs[1:]); normalizationError != nil {
+		return "", normalizationError
+	}
 	if validationError := validateSubgraphs(rawDocs[1:]); validationError != nil {
 		return "", validationError
 	}
@@ -130,6 +133,7 @@ func (m *normalizer) setupWalkers() {
 			newExtendObjectTypeDefinition(collectedEntities),
 			newRemoveEmptyObjectTypeDefinition(),
 			newRemoveMergedTypeExtensions(),
+			newAddEntityInterfaceFieldsVisitor(collectedEntities),
 		},
 		// visitors for cleaning up federated duplicated fields and directives
 		{
@@ -140,6 +144,13 @@ func (m *normalizer) setupWalkers() {
 			newRemoveObjectTypeDefinitionDirective("key"),
 			newRemoveFieldDefinitionDirective("provides", "requires"),
 		},
+		// visitors for federation v2 fields defined by several subgraphs and v2 directives
+		{
+			newMergeSharedFieldDefinitionsVisitor(),
+			newRemoveFieldDefinitionDirective(shareableDirectiveName, overrideDirectiveName),
+			newRemoveInaccessibleVisitor(),
+			newAddTagDirectiveDefinitionVisitor(),
+		},
 	}
 
 	for _, visitorGroup := range visitorGroups {
@@ -140,6 +140,26 @@ func TestMergeSDLs(t *testing.T) {
 		emptyTypeBodyErrorMessage("object", "Message"),
 		accountSchema, negativeTestingProductSchema,
 	))
+
+	t.Run("should merge federation v2 sdls successfully", runMergeTest(
+		federatedV2Schema,
+		productV2Schema, inventoryV2Schema,
+	))
+
+	t.Run("should merge federation v1 and v2 sdls successfully", runMergeTest(
+		federatedV1AndV2Schema,
+		accountSchema, productV2Schema,
+	))
+
+	t.Run("Fields defined by several federation v2 subgraphs must be shareable", runMergeTestAndExpectError(
+		sharedFieldMustBeShareableMergeErrorMessage("Product", "price"),
+		productV2Schema, negativeTestingInventoryV2Schema,
+	))
+
+	t.Run("Fields added to an entity interface are added to the implementing types", runMergeTest(
+		federatedEntityInterfaceSchema,
+		accountEntityInterfaceSchema, reviewEntityInterfaceSchema,
+	))
 }
 
 const (
@@ -526,6 +546,120 @@ const (
 			name: String!
 		}
 	`
+	productV2Schema = `
+		extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key", "@shareable", {name: "@tag", as: "@label"}, "@inaccessible"])
+
+		type Query {
+			topProducts: [Product] @label(name: "public")
+			internalProducts: [InternalProduct] @inaccessible
+		}
+
+		type Product @key(fields: "upc") {
+			upc: String!
+			name: String! @shareable
+			price: Money @shareable
+			cost: Int @inaccessible
+		}
+
+		type Money @shareable {
+			amount: Int!
+			currency: String!
+		}
+
+		type InternalProduct @inaccessible {
+			upc: String!
+		}
+	`
+
+	inventoryV2Schema = `
+		extend schema @link(url: "https://specs.apollo.dev/federation/v2.3", import: ["@key", "@override", "@external"])
+
+		type Product @key(fields: "upc") {
+			upc: String!
+			name: String! @federation__shareable @federation__tag(name: "catalog")
+			price: Money @federation__shareable
+			inStock: Boolean @override(from: "products")
+		}
+
+		type Money @federation__shareable {
+			amount: Int!
+			currency: String!
+		}
+	`
+
+	negativeTestingInventoryV2Schema = `
+		extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key"])
+
+		type Product @key(fields: "upc") {
+			upc: String!
+			price: Money
+		}
+
+		type Money @federation__shareable {
+			amount: Int!
+			currency: String!
+		}
+	`
+
+	federatedV2Schema = `
+		type Query {
+			topProducts: [Product] @tag(name: "public")
+		}
+
+		type Product {
+			upc: String!
+			name: String! @tag(name: "catalog")
+			price: Money
+			inStock: Boolean
+		}
+
+		type Money {
+			amount: Int!
+			currency: String!
+		}
+
+		directive @tag(name: String!) repeatable on SCALAR | OBJECT | FIELD_DEFINITION | ARGUMENT_DEFINITION | INTERFACE | UNION | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+	`
+
+	federatedV1AndV2Schema = `
+		type Query {
+			me: User
+			topProducts: [Product] @tag(name: "public")
+		}
+
+		union AlphaNumeric = Int | String | Float
+
+		scalar DateTime
+
+		scalar CustomScalar
+
+		type User {
+			id: ID!
+			username: String!
+			created: DateTime!
+			reputation: CustomScalar!
+		}
+
+		enum Satisfaction {
+			HAPPY,
+			NEUTRAL,
+			UNHAPPY,
+		}
+
+		type Product {
+			upc: String!
+			name: String!
+			price: Money
+		}
+
+		type Money {
+			amount: Int!
+			currency: String!
+		}
+
+		directive @tag(name: String!) repeatable on SCALAR | OBJECT | FIELD_DEFINITION | ARGUMENT_DEFINITION | INTERFACE | UNION | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+	`
+
 	federatedSchema = `
 		type Query {
 			me: User
@@ -661,3 +795,81 @@ func nonEntityExtensionErrorMessage(typeName string) string {
 func duplicateEntityErrorMessage(typeName string) string {
 	return fmt.Sprintf("the entity named '%s' is defined in the subgraph(s) more than once", typeName)
 }
+
+func sharedFieldMustBeShareableErrorMessage(typeName, fieldName string) string {
+	return fmt.Sprintf("the field '%s.%s' is defined in several subgraphs and must be marked @shareable", typeName, fieldName)
+}
+
+func nonIdenticalSharedTypeErrorMessage(typeName string) string {
+	return fmt.Sprintf("the shared type named '%s' must be identical in any subgraphs to federate", typeName)
+}
+
+func inaccessibleTypeReferencedErrorMessage(typeName, fieldName, inaccessibleTypeName string) string {
+	return fmt.Sprintf("the field '%s.%s' references the @inaccessible type '%s' and must be marked @inaccessible", typeName, fieldName, inaccessibleTypeName)
+}
+
+func sharedFieldMustBeShareableMergeErrorMessage(typeName, fieldName string) string {
+	return fmt.Sprintf("merge ast: walk: external: the field '%s.%s' is defined in several subgraphs and must be marked @shareable, locations: [], path: []", typeName, fieldName)
+}
+
+const (
+	accountEntityInterfaceSchema = `
+		extend type Query {
+			accounts: [Account]
+		}
+
+		interface Account @key(fields: "id") {
+			id: ID!
+			name: String!
+		}
+
+		type User implements Account @key(fields: "id") {
+			id: ID!
+			name: String!
+		}
+
+		type Admin implements Account @key(fields: "id") {
+			id: ID!
+			name: String!
+			level: Int!
+		}
+	`
+	reviewEntityInterfaceSchema = `
+		type Review {
+			body: String!
+		}
+
+		extend interface Account @key(fields: "id") {
+			id: ID! @external
+			reviews: [Review]
+		}
+	`
+	federatedEntityInterfaceSchema = `
+		type Query {
+			accounts: [Account]
+		}
+
+		interface Account {
+			id: ID!
+			name: String!
+			reviews: [Review]
+		}
+
+		type User implements Account {
+			id: ID!
+			name: String!
+			reviews: [Review]
+		}
+
+		type Admin implements Account {
+			id: ID!
+			name: String!
+			level: Int!
+			reviews: [Review]
+		}
+
+		type Review {
+			body: String!
+		}
+	`
+)
@@ -0,0 +1,493 @@
+package sdlmerge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/astparser"
+	"github.com/pvormste/graphql-go-tools/pkg/astprinter"
+	"github.com/pvormste/graphql-go-tools/pkg/astvisitor"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+)
+
+const (
+	joinGraphEnumName           = "join__Graph"
+	joinGraphDirectiveName      = "join__graph"
+	joinTypeDirectiveName       = "join__type"
+	joinFieldDirectiveName      = "join__field"
+	joinOwnerDirectiveName      = "join__owner"
+	joinImplementsDirectiveName = "join__implements"
+	coreDirectiveName           = "core"
+
+	joinNamespacePrefix = "join__"
+	linkNamespacePrefix = "link__"
+	coreNamespacePrefix = "core__"
+
+	requiresDirectiveName = "requires"
+	providesDirectiveName = "provides"
+	fieldsArgumentName    = "fields"
+	fromArgumentName      = "from"
+)
+
+var (
+	joinGraphArgumentNameBytes      = []byte("graph")
+	joinNameArgumentNameBytes       = []byte("name")
+	joinKeyArgumentNameBytes        = []byte("key")
+	joinExtensionArgumentNameBytes  = []byte("extension")
+	joinResolvableArgumentNameBytes = []byte("resolvable")
+	joinExternalArgumentNameBytes   = []byte("external")
+	joinRequiresArgumentNameBytes   = []byte(requiresDirectiveName)
+	joinProvidesArgumentNameBytes   = []byte(providesDirectiveName)
+	joinOverrideArgumentNameBytes   = []byte(overrideDirectiveName)
+	joinInterfaceArgumentNameBytes  = []byte("interface")
+	fieldsArgumentNameBytes         = []byte(fieldsArgumentName)
+)
+
+// Subgraph is a subgraph of a supergraph, declared by a value of the join__Graph enum, e.g.
+// enum join__Graph { ACCOUNTS @join__graph(name: "accounts", url: "http://accounts.service") }
+type Subgraph struct {
+	Name string
+	URL  string
+	// SDL is the federation SDL of the subgraph with @key, @external, @requires, @provides and @override
+	SDL string
+}
+
+// Supergraph is a supergraph SDL composed by external tooling, e.g. rover supergraph compose.
+type Supergraph struct {
+	// Schema is the api schema of the supergraph without the types and directives of the join,
+	// link and core specifications and without the elements marked @inaccessible.
+	Schema    string
+	Subgraphs []Subgraph
+}
+
+// ParseSupergraph extracts the api schema and the subgraphs from a supergraph SDL.
+// The fields of every subgraph are taken from the directives of the join specification:
+// @join__graph, @join__type, @join__field, @join__implements and the @join__owner of federation v1 supergraphs.
+func ParseSupergraph(supergraphSDL string) (*Supergraph, error) {
+	doc, report := astparser.ParseGraphqlDocumentString(supergraphSDL)
+	if report.HasErrors() {
+		return nil, fmt.Errorf(parseDocumentError, report.Error())
+	}
+	graphs, err := supergraphGraphs(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	supergraph := &Supergraph{
+		Subgraphs: make([]Subgraph, 0, len(graphs)),
+	}
+	if supergraph.Schema, err = supergraphAPISchema(supergraphSDL); err != nil {
+		return nil, err
+	}
+	for _, graph := range graphs {
+		sdl, err := extractSubgraphSDL(supergraphSDL, graph.enumValue)
+		if err != nil {
+			return nil, fmt.Errorf("extract subgraph %s: %v", graph.name, err)
+		}
+		supergraph.Subgraphs = append(supergraph.Subgraphs, Subgraph{
+			Name: graph.name,
+			URL:  graph.url,
+			SDL:  sdl,
+		})
+	}
+	return supergraph, nil
+}
+
+type supergraphGraph struct {
+	enumValue string
+	name      string
+	url       string
+}
+
+// supergraphGraphs returns the graphs declared by the values of the join__Graph enum.
+func supergraphGraphs(document *ast.Document) ([]supergraphGraph, error) {
+	for ref := range document.EnumTypeDefinitions {
+		if document.EnumTypeDefinitionNameString(ref) != joinGraphEnumName {
+			continue
+		}
+		var graphs []supergraphGraph
+		for _, valueRef := range document.EnumTypeDefinitions[ref].EnumValuesDefinition.Refs {
+			enumValue := document.EnumValueDefinitionNameString(valueRef)
+			directiveRef, exists := directiveByName(document, document.EnumValueDefinitions[valueRef].Directives.Refs, joinGraphDirectiveName)
+			if !exists {
+				return nil, fmt.Errorf("the graph %s has no @%s directive", enumValue, joinGraphDirectiveName)
+			}
+			name, ok := directiveStringArgument(document, directiveRef, joinNameArgumentNameBytes)
+			if !ok {
+				return nil, fmt.Errorf("the graph %s has no name", enumValue)
+			}
+			url, _ := directiveStringArgument(document, directiveRef, linkURLArgumentNameBytes)
+			graphs = append(graphs, supergraphGraph{
+				enumValue: enumValue,
+				name:      name,
+				url:       url,
+			})
+		}
+		return graphs, nil
+	}
+	return nil, fmt.Errorf("the supergraph has no %s enum", joinGraphEnumName)
+}
+
+// supergraphAPISchema removes the join, link and core specifications and the elements marked @inaccessible from the supergraph.
+func supergraphAPISchema(supergraphSDL string) (string, error) {
+	doc, report := astparser.ParseGraphqlDocumentString(supergraphSDL)
+	if report.HasErrors() {
+		return "", fmt.Errorf(parseDocumentError, report.Error())
+	}
+	removeSupergraphSpecifications(&doc)
+
+	walker := astvisitor.NewWalker(48)
+	newRemoveInaccessibleVisitor().Register(&walker)
+	walker.Walk(&doc, nil, &report)
+	if report.HasErrors() {
+		return "", fmt.Errorf("remove inaccessible elements: %s", report.Error())
+	}
+	if ref, exists := doc.DirectiveDefinitionByName(inaccessibleDirectiveName); exists {
+		doc.DeleteRootNode(ast.Node{Kind: ast.NodeKindDirectiveDefinition, Ref: ref})
+	}
+
+	out, err := astprinter.PrintString(&doc, nil)
+	if err != nil {
+		return "", fmt.Errorf("stringify schema: %s", err.Error())
+	}
+	return out, nil
+}
+
+// extractSubgraphSDL keeps the types and fields of the graph in the supergraph and turns their join directives
+// into the directives of a federation subgraph.
+func extractSubgraphSDL(supergraphSDL, graph string) (string, error) {
+	doc, report := astparser.ParseGraphqlDocumentString(supergraphSDL)
+	if report.HasErrors() {
+		return "", fmt.Errorf(parseDocumentError, report.Error())
+	}
+	extractor := subgraphExtractor{
+		document: &doc,
+		graph:    graph,
+	}
+	extractor.extract()
+	removeSupergraphSpecifications(&doc)
+
+	out, err := astprinter.PrintString(&doc, nil)
+	if err != nil {
+		return "", fmt.Errorf("stringify schema: %s", err.Error())
+	}
+	return out, nil
+}
+
+type subgraphExtractor struct {
+	document *ast.Document
+	graph    string
+}
+
+func (s *subgraphExtractor) extract() {
+	var nodesToRemove []ast.Node
+	for i, node := range s.document.RootNodes {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition:
+			if !s.extractFieldedType(i) {
+				nodesToRemove = append(nodesToRemove, node)
+			}
+		case ast.NodeKindUnionTypeDefinition, ast.NodeKindEnumTypeDefinition,
+			ast.NodeKindInputObjectTypeDefinition, ast.NodeKindScalarTypeDefinition:
+			if len(s.joinTypes(node)) == 0 && len(s.allJoinTypes(node)) != 0 {
+				nodesToRemove = append(nodesToRemove, node)
+			}
+		}
+	}
+	s.document.DeleteRootNodes(nodesToRemove)
+}
+
+// extractFieldedType keeps the fields of an object or interface type resolved by the graph.
+// It returns false if the type isn't part of the graph.
+func (s *subgraphExtractor) extractFieldedType(rootNodeIndex int) bool {
+	node := s.document.RootNodes[rootNodeIndex]
+	joinTypes := s.joinTypes(node)
+	if len(joinTypes) == 0 && len(s.allJoinTypes(node)) != 0 {
+		return false
+	}
+
+	// a federation v1 supergraph declares the graph owning the type, the fields without @join__field belong to the owner
+	owner, hasOwner := "", false
+	if ref, exists := directiveByName(s.document, s.document.NodeDirectives(node), joinOwnerDirectiveName); exists {
+		owner, hasOwner = s.graphArgument(ref)
+	}
+	isExtension := hasOwner && owner != s.graph
+
+	var keys []ast.Value
+	for _, ref := range joinTypes {
+		if s.booleanArgument(ref, joinExtensionArgumentNameBytes, false) {
+			isExtension = true
+		}
+		key, exists := s.document.DirectiveArgumentValueByName(ref, joinKeyArgumentNameBytes)
+		if !exists || !s.booleanArgument(ref, joinResolvableArgumentNameBytes, true) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	fieldDefinitionRefs := s.document.NodeFieldDefinitions(node)
+	fieldDirectiveRefs := make([][]int, len(fieldDefinitionRefs))
+	fieldBelongsToGraph := make([]bool, len(fieldDefinitionRefs))
+	// the key fields and the required fields of an extension are resolved by another graph
+	externalFieldSets := append([]ast.Value(nil), keys...)
+	for i, fieldRef := range fieldDefinitionRefs {
+		fieldDirectiveRefs[i], fieldBelongsToGraph[i] = s.fieldDirectives(fieldRef, owner, hasOwner)
+		for _, ref := range fieldDirectiveRefs[i] {
+			if s.document.DirectiveNameString(ref) != requiresDirectiveName {
+				continue
+			}
+			if value, exists := s.document.DirectiveArgumentValueByName(ref, fieldsArgumentNameBytes); exists {
+				externalFieldSets = append(externalFieldSets, value)
+			}
+		}
+	}
+	externalFieldNames := make(map[string]struct{})
+	if isExtension {
+		for _, fieldSet := range externalFieldSets {
+			for _, path := range plan.FieldSetLeafPaths(s.document.StringValueContentString(fieldSet.Ref)) {
+				externalFieldNames[path[0]] = struct{}{}
+			}
+		}
+	}
+
+	var fieldRefs []int
+	for i, fieldRef := range fieldDefinitionRefs {
+		directiveRefs := fieldDirectiveRefs[i]
+		if !fieldBelongsToGraph[i] {
+			if _, isExternal := externalFieldNames[s.document.FieldDefinitionNameString(fieldRef)]; !isExternal {
+				continue
+			}
+			directiveRefs = []int{s.document.ImportDirective(externalDirectiveName, nil)}
+		}
+		s.document.FieldDefinitions[fieldRef].Directives.Refs = append(s.document.FieldDefinitions[fieldRef].Directives.Refs, directiveRefs...)
+		s.document.FieldDefinitions[fieldRef].HasDirectives = len(s.document.FieldDefinitions[fieldRef].Directives.Refs) > 0
+		fieldRefs = append(fieldRefs, fieldRef)
+	}
+	if len(fieldRefs) == 0 {
+		return false
+	}
+
+	keyDirectiveRefs := make([]int, 0, len(keys))
+	for _, key := range keys {
+		keyDirectiveRefs = append(keyDirectiveRefs, s.document.ImportDirective(plan.FederationKeyDirectiveName, []int{
+			s.document.ImportArgument(fieldsArgumentName, key),
+		}))
+	}
+
+	switch node.Kind {
+	case ast.NodeKindObjectTypeDefinition:
+		objectType := &s.document.ObjectTypeDefinitions[node.Ref]
+		objectType.FieldsDefinition.Refs = fieldRefs
+		objectType.Directives.Refs = append(objectType.Directives.Refs, keyDirectiveRefs...)
+		objectType.HasDirectives = len(objectType.Directives.Refs) > 0
+		objectType.ImplementsInterfaces.Refs = s.implementedInterfaces(node, objectType.ImplementsInterfaces.Refs)
+		if isExtension {
+			ref := s.document.AddObjectTypeDefinitionExtension(ast.ObjectTypeExtension{ObjectTypeDefinition: *objectType})
+			s.document.RootNodes[rootNodeIndex] = ast.Node{Kind: ast.NodeKindObjectTypeExtension, Ref: ref}
+		}
+	case ast.NodeKindInterfaceTypeDefinition:
+		interfaceType := &s.document.InterfaceTypeDefinitions[node.Ref]
+		interfaceType.FieldsDefinition.Refs = fieldRefs
+		interfaceType.Directives.Refs = append(interfaceType.Directives.Refs, keyDirectiveRefs...)
+		interfaceType.HasDirectives = len(interfaceType.Directives.Refs) > 0
+		if isExtension {
+			ref := s.document.AddInterfaceTypeExtension(ast.InterfaceTypeExtension{InterfaceTypeDefinition: *interfaceType})
+			s.document.RootNodes[rootNodeIndex] = ast.Node{Kind: ast.NodeKindInterfaceTypeExtension, Ref: ref}
+		}
+	}
+	return true
+}
+
+// fieldDirectives returns the federation directives of a field resolved by the graph,
+// e.g. @join__field(graph: INVENTORY, requires: "weight") turns into @requires(fields: "weight").
+// A field without @join__field belongs to every graph of its type or to the owner of the type.
+func (s *subgraphExtractor) fieldDirectives(fieldRef int, owner string, hasOwner bool) (directiveRefs []int, belongsToGraph bool) {
+	var hasJoinField bool
+	for _, ref := range s.document.FieldDefinitions[fieldRef].Directives.Refs {
+		if s.document.DirectiveNameString(ref) != joinFieldDirectiveName {
+			continue
+		}
+		graph, ok := s.graphArgument(ref)
+		if !ok {
+			continue
+		}
+		hasJoinField = true
+		if graph != s.graph {
+			continue
+		}
+		belongsToGraph = true
+		if s.booleanArgument(ref, joinExternalArgumentNameBytes, false) {
+			directiveRefs = append(directiveRefs, s.document.ImportDirective(externalDirectiveName, nil))
+		}
+		if value, exists := s.document.DirectiveArgumentValueByName(ref, joinRequiresArgumentNameBytes); exists {
+			directiveRefs = append(directiveRefs, s.document.ImportDirective(requiresDirectiveName, []int{
+				s.document.ImportArgument(fieldsArgumentName, value),
+			}))
+		}
+		if value, exists := s.document.DirectiveArgumentValueByName(ref, joinProvidesArgumentNameBytes); exists {
+			directiveRefs = append(directiveRefs, s.document.ImportDirective(providesDirectiveName, []int{
+				s.document.ImportArgument(fieldsArgumentName, value),
+			}))
+		}
+		if value, exists := s.document.DirectiveArgumentValueByName(ref, joinOverrideArgumentNameBytes); exists {
+			directiveRefs = append(directiveRefs, s.document.ImportDirective(overrideDirectiveName, []int{
+				s.document.ImportArgument(fromArgumentName, value),
+			}))
+		}
+	}
+	if !hasJoinField {
+		return nil, !hasOwner || owner == s.graph
+	}
+	return directiveRefs, belongsToGraph
+}
+
+// implementedInterfaces returns the interfaces the object type implements in the graph.
+// The interfaces are kept as they are if the supergraph has no @join__implements directives for the type.
+func (s *subgraphExtractor) implementedInterfaces(node ast.Node, interfaceRefs []int) []int {
+	var hasJoinImplements bool
+	interfaceNames := make(map[string]struct{})
+	for _, ref := range s.document.NodeDirectives(node) {
+		if s.document.DirectiveNameString(ref) != joinImplementsDirectiveName {
+			continue
+		}
+		hasJoinImplements = true
+		if graph, ok := s.graphArgument(ref); !ok || graph != s.graph {
+			continue
+		}
+		if name, ok := directiveStringArgument(s.document, ref, joinInterfaceArgumentNameBytes); ok {
+			interfaceNames[name] = struct{}{}
+		}
+	}
+	if !hasJoinImplements {
+		return interfaceRefs
+	}
+	refs := make([]int, 0, len(interfaceRefs))
+	for _, ref := range interfaceRefs {
+		if _, ok := interfaceNames[s.document.TypeNameString(ref)]; ok {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// joinTypes returns the @join__type directives of the node for the graph.
+func (s *subgraphExtractor) joinTypes(node ast.Node) (refs []int) {
+	for _, ref := range s.allJoinTypes(node) {
+		if graph, ok := s.graphArgument(ref); ok && graph == s.graph {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// allJoinTypes returns the @join__type directives of the node for all graphs.
+// A type without @join__type, e.g. a value type of a federation v1 supergraph, belongs to every graph.
+func (s *subgraphExtractor) allJoinTypes(node ast.Node) (refs []int) {
+	for _, ref := range s.document.NodeDirectives(node) {
+		if s.document.DirectiveNameString(ref) == joinTypeDirectiveName {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+func (s *subgraphExtractor) graphArgument(directiveRef int) (string, bool) {
+	value, exists := s.document.DirectiveArgumentValueByName(directiveRef, joinGraphArgumentNameBytes)
+	if !exists || value.Kind != ast.ValueKindEnum {
+		return "", false
+	}
+	return s.document.EnumValueNameString(value.Ref), true
+}
+
+func (s *subgraphExtractor) booleanArgument(directiveRef int, argumentName []byte, defaultValue bool) bool {
+	value, exists := s.document.DirectiveArgumentValueByName(directiveRef, argumentName)
+	if !exists || value.Kind != ast.ValueKindBoolean {
+		return defaultValue
+	}
+	return bool(s.document.BooleanValue(value.Ref))
+}
+
+func directiveByName(document *ast.Document, directiveRefs []int, name string) (int, bool) {
+	for _, ref := range directiveRefs {
+		if document.DirectiveNameString(ref) == name {
+			return ref, true
+		}
+	}
+	return -1, false
+}
+
+// removeSupergraphSpecifications removes the directives and types of the join, link and core specifications.
+func removeSupergraphSpecifications(document *ast.Document) {
+	var nodesToRemove []ast.Node
+	for _, node := range document.RootNodes {
+		switch node.Kind {
+		case ast.NodeKindDirectiveDefinition:
+			if isSupergraphDirective(document.DirectiveDefinitionNameString(node.Ref)) {
+				nodesToRemove = append(nodesToRemove, node)
+			}
+		case ast.NodeKindScalarTypeDefinition, ast.NodeKindEnumTypeDefinition, ast.NodeKindInputObjectTypeDefinition:
+			if isSupergraphType(document.NodeNameString(node)) {
+				nodesToRemove = append(nodesToRemove, node)
+			}
+		}
+	}
+	document.DeleteRootNodes(nodesToRemove)
+
+	for i := range document.SchemaDefinitions {
+		removeSupergraphDirectives(document, &document.SchemaDefinitions[i].Directives, &document.SchemaDefinitions[i].HasDirectives)
+	}
+	for i := range document.ObjectTypeDefinitions {
+		removeSupergraphDirectives(document, &document.ObjectTypeDefinitions[i].Directives, &document.ObjectTypeDefinitions[i].HasDirectives)
+	}
+	for i := range document.ObjectTypeExtensions {
+		removeSupergraphDirectives(document, &document.ObjectTypeExtensions[i].Directives, &document.ObjectTypeExtensions[i].HasDirectives)
+	}
+	for i := range document.InterfaceTypeDefinitions {
+		removeSupergraphDirectives(document, &document.InterfaceTypeDefinitions[i].Directives, &document.InterfaceTypeDefinitions[i].HasDirectives)
+	}
+	for i := range document.InterfaceTypeExtensions {
+		removeSupergraphDirectives(document, &document.InterfaceTypeExtensions[i].Directives, &document.InterfaceTypeExtensions[i].HasDirectives)
+	}
+	for i := range document.UnionTypeDefinitions {
+		removeSupergraphDirectives(document, &document.UnionTypeDefinitions[i].Directives, &document.UnionTypeDefinitions[i].HasDirectives)
+	}
+	for i := range document.EnumTypeDefinitions {
+		removeSupergraphDirectives(document, &document.EnumTypeDefinitions[i].Directives, &document.EnumTypeDefinitions[i].HasDirectives)
+	}
+	for i := range document.EnumValueDefinitions {
+		removeSupergraphDirectives(document, &document.EnumValueDefinitions[i].Directives, &document.EnumValueDefinitions[i].HasDirectives)
+	}
+	for i := range document.InputObjectTypeDefinitions {
+		removeSupergraphDirectives(document, &document.InputObjectTypeDefinitions[i].Directives, &document.InputObjectTypeDefinitions[i].HasDirectives)
+	}
+	for i := range document.ScalarTypeDefinitions {
+		removeSupergraphDirectives(document, &document.ScalarTypeDefinitions[i].Directives, &document.ScalarTypeDefinitions[i].HasDirectives)
+	}
+	for i := range document.FieldDefinitions {
+		removeSupergraphDirectives(document, &document.FieldDefinitions[i].Directives, &document.FieldDefinitions[i].HasDirectives)
+	}
+	for i := range document.InputValueDefinitions {
+		removeSupergraphDirectives(document, &document.InputValueDefinitions[i].Directives, &document.InputValueDefinitions[i].HasDirectives)
+	}
+}
+
+func removeSupergraphDirectives(document *ast.Document, directives *ast.DirectiveList, hasDirectives *bool) {
+	// the directives are copied, an extension shares them with the definition it was created from
+	refs := make([]int, 0, len(directives.Refs))
+	for _, ref := range directives.Refs {
+		if !isSupergraphDirective(document.DirectiveNameString(ref)) {
+			refs = append(refs, ref)
+		}
+	}
+	directives.Refs = refs
+	*hasDirectives = len(refs) > 0
+}
+
+func isSupergraphDirective(name string) bool {
+	return name == linkDirectiveName || name == coreDirectiveName || strings.HasPrefix(name, joinNamespacePrefix)
+}
+
+func isSupergraphType(name string) bool {
+	return strings.HasPrefix(name, joinNamespacePrefix) || strings.HasPrefix(name, linkNamespacePrefix) || strings.HasPrefix(name, coreNamespacePrefix)
+}
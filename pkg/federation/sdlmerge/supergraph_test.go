@@ -0,0 +1,224 @@
+package sdlmerge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/pvormste/graphql-go-tools/pkg/astprinter"
+)
+
+func TestParseSupergraph(t *testing.T) {
+	normalized := func(sdl string) string {
+		document := unsafeparser.ParseGraphqlDocumentString(sdl)
+		return mustString(astprinter.PrintString(&document, nil))
+	}
+
+	t.Run("federation v2 supergraph", func(t *testing.T) {
+		supergraph, err := ParseSupergraph(federationV2Supergraph)
+		require.NoError(t, err)
+
+		assert.Equal(t, normalized(`
+			schema {
+				query: Query
+			}
+			type Product {
+				upc: String!
+				weight: Int
+				name: String
+				inStock: Boolean
+				shippingEstimate: Int
+			}
+			type Query {
+				topProducts(first: Int = 5): [Product]
+			}
+		`), supergraph.Schema)
+
+		require.Len(t, supergraph.Subgraphs, 2)
+		assert.Equal(t, "inventory", supergraph.Subgraphs[0].Name)
+		assert.Equal(t, "http://inventory.service", supergraph.Subgraphs[0].URL)
+		assert.Equal(t, normalized(`
+			schema {
+				query: Query
+			}
+			directive @inaccessible on FIELD_DEFINITION | OBJECT | INTERFACE | UNION | ARGUMENT_DEFINITION | SCALAR | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+			type Product @key(fields: "upc") {
+				upc: String!
+				weight: Int @external
+				inStock: Boolean @override(from: "products")
+				shippingEstimate: Int @requires(fields: "weight")
+			}
+		`), supergraph.Subgraphs[0].SDL)
+
+		assert.Equal(t, "products", supergraph.Subgraphs[1].Name)
+		assert.Equal(t, "http://products.service", supergraph.Subgraphs[1].URL)
+		assert.Equal(t, normalized(`
+			schema {
+				query: Query
+			}
+			directive @inaccessible on FIELD_DEFINITION | OBJECT | INTERFACE | UNION | ARGUMENT_DEFINITION | SCALAR | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+			type Product @key(fields: "upc") {
+				upc: String!
+				weight: Int
+				name: String
+				secret: String @inaccessible
+			}
+			type Query {
+				topProducts(first: Int = 5): [Product]
+			}
+		`), supergraph.Subgraphs[1].SDL)
+	})
+
+	t.Run("federation v1 supergraph", func(t *testing.T) {
+		supergraph, err := ParseSupergraph(federationV1Supergraph)
+		require.NoError(t, err)
+
+		assert.Equal(t, normalized(`
+			schema {
+				query: Query
+			}
+			type User {
+				id: ID!
+				username: String!
+				reviews: [Review]
+			}
+			type Review {
+				body: String!
+			}
+			type Query {
+				me: User
+			}
+		`), supergraph.Schema)
+
+		require.Len(t, supergraph.Subgraphs, 2)
+		assert.Equal(t, normalized(`
+			schema {
+				query: Query
+			}
+			type User @key(fields: "id") {
+				id: ID!
+				username: String!
+			}
+			type Review {
+				body: String!
+			}
+			type Query {
+				me: User
+			}
+		`), supergraph.Subgraphs[0].SDL)
+		assert.Equal(t, normalized(`
+			schema {
+				query: Query
+			}
+			extend type User @key(fields: "id") {
+				id: ID! @external
+				reviews: [Review]
+			}
+			type Review {
+				body: String!
+			}
+		`), supergraph.Subgraphs[1].SDL)
+	})
+
+	t.Run("a supergraph without graphs returns an error", func(t *testing.T) {
+		_, err := ParseSupergraph(`type Query { me: String }`)
+		assert.EqualError(t, err, "the supergraph has no join__Graph enum")
+	})
+}
+
+const (
+	federationV2Supergraph = `
+		schema
+			@link(url: "https://specs.apollo.dev/link/v1.0")
+			@link(url: "https://specs.apollo.dev/join/v0.2", for: EXECUTION)
+		{
+			query: Query
+		}
+
+		directive @join__field(graph: join__Graph!, requires: join__FieldSet, provides: join__FieldSet, type: String, external: Boolean, override: String, usedOverridden: Boolean) repeatable on FIELD_DEFINITION | INPUT_FIELD_DEFINITION
+		directive @join__graph(name: String!, url: String!) on ENUM_VALUE
+		directive @join__implements(graph: join__Graph!, interface: String!) repeatable on OBJECT | INTERFACE
+		directive @join__type(graph: join__Graph!, key: join__FieldSet, extension: Boolean! = false, resolvable: Boolean! = true) repeatable on OBJECT | INTERFACE | UNION | ENUM | INPUT_OBJECT | SCALAR
+		directive @link(url: String, as: String, for: link__Purpose, import: [link__Import]) repeatable on SCHEMA
+		directive @inaccessible on FIELD_DEFINITION | OBJECT | INTERFACE | UNION | ARGUMENT_DEFINITION | SCALAR | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+
+		scalar join__FieldSet
+
+		enum join__Graph {
+			INVENTORY @join__graph(name: "inventory", url: "http://inventory.service")
+			PRODUCTS @join__graph(name: "products", url: "http://products.service")
+		}
+
+		scalar link__Import
+
+		enum link__Purpose {
+			SECURITY
+			EXECUTION
+		}
+
+		type Product
+			@join__type(graph: INVENTORY, key: "upc")
+			@join__type(graph: PRODUCTS, key: "upc")
+		{
+			upc: String!
+			weight: Int @join__field(graph: INVENTORY, external: true) @join__field(graph: PRODUCTS)
+			name: String @join__field(graph: PRODUCTS)
+			secret: String @join__field(graph: PRODUCTS) @inaccessible
+			inStock: Boolean @join__field(graph: INVENTORY, override: "products")
+			shippingEstimate: Int @join__field(graph: INVENTORY, requires: "weight")
+		}
+
+		type Query
+			@join__type(graph: INVENTORY)
+			@join__type(graph: PRODUCTS)
+		{
+			topProducts(first: Int = 5): [Product] @join__field(graph: PRODUCTS)
+		}
+	`
+	federationV1Supergraph = `
+		schema
+			@core(feature: "https://specs.apollo.dev/core/v0.2"),
+			@core(feature: "https://specs.apollo.dev/join/v0.1", for: EXECUTION)
+		{
+			query: Query
+		}
+
+		directive @core(as: String, feature: String!, for: core__Purpose) repeatable on SCHEMA
+		directive @join__field(graph: join__Graph, provides: join__FieldSet, requires: join__FieldSet) on FIELD_DEFINITION
+		directive @join__graph(name: String!, url: String!) on ENUM_VALUE
+		directive @join__owner(graph: join__Graph!) on INTERFACE | OBJECT
+		directive @join__type(graph: join__Graph!, key: join__FieldSet) repeatable on INTERFACE | OBJECT
+
+		type User
+			@join__owner(graph: ACCOUNTS)
+			@join__type(graph: ACCOUNTS, key: "id")
+			@join__type(graph: REVIEWS, key: "id")
+		{
+			id: ID! @join__field(graph: ACCOUNTS)
+			username: String! @join__field(graph: ACCOUNTS)
+			reviews: [Review] @join__field(graph: REVIEWS)
+		}
+
+		type Review {
+			body: String!
+		}
+
+		type Query {
+			me: User @join__field(graph: ACCOUNTS)
+		}
+
+		enum core__Purpose {
+			EXECUTION
+			SECURITY
+		}
+
+		scalar join__FieldSet
+
+		enum join__Graph {
+			ACCOUNTS @join__graph(name: "accounts" url: "http://accounts.service")
+			REVIEWS @join__graph(name: "reviews" url: "http://reviews.service")
+		}
+	`
+)
@@ -0,0 +1,89 @@
+package sdlmerge
+
+import (
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/astvisitor"
+)
+
+// tagDirectiveLocations are the locations of the federation v2 directive:
+// directive @tag(name: String!) repeatable on FIELD_DEFINITION | OBJECT | ...
+var tagDirectiveLocations = []string{
+	"FIELD_DEFINITION", "OBJECT", "INTERFACE", "UNION", "ARGUMENT_DEFINITION", "SCALAR",
+	"ENUM", "ENUM_VALUE", "INPUT_OBJECT", "INPUT_FIELD_DEFINITION",
+}
+
+// newAddTagDirectiveDefinitionVisitor adds the definition of @tag to the merged schema if a subgraph uses @tag.
+// The tags are kept in the merged schema, so that they can be used e.g. to filter the schema for a contract.
+func newAddTagDirectiveDefinitionVisitor() *addTagDirectiveDefinitionVisitor {
+	return &addTagDirectiveDefinitionVisitor{}
+}
+
+type addTagDirectiveDefinitionVisitor struct {
+	document *ast.Document
+}
+
+func (a *addTagDirectiveDefinitionVisitor) Register(walker *astvisitor.Walker) {
+	walker.RegisterEnterDocumentVisitor(a)
+	walker.RegisterLeaveDocumentVisitor(a)
+}
+
+func (a *addTagDirectiveDefinitionVisitor) EnterDocument(operation, _ *ast.Document) {
+	a.document = operation
+}
+
+func (a *addTagDirectiveDefinitionVisitor) LeaveDocument(_, _ *ast.Document) {
+	if _, exists := a.document.DirectiveDefinitionByName(tagDirectiveName); exists || !a.usesTagDirective() {
+		return
+	}
+
+	nameArgumentRef := a.document.ImportInputValueDefinition("name", "", a.document.AddNonNullNamedType([]byte("String")), ast.DefaultValue{})
+	ref := a.document.ImportDirectiveDefinition(tagDirectiveName, "", []int{nameArgumentRef}, tagDirectiveLocations)
+	a.document.DirectiveDefinitions[ref].Repeatable.IsRepeatable = true
+}
+
+func (a *addTagDirectiveDefinitionVisitor) usesTagDirective() bool {
+	for _, node := range a.document.RootNodes {
+		if a.hasTagDirective(a.document.NodeDirectives(node)) {
+			return true
+		}
+		var fieldRefs []int
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition:
+			fieldRefs = a.document.ObjectTypeDefinitions[node.Ref].FieldsDefinition.Refs
+		case ast.NodeKindInterfaceTypeDefinition:
+			fieldRefs = a.document.InterfaceTypeDefinitions[node.Ref].FieldsDefinition.Refs
+		case ast.NodeKindInputObjectTypeDefinition:
+			for _, ref := range a.document.InputObjectTypeDefinitions[node.Ref].InputFieldsDefinition.Refs {
+				if a.hasTagDirective(a.document.InputValueDefinitions[ref].Directives.Refs) {
+					return true
+				}
+			}
+		case ast.NodeKindEnumTypeDefinition:
+			for _, ref := range a.document.EnumTypeDefinitions[node.Ref].EnumValuesDefinition.Refs {
+				if a.hasTagDirective(a.document.EnumValueDefinitions[ref].Directives.Refs) {
+					return true
+				}
+			}
+		}
+		for _, fieldRef := range fieldRefs {
+			if a.hasTagDirective(a.document.FieldDefinitions[fieldRef].Directives.Refs) {
+				return true
+			}
+			for _, argumentRef := range a.document.FieldDefinitions[fieldRef].ArgumentsDefinition.Refs {
+				if a.hasTagDirective(a.document.InputValueDefinitions[argumentRef].Directives.Refs) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (a *addTagDirectiveDefinitionVisitor) hasTagDirective(directiveRefs []int) bool {
+	for _, directiveRef := range directiveRefs {
+		if a.document.DirectiveNameString(directiveRef) == tagDirectiveName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package graphql
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/astvisitor"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+	"github.com/pvormste/graphql-go-tools/pkg/lexer/literal"
+	"github.com/pvormste/graphql-go-tools/pkg/operationreport"
+)
+
+var (
+	cacheControlDirectiveName = []byte("cacheControl")
+	cacheControlMaxAge        = []byte("maxAge")
+	cacheControlScope         = []byte("scope")
+)
+
+// CachePolicy describes for how long and for whom a response may be cached.
+// It is the most restrictive combination of the cache hints of all fields of an operation.
+type CachePolicy struct {
+	MaxAge time.Duration
+	Scope  plan.CacheControlScope
+}
+
+func (c CachePolicy) Cacheable() bool {
+	return c.MaxAge > 0
+}
+
+// HeaderValue returns the value of the Cache-Control http header, e.g. "max-age=60, public".
+func (c CachePolicy) HeaderValue() string {
+	if !c.Cacheable() {
+		return "no-store"
+	}
+
+	scope := "public"
+	if c.Scope == plan.CacheControlScopePrivate {
+		scope = "private"
+	}
+	return fmt.Sprintf("max-age=%d, %s", int64(c.MaxAge/time.Second), scope)
+}
+
+// cachePolicyCalculator calculates the CachePolicy of a normalized operation.
+//
+// The max age of a field is taken from its FieldConfiguration, the @cacheControl directive on the field definition
+// or the @cacheControl directive on the type the field returns, in this order.
+// Root fields and fields returning composite types without max age get the default max age,
+// all other fields inherit the max age of their parent.
+type cachePolicyCalculator struct {
+	walker  *astvisitor.Walker
+	visitor *cachePolicyVisitor
+}
+
+func newCachePolicyCalculator() *cachePolicyCalculator {
+	walker := astvisitor.NewWalker(48)
+	visitor := cachePolicyVisitor{
+		Walker: &walker,
+	}
+
+	walker.RegisterEnterFieldVisitor(&visitor)
+
+	return &cachePolicyCalculator{
+		walker:  &walker,
+		visitor: &visitor,
+	}
+}
+
+func (c *cachePolicyCalculator) Calculate(operation, definition *ast.Document, fields plan.FieldConfigurations, defaultMaxAge time.Duration) (CachePolicy, error) {
+	c.visitor.operation = operation
+	c.visitor.definition = definition
+	c.visitor.fields = fields
+	c.visitor.defaultMaxAge = defaultMaxAge
+	c.visitor.maxAge = -1
+	c.visitor.scope = plan.CacheControlScopePublic
+
+	report := operationreport.Report{}
+	c.walker.Walk(operation, definition, &report)
+	if report.HasErrors() {
+		return CachePolicy{}, report
+	}
+
+	policy := CachePolicy{
+		MaxAge: c.visitor.maxAge,
+		Scope:  c.visitor.scope,
+	}
+	if policy.MaxAge < 0 {
+		policy.MaxAge = 0
+	}
+	return policy, nil
+}
+
+type cachePolicyVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	fields                plan.FieldConfigurations
+	defaultMaxAge         time.Duration
+
+	maxAge time.Duration
+	scope  plan.CacheControlScope
+}
+
+func (c *cachePolicyVisitor) EnterField(ref int) {
+	fieldName := c.operation.FieldNameBytes(ref)
+	if bytes.Equal(fieldName, literal.TYPENAME) {
+		return
+	}
+
+	fieldDefinition, exists := c.FieldDefinition(ref)
+	if !exists {
+		return
+	}
+
+	typeName := c.definition.NodeNameBytes(c.EnclosingTypeDefinition)
+	returnTypeName := c.definition.ResolveTypeNameBytes(c.definition.FieldDefinitionType(fieldDefinition))
+	returnTypeNode, _ := c.definition.Index.FirstNodeByNameBytes(returnTypeName)
+	isComposite := returnTypeNode.Kind == ast.NodeKindObjectTypeDefinition ||
+		returnTypeNode.Kind == ast.NodeKindInterfaceTypeDefinition ||
+		returnTypeNode.Kind == ast.NodeKindUnionTypeDefinition
+
+	if fieldConfig := c.fields.ForTypeField(string(typeName), string(fieldName)); fieldConfig != nil && fieldConfig.CacheControl != nil {
+		c.restrict(fieldConfig.CacheControl.MaxAge, true, fieldConfig.CacheControl.Scope)
+		return
+	}
+
+	maxAge, hasMaxAge, scope := c.directiveHint(c.definition.FieldDefinitions[fieldDefinition].Directives.Refs)
+	if isComposite {
+		typeMaxAge, typeHasMaxAge, typeScope := c.directiveHint(c.definition.NodeDirectives(returnTypeNode))
+		if !hasMaxAge {
+			maxAge, hasMaxAge = typeMaxAge, typeHasMaxAge
+		}
+		if typeScope == plan.CacheControlScopePrivate {
+			scope = typeScope
+		}
+	}
+
+	if !hasMaxAge && (isComposite || c.isRootOperationType(typeName)) {
+		maxAge, hasMaxAge = c.defaultMaxAge, true
+	}
+
+	c.restrict(maxAge, hasMaxAge, scope)
+}
+
+func (c *cachePolicyVisitor) restrict(maxAge time.Duration, hasMaxAge bool, scope plan.CacheControlScope) {
+	if hasMaxAge && (c.maxAge < 0 || maxAge < c.maxAge) {
+		c.maxAge = maxAge
+	}
+	if scope == plan.CacheControlScopePrivate {
+		c.scope = plan.CacheControlScopePrivate
+	}
+}
+
+func (c *cachePolicyVisitor) directiveHint(directiveRefs []int) (maxAge time.Duration, hasMaxAge bool, scope plan.CacheControlScope) {
+	for _, directive := range directiveRefs {
+		if !bytes.Equal(c.definition.DirectiveNameBytes(directive), cacheControlDirectiveName) {
+			continue
+		}
+
+		if value, ok := c.definition.DirectiveArgumentValueByName(directive, cacheControlMaxAge); ok && value.Kind == ast.ValueKindInteger {
+			maxAge, hasMaxAge = time.Duration(c.definition.IntValueAsInt(value.Ref))*time.Second, true
+		}
+		if value, ok := c.definition.DirectiveArgumentValueByName(directive, cacheControlScope); ok && value.Kind == ast.ValueKindEnum {
+			scope = plan.CacheControlScope(c.definition.EnumValueNameString(value.Ref))
+		}
+	}
+	return maxAge, hasMaxAge, scope
+}
+
+func (c *cachePolicyVisitor) isRootOperationType(typeName []byte) bool {
+	return bytes.Equal(typeName, c.definition.Index.QueryTypeName) ||
+		bytes.Equal(typeName, c.definition.Index.MutationTypeName) ||
+		bytes.Equal(typeName, c.definition.Index.SubscriptionTypeName)
+}
@@ -0,0 +1,112 @@
+package graphql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+)
+
+const cacheControlTestSchema = `
+	enum CacheControlScope { PUBLIC PRIVATE }
+	directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+
+	type Query {
+		hello: String @cacheControl(maxAge: 60)
+		uncached: String
+		product: Product
+		me: User @cacheControl(maxAge: 10, scope: PRIVATE)
+		configured: String
+	}
+
+	type Product @cacheControl(maxAge: 30) {
+		name: String
+		price: Int @cacheControl(maxAge: 5)
+		reviews: [Review]
+	}
+
+	type Review {
+		body: String
+	}
+
+	type User {
+		name: String
+	}
+`
+
+func TestCachePolicyCalculator_Calculate(t *testing.T) {
+	schema, err := NewSchemaFromString(cacheControlTestSchema)
+	require.NoError(t, err)
+
+	fields := plan.FieldConfigurations{
+		{
+			TypeName:  "Query",
+			FieldName: "configured",
+			CacheControl: &plan.CacheControl{
+				MaxAge: 20 * time.Second,
+				Scope:  plan.CacheControlScopePrivate,
+			},
+		},
+	}
+
+	calculate := func(t *testing.T, query string, defaultMaxAge time.Duration) CachePolicy {
+		t.Helper()
+		request := Request{Query: query}
+		result, err := request.Normalize(schema)
+		require.NoError(t, err)
+		require.True(t, result.Successful)
+
+		policy, err := newCachePolicyCalculator().Calculate(&request.document, &schema.document, fields, defaultMaxAge)
+		require.NoError(t, err)
+		return policy
+	}
+
+	t.Run("field directive", func(t *testing.T) {
+		policy := calculate(t, `{ hello }`, 0)
+		assert.Equal(t, CachePolicy{MaxAge: 60 * time.Second, Scope: plan.CacheControlScopePublic}, policy)
+	})
+
+	t.Run("root field without hint uses default max age", func(t *testing.T) {
+		assert.Equal(t, time.Duration(0), calculate(t, `{ hello uncached }`, 0).MaxAge)
+		assert.Equal(t, 15*time.Second, calculate(t, `{ hello uncached }`, 15*time.Second).MaxAge)
+	})
+
+	t.Run("type directive applies to fields returning the type", func(t *testing.T) {
+		policy := calculate(t, `{ product { name } }`, 0)
+		assert.Equal(t, 30*time.Second, policy.MaxAge)
+	})
+
+	t.Run("minimum max age of all fields", func(t *testing.T) {
+		policy := calculate(t, `{ hello product { name price } }`, 0)
+		assert.Equal(t, 5*time.Second, policy.MaxAge)
+	})
+
+	t.Run("composite field without hint uses default max age", func(t *testing.T) {
+		assert.Equal(t, time.Duration(0), calculate(t, `{ product { reviews { body } } }`, 0).MaxAge)
+		assert.Equal(t, 25*time.Second, calculate(t, `{ product { reviews { body } } }`, 25*time.Second).MaxAge)
+	})
+
+	t.Run("private scope", func(t *testing.T) {
+		policy := calculate(t, `{ hello me { name } }`, 0)
+		assert.Equal(t, CachePolicy{MaxAge: 10 * time.Second, Scope: plan.CacheControlScopePrivate}, policy)
+	})
+
+	t.Run("field configuration takes precedence", func(t *testing.T) {
+		policy := calculate(t, `{ hello configured }`, 0)
+		assert.Equal(t, CachePolicy{MaxAge: 20 * time.Second, Scope: plan.CacheControlScopePrivate}, policy)
+	})
+
+	t.Run("__typename is ignored", func(t *testing.T) {
+		policy := calculate(t, `{ __typename hello }`, 0)
+		assert.Equal(t, 60*time.Second, policy.MaxAge)
+	})
+}
+
+func TestCachePolicy_HeaderValue(t *testing.T) {
+	assert.Equal(t, "no-store", CachePolicy{}.HeaderValue())
+	assert.Equal(t, "max-age=60, public", CachePolicy{MaxAge: time.Minute}.HeaderValue())
+	assert.Equal(t, "max-age=10, private", CachePolicy{MaxAge: 10 * time.Second, Scope: plan.CacheControlScopePrivate}.HeaderValue())
+}
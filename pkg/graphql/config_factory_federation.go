@@ -5,15 +5,18 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
 	"github.com/pvormste/graphql-go-tools/pkg/astparser"
 	graphqlDataSource "github.com/pvormste/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
 	"github.com/pvormste/graphql-go-tools/pkg/federation"
+	"github.com/pvormste/graphql-go-tools/pkg/federation/sdlmerge"
 )
 
 type federationEngineConfigFactoryOptions struct {
-	httpClient *http.Client
+	httpClient        *http.Client
+	configureSubgraph func(subgraphName string, config *graphqlDataSource.Configuration)
 }
 
 type FederationEngineConfigFactoryOption func(options *federationEngineConfigFactoryOptions)
@@ -24,7 +27,15 @@ func WithFederationHttpClient(client *http.Client) FederationEngineConfigFactory
 	}
 }
 
-func NewFederationEngineConfigFactory(dataSourceConfigs []graphqlDataSource.Configuration, batchFactory resolve.DataSourceBatchFactory, opts ...FederationEngineConfigFactoryOption) *FederationEngineConfigFactory {
+// WithFederationSubgraphConfiguration customizes the data source configuration of every subgraph of a supergraph
+// loaded by NewFederationEngineConfigFactoryFromSupergraph, e.g. to set headers or the subscription url.
+func WithFederationSubgraphConfiguration(configure func(subgraphName string, config *graphqlDataSource.Configuration)) FederationEngineConfigFactoryOption {
+	return func(options *federationEngineConfigFactoryOptions) {
+		options.configureSubgraph = configure
+	}
+}
+
+func newFederationEngineConfigFactoryOptions(opts ...FederationEngineConfigFactoryOption) federationEngineConfigFactoryOptions {
 	options := federationEngineConfigFactoryOptions{
 		httpClient: &http.Client{
 			Timeout: time.Second * 10,
@@ -39,6 +50,12 @@ func NewFederationEngineConfigFactory(dataSourceConfigs []graphqlDataSource.Conf
 		optFunc(&options)
 	}
 
+	return options
+}
+
+func NewFederationEngineConfigFactory(dataSourceConfigs []graphqlDataSource.Configuration, batchFactory resolve.DataSourceBatchFactory, opts ...FederationEngineConfigFactoryOption) *FederationEngineConfigFactory {
+	options := newFederationEngineConfigFactoryOptions(opts...)
+
 	return &FederationEngineConfigFactory{
 		httpClient:        options.httpClient,
 		dataSourceConfigs: dataSourceConfigs,
@@ -46,6 +63,44 @@ func NewFederationEngineConfigFactory(dataSourceConfigs []graphqlDataSource.Conf
 	}
 }
 
+// NewFederationEngineConfigFactoryFromSupergraph creates a FederationEngineConfigFactory from a supergraph SDL
+// composed by external tooling instead of composing the SDLs of the subgraphs at startup.
+// The schema of the engine is the api schema of the supergraph and every subgraph declared by
+// @join__graph gets a data source fetching from its url.
+func NewFederationEngineConfigFactoryFromSupergraph(supergraphSDL string, batchFactory resolve.DataSourceBatchFactory, opts ...FederationEngineConfigFactoryOption) (*FederationEngineConfigFactory, error) {
+	options := newFederationEngineConfigFactoryOptions(opts...)
+
+	supergraph, err := sdlmerge.ParseSupergraph(supergraphSDL)
+	if err != nil {
+		return nil, fmt.Errorf("parse supergraph: %v", err)
+	}
+
+	dataSourceConfigs := make([]graphqlDataSource.Configuration, 0, len(supergraph.Subgraphs))
+	for _, subgraph := range supergraph.Subgraphs {
+		dataSourceConfig := graphqlDataSource.Configuration{
+			Fetch: graphqlDataSource.FetchConfiguration{
+				URL:    subgraph.URL,
+				Method: http.MethodPost,
+			},
+			Federation: graphqlDataSource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: subgraph.SDL,
+			},
+			ServiceName: subgraph.Name,
+		}
+		if options.configureSubgraph != nil {
+			options.configureSubgraph(subgraph.Name, &dataSourceConfig)
+		}
+		dataSourceConfigs = append(dataSourceConfigs, dataSourceConfig)
+	}
+
+	factory := NewFederationEngineConfigFactory(dataSourceConfigs, batchFactory, opts...)
+	if err = factory.SetMergedSchemaFromString(supergraph.Schema); err != nil {
+		return nil, err
+	}
+	return factory, nil
+}
+
 // FederationEngineConfigFactory is used to create a v2 engine config for a supergraph with multiple data sources for subgraphs.
 type FederationEngineConfigFactory struct {
 	httpClient        *http.Client
@@ -97,7 +152,7 @@ func (f *FederationEngineConfigFactory) EngineV2Configuration() (conf EngineV2Co
 		return conf, fmt.Errorf("create field configs: %v", err)
 	}
 
-	dataSources, err := f.engineConfigDataSources()
+	dataSources, err := f.engineConfigDataSources(schema)
 	if err != nil {
 		return conf, fmt.Errorf("create datasource config: %v", err)
 	}
@@ -112,28 +167,64 @@ func (f *FederationEngineConfigFactory) engineConfigFieldConfigs(schema *Schema)
 	var planFieldConfigs plan.FieldConfigurations
 
 	for _, dataSourceConfig := range f.dataSourceConfigs {
-		doc, report := astparser.ParseGraphqlDocumentString(dataSourceConfig.Federation.ServiceSDL)
-		if report.HasErrors() {
-			return nil, fmt.Errorf("parse graphql document string: %s", report.Error())
+		doc, err := parseServiceSDL(dataSourceConfig.Federation.ServiceSDL)
+		if err != nil {
+			return nil, err
 		}
-		extractor := plan.NewRequiredFieldExtractor(&doc)
+		extractor := plan.NewRequiredFieldExtractor(doc)
 		planFieldConfigs = append(planFieldConfigs, extractor.GetAllRequiredFields()...)
 	}
 
+	// the fields of entity interfaces require the key for the object types implementing them as well
+	planFieldConfigs = plan.AddImplementingTypeFieldConfigurations(planFieldConfigs, &schema.document)
 	planFieldConfigs = newGraphQLFieldConfigsV2Generator(schema).Generate(planFieldConfigs...)
 	return planFieldConfigs, nil
 }
 
-func (f *FederationEngineConfigFactory) engineConfigDataSources() (planDataSources []plan.DataSourceConfiguration, err error) {
+func (f *FederationEngineConfigFactory) engineConfigDataSources(schema *Schema) (planDataSources []plan.DataSourceConfiguration, err error) {
+	docs := make([]*ast.Document, 0, len(f.dataSourceConfigs))
+	serviceNames := make(map[string]struct{}, len(f.dataSourceConfigs))
+	var overrides []plan.FieldOverride
 	for _, dataSourceConfig := range f.dataSourceConfigs {
-		doc, report := astparser.ParseGraphqlDocumentString(dataSourceConfig.Federation.ServiceSDL)
-		if report.HasErrors() {
-			return nil, fmt.Errorf("parse graphql document string: %s", report.Error())
+		doc, err := parseServiceSDL(dataSourceConfig.Federation.ServiceSDL)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+		if dataSourceConfig.ServiceName != "" {
+			serviceNames[dataSourceConfig.ServiceName] = struct{}{}
 		}
+		overrides = append(overrides, plan.NewOverriddenFieldExtractor(doc).GetAllOverriddenFields()...)
+	}
+
+	for _, override := range overrides {
+		if _, ok := serviceNames[override.From]; !ok {
+			return nil, fmt.Errorf("field %s.%s overrides the subgraph %q, but no subgraph with this service name is configured", override.TypeName, override.FieldName, override.From)
+		}
+	}
 
-		planDataSource := newGraphQLDataSourceV2Generator(&doc).Generate(dataSourceConfig, f.batchFactory, f.httpClient)
+	for i, dataSourceConfig := range f.dataSourceConfigs {
+		planDataSource := newGraphQLDataSourceV2Generator(docs[i]).Generate(dataSourceConfig, f.batchFactory, f.httpClient)
+		// fields taken over by another subgraph with @override are no longer resolved by this subgraph
+		planDataSource.RootNodes = plan.RemoveOverriddenFields(planDataSource.RootNodes, overrides, dataSourceConfig.ServiceName)
+		planDataSource.ChildNodes = plan.RemoveOverriddenFields(planDataSource.ChildNodes, overrides, dataSourceConfig.ServiceName)
+		// a subgraph extending an entity interface resolves its fields for the object types implementing it
+		planDataSource.RootNodes = plan.AddImplementingTypeFields(planDataSource.RootNodes, &schema.document)
+		planDataSource.ChildNodes = plan.AddImplementingTypeFields(planDataSource.ChildNodes, &schema.document)
 		planDataSources = append(planDataSources, planDataSource)
 	}
 
 	return
 }
+
+// parseServiceSDL parses the SDL of a subgraph, the directives of federation v2 subgraphs are normalized to their canonical names.
+func parseServiceSDL(serviceSDL string) (*ast.Document, error) {
+	doc, report := astparser.ParseGraphqlDocumentString(serviceSDL)
+	if report.HasErrors() {
+		return nil, fmt.Errorf("parse graphql document string: %s", report.Error())
+	}
+	if _, err := sdlmerge.NormalizeFederationV2Directives(&doc); err != nil {
+		return nil, fmt.Errorf("normalize federation v2 directives: %v", err)
+	}
+	return &doc, nil
+}
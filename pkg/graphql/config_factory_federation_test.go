@@ -1,9 +1,11 @@
 package graphql
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
+	"github.com/buger/jsonparser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -12,6 +14,7 @@ import (
 	graphqlDataSource "github.com/pvormste/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
+	"github.com/pvormste/graphql-go-tools/pkg/operationreport"
 )
 
 func TestEngineConfigV2Factory_EngineV2Configuration(t *testing.T) {
@@ -203,7 +206,16 @@ func TestEngineConfigV2Factory_EngineV2Configuration(t *testing.T) {
 						},
 						{
 							TypeName:   "User",
-							FieldNames: []string{"reviews", "id", "username"},
+							FieldNames: []string{"reviews", "id"},
+						},
+					},
+					Provides: []plan.FieldProvides{
+						{
+							TypeName:  "Review",
+							FieldName: "author",
+							Provides: []plan.FieldProvides{
+								{TypeName: "User", FieldName: "username"},
+							},
 						},
 					},
 					Factory: &graphqlDataSource.Factory{
@@ -227,6 +239,457 @@ func TestEngineConfigV2Factory_EngineV2Configuration(t *testing.T) {
 	})
 }
 
+func TestFederationEngineConfigFactory_Provides(t *testing.T) {
+	const (
+		accounts = `
+			extend type Query {
+				me: User
+			}
+			type User @key(fields: "id") {
+				id: ID!
+				username: String!
+				address: Address!
+			}
+			type Address {
+				street: String!
+				city: String!
+			}`
+		reviews = `
+			extend type Query {
+				topReviews: [Review]
+			}
+			type Review {
+				body: String!
+				author: User! @provides(fields: "username address { street }")
+				editor: User!
+			}
+			extend type User @key(fields: "id") {
+				id: ID! @external
+				username: String! @external
+				address: Address! @external
+				reviews: [Review]
+			}
+			extend type Address {
+				street: String! @external
+			}`
+	)
+
+	factory := NewFederationEngineConfigFactory([]graphqlDataSource.Configuration{
+		{
+			Fetch:      graphqlDataSource.FetchConfiguration{URL: "http://user.service"},
+			Federation: graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: accounts},
+		},
+		{
+			Fetch:      graphqlDataSource.FetchConfiguration{URL: "http://review.service"},
+			Federation: graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: reviews},
+		},
+	}, graphqlDataSource.NewBatchFactory(), WithFederationHttpClient(&http.Client{}))
+	conf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	t.Run("provided fields are resolved by the providing subgraph", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`http://review.service {topReviews {author {username id}}}`,
+		}, federationUpstreamQueries(t, conf, `{ topReviews { author { username } } }`))
+	})
+
+	t.Run("nested provided fields are resolved by the providing subgraph", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`http://review.service {topReviews {author {address {street} id}}}`,
+		}, federationUpstreamQueries(t, conf, `{ topReviews { author { address { street } } } }`))
+	})
+
+	t.Run("provides on a nested path", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`http://user.service {me {id}}`,
+			`http://review.service query($representations: [_Any!]!){_entities(representations: $representations){... on User {reviews {author {username id}}}}}`,
+		}, federationUpstreamQueries(t, conf, `{ me { reviews { author { username } } } }`))
+	})
+
+	t.Run("fields which aren't provided on the path are fetched from the owning subgraph", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`http://review.service {topReviews {editor {id}}}`,
+			`http://user.service query($representations: [_Any!]!){_entities(representations: $representations){... on User {username}}}`,
+		}, federationUpstreamQueries(t, conf, `{ topReviews { editor { username } } }`))
+	})
+
+	t.Run("fields which aren't provided are fetched from the owning subgraph", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`http://review.service {topReviews {author {id}}}`,
+			`http://user.service query($representations: [_Any!]!){_entities(representations: $representations){... on User {address {city}}}}`,
+		}, federationUpstreamQueries(t, conf, `{ topReviews { author { address { city } } } }`))
+	})
+}
+
+func TestFederationEngineConfigFactory_FederationV2(t *testing.T) {
+	const (
+		products = `
+			extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: [{name: "@key", as: "@primaryKey"}, "@shareable", "@tag"])
+			type Query {
+				topProducts: [Product] @tag(name: "public")
+			}
+			type Product @primaryKey(fields: "upc") {
+				upc: String!
+				name: String! @shareable
+				inStock: Boolean
+			}`
+		inventory = `
+			extend schema @link(url: "https://specs.apollo.dev/federation/v2.3", import: ["@key", "@shareable", "@override", "@inaccessible"])
+			type Product @key(fields: "upc") {
+				upc: String!
+				name: String! @shareable
+				inStock: Boolean @override(from: "products")
+				warehouse: String @inaccessible
+			}`
+	)
+
+	factory := NewFederationEngineConfigFactory([]graphqlDataSource.Configuration{
+		{
+			Fetch:       graphqlDataSource.FetchConfiguration{URL: "http://products.service"},
+			Federation:  graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: products},
+			ServiceName: "products",
+		},
+		{
+			Fetch:       graphqlDataSource.FetchConfiguration{URL: "http://inventory.service"},
+			Federation:  graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: inventory},
+			ServiceName: "inventory",
+		},
+	}, graphqlDataSource.NewBatchFactory(), WithFederationHttpClient(&http.Client{}))
+	conf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	t.Run("inaccessible fields are not part of the schema", func(t *testing.T) {
+		request := Request{Query: `{ topProducts { warehouse } }`}
+		result, err := request.ValidateForSchema(conf.schema)
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+	})
+
+	t.Run("shareable fields are resolved by the subgraph of the parent", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`http://products.service {topProducts {name upc}}`,
+		}, federationUpstreamQueries(t, conf, `{ topProducts { name } }`))
+	})
+
+	t.Run("overridden fields are resolved by the overriding subgraph", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`http://products.service {topProducts {name upc}}`,
+			`http://inventory.service query($representations: [_Any!]!){_entities(representations: $representations){... on Product {inStock}}}`,
+		}, federationUpstreamQueries(t, conf, `{ topProducts { name inStock } }`))
+	})
+
+	t.Run("overriding a subgraph which isn't configured is rejected", func(t *testing.T) {
+		factory := NewFederationEngineConfigFactory([]graphqlDataSource.Configuration{
+			{
+				Fetch:      graphqlDataSource.FetchConfiguration{URL: "http://products.service"},
+				Federation: graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: products},
+			},
+			{
+				Fetch:       graphqlDataSource.FetchConfiguration{URL: "http://inventory.service"},
+				Federation:  graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: inventory},
+				ServiceName: "inventory",
+			},
+		}, graphqlDataSource.NewBatchFactory(), WithFederationHttpClient(&http.Client{}))
+		_, err := factory.EngineV2Configuration()
+		assert.EqualError(t, err, `create datasource config: field Product.inStock overrides the subgraph "products", but no subgraph with this service name is configured`)
+	})
+}
+
+func TestFederationEngineConfigFactory_Keys(t *testing.T) {
+	t.Run("the key resolvable by the parent subgraph is picked", func(t *testing.T) {
+		const (
+			products = `
+				extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key"])
+				type Query {
+					topProducts: [Product]
+				}
+				type Product @key(fields: "sku") {
+					sku: String!
+					name: String!
+				}`
+			inventory = `
+				extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key"])
+				type Product @key(fields: "upc") @key(fields: "sku") {
+					upc: String!
+					sku: String!
+					inStock: Boolean
+				}`
+		)
+
+		factory := NewFederationEngineConfigFactory([]graphqlDataSource.Configuration{
+			{
+				Fetch:      graphqlDataSource.FetchConfiguration{URL: "http://products.service"},
+				Federation: graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: products},
+			},
+			{
+				Fetch:      graphqlDataSource.FetchConfiguration{URL: "http://inventory.service"},
+				Federation: graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: inventory},
+			},
+		}, graphqlDataSource.NewBatchFactory(), WithFederationHttpClient(&http.Client{}))
+		conf, err := factory.EngineV2Configuration()
+		require.NoError(t, err)
+
+		query := `{ topProducts { name inStock } }`
+		assert.Equal(t, []string{
+			`http://products.service {topProducts {name sku}}`,
+			`http://inventory.service query($representations: [_Any!]!){_entities(representations: $representations){... on Product {inStock}}}`,
+		}, federationUpstreamQueries(t, conf, query))
+
+		fetches := federationFetches(t, conf, query)
+		require.Len(t, fetches, 2)
+		assert.Contains(t, fetches[1].Input, `"variables":{"representations":[{"sku":$$0$$,"__typename":"Product"}]}`)
+	})
+
+	t.Run("compound keys are rendered as nested representations", func(t *testing.T) {
+		const (
+			accounts = `
+				extend type Query {
+					me: User
+				}
+				type User @key(fields: "id organization { id }") {
+					id: ID!
+					organization: Organization!
+					username: String!
+				}
+				type Organization {
+					id: ID!
+				}`
+			reviews = `
+				type Review {
+					body: String!
+				}
+				type Organization {
+					id: ID!
+				}
+				extend type User @key(fields: "id organization { id }") {
+					id: ID! @external
+					organization: Organization! @external
+					reviews: [Review]
+				}`
+		)
+
+		factory := NewFederationEngineConfigFactory([]graphqlDataSource.Configuration{
+			{
+				Fetch:      graphqlDataSource.FetchConfiguration{URL: "http://accounts.service"},
+				Federation: graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: accounts},
+			},
+			{
+				Fetch:      graphqlDataSource.FetchConfiguration{URL: "http://reviews.service"},
+				Federation: graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: reviews},
+			},
+		}, graphqlDataSource.NewBatchFactory(), WithFederationHttpClient(&http.Client{}))
+		conf, err := factory.EngineV2Configuration()
+		require.NoError(t, err)
+
+		query := `{ me { username reviews { body } } }`
+		assert.Equal(t, []string{
+			`http://accounts.service {me {username id organization {id}}}`,
+			`http://reviews.service query($representations: [_Any!]!){_entities(representations: $representations){... on User {reviews {body}}}}`,
+		}, federationUpstreamQueries(t, conf, query))
+
+		fetches := federationFetches(t, conf, query)
+		require.Len(t, fetches, 2)
+		assert.Contains(t, fetches[1].Input, `"variables":{"representations":[{"organization":{"id":$$1$$},"id":$$0$$,"__typename":"User"}]}`)
+		require.Len(t, fetches[1].Variables, 2)
+		assert.Equal(t, []string{"organization", "id"}, fetches[1].Variables[1].(*resolve.ObjectVariable).Path)
+	})
+}
+
+func TestFederationEngineConfigFactory_EntityInterfaces(t *testing.T) {
+	const (
+		accounts = `
+			extend type Query {
+				accounts: [Account]
+			}
+			interface Account @key(fields: "id") {
+				id: ID!
+				name: String!
+			}
+			type User implements Account @key(fields: "id") {
+				id: ID!
+				name: String!
+			}
+			type Admin implements Account @key(fields: "id") {
+				id: ID!
+				name: String!
+				level: Int!
+			}`
+		reviews = `
+			type Review {
+				body: String!
+			}
+			extend interface Account @key(fields: "id") {
+				id: ID! @external
+				reviews: [Review]
+			}`
+	)
+
+	factory := NewFederationEngineConfigFactory([]graphqlDataSource.Configuration{
+		{
+			Fetch:      graphqlDataSource.FetchConfiguration{URL: "http://accounts.service"},
+			Federation: graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: accounts},
+		},
+		{
+			Fetch:      graphqlDataSource.FetchConfiguration{URL: "http://reviews.service"},
+			Federation: graphqlDataSource.FederationConfiguration{Enabled: true, ServiceSDL: reviews},
+		},
+	}, graphqlDataSource.NewBatchFactory(), WithFederationHttpClient(&http.Client{}))
+	conf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	t.Run("field added to the interface by another subgraph", func(t *testing.T) {
+		query := `{ accounts { name reviews { body } } }`
+		assert.Equal(t, []string{
+			`http://accounts.service {accounts {__typename name id}}`,
+			`http://reviews.service query($representations: [_Any!]!){_entities(representations: $representations){... on Account {reviews {body}}}}`,
+		}, federationUpstreamQueries(t, conf, query))
+
+		fetches := federationFetches(t, conf, query)
+		require.Len(t, fetches, 2)
+		assert.Contains(t, fetches[1].Input, `"variables":{"representations":[{"id":$$1$$,"__typename":$$0$$}]}`)
+		require.Len(t, fetches[1].Variables, 2)
+		assert.Equal(t, []string{"__typename"}, fetches[1].Variables[0].(*resolve.ObjectVariable).Path)
+	})
+
+	t.Run("fragment on a concrete type is dispatched to the subgraph extending the interface", func(t *testing.T) {
+		query := `{ accounts { ... on Admin { level reviews { body } } } }`
+		assert.Equal(t, []string{
+			`http://accounts.service {accounts {__typename ... on Admin {level id}}}`,
+			`http://reviews.service query($representations: [_Any!]!){_entities(representations: $representations){... on Account {reviews {body}}}}`,
+		}, federationUpstreamQueries(t, conf, query))
+
+		fetches := federationFetches(t, conf, query)
+		require.Len(t, fetches, 2)
+		assert.Contains(t, fetches[1].Input, `"variables":{"representations":[{"id":$$0$$,"__typename":"Admin"}]}`)
+	})
+}
+
+func TestFederationEngineConfigFactory_Supergraph(t *testing.T) {
+	const supergraph = `
+		schema
+			@link(url: "https://specs.apollo.dev/link/v1.0")
+			@link(url: "https://specs.apollo.dev/join/v0.2", for: EXECUTION)
+		{
+			query: Query
+		}
+
+		directive @join__field(graph: join__Graph!, requires: join__FieldSet, provides: join__FieldSet, type: String, external: Boolean, override: String, usedOverridden: Boolean) repeatable on FIELD_DEFINITION | INPUT_FIELD_DEFINITION
+		directive @join__graph(name: String!, url: String!) on ENUM_VALUE
+		directive @join__type(graph: join__Graph!, key: join__FieldSet, extension: Boolean! = false, resolvable: Boolean! = true) repeatable on OBJECT | INTERFACE | UNION | ENUM | INPUT_OBJECT | SCALAR
+		directive @link(url: String, as: String, for: link__Purpose, import: [link__Import]) repeatable on SCHEMA
+
+		scalar join__FieldSet
+
+		enum join__Graph {
+			INVENTORY @join__graph(name: "inventory", url: "http://inventory.service")
+			PRODUCTS @join__graph(name: "products", url: "http://products.service")
+		}
+
+		scalar link__Import
+
+		enum link__Purpose {
+			SECURITY
+			EXECUTION
+		}
+
+		type Product
+			@join__type(graph: INVENTORY, key: "upc")
+			@join__type(graph: PRODUCTS, key: "upc")
+		{
+			upc: String!
+			weight: Int @join__field(graph: INVENTORY, external: true) @join__field(graph: PRODUCTS)
+			name: String @join__field(graph: PRODUCTS)
+			inStock: Boolean @join__field(graph: INVENTORY)
+			shippingEstimate: Int @join__field(graph: INVENTORY, requires: "weight")
+		}
+
+		type Query
+			@join__type(graph: INVENTORY)
+			@join__type(graph: PRODUCTS)
+		{
+			topProducts(first: Int = 5): [Product] @join__field(graph: PRODUCTS)
+		}
+	`
+
+	var configuredSubgraphs []string
+	factory, err := NewFederationEngineConfigFactoryFromSupergraph(supergraph, graphqlDataSource.NewBatchFactory(),
+		WithFederationHttpClient(&http.Client{}),
+		WithFederationSubgraphConfiguration(func(subgraphName string, config *graphqlDataSource.Configuration) {
+			configuredSubgraphs = append(configuredSubgraphs, subgraphName)
+			config.Fetch.Header = http.Header{"X-Subgraph": []string{subgraphName}}
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"inventory", "products"}, configuredSubgraphs)
+
+	schema, err := factory.MergedSchema()
+	require.NoError(t, err)
+	_, joinGraphExists := schema.document.Index.FirstNodeByNameStr("join__Graph")
+	assert.False(t, joinGraphExists)
+
+	conf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	query := `{ topProducts { name shippingEstimate } }`
+	assert.Equal(t, []string{
+		`http://products.service query($a: Int){topProducts(first: $a){name upc weight}}`,
+		`http://inventory.service query($representations: [_Any!]!){_entities(representations: $representations){... on Product {shippingEstimate}}}`,
+	}, federationUpstreamQueries(t, conf, query))
+
+	fetches := federationFetches(t, conf, query)
+	require.Len(t, fetches, 2)
+	assert.Contains(t, fetches[1].Input, `"header":{"X-Subgraph":["inventory"]}`)
+	// the subgraph name is used to wrap upstream errors and in traces
+	assert.Equal(t, "products", fetches[0].ProcessResponseConfig.UpstreamServiceName)
+	assert.Equal(t, "inventory", fetches[1].ProcessResponseConfig.UpstreamServiceName)
+}
+
+// federationUpstreamQueries returns the upstream url and query of every fetch of the plan for the query
+func federationUpstreamQueries(t *testing.T, conf EngineV2Configuration, query string) []string {
+	var queries []string
+	for _, fetch := range federationFetches(t, conf, query) {
+		url, err := jsonparser.GetString([]byte(fetch.Input), "url")
+		require.NoError(t, err)
+		upstreamQuery, err := jsonparser.GetString([]byte(fetch.Input), "body", "query")
+		require.NoError(t, err)
+		queries = append(queries, url+" "+upstreamQuery)
+	}
+	return queries
+}
+
+// federationFetches returns every fetch of the plan for the query
+func federationFetches(t *testing.T, conf EngineV2Configuration, query string) []*resolve.SingleFetch {
+	request := Request{Query: query}
+	result, err := request.Normalize(conf.schema)
+	require.NoError(t, err)
+	require.True(t, result.Successful)
+
+	var report operationreport.Report
+	planned := plan.NewPlanner(context.Background(), conf.plannerConfig).Plan(&request.document, &conf.schema.document, "", &report)
+	require.False(t, report.HasErrors(), report.Error())
+
+	var fetches []*resolve.SingleFetch
+	var walk func(node resolve.Node)
+	walk = func(node resolve.Node) {
+		switch n := node.(type) {
+		case *resolve.Object:
+			switch fetch := n.Fetch.(type) {
+			case *resolve.SingleFetch:
+				fetches = append(fetches, fetch)
+			case *resolve.BatchFetch:
+				fetches = append(fetches, fetch.Fetch)
+			}
+			for _, field := range n.Fields {
+				walk(field.Value)
+			}
+		case *resolve.Array:
+			walk(n.Item)
+		}
+	}
+	walk(planned.(*plan.SynchronousResponsePlan).Response.Data)
+	return fetches
+}
+
 const (
 	accountSchema = `
 		extend type Query {
@@ -7,6 +7,7 @@ import (
 	graphqlDataSource "github.com/pvormste/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/tracing"
 )
 
 const (
@@ -18,6 +19,11 @@ type EngineV2Configuration struct {
 	plannerConfig            plan.Configuration
 	websocketBeforeStartHook WebsocketBeforeStartHook
 	dataLoaderConfig         dataLoaderConfig
+	persistedQueryConfig     PersistedQueryConfiguration
+	responseCacheConfig      *ResponseCacheConfiguration
+	operationLimitsConfig    *OperationLimitsConfiguration
+	rateLimitConfig          *RateLimitConfiguration
+	tracer                   tracing.Tracer
 }
 
 func NewEngineV2Configuration(schema *Schema) EngineV2Configuration {
@@ -73,6 +79,31 @@ func (e *EngineV2Configuration) SetWebsocketBeforeStartHook(hook WebsocketBefore
 	e.websocketBeforeStartHook = hook
 }
 
+// SetPersistedQueryConfiguration enables automatic persisted queries, optionally restricted to an allow-list
+func (e *EngineV2Configuration) SetPersistedQueryConfiguration(config PersistedQueryConfiguration) {
+	e.persistedQueryConfig = config
+}
+
+// SetResponseCacheConfiguration enables cache policies and, if a store is configured, caching of query responses
+func (e *EngineV2Configuration) SetResponseCacheConfiguration(config ResponseCacheConfiguration) {
+	e.responseCacheConfig = &config
+}
+
+// SetOperationLimitsConfiguration enables limits for the cost, depth and node count of operations
+func (e *EngineV2Configuration) SetOperationLimitsConfiguration(config OperationLimitsConfiguration) {
+	e.operationLimitsConfig = &config
+}
+
+// SetRateLimitConfiguration enables rate limiting which charges the cost of operations against a token bucket per caller
+func (e *EngineV2Configuration) SetRateLimitConfiguration(config RateLimitConfiguration) {
+	e.rateLimitConfig = &config
+}
+
+// SetTracer enables spans for parsing, normalization, validation, planning, resolving and every fetch of an operation
+func (e *EngineV2Configuration) SetTracer(tracer tracing.Tracer) {
+	e.tracer = tracer
+}
+
 type graphqlDataSourceV2Generator struct {
 	document *ast.Document
 }
@@ -87,6 +118,7 @@ func (d *graphqlDataSourceV2Generator) Generate(config graphqlDataSource.Configu
 	var planDataSource plan.DataSourceConfiguration
 	extractor := plan.NewLocalTypeFieldExtractor(d.document)
 	planDataSource.RootNodes, planDataSource.ChildNodes = extractor.GetAllNodes()
+	planDataSource.Provides = plan.NewProvidedFieldExtractor(d.document).GetAllProvidedFields()
 
 	factory := &graphqlDataSource.Factory{
 		HTTPClient:   httpClient,
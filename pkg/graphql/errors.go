@@ -23,6 +23,15 @@ func RequestErrorsFromError(err error) RequestErrors {
 	if errors, ok := err.(RequestErrors); ok {
 		return errors
 	}
+	if persistedQueryErr, ok := err.(PersistedQueryError); ok {
+		return RequestErrors{persistedQueryErr.requestError()}
+	}
+	if operationLimitErr, ok := err.(OperationLimitError); ok {
+		return RequestErrors{operationLimitErr.requestError()}
+	}
+	if rateLimitErr, ok := err.(RateLimitError); ok {
+		return RequestErrors{rateLimitErr.requestError()}
+	}
 	if report, ok := err.(operationreport.Report); ok {
 		if len(report.ExternalErrors) == 0 {
 			return RequestErrors{
@@ -111,19 +120,22 @@ func (o RequestErrors) ErrorByIndex(i int) error {
 }
 
 type RequestError struct {
-	Message   string                   `json:"message"`
-	Locations []graphqlerrors.Location `json:"locations,omitempty"`
-	Path      ErrorPath                `json:"path"`
+	Message    string                   `json:"message"`
+	Locations  []graphqlerrors.Location `json:"locations,omitempty"`
+	Path       ErrorPath                `json:"path"`
+	Extensions json.RawMessage          `json:"extensions,omitempty"`
 }
 
 func (o RequestError) MarshalJSON() ([]byte, error) {
 	if o.Path.Len() == 0 {
 		return json.Marshal(struct {
-			Message   string                   `json:"message"`
-			Locations []graphqlerrors.Location `json:"locations,omitempty"`
+			Message    string                   `json:"message"`
+			Locations  []graphqlerrors.Location `json:"locations,omitempty"`
+			Extensions json.RawMessage          `json:"extensions,omitempty"`
 		}{
-			Message:   o.Message,
-			Locations: o.Locations,
+			Message:    o.Message,
+			Locations:  o.Locations,
+			Extensions: o.Extensions,
 		})
 	}
 	path, err := o.Path.MarshalJSON()
@@ -131,13 +143,15 @@ func (o RequestError) MarshalJSON() ([]byte, error) {
 		return nil, err
 	}
 	return json.Marshal(struct {
-		Message   string                   `json:"message"`
-		Locations []graphqlerrors.Location `json:"locations,omitempty"`
-		Path      json.RawMessage          `json:"path"`
+		Message    string                   `json:"message"`
+		Locations  []graphqlerrors.Location `json:"locations,omitempty"`
+		Path       json.RawMessage          `json:"path"`
+		Extensions json.RawMessage          `json:"extensions,omitempty"`
 	}{
-		Message:   o.Message,
-		Locations: o.Locations,
-		Path:      path,
+		Message:    o.Message,
+		Locations:  o.Locations,
+		Path:       path,
+		Extensions: o.Extensions,
 	})
 }
 
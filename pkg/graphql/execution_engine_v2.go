@@ -6,6 +6,7 @@ import (
 	"compress/gzip"
 	"context"
 	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
@@ -20,6 +21,7 @@ import (
 	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/httpclient"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/tracing"
 	"github.com/pvormste/graphql-go-tools/pkg/operationreport"
 	"github.com/pvormste/graphql-go-tools/pkg/pool"
 	"github.com/pvormste/graphql-go-tools/pkg/postprocess"
@@ -105,14 +107,16 @@ func (e *EngineResultWriter) AsHTTPResponse(status int, headers http.Header) *ht
 }
 
 type internalExecutionContext struct {
-	resolveContext *resolve.Context
-	postProcessor  *postprocess.Processor
+	resolveContext        *resolve.Context
+	postProcessor         *postprocess.Processor
+	cachePolicyCalculator *cachePolicyCalculator
 }
 
 func newInternalExecutionContext() *internalExecutionContext {
 	return &internalExecutionContext{
-		resolveContext: resolve.NewContext(context.Background()),
-		postProcessor:  postprocess.DefaultProcessor(),
+		resolveContext:        resolve.NewContext(context.Background()),
+		postProcessor:         postprocess.DefaultProcessor(),
+		cachePolicyCalculator: newCachePolicyCalculator(),
 	}
 }
 
@@ -146,6 +150,7 @@ type ExecutionEngineV2 struct {
 	resolver                     *resolve.Resolver
 	internalExecutionContextPool sync.Pool
 	executionPlanCache           *lru.Cache
+	rateLimiter                  *rateLimiter
 }
 
 type WebsocketBeforeStartHook interface {
@@ -166,6 +171,16 @@ func WithAfterFetchHook(hook resolve.AfterFetchHook) ExecutionOptionsV2 {
 	}
 }
 
+// WithExecutionTracing adds the start and end time of every fetch and resolved field to the extensions of the response.
+// resolve.TraceFormatJSON adds a readable trace as extensions.tracing,
+// resolve.TraceFormatFTV1 adds an Apollo Federated Tracing trace as extensions.ftv1, including the ftv1 traces of federated subgraphs.
+// Tracing is only supported for synchronous responses.
+func WithExecutionTracing(format resolve.TraceFormat) ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.resolveContext.EnableTracing(format)
+	}
+}
+
 func WithAdditionalHttpHeaders(headers http.Header, excludeByKeys ...string) ExecutionOptionsV2 {
 	return func(ctx *internalExecutionContext) {
 		if len(headers) == 0 {
@@ -200,6 +215,11 @@ func NewExecutionEngineV2(ctx context.Context, logger abstractlogger.Logger, eng
 	}
 	fetcher := resolve.NewFetcher(engineConfig.dataLoaderConfig.EnableSingleFlightLoader)
 
+	rateLimiter, err := newRateLimiter(engineConfig.rateLimitConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	introspectionCfg, err := introspection_datasource.NewIntrospectionConfigFactory(&engineConfig.schema.document)
 	if err != nil {
 		return nil, err
@@ -221,72 +241,291 @@ func NewExecutionEngineV2(ctx context.Context, logger abstractlogger.Logger, eng
 			},
 		},
 		executionPlanCache: executionPlanCache,
+		rateLimiter:        rateLimiter,
 	}, nil
 }
 
+// LoadPersistedQuery resolves the query of requests using automatic persisted queries.
+// It is called by Execute, but must be called before the query of a request gets inspected, e.g. for its operation type.
+func (e *ExecutionEngineV2) LoadPersistedQuery(ctx context.Context, operation *Request) error {
+	return loadPersistedQuery(ctx, e.config.persistedQueryConfig, operation)
+}
+
 func (e *ExecutionEngineV2) Execute(ctx context.Context, operation *Request, writer resolve.FlushWriter, options ...ExecutionOptionsV2) error {
-	if !operation.IsNormalized() {
-		result, err := operation.Normalize(e.config.schema)
-		if err != nil {
+	if err := e.LoadPersistedQuery(ctx, operation); err != nil {
+		return err
+	}
+
+	if !operation.isParsed {
+		if err := e.parse(ctx, operation); err != nil {
 			return err
 		}
+	}
 
-		if !result.Successful {
-			return result.Errors
+	if !operation.IsNormalized() {
+		if err := e.normalize(ctx, operation); err != nil {
+			return err
 		}
 	}
 
-	result, err := operation.ValidateForSchema(e.config.schema)
-	if err != nil {
+	if err := e.validate(ctx, operation); err != nil {
 		return err
 	}
-	if !result.Valid {
-		return result.Errors
+
+	cost := &operationCost{operation: operation, schema: e.config.schema}
+	if err := checkOperationLimits(e.config.operationLimitsConfig, operation, e.config.schema, cost); err != nil {
+		return err
 	}
 
 	execContext := e.getExecutionCtx()
 	defer e.putExecutionCtx(execContext)
 
 	execContext.prepare(ctx, operation.Variables, operation.request)
+	execContext.resolveContext.SetTracer(e.config.tracer)
 
 	for i := range options {
 		options[i](execContext)
 	}
 
-	var report operationreport.Report
-	cachedPlan := e.getCachedPlan(execContext, &operation.document, &e.config.schema.document, operation.OperationName, &report)
-	if report.HasErrors() {
-		return report
+	if err := e.rateLimiter.charge(ctx, operation, execContext.resolveContext.Request.Header, cost); err != nil {
+		return err
+	}
+
+	cachedPlan, err := e.plan(ctx, execContext, operation)
+	if err != nil {
+		return err
 	}
 
+	resolveCtx, span := tracing.Start(ctx, e.config.tracer, tracing.SpanResolve, e.operationAttributes(operation)...)
+	execContext.resolveContext.Context = resolveCtx
+
 	switch p := cachedPlan.(type) {
 	case *plan.SynchronousResponsePlan:
-		err = e.resolver.ResolveGraphQLResponse(execContext.resolveContext, p.Response, nil, writer)
+		err = e.resolveSynchronousResponse(execContext, operation, p, writer)
+	case *plan.StreamingResponsePlan:
+		err = e.resolver.ResolveGraphQLStreamingResponse(execContext.resolveContext, p.Response, nil, writer)
 	case *plan.SubscriptionResponsePlan:
 		err = e.resolver.ResolveGraphQLSubscription(execContext.resolveContext, p.Response, writer)
 	default:
-		return errors.New("execution of operation is not possible")
+		err = errors.New("execution of operation is not possible")
 	}
 
+	tracing.End(span, err)
 	return err
 }
 
-func (e *ExecutionEngineV2) getCachedPlan(ctx *internalExecutionContext, operation, definition *ast.Document, operationName string, report *operationreport.Report) plan.Plan {
+// parse parses the query within a parse span.
+// Operations parsed before Execute, e.g. to inspect the operation type, don't get a parse span.
+func (e *ExecutionEngineV2) parse(ctx context.Context, operation *Request) (err error) {
+	_, span := tracing.Start(ctx, e.config.tracer, tracing.SpanParse)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	report := operation.parseQueryOnce()
+	result, err := normalizationResultFromReport(report)
+	if err != nil {
+		return err
+	}
+	if !result.Successful {
+		return result.Errors
+	}
+	return nil
+}
+
+func (e *ExecutionEngineV2) normalize(ctx context.Context, operation *Request) (err error) {
+	_, span := tracing.Start(ctx, e.config.tracer, tracing.SpanNormalize)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	result, err := operation.Normalize(e.config.schema)
+	if err != nil {
+		return err
+	}
+	if !result.Successful {
+		return result.Errors
+	}
+	return nil
+}
 
+func (e *ExecutionEngineV2) validate(ctx context.Context, operation *Request) (err error) {
+	_, span := tracing.Start(ctx, e.config.tracer, tracing.SpanValidate)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	result, err := operation.ValidateForSchema(e.config.schema)
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		return result.Errors
+	}
+	return nil
+}
+
+// plan returns the plan of the operation within a plan span, which reports whether the plan was cached.
+func (e *ExecutionEngineV2) plan(ctx context.Context, execContext *internalExecutionContext, operation *Request) (p plan.Plan, err error) {
+	_, span := tracing.Start(ctx, e.config.tracer, tracing.SpanPlan, e.operationAttributes(operation)...)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	var report operationreport.Report
+	p, cacheHit := e.lookupPlan(execContext, &operation.document, &e.config.schema.document, operation.OperationName, &report)
+	span.SetAttributes(tracing.Bool(tracing.AttributePlanCacheHit, cacheHit))
+	if report.HasErrors() {
+		return nil, report
+	}
+	return p, nil
+}
+
+func (e *ExecutionEngineV2) operationAttributes(operation *Request) []tracing.Attribute {
+	if e.config.tracer == nil {
+		return nil
+	}
+
+	attributes := []tracing.Attribute{
+		tracing.String(tracing.AttributeOperationName, operation.OperationName),
+	}
+	if operationType, err := operation.OperationType(); err == nil {
+		attributes = append(attributes, tracing.String(tracing.AttributeOperationType, operationTypeName(operationType)))
+	}
+	return attributes
+}
+
+func operationTypeName(operationType OperationType) string {
+	switch operationType {
+	case OperationTypeQuery:
+		return "query"
+	case OperationTypeMutation:
+		return "mutation"
+	case OperationTypeSubscription:
+		return "subscription"
+	default:
+		return "unknown"
+	}
+}
+
+// resolveSynchronousResponse resolves the response or, if the response cache is enabled, serves it from the cache.
+// Only query responses without errors are cacheable, traced responses bypass the cache.
+func (e *ExecutionEngineV2) resolveSynchronousResponse(ctx *internalExecutionContext, operation *Request, p *plan.SynchronousResponsePlan, writer io.Writer) error {
+	cacheConfig := e.config.responseCacheConfig
+	if cacheConfig == nil {
+		return e.resolver.ResolveGraphQLResponse(ctx.resolveContext, p.Response, nil, writer)
+	}
+
+	operationType, err := operation.OperationType()
+	if err != nil {
+		return err
+	}
+
+	policy := CachePolicy{}
+	if operationType == OperationTypeQuery {
+		policy, err = ctx.cachePolicyCalculator.Calculate(&operation.document, &e.config.schema.document, e.config.plannerConfig.Fields, cacheConfig.DefaultMaxAge)
+		if err != nil {
+			return err
+		}
+	}
+	operation.cachePolicy = &policy
+
+	if !policy.Cacheable() {
+		return e.resolver.ResolveGraphQLResponse(ctx.resolveContext, p.Response, nil, writer)
+	}
+
+	// without a header identifying the caller, a private response can't be stored,
+	// the trace of a traced response belongs to this execution, so it is neither stored nor served from the cache
+	header := ctx.resolveContext.Request.Header
+	_, traced := ctx.resolveContext.Trace()
+	storable := cacheConfig.Store != nil && !traced &&
+		(policy.Scope != plan.CacheControlScopePrivate || hasAnyHeader(header, cacheConfig.VaryHeaders))
+
+	var cacheKey string
+	if storable {
+		if cacheKey, err = e.responseCacheKey(operation, header, cacheConfig.VaryHeaders); err != nil {
+			return err
+		}
+
+		cached, found, err := cacheConfig.Store.Get(ctx.resolveContext.Context, cacheKey)
+		if err != nil {
+			e.logger.Error("ExecutionEngineV2.resolveSynchronousResponse", abstractlogger.Error(err))
+		}
+		if found {
+			_, err = writer.Write(cached)
+			return err
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err = e.resolver.ResolveGraphQLResponse(ctx.resolveContext, p.Response, nil, buf); err != nil {
+		return err
+	}
+
+	if ctx.resolveContext.HasErrors() {
+		operation.cachePolicy.MaxAge = 0
+	} else if storable {
+		if err = cacheConfig.Store.Set(ctx.resolveContext.Context, cacheKey, buf.Bytes(), policy.MaxAge); err != nil {
+			e.logger.Error("ExecutionEngineV2.resolveSynchronousResponse", abstractlogger.Error(err))
+		}
+	}
+
+	_, err = writer.Write(buf.Bytes())
+	return err
+}
+
+// responseCacheKey identifies a response by the normalized operation, its variables and the vary headers.
+func (e *ExecutionEngineV2) responseCacheKey(operation *Request, header http.Header, varyHeaders []string) (string, error) {
+	hash := pool.Hash64.Get()
+	hash.Reset()
+	defer pool.Hash64.Put(hash)
+
+	if err := astprinter.Print(&operation.document, &e.config.schema.document, hash); err != nil {
+		return "", err
+	}
+
+	separator := []byte{0}
+	_, _ = hash.Write(separator)
+	_, _ = hash.Write([]byte(operation.OperationName))
+	_, _ = hash.Write(separator)
+	_, _ = hash.Write(operation.Variables)
+	for _, key := range varyHeaders {
+		_, _ = hash.Write(separator)
+		_, _ = hash.Write([]byte(key))
+		for _, value := range header.Values(key) {
+			_, _ = hash.Write(separator)
+			_, _ = hash.Write([]byte(value))
+		}
+	}
+
+	return strconv.FormatUint(hash.Sum64(), 16), nil
+}
+
+func hasAnyHeader(header http.Header, keys []string) bool {
+	for _, key := range keys {
+		if header.Get(key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupPlan returns the plan from the cache or plans the operation, cacheHit reports which one happened.
+func (e *ExecutionEngineV2) lookupPlan(ctx *internalExecutionContext, operation, definition *ast.Document, operationName string, report *operationreport.Report) (p plan.Plan, cacheHit bool) {
 	hash := pool.Hash64.Get()
 	hash.Reset()
 	defer pool.Hash64.Put(hash)
 	err := astprinter.Print(operation, definition, hash)
 	if err != nil {
 		report.AddInternalError(err)
-		return nil
+		return nil, false
 	}
 
 	cacheKey := hash.Sum64()
 
 	if cached, ok := e.executionPlanCache.Get(cacheKey); ok {
 		if p, ok := cached.(plan.Plan); ok {
-			return p
+			return p, true
 		}
 	}
 
@@ -294,12 +533,12 @@ func (e *ExecutionEngineV2) getCachedPlan(ctx *internalExecutionContext, operati
 	defer e.plannerMu.Unlock()
 	planResult := e.planner.Plan(operation, definition, operationName, report)
 	if report.HasErrors() {
-		return nil
+		return nil, false
 	}
 
-	p := ctx.postProcessor.Process(planResult)
+	p = ctx.postProcessor.Process(planResult)
 	e.executionPlanCache.Add(cacheKey, p)
-	return p
+	return p, false
 }
 
 func (e *ExecutionEngineV2) GetWebsocketBeforeStartHook() WebsocketBeforeStartHook {
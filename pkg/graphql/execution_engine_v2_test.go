@@ -4,13 +4,16 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
 
+	"github.com/buger/jsonparser"
 	"github.com/jensneuse/abstractlogger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,6 +24,7 @@ import (
 	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/staticdatasource"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/tracing"
 	"github.com/pvormste/graphql-go-tools/pkg/operationreport"
 	"github.com/pvormste/graphql-go-tools/pkg/starwars"
 )
@@ -337,6 +341,70 @@ func TestExecutionEngineV2_Execute(t *testing.T) {
 		},
 	))
 
+	t.Run("execute simple hero operation with rest data source and unsuccessful status code", runWithoutError(
+		ExecutionEngineV2TestCase{
+			schema:    starwarsSchema(t),
+			operation: loadStarWarsQuery(starwars.FileSimpleHeroQuery, nil),
+			dataSources: []plan.DataSourceConfiguration{
+				{
+					RootNodes: []plan.TypeField{
+						{TypeName: "Query", FieldNames: []string{"hero"}},
+					},
+					Factory: &rest_datasource.Factory{
+						Client: testNetHttpClient(t, roundTripperTestCase{
+							expectedHost:     "example.com",
+							expectedPath:     "/",
+							expectedBody:     "",
+							sendResponseBody: `<html>Internal Server Error</html>`,
+							sendStatusCode:   500,
+						}),
+					},
+					Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+						Fetch: rest_datasource.FetchConfiguration{
+							URL:              "https://example.com/",
+							Method:           "GET",
+							IncludeErrorBody: true,
+						},
+					}),
+				},
+			},
+			fields:           []plan.FieldConfiguration{},
+			expectedResponse: `{"errors":[{"message":"unexpected status code from origin: 500","extensions":{"statusCode":500,"response":"<html>Internal Server Error</html>"}}],"data":{"hero":null}}`,
+		},
+	))
+
+	t.Run("execute simple hero operation with rest data source and configured success status codes", runWithoutError(
+		ExecutionEngineV2TestCase{
+			schema:    starwarsSchema(t),
+			operation: loadStarWarsQuery(starwars.FileSimpleHeroQuery, nil),
+			dataSources: []plan.DataSourceConfiguration{
+				{
+					RootNodes: []plan.TypeField{
+						{TypeName: "Query", FieldNames: []string{"hero"}},
+					},
+					Factory: &rest_datasource.Factory{
+						Client: testNetHttpClient(t, roundTripperTestCase{
+							expectedHost:     "example.com",
+							expectedPath:     "/",
+							expectedBody:     "",
+							sendResponseBody: `{"hero": {"name": "Luke Skywalker"}}`,
+							sendStatusCode:   203,
+						}),
+					},
+					Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+						Fetch: rest_datasource.FetchConfiguration{
+							URL:                "https://example.com/",
+							Method:             "GET",
+							SuccessStatusCodes: []int{200},
+						},
+					}),
+				},
+			},
+			fields:           []plan.FieldConfiguration{},
+			expectedResponse: `{"errors":[{"message":"unexpected status code from origin: 203","extensions":{"statusCode":203}}],"data":{"hero":null}}`,
+		},
+	))
+
 	t.Run("execute with header injection", runWithoutError(
 		ExecutionEngineV2TestCase{
 			schema: starwarsSchema(t),
@@ -570,6 +638,68 @@ func TestExecutionEngineV2_Execute(t *testing.T) {
 		},
 	))
 
+	t.Run("execute simple hero operation with graphql data source and unsuccessful status code", runWithoutError(
+		ExecutionEngineV2TestCase{
+			schema:    starwarsSchema(t),
+			operation: loadStarWarsQuery(starwars.FileSimpleHeroQuery, nil),
+			dataSources: []plan.DataSourceConfiguration{
+				{
+					RootNodes: []plan.TypeField{
+						{TypeName: "Query", FieldNames: []string{"hero"}},
+					},
+					Factory: &graphql_datasource.Factory{
+						HTTPClient: testNetHttpClient(t, roundTripperTestCase{
+							expectedHost:     "example.com",
+							expectedPath:     "/",
+							expectedBody:     "",
+							sendResponseBody: `Bad Gateway`,
+							sendStatusCode:   502,
+						}),
+					},
+					Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+						Fetch: graphql_datasource.FetchConfiguration{
+							URL:    "https://example.com/",
+							Method: "GET",
+						},
+					}),
+				},
+			},
+			fields:           []plan.FieldConfiguration{},
+			expectedResponse: `{"errors":[{"message":"unexpected status code from origin: 502","extensions":{"statusCode":502}}],"data":{"hero":null}}`,
+		},
+	))
+
+	t.Run("execute simple hero operation with graphql data source and graphql errors with unsuccessful status code", runWithoutError(
+		ExecutionEngineV2TestCase{
+			schema:    starwarsSchema(t),
+			operation: loadStarWarsQuery(starwars.FileSimpleHeroQuery, nil),
+			dataSources: []plan.DataSourceConfiguration{
+				{
+					RootNodes: []plan.TypeField{
+						{TypeName: "Query", FieldNames: []string{"hero"}},
+					},
+					Factory: &graphql_datasource.Factory{
+						HTTPClient: testNetHttpClient(t, roundTripperTestCase{
+							expectedHost:     "example.com",
+							expectedPath:     "/",
+							expectedBody:     "",
+							sendResponseBody: `{"errors":[{"message":"Cannot query field \"hero\" on type \"Query\"."}]}`,
+							sendStatusCode:   400,
+						}),
+					},
+					Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+						Fetch: graphql_datasource.FetchConfiguration{
+							URL:    "https://example.com/",
+							Method: "GET",
+						},
+					}),
+				},
+			},
+			fields:           []plan.FieldConfiguration{},
+			expectedResponse: `{"errors":[{"message":"Cannot query field \"hero\" on type \"Query\"."}],"data":{"hero":null}}`,
+		},
+	))
+
 	t.Run("execute the correct operation when sending multiple queries", runWithoutError(
 		ExecutionEngineV2TestCase{
 			schema: starwarsSchema(t),
@@ -1352,6 +1482,130 @@ func TestExecutionWithOptions(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestExecutionEngineV2_ExecuteStreamingResponse(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		directive @defer on FIELD
+		directive @stream(initialBatchSize: Int) on FIELD
+
+		type Query { hero: Hero }
+		type Hero { name: String friends: [Friend] }
+		type Friend { name: String }
+	`)
+	require.NoError(t, err)
+
+	engineConf := NewEngineV2Configuration(schema)
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"hero"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "Hero", FieldNames: []string{"name", "friends"}},
+				{TypeName: "Friend", FieldNames: []string{"name"}},
+			},
+			Factory: &staticdatasource.Factory{},
+			Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+				Data: `{"name":"Luke","friends":[{"name":"Han"},{"name":"Leia"}]}`,
+			}),
+		},
+	})
+	engineConf.SetFieldConfigurations([]plan.FieldConfiguration{
+		{TypeName: "Query", FieldName: "hero", DisableDefaultMapping: true},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, query string) []string {
+		var flushed []string
+		resultWriter := NewEngineResultWriter()
+		resultWriter.SetFlushCallback(func(data []byte) {
+			flushed = append(flushed, string(data))
+		})
+
+		request := Request{Query: query}
+		require.NoError(t, engine.Execute(context.Background(), &request, &resultWriter))
+		return flushed
+	}
+
+	t.Run("defer", func(t *testing.T) {
+		flushed := execute(t, `{ hero { name friends @defer { name } } }`)
+		require.Len(t, flushed, 2)
+		assert.Equal(t, `{"data":{"hero":{"name":"Luke","friends":null}}}`, flushed[0])
+		assert.Equal(t, `[{"op":"replace","path":"/data/hero/friends","value":[{"name":"Han"},{"name":"Leia"}]}]`, flushed[1])
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		flushed := execute(t, `{ hero { name friends @stream(initialBatchSize: 1) { name } } }`)
+		require.Len(t, flushed, 2)
+		assert.Equal(t, `{"data":{"hero":{"name":"Luke","friends":[{"name":"Han"}]}}}`, flushed[0])
+		assert.Equal(t, `[{"op":"add","path":"/data/hero/friends/1","value":{"name":"Leia"}}]`, flushed[1])
+	})
+}
+
+func TestExecutionEngineV2_ExecuteRESTSubscription(t *testing.T) {
+	var polls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"value":%d}`, atomic.AddInt32(&polls, 1))
+	}))
+	defer upstream.Close()
+
+	schema, err := NewSchemaFromString(`
+		type Query { hello: String }
+		type Subscription { counter: Counter }
+		type Counter { value: Int }
+	`)
+	require.NoError(t, err)
+
+	engineConf := NewEngineV2Configuration(schema)
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Subscription", FieldNames: []string{"counter"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "Counter", FieldNames: []string{"value"}},
+			},
+			Factory: &rest_datasource.Factory{Client: http.DefaultClient},
+			Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+				Fetch: rest_datasource.FetchConfiguration{
+					URL:    upstream.URL,
+					Method: http.MethodGet,
+				},
+				Subscription: rest_datasource.SubscriptionConfiguration{
+					PollingIntervalMillis: 1,
+					MaxPolls:              2,
+				},
+			}),
+		},
+	})
+	engineConf.SetFieldConfigurations([]plan.FieldConfiguration{
+		{TypeName: "Subscription", FieldName: "counter", DisableDefaultMapping: true},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	var flushed []string
+	resultWriter := NewEngineResultWriter()
+	resultWriter.SetFlushCallback(func(data []byte) {
+		flushed = append(flushed, string(data))
+	})
+
+	request := Request{Query: `subscription { counter { value } }`}
+	require.NoError(t, engine.Execute(ctx, &request, &resultWriter))
+	assert.Equal(t, []string{
+		`{"data":{"counter":{"value":1}}}`,
+		`{"data":{"counter":{"value":2}}}`,
+	}, flushed)
+}
+
 func TestExecutionEngineV2_GetCachedPlan(t *testing.T) {
 	schema, err := NewSchemaFromString(testSubscriptionDefinition)
 	require.NoError(t, err)
@@ -1421,7 +1675,8 @@ func TestExecutionEngineV2_GetCachedPlan(t *testing.T) {
 		}
 
 		report := operationreport.Report{}
-		cachedPlan := engine.getCachedPlan(firstInternalExecCtx, &gqlRequest.document, &schema.document, gqlRequest.OperationName, &report)
+		cachedPlan, cacheHit := engine.lookupPlan(firstInternalExecCtx, &gqlRequest.document, &schema.document, gqlRequest.OperationName, &report)
+		assert.False(t, cacheHit)
 		_, oldestCachedPlan, _ := engine.executionPlanCache.GetOldest()
 		assert.False(t, report.HasErrors())
 		assert.Equal(t, 1, engine.executionPlanCache.Len())
@@ -1432,7 +1687,8 @@ func TestExecutionEngineV2_GetCachedPlan(t *testing.T) {
 			http.CanonicalHeaderKey("Authorization"): []string{"123abc"},
 		}
 
-		cachedPlan = engine.getCachedPlan(secondInternalExecCtx, &gqlRequest.document, &schema.document, gqlRequest.OperationName, &report)
+		cachedPlan, cacheHit = engine.lookupPlan(secondInternalExecCtx, &gqlRequest.document, &schema.document, gqlRequest.OperationName, &report)
+		assert.True(t, cacheHit)
 		_, oldestCachedPlan, _ = engine.executionPlanCache.GetOldest()
 		assert.False(t, report.HasErrors())
 		assert.Equal(t, 1, engine.executionPlanCache.Len())
@@ -1449,7 +1705,8 @@ func TestExecutionEngineV2_GetCachedPlan(t *testing.T) {
 		}
 
 		report := operationreport.Report{}
-		cachedPlan := engine.getCachedPlan(firstInternalExecCtx, &gqlRequest.document, &schema.document, gqlRequest.OperationName, &report)
+		cachedPlan, cacheHit := engine.lookupPlan(firstInternalExecCtx, &gqlRequest.document, &schema.document, gqlRequest.OperationName, &report)
+		assert.False(t, cacheHit)
 		_, oldestCachedPlan, _ := engine.executionPlanCache.GetOldest()
 		assert.False(t, report.HasErrors())
 		assert.Equal(t, 1, engine.executionPlanCache.Len())
@@ -1460,7 +1717,8 @@ func TestExecutionEngineV2_GetCachedPlan(t *testing.T) {
 			http.CanonicalHeaderKey("Authorization"): []string{"xyz098"},
 		}
 
-		cachedPlan = engine.getCachedPlan(secondInternalExecCtx, &differentGqlRequest.document, &schema.document, differentGqlRequest.OperationName, &report)
+		cachedPlan, cacheHit = engine.lookupPlan(secondInternalExecCtx, &differentGqlRequest.document, &schema.document, differentGqlRequest.OperationName, &report)
+		assert.False(t, cacheHit)
 		_, oldestCachedPlan, _ = engine.executionPlanCache.GetOldest()
 		assert.False(t, report.HasErrors())
 		assert.Equal(t, 2, engine.executionPlanCache.Len())
@@ -1597,6 +1855,197 @@ type Review {
 	return NewSchemaFromString(rawSchema)
 }
 
+func TestExecutionEngineV2_Tracing(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `"%s"`, r.Header.Get("traceparent"))
+	}))
+	defer upstream.Close()
+
+	schema, err := NewSchemaFromString(`type Query { hello: String }`)
+	require.NoError(t, err)
+
+	tracer := &recordingTracer{}
+	engineConf := NewEngineV2Configuration(schema)
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"hello"}},
+			},
+			Factory: &rest_datasource.Factory{Client: http.DefaultClient},
+			Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+				Fetch: rest_datasource.FetchConfiguration{
+					URL:    upstream.URL,
+					Method: http.MethodGet,
+				},
+			}),
+		},
+	})
+	engineConf.SetFieldConfigurations([]plan.FieldConfiguration{
+		{TypeName: "Query", FieldName: "hello", DisableDefaultMapping: true},
+	})
+	engineConf.SetTracer(tracer)
+
+	engine, err := NewExecutionEngineV2(context.Background(), abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T) {
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &Request{OperationName: "Hello", Query: `query Hello { hello }`}, &resultWriter))
+		assert.Equal(t, `{"data":{"hello":"graphql.fetch"}}`, resultWriter.String())
+	}
+
+	execute(t)
+	assert.Equal(t, []recordedSpan{
+		{name: tracing.SpanParse},
+		{name: tracing.SpanNormalize},
+		{name: tracing.SpanValidate},
+		{name: tracing.SpanPlan, attributes: []tracing.Attribute{
+			tracing.String(tracing.AttributeOperationName, "Hello"),
+			tracing.String(tracing.AttributeOperationType, "query"),
+			tracing.Bool(tracing.AttributePlanCacheHit, false),
+		}},
+		{name: tracing.SpanFetch, parent: tracing.SpanResolve, attributes: []tracing.Attribute{
+			tracing.String(tracing.AttributeDataSourceID, "rest_datasource.Source"),
+			tracing.String(tracing.AttributeUpstreamURL, upstream.URL),
+		}},
+		{name: tracing.SpanResolve, attributes: []tracing.Attribute{
+			tracing.String(tracing.AttributeOperationName, "Hello"),
+			tracing.String(tracing.AttributeOperationType, "query"),
+		}},
+	}, tracer.ended)
+
+	tracer.ended = nil
+	execute(t)
+	require.Len(t, tracer.ended, 6)
+	assert.Equal(t, tracing.Bool(tracing.AttributePlanCacheHit, true), tracer.ended[3].attributes[2])
+
+	t.Run("records errors", func(t *testing.T) {
+		tracer.ended = nil
+		resultWriter := NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &Request{Query: `{ unknown }`}, &resultWriter)
+		require.Error(t, err)
+		require.Len(t, tracer.ended, 2)
+		assert.Equal(t, tracing.SpanNormalize, tracer.ended[1].name)
+		assert.Equal(t, err, tracer.ended[1].err)
+	})
+}
+
+func TestExecutionEngineV2_ExecutionTracing(t *testing.T) {
+	const subgraphTrace = "subgraph trace"
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(tracing.HeaderIncludeTrace) != tracing.HeaderIncludeTraceFTV1 {
+			_, _ = w.Write([]byte(`{"data":{"hello":"world"}}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"data":{"hello":"world"},"extensions":{"ftv1":"%s"}}`, base64.StdEncoding.EncodeToString([]byte(subgraphTrace)))
+	}))
+	defer upstream.Close()
+
+	schema, err := NewSchemaFromString(`type Query { hello: String }`)
+	require.NoError(t, err)
+
+	engineConf := NewEngineV2Configuration(schema)
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"hello"}},
+			},
+			Factory: &graphql_datasource.Factory{HTTPClient: http.DefaultClient},
+			Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL:    upstream.URL,
+					Method: http.MethodPost,
+				},
+				ServiceName: "hello",
+			}),
+		},
+	})
+
+	engine, err := NewExecutionEngineV2(context.Background(), abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, options ...ExecutionOptionsV2) []byte {
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &Request{Query: `{ hello }`}, &resultWriter, options...))
+		return resultWriter.Bytes()
+	}
+
+	t.Run("without tracing", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"hello":"world"}}`, string(execute(t)))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		response := execute(t, WithExecutionTracing(resolve.TraceFormatJSON))
+
+		data, _, _, err := jsonparser.Get(response, "data")
+		require.NoError(t, err)
+		assert.Equal(t, `{"hello":"world"}`, string(data))
+
+		fieldName, err := jsonparser.GetString(response, "extensions", "tracing", "execution", "resolvers", "[0]", "fieldName")
+		require.NoError(t, err)
+		assert.Equal(t, "hello", fieldName)
+		serviceName, err := jsonparser.GetString(response, "extensions", "tracing", "fetches", "[0]", "serviceName")
+		require.NoError(t, err)
+		assert.Equal(t, "hello", serviceName)
+		_, _, _, err = jsonparser.Get(response, "extensions", "tracing", "fetches", "[0]", "ftv1")
+		assert.Error(t, err, "subgraph traces must only be requested for ftv1")
+	})
+
+	t.Run("ftv1 merges the subgraph trace", func(t *testing.T) {
+		response := execute(t, WithExecutionTracing(resolve.TraceFormatFTV1))
+
+		encoded, err := jsonparser.GetString(response, "extensions", "ftv1")
+		require.NoError(t, err)
+		trace, err := base64.StdEncoding.DecodeString(encoded)
+		require.NoError(t, err)
+		assert.Contains(t, string(trace), subgraphTrace)
+	})
+}
+
+type recordedSpan struct {
+	name       string
+	parent     string
+	attributes []tracing.Attribute
+	err        error
+	tracer     *recordingTracer
+}
+
+// recordingTracer records ended spans, the upstream receives the name of the span as traceparent
+type recordingTracer struct {
+	mu    sync.Mutex
+	ended []recordedSpan
+}
+
+type recordedSpanKey struct{}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attributes ...tracing.Attribute) (context.Context, tracing.Span) {
+	span := &recordedSpan{name: name, attributes: attributes, tracer: r}
+	if parent, ok := ctx.Value(recordedSpanKey{}).(*recordedSpan); ok {
+		span.parent = parent.name
+	}
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+func (r *recordedSpan) SetAttributes(attributes ...tracing.Attribute) {
+	r.attributes = append(r.attributes, attributes...)
+}
+
+func (r *recordedSpan) RecordError(err error) {
+	r.err = err
+}
+
+func (r *recordedSpan) End() {
+	r.tracer.mu.Lock()
+	defer r.tracer.mu.Unlock()
+	ended := *r
+	ended.tracer = nil
+	r.tracer.ended = append(r.tracer.ended, ended)
+}
+
+func (r *recordedSpan) InjectHeader(header http.Header) {
+	header.Set("traceparent", r.name)
+}
+
 func newPollingUpstreamHandler() http.Handler {
 	counter := 0
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,169 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pvormste/graphql-go-tools/pkg/middleware/operation_complexity"
+	"github.com/pvormste/graphql-go-tools/pkg/operationreport"
+)
+
+const (
+	OperationLimitCost      = "cost"
+	OperationLimitDepth     = "depth"
+	OperationLimitNodeCount = "nodeCount"
+)
+
+// OperationLimitsConfiguration makes the ExecutionEngineV2 reject operations exceeding one of the limits before they get planned.
+// A limit of 0 disables it.
+type OperationLimitsConfiguration struct {
+	// MaxCost is the maximum cost of an operation calculated from the @cost and @listSize directives of the schema.
+	MaxCost int
+	// MaxDepth is the maximum depth of an operation.
+	MaxDepth int
+	// MaxNodeCount is the maximum number of nodes an operation may return.
+	MaxNodeCount int
+	// DefaultListSize is the size assumed for lists without @listSize directive, it defaults to operation_complexity.DefaultListSize.
+	DefaultListSize int
+}
+
+func (c *OperationLimitsConfiguration) enabled() bool {
+	return c.MaxCost > 0 || c.MaxDepth > 0 || c.MaxNodeCount > 0
+}
+
+// OperationLimitError is returned when an operation exceeds a limit of the OperationLimitsConfiguration.
+// Besides the exceeded limit it reports the calculated stats of the operation, the cost is only calculated if MaxCost is set.
+type OperationLimitError struct {
+	// Limit is the exceeded limit, one of OperationLimitCost, OperationLimitDepth or OperationLimitNodeCount.
+	Limit     string
+	Max       int
+	Cost      int
+	Depth     int
+	NodeCount int
+}
+
+func (o OperationLimitError) Error() string {
+	return fmt.Sprintf("operation %s of %d exceeds the maximum %s of %d", o.Limit, o.actual(), o.Limit, o.Max)
+}
+
+func (o OperationLimitError) actual() int {
+	switch o.Limit {
+	case OperationLimitDepth:
+		return o.Depth
+	case OperationLimitNodeCount:
+		return o.NodeCount
+	default:
+		return o.Cost
+	}
+}
+
+func (o OperationLimitError) WriteResponse(writer io.Writer) (n int, err error) {
+	return RequestErrors{o.requestError()}.WriteResponse(writer)
+}
+
+func (o OperationLimitError) Count() int {
+	return 1
+}
+
+func (o OperationLimitError) ErrorByIndex(i int) error {
+	if i != 0 {
+		return nil
+	}
+	return o
+}
+
+func (o OperationLimitError) requestError() RequestError {
+	extensions, _ := json.Marshal(struct {
+		Code      string `json:"code"`
+		Limit     string `json:"limit"`
+		Max       int    `json:"max"`
+		Cost      int    `json:"cost"`
+		Depth     int    `json:"depth"`
+		NodeCount int    `json:"nodeCount"`
+	}{
+		Code:      "OPERATION_LIMIT_EXCEEDED",
+		Limit:     o.Limit,
+		Max:       o.Max,
+		Cost:      o.Cost,
+		Depth:     o.Depth,
+		NodeCount: o.NodeCount,
+	})
+
+	return RequestError{
+		Message:    o.Error(),
+		Extensions: extensions,
+	}
+}
+
+// operationCost calculates the cost of a normalized operation at most once per default list size,
+// so the operation limits and the rate limiter share the calculation.
+type operationCost struct {
+	operation *Request
+	schema    *Schema
+
+	calculated      bool
+	defaultListSize int
+	cost            int
+	err             error
+}
+
+func (o *operationCost) calculate(defaultListSize int) (int, error) {
+	if defaultListSize <= 0 {
+		defaultListSize = operation_complexity.DefaultListSize
+	}
+	if o.calculated && o.defaultListSize == defaultListSize {
+		return o.cost, o.err
+	}
+
+	var report operationreport.Report
+	o.cost = operation_complexity.CalculateOperationCost(&o.operation.document, &o.schema.document, defaultListSize, &report)
+	o.err = nil
+	if report.HasErrors() {
+		o.err = report
+	}
+	o.calculated, o.defaultListSize = true, defaultListSize
+	return o.cost, o.err
+}
+
+// checkOperationLimits calculates the cost, depth and node count of the normalized operation
+// and returns an OperationLimitError if one of them exceeds its limit.
+func checkOperationLimits(config *OperationLimitsConfiguration, operation *Request, schema *Schema, operationCost *operationCost) error {
+	if config == nil || !config.enabled() {
+		return nil
+	}
+
+	var report operationreport.Report
+	stats, _ := operation_complexity.CalculateOperationComplexity(&operation.document, &schema.document, &report)
+	if report.HasErrors() {
+		return report
+	}
+
+	// the cost is only calculated if it is limited, as it fails for operations violating the @listSize directives
+	var cost int
+	if config.MaxCost > 0 {
+		var err error
+		if cost, err = operationCost.calculate(config.DefaultListSize); err != nil {
+			return err
+		}
+	}
+
+	limitErr := OperationLimitError{
+		Cost:      cost,
+		Depth:     stats.Depth,
+		NodeCount: stats.NodeCount,
+	}
+
+	switch {
+	case config.MaxCost > 0 && cost > config.MaxCost:
+		limitErr.Limit, limitErr.Max = OperationLimitCost, config.MaxCost
+	case config.MaxDepth > 0 && stats.Depth > config.MaxDepth:
+		limitErr.Limit, limitErr.Max = OperationLimitDepth, config.MaxDepth
+	case config.MaxNodeCount > 0 && stats.NodeCount > config.MaxNodeCount:
+		limitErr.Limit, limitErr.Max = OperationLimitNodeCount, config.MaxNodeCount
+	default:
+		return nil
+	}
+
+	return limitErr
+}
@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/staticdatasource"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+)
+
+func TestOperationLimitError_WriteResponse(t *testing.T) {
+	limitErr := OperationLimitError{Limit: OperationLimitCost, Max: 10, Cost: 20, Depth: 3, NodeCount: 11}
+
+	buf := &bytes.Buffer{}
+	_, err := limitErr.WriteResponse(buf)
+	require.NoError(t, err)
+	assert.Equal(t, `{"errors":[{"message":"operation cost of 20 exceeds the maximum cost of 10","extensions":{"code":"OPERATION_LIMIT_EXCEEDED","limit":"cost","max":10,"cost":20,"depth":3,"nodeCount":11}}]}`, buf.String())
+
+	buf.Reset()
+	_, err = RequestErrorsFromError(limitErr).WriteResponse(buf)
+	require.NoError(t, err)
+	assert.Equal(t, `{"errors":[{"message":"operation cost of 20 exceeds the maximum cost of 10","extensions":{"code":"OPERATION_LIMIT_EXCEEDED","limit":"cost","max":10,"cost":20,"depth":3,"nodeCount":11}}]}`, buf.String())
+}
+
+func TestExecutionEngineV2_OperationLimits(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		directive @listSize(assumedSize: Int, slicingArguments: [String!], sizedFields: [String!], requireOneSlicingArgument: Boolean = true) on FIELD_DEFINITION
+
+		type Query {
+			users(first: Int): [User] @listSize(slicingArguments: ["first"])
+		}
+
+		type User {
+			name: String
+			friends: [User]
+		}
+	`)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, limits OperationLimitsConfiguration, query string) (string, error) {
+		engineConf := NewEngineV2Configuration(schema)
+		engineConf.SetDataSources([]plan.DataSourceConfiguration{
+			{
+				RootNodes: []plan.TypeField{
+					{TypeName: "Query", FieldNames: []string{"users"}},
+				},
+				ChildNodes: []plan.TypeField{
+					{TypeName: "User", FieldNames: []string{"name", "friends"}},
+				},
+				Factory: &staticdatasource.Factory{},
+				Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+					Data: `[{"name":"Jens","friends":[]}]`,
+				}),
+			},
+		})
+		engineConf.SetFieldConfigurations([]plan.FieldConfiguration{
+			{TypeName: "Query", FieldName: "users", DisableDefaultMapping: true},
+		})
+		engineConf.SetOperationLimitsConfiguration(limits)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+
+		resultWriter := NewEngineResultWriter()
+		err = engine.Execute(context.Background(), &Request{Query: query}, &resultWriter)
+		return resultWriter.String(), err
+	}
+
+	const usersQuery = `{ users(first: 10) { name } }`
+	const friendsQuery = `{ users(first: 10) { name friends { name } } }`
+
+	t.Run("executes operations within the limits", func(t *testing.T) {
+		response, err := execute(t, OperationLimitsConfiguration{MaxCost: 10, MaxDepth: 2, MaxNodeCount: 1}, usersQuery)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"users":[{"name":"Jens"}]}}`, response)
+	})
+
+	t.Run("rejects operations exceeding the maximum cost", func(t *testing.T) {
+		response, err := execute(t, OperationLimitsConfiguration{MaxCost: 15}, friendsQuery)
+		assert.Equal(t, OperationLimitError{Limit: OperationLimitCost, Max: 15, Cost: 20, Depth: 3, NodeCount: 2}, err)
+		assert.Equal(t, "", response)
+	})
+
+	t.Run("uses the default list size", func(t *testing.T) {
+		_, err := execute(t, OperationLimitsConfiguration{MaxCost: 50, DefaultListSize: 5}, friendsQuery)
+		assert.Equal(t, OperationLimitError{Limit: OperationLimitCost, Max: 50, Cost: 60, Depth: 3, NodeCount: 2}, err)
+	})
+
+	t.Run("rejects operations exceeding the maximum depth", func(t *testing.T) {
+		_, err := execute(t, OperationLimitsConfiguration{MaxDepth: 2}, friendsQuery)
+		assert.Equal(t, OperationLimitError{Limit: OperationLimitDepth, Max: 2, Depth: 3, NodeCount: 2}, err)
+	})
+
+	t.Run("rejects operations exceeding the maximum node count", func(t *testing.T) {
+		_, err := execute(t, OperationLimitsConfiguration{MaxNodeCount: 1}, friendsQuery)
+		assert.Equal(t, OperationLimitError{Limit: OperationLimitNodeCount, Max: 1, Depth: 3, NodeCount: 2}, err)
+	})
+
+	t.Run("rejects operations without slicing argument", func(t *testing.T) {
+		_, err := execute(t, OperationLimitsConfiguration{MaxCost: 100}, `{ users { name } }`)
+		require.Error(t, err)
+		assert.Equal(t, "field 'Query.users' requires exactly one of the slicing arguments: first", RequestErrorsFromError(err)[0].Message)
+	})
+
+	t.Run("does not calculate the cost without maximum cost", func(t *testing.T) {
+		response, err := execute(t, OperationLimitsConfiguration{MaxDepth: 2, MaxNodeCount: 1}, `{ users { name } }`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"users":[{"name":"Jens"}]}}`, response)
+	})
+}
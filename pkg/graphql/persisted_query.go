@@ -0,0 +1,219 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	DefaultPersistedQueryCacheSize = 1024
+
+	persistedQueryVersion = 1
+	// persistedQueryManifestFormat is the format of manifests generated by @apollo/generate-persisted-query-manifest
+	persistedQueryManifestFormat = "apollo-persisted-query-manifest"
+)
+
+// PersistedQueryError is returned when an operation using automatic persisted queries (APQ) can't be executed.
+// The message and extension code follow the APQ protocol, so clients know when to retry with the full query.
+type PersistedQueryError struct {
+	message string
+	code    string
+}
+
+var (
+	ErrPersistedQueryNotFound     = PersistedQueryError{message: "PersistedQueryNotFound", code: "PERSISTED_QUERY_NOT_FOUND"}
+	ErrPersistedQueryNotSupported = PersistedQueryError{message: "PersistedQueryNotSupported", code: "PERSISTED_QUERY_NOT_SUPPORTED"}
+	ErrPersistedQueryNotInList    = PersistedQueryError{message: "PersistedQueryNotInList", code: "PERSISTED_QUERY_NOT_IN_LIST"}
+	ErrPersistedQueryHashMismatch = PersistedQueryError{message: "provided sha does not match query", code: "INVALID_PERSISTED_QUERY_HASH"}
+	ErrPersistedQueryVersion      = PersistedQueryError{message: "unsupported persisted query version", code: "PERSISTED_QUERY_VERSION_NOT_SUPPORTED"}
+)
+
+func (p PersistedQueryError) Error() string {
+	return p.message
+}
+
+func (p PersistedQueryError) Code() string {
+	return p.code
+}
+
+func (p PersistedQueryError) WriteResponse(writer io.Writer) (n int, err error) {
+	return RequestErrors{p.requestError()}.WriteResponse(writer)
+}
+
+func (p PersistedQueryError) Count() int {
+	return 1
+}
+
+func (p PersistedQueryError) ErrorByIndex(i int) error {
+	if i != 0 {
+		return nil
+	}
+	return p
+}
+
+func (p PersistedQueryError) requestError() RequestError {
+	return RequestError{
+		Message:    p.message,
+		Extensions: json.RawMessage(fmt.Sprintf(`{"code":"%s"}`, p.code)),
+	}
+}
+
+// PersistedQueryStore stores queries by the hex encoded sha256 hash of the query.
+type PersistedQueryStore interface {
+	Get(ctx context.Context, hash string) (query string, found bool, err error)
+	Set(ctx context.Context, hash, query string) error
+}
+
+// PersistedQueryConfiguration enables automatic persisted queries for the ExecutionEngineV2.
+type PersistedQueryConfiguration struct {
+	// Store holds the persisted queries, use NewInMemoryPersistedQueryStore for a LRU cache.
+	Store PersistedQueryStore
+	// AllowListOnly rejects every operation which isn't part of Store.
+	// The store is never written to, so it should be loaded upfront e.g. with NewStaticPersistedQueryStore.
+	AllowListOnly bool
+}
+
+// InMemoryPersistedQueryStore keeps the most recently used persisted queries in memory.
+type InMemoryPersistedQueryStore struct {
+	cache *lru.Cache
+}
+
+func NewInMemoryPersistedQueryStore(size int) (*InMemoryPersistedQueryStore, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &InMemoryPersistedQueryStore{
+		cache: cache,
+	}, nil
+}
+
+func (i *InMemoryPersistedQueryStore) Get(_ context.Context, hash string) (query string, found bool, err error) {
+	cached, ok := i.cache.Get(hash)
+	if !ok {
+		return "", false, nil
+	}
+	query, ok = cached.(string)
+	return query, ok, nil
+}
+
+func (i *InMemoryPersistedQueryStore) Set(_ context.Context, hash, query string) error {
+	i.cache.Add(hash, query)
+	return nil
+}
+
+// StaticPersistedQueryStore is a read only store, it is meant to be used as allow-list.
+type StaticPersistedQueryStore struct {
+	queries map[string]string
+}
+
+func NewStaticPersistedQueryStore(queries map[string]string) *StaticPersistedQueryStore {
+	return &StaticPersistedQueryStore{
+		queries: queries,
+	}
+}
+
+// NewStaticPersistedQueryStoreFromManifest reads either a JSON object of hashes to queries
+// or a manifest in the format of @apollo/generate-persisted-query-manifest.
+func NewStaticPersistedQueryStoreFromManifest(reader io.Reader) (*StaticPersistedQueryStore, error) {
+	manifest, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if !gjson.ValidBytes(manifest) {
+		return nil, errors.New("persisted query manifest is not valid JSON")
+	}
+
+	queries := map[string]string{}
+	if format := gjson.GetBytes(manifest, "format"); format.Exists() {
+		if format.String() != persistedQueryManifestFormat {
+			return nil, fmt.Errorf("unsupported persisted query manifest format: %s", format.String())
+		}
+		for _, operation := range gjson.GetBytes(manifest, "operations").Array() {
+			queries[operation.Get("id").String()] = operation.Get("body").String()
+		}
+		return NewStaticPersistedQueryStore(queries), nil
+	}
+
+	if err = json.Unmarshal(manifest, &queries); err != nil {
+		return nil, err
+	}
+	return NewStaticPersistedQueryStore(queries), nil
+}
+
+func (s *StaticPersistedQueryStore) Get(_ context.Context, hash string) (query string, found bool, err error) {
+	query, found = s.queries[hash]
+	return query, found, nil
+}
+
+func (s *StaticPersistedQueryStore) Set(_ context.Context, _, _ string) error {
+	return nil
+}
+
+// PersistedQueryHash returns the hex encoded sha256 hash which identifies the query.
+func PersistedQueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadPersistedQuery sets the query of hash only requests and registers new persisted queries.
+// If allow-list mode is enabled, all operations which aren't part of the store get rejected.
+func loadPersistedQuery(ctx context.Context, config PersistedQueryConfiguration, request *Request) error {
+	if request.isPersistedQueryLoaded {
+		return nil
+	}
+
+	hash, isPersistedQuery, err := request.persistedQueryHash()
+	if err != nil {
+		return err
+	}
+
+	if config.Store == nil {
+		if isPersistedQuery {
+			return ErrPersistedQueryNotSupported
+		}
+		return nil
+	}
+
+	if !isPersistedQuery {
+		if !config.AllowListOnly {
+			return nil
+		}
+		hash = PersistedQueryHash(request.Query)
+	}
+
+	if request.Query != "" && hash != PersistedQueryHash(request.Query) {
+		return ErrPersistedQueryHashMismatch
+	}
+
+	query, found, err := config.Store.Get(ctx, hash)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case found:
+		request.Query = query
+	case config.AllowListOnly && request.Query != "":
+		return ErrPersistedQueryNotInList
+	case request.Query == "":
+		return ErrPersistedQueryNotFound
+	default:
+		if err = config.Store.Set(ctx, hash, request.Query); err != nil {
+			return err
+		}
+	}
+
+	request.isPersistedQueryLoaded = true
+	return nil
+}
@@ -0,0 +1,248 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/staticdatasource"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+)
+
+const persistedQueryTestQuery = `{hello}`
+
+func persistedQueryExtensions(hash string) []byte {
+	return []byte(fmt.Sprintf(`{"persistedQuery":{"version":1,"sha256Hash":"%s"}}`, hash))
+}
+
+type failingPersistedQueryStore struct{}
+
+func (f failingPersistedQueryStore) Get(_ context.Context, _ string) (string, bool, error) {
+	return "", false, errors.New("store unavailable")
+}
+
+func (f failingPersistedQueryStore) Set(_ context.Context, _, _ string) error {
+	return errors.New("store unavailable")
+}
+
+func TestPersistedQueryHash(t *testing.T) {
+	assert.Equal(t, "ecf4edb46db40b5132295c0291d62fb65d6759a9eedfa4d5d612dd5ec54a6b38", PersistedQueryHash(`{__typename}`))
+}
+
+func TestLoadPersistedQuery(t *testing.T) {
+	hash := PersistedQueryHash(persistedQueryTestQuery)
+
+	newStore := func(t *testing.T) *InMemoryPersistedQueryStore {
+		store, err := NewInMemoryPersistedQueryStore(DefaultPersistedQueryCacheSize)
+		require.NoError(t, err)
+		return store
+	}
+
+	t.Run("should ignore requests without persisted query extension", func(t *testing.T) {
+		request := Request{Query: persistedQueryTestQuery, Extensions: []byte(`{"foo":"bar"}`)}
+		err := loadPersistedQuery(context.Background(), PersistedQueryConfiguration{Store: newStore(t)}, &request)
+		require.NoError(t, err)
+		assert.Equal(t, persistedQueryTestQuery, request.Query)
+	})
+
+	t.Run("should return not supported when no store is configured", func(t *testing.T) {
+		request := Request{Extensions: persistedQueryExtensions(hash)}
+		err := loadPersistedQuery(context.Background(), PersistedQueryConfiguration{}, &request)
+		assert.Equal(t, ErrPersistedQueryNotSupported, err)
+	})
+
+	t.Run("should return not found for unknown hash", func(t *testing.T) {
+		request := Request{Extensions: persistedQueryExtensions(hash)}
+		err := loadPersistedQuery(context.Background(), PersistedQueryConfiguration{Store: newStore(t)}, &request)
+		assert.Equal(t, ErrPersistedQueryNotFound, err)
+	})
+
+	t.Run("should register query and resolve it by hash afterwards", func(t *testing.T) {
+		config := PersistedQueryConfiguration{Store: newStore(t)}
+
+		register := Request{Query: persistedQueryTestQuery, Extensions: persistedQueryExtensions(hash)}
+		require.NoError(t, loadPersistedQuery(context.Background(), config, &register))
+
+		request := Request{Extensions: persistedQueryExtensions(hash)}
+		require.NoError(t, loadPersistedQuery(context.Background(), config, &request))
+		assert.Equal(t, persistedQueryTestQuery, request.Query)
+	})
+
+	t.Run("should reject query not matching the hash", func(t *testing.T) {
+		config := PersistedQueryConfiguration{Store: newStore(t)}
+
+		request := Request{Query: `{foo}`, Extensions: persistedQueryExtensions(hash)}
+		err := loadPersistedQuery(context.Background(), config, &request)
+		assert.Equal(t, ErrPersistedQueryHashMismatch, err)
+
+		_, found, _ := config.Store.Get(context.Background(), hash)
+		assert.False(t, found)
+	})
+
+	t.Run("should reject unsupported version", func(t *testing.T) {
+		request := Request{Extensions: []byte(fmt.Sprintf(`{"persistedQuery":{"version":2,"sha256Hash":"%s"}}`, hash))}
+		err := loadPersistedQuery(context.Background(), PersistedQueryConfiguration{Store: newStore(t)}, &request)
+		assert.Equal(t, ErrPersistedQueryVersion, err)
+	})
+
+	t.Run("should return store errors", func(t *testing.T) {
+		request := Request{Extensions: persistedQueryExtensions(hash)}
+		err := loadPersistedQuery(context.Background(), PersistedQueryConfiguration{Store: failingPersistedQueryStore{}}, &request)
+		assert.EqualError(t, err, "store unavailable")
+	})
+
+	t.Run("allow-list", func(t *testing.T) {
+		config := PersistedQueryConfiguration{
+			Store:         NewStaticPersistedQueryStore(map[string]string{hash: persistedQueryTestQuery}),
+			AllowListOnly: true,
+		}
+
+		t.Run("should resolve hash from allow-list", func(t *testing.T) {
+			request := Request{Extensions: persistedQueryExtensions(hash)}
+			require.NoError(t, loadPersistedQuery(context.Background(), config, &request))
+			assert.Equal(t, persistedQueryTestQuery, request.Query)
+		})
+
+		t.Run("should allow full query from allow-list", func(t *testing.T) {
+			request := Request{Query: persistedQueryTestQuery}
+			require.NoError(t, loadPersistedQuery(context.Background(), config, &request))
+		})
+
+		t.Run("should return not found for unknown hash", func(t *testing.T) {
+			request := Request{Extensions: persistedQueryExtensions(PersistedQueryHash(`{foo}`))}
+			err := loadPersistedQuery(context.Background(), config, &request)
+			assert.Equal(t, ErrPersistedQueryNotFound, err)
+		})
+
+		t.Run("should reject full query not in allow-list", func(t *testing.T) {
+			request := Request{Query: `{foo}`}
+			err := loadPersistedQuery(context.Background(), config, &request)
+			assert.Equal(t, ErrPersistedQueryNotInList, err)
+		})
+
+		t.Run("should not register new queries", func(t *testing.T) {
+			request := Request{Query: `{foo}`, Extensions: persistedQueryExtensions(PersistedQueryHash(`{foo}`))}
+			err := loadPersistedQuery(context.Background(), config, &request)
+			assert.Equal(t, ErrPersistedQueryNotInList, err)
+		})
+	})
+}
+
+func TestInMemoryPersistedQueryStore(t *testing.T) {
+	store, err := NewInMemoryPersistedQueryStore(1)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set(context.Background(), "a", "{a}"))
+	require.NoError(t, store.Set(context.Background(), "b", "{b}"))
+
+	_, found, err := store.Get(context.Background(), "a")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	query, found, err := store.Get(context.Background(), "b")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "{b}", query)
+}
+
+func TestNewStaticPersistedQueryStoreFromManifest(t *testing.T) {
+	t.Run("apollo manifest", func(t *testing.T) {
+		store, err := NewStaticPersistedQueryStoreFromManifest(strings.NewReader(`{
+			"format": "apollo-persisted-query-manifest",
+			"version": 1,
+			"operations": [{"id": "abc", "name": "Hello", "type": "query", "body": "query Hello { hello }"}]
+		}`))
+		require.NoError(t, err)
+
+		query, found, err := store.Get(context.Background(), "abc")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "query Hello { hello }", query)
+	})
+
+	t.Run("hash to query map", func(t *testing.T) {
+		store, err := NewStaticPersistedQueryStoreFromManifest(strings.NewReader(`{"abc":"{hello}"}`))
+		require.NoError(t, err)
+
+		query, found, err := store.Get(context.Background(), "abc")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "{hello}", query)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := NewStaticPersistedQueryStoreFromManifest(strings.NewReader(`{"format":"relay"}`))
+		assert.EqualError(t, err, "unsupported persisted query manifest format: relay")
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := NewStaticPersistedQueryStoreFromManifest(strings.NewReader(`{`))
+		assert.Error(t, err)
+	})
+}
+
+func TestPersistedQueryError_WriteResponse(t *testing.T) {
+	buf := &bytes.Buffer{}
+	_, err := ErrPersistedQueryNotFound.WriteResponse(buf)
+	require.NoError(t, err)
+	assert.Equal(t, `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`, buf.String())
+
+	buf.Reset()
+	_, err = RequestErrorsFromError(ErrPersistedQueryNotFound).WriteResponse(buf)
+	require.NoError(t, err)
+	assert.Equal(t, `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`, buf.String())
+}
+
+func TestExecutionEngineV2_PersistedQueries(t *testing.T) {
+	schema, err := NewSchemaFromString(`type Query { hello: String }`)
+	require.NoError(t, err)
+
+	store, err := NewInMemoryPersistedQueryStore(DefaultPersistedQueryCacheSize)
+	require.NoError(t, err)
+
+	engineConf := NewEngineV2Configuration(schema)
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"hello"}},
+			},
+			Factory: &staticdatasource.Factory{},
+			Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+				Data: `"world"`,
+			}),
+		},
+	})
+	engineConf.SetFieldConfigurations([]plan.FieldConfiguration{
+		{TypeName: "Query", FieldName: "hello", DisableDefaultMapping: true},
+	})
+	engineConf.SetPersistedQueryConfiguration(PersistedQueryConfiguration{Store: store})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	extensions := persistedQueryExtensions(PersistedQueryHash(persistedQueryTestQuery))
+
+	resultWriter := NewEngineResultWriter()
+	err = engine.Execute(context.Background(), &Request{Extensions: extensions}, &resultWriter)
+	assert.Equal(t, ErrPersistedQueryNotFound, err)
+
+	resultWriter.Reset()
+	err = engine.Execute(context.Background(), &Request{Query: persistedQueryTestQuery, Extensions: extensions}, &resultWriter)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"hello":"world"}}`, resultWriter.String())
+
+	resultWriter.Reset()
+	err = engine.Execute(context.Background(), &Request{Extensions: extensions}, &resultWriter)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"hello":"world"}}`, resultWriter.String())
+}
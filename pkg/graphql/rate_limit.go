@@ -0,0 +1,292 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	DefaultRateLimitStoreSize = 10000
+
+	RateLimitHeaderLimit     = "X-RateLimit-Limit"
+	RateLimitHeaderRemaining = "X-RateLimit-Remaining"
+	RateLimitHeaderReset     = "X-RateLimit-Reset"
+	RateLimitHeaderRetry     = "Retry-After"
+)
+
+// RateLimitKeyFunc returns the identity of the caller whose bucket gets charged.
+// header contains the request headers including the additional headers of the execution options,
+// remoteAddr is the network address of the client if it has been set on the Request.
+type RateLimitKeyFunc func(header http.Header, remoteAddr string) string
+
+// RateLimitKeyFromHeader identifies callers by the value of a request header, e.g. an API key.
+func RateLimitKeyFromHeader(name string) RateLimitKeyFunc {
+	return func(header http.Header, _ string) string {
+		return header.Get(name)
+	}
+}
+
+// RateLimitKeyFromClientIP identifies callers by their IP address.
+// If trustForwardedFor is true, the first address of the X-Forwarded-For header is preferred,
+// which must only be enabled behind a proxy setting the header, as clients could spoof it otherwise.
+func RateLimitKeyFromClientIP(trustForwardedFor bool) RateLimitKeyFunc {
+	return func(header http.Header, remoteAddr string) string {
+		if trustForwardedFor {
+			if forwardedFor := header.Get("X-Forwarded-For"); forwardedFor != "" {
+				return strings.TrimSpace(strings.Split(forwardedFor, ",")[0])
+			}
+		}
+		host, _, err := net.SplitHostPort(remoteAddr)
+		if err != nil {
+			return remoteAddr
+		}
+		return host
+	}
+}
+
+// RateLimitBucket describes the token bucket every caller gets.
+type RateLimitBucket struct {
+	// Capacity is the maximum budget of a caller, a new bucket starts full.
+	Capacity int
+	// RefillAmount is the amount of tokens added to the bucket every RefillInterval.
+	RefillAmount   int
+	RefillInterval time.Duration
+}
+
+func (b RateLimitBucket) refills() bool {
+	return b.RefillAmount > 0 && b.RefillInterval > 0
+}
+
+// refilledTokens returns the amount of tokens added to the bucket within elapsed.
+func (b RateLimitBucket) refilledTokens(elapsed time.Duration) float64 {
+	if !b.refills() {
+		return 0
+	}
+	return float64(elapsed) * float64(b.RefillAmount) / float64(b.RefillInterval)
+}
+
+// refillDuration returns the time it takes to add the amount of tokens to the bucket.
+func (b RateLimitBucket) refillDuration(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(b.RefillInterval) / float64(b.RefillAmount)))
+}
+
+// RateLimitStore holds the token buckets of all callers, implementations must be safe for concurrent use.
+type RateLimitStore interface {
+	// Take charges cost against the bucket of key.
+	// If the bucket doesn't hold enough tokens nothing is charged and the result isn't Allowed.
+	Take(ctx context.Context, key string, cost int, bucket RateLimitBucket) (RateLimitResult, error)
+}
+
+// RateLimitConfiguration makes the ExecutionEngineV2 charge the cost of every operation against the bucket of its caller.
+// The cost is calculated from the @cost and @listSize directives of the schema, see operation_complexity.OperationCostEstimator.
+type RateLimitConfiguration struct {
+	RateLimitBucket
+	// Key identifies the caller, requests without identity share a single bucket.
+	// Use RateLimitKeyFromHeader or RateLimitKeyFromClientIP.
+	Key RateLimitKeyFunc
+	// Store holds the buckets, it defaults to an InMemoryRateLimitStore.
+	Store RateLimitStore
+	// DefaultListSize is the size assumed for lists without @listSize directive, it defaults to operation_complexity.DefaultListSize.
+	DefaultListSize int
+}
+
+// RateLimitResult is the state of the bucket of a caller after charging the cost of an operation.
+type RateLimitResult struct {
+	Allowed bool
+	Cost    int
+	// Limit is the capacity of the bucket.
+	Limit     int
+	Remaining int
+	// ResetAfter is the time until the bucket is full again.
+	ResetAfter time.Duration
+	// RetryAfter is the time until the bucket holds enough tokens for the operation, it is only set if it isn't Allowed.
+	RetryAfter time.Duration
+}
+
+// Header returns the headers informing the caller about the remaining budget.
+func (r RateLimitResult) Header() http.Header {
+	header := http.Header{}
+	header.Set(RateLimitHeaderLimit, strconv.Itoa(r.Limit))
+	header.Set(RateLimitHeaderRemaining, strconv.Itoa(r.Remaining))
+	header.Set(RateLimitHeaderReset, strconv.Itoa(durationSeconds(r.ResetAfter)))
+	if !r.Allowed {
+		header.Set(RateLimitHeaderRetry, strconv.Itoa(durationSeconds(r.RetryAfter)))
+	}
+	return header
+}
+
+// durationSeconds rounds up to full seconds as required by the rate limit headers.
+func durationSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
+
+// RateLimitError is returned when the cost of an operation exceeds the remaining budget of its caller.
+type RateLimitError struct {
+	Result RateLimitResult
+}
+
+func (r RateLimitError) Error() string {
+	return fmt.Sprintf("operation cost of %d exceeds the remaining rate limit budget of %d", r.Result.Cost, r.Result.Remaining)
+}
+
+func (r RateLimitError) WriteResponse(writer io.Writer) (n int, err error) {
+	return RequestErrors{r.requestError()}.WriteResponse(writer)
+}
+
+func (r RateLimitError) Count() int {
+	return 1
+}
+
+func (r RateLimitError) ErrorByIndex(i int) error {
+	if i != 0 {
+		return nil
+	}
+	return r
+}
+
+func (r RateLimitError) requestError() RequestError {
+	extensions, _ := json.Marshal(struct {
+		Code       string `json:"code"`
+		Cost       int    `json:"cost"`
+		Limit      int    `json:"limit"`
+		Remaining  int    `json:"remaining"`
+		RetryAfter int    `json:"retryAfter"`
+	}{
+		Code:       "RATE_LIMITED",
+		Cost:       r.Result.Cost,
+		Limit:      r.Result.Limit,
+		Remaining:  r.Result.Remaining,
+		RetryAfter: durationSeconds(r.Result.RetryAfter),
+	})
+
+	return RequestError{
+		Message:    r.Error(),
+		Extensions: extensions,
+	}
+}
+
+type inMemoryTokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// InMemoryRateLimitStore keeps the buckets of the most recently seen callers in memory.
+// Evicted callers start with a full bucket again.
+type InMemoryRateLimitStore struct {
+	mu    sync.Mutex
+	cache *lru.Cache
+	now   func() time.Time
+}
+
+func NewInMemoryRateLimitStore(size int) (*InMemoryRateLimitStore, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &InMemoryRateLimitStore{
+		cache: cache,
+		now:   time.Now,
+	}, nil
+}
+
+func (i *InMemoryRateLimitStore) Take(_ context.Context, key string, cost int, bucket RateLimitBucket) (RateLimitResult, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := i.now()
+	capacity := float64(bucket.Capacity)
+
+	state := &inMemoryTokenBucket{tokens: capacity, updatedAt: now}
+	if cached, ok := i.cache.Get(key); ok {
+		state = cached.(*inMemoryTokenBucket)
+		state.tokens = math.Min(capacity, state.tokens+bucket.refilledTokens(now.Sub(state.updatedAt)))
+		state.updatedAt = now
+	} else {
+		i.cache.Add(key, state)
+	}
+
+	result := RateLimitResult{
+		Allowed: float64(cost) <= state.tokens,
+		Cost:    cost,
+		Limit:   bucket.Capacity,
+	}
+	if result.Allowed {
+		state.tokens = math.Min(capacity, state.tokens-float64(cost))
+	} else if bucket.refills() {
+		result.RetryAfter = bucket.refillDuration(float64(cost) - state.tokens)
+	}
+
+	result.Remaining = int(math.Floor(state.tokens))
+	if bucket.refills() {
+		result.ResetAfter = bucket.refillDuration(capacity - state.tokens)
+	}
+
+	return result, nil
+}
+
+// rateLimiter charges the cost of operations against the buckets of their callers.
+type rateLimiter struct {
+	config RateLimitConfiguration
+}
+
+func newRateLimiter(config *RateLimitConfiguration) (*rateLimiter, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	limiter := &rateLimiter{
+		config: *config,
+	}
+	if limiter.config.Store == nil {
+		store, err := NewInMemoryRateLimitStore(DefaultRateLimitStoreSize)
+		if err != nil {
+			return nil, err
+		}
+		limiter.config.Store = store
+	}
+	return limiter, nil
+}
+
+// charge calculates the cost of the normalized operation and takes it from the bucket of the caller.
+// The result is kept on the operation, so the rate limit headers can be written for rejected operations as well.
+func (r *rateLimiter) charge(ctx context.Context, operation *Request, header http.Header, operationCost *operationCost) error {
+	if r == nil {
+		return nil
+	}
+
+	cost, err := operationCost.calculate(r.config.DefaultListSize)
+	if err != nil {
+		return err
+	}
+	// negative weights in the schema must not add tokens to the bucket
+	if cost < 0 {
+		cost = 0
+	}
+
+	var key string
+	if r.config.Key != nil {
+		key = r.config.Key(header, operation.remoteAddr)
+	}
+
+	result, err := r.config.Store.Take(ctx, key, cost, r.config.RateLimitBucket)
+	if err != nil {
+		return err
+	}
+
+	operation.rateLimitResult = &result
+	if !result.Allowed {
+		return RateLimitError{Result: result}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/staticdatasource"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+	"github.com/pvormste/graphql-go-tools/pkg/middleware/operation_complexity"
+)
+
+func TestInMemoryRateLimitStore(t *testing.T) {
+	store, err := NewInMemoryRateLimitStore(DefaultRateLimitStoreSize)
+	require.NoError(t, err)
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	bucket := RateLimitBucket{Capacity: 10, RefillAmount: 2, RefillInterval: time.Second}
+	take := func(key string, cost int) RateLimitResult {
+		result, err := store.Take(context.Background(), key, cost, bucket)
+		require.NoError(t, err)
+		return result
+	}
+
+	assert.Equal(t, RateLimitResult{Allowed: true, Cost: 8, Limit: 10, Remaining: 2, ResetAfter: 4 * time.Second}, take("a", 8))
+	assert.Equal(t, RateLimitResult{Allowed: false, Cost: 5, Limit: 10, Remaining: 2, ResetAfter: 4 * time.Second, RetryAfter: 1500 * time.Millisecond}, take("a", 5))
+	assert.Equal(t, RateLimitResult{Allowed: true, Cost: 5, Limit: 10, Remaining: 5, ResetAfter: 2500 * time.Millisecond}, take("b", 5))
+
+	now = now.Add(1500 * time.Millisecond)
+	assert.Equal(t, RateLimitResult{Allowed: true, Cost: 5, Limit: 10, Remaining: 0, ResetAfter: 5 * time.Second}, take("a", 5))
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, RateLimitResult{Allowed: true, Cost: 0, Limit: 10, Remaining: 10}, take("a", 0))
+
+	// tokens never exceed the capacity of the bucket
+	assert.Equal(t, RateLimitResult{Allowed: true, Cost: -100, Limit: 10, Remaining: 10}, take("c", -100))
+	assert.Equal(t, RateLimitResult{Allowed: false, Cost: 11, Limit: 10, Remaining: 10, RetryAfter: 500 * time.Millisecond}, take("c", 11))
+}
+
+func TestRateLimiter_ChargeNegativeCost(t *testing.T) {
+	limiter, err := newRateLimiter(&RateLimitConfiguration{
+		RateLimitBucket: RateLimitBucket{Capacity: 10},
+	})
+	require.NoError(t, err)
+
+	operation := &Request{}
+	cost := &operationCost{operation: operation, calculated: true, defaultListSize: operation_complexity.DefaultListSize, cost: -100}
+	require.NoError(t, limiter.charge(context.Background(), operation, nil, cost))
+
+	result, exists := operation.RateLimitResult()
+	require.True(t, exists)
+	assert.Equal(t, 0, result.Cost)
+	assert.Equal(t, 10, result.Remaining)
+}
+
+func TestRateLimitResult_Header(t *testing.T) {
+	result := RateLimitResult{Allowed: false, Cost: 5, Limit: 10, Remaining: 2, ResetAfter: 4 * time.Second, RetryAfter: 1500 * time.Millisecond}
+	assert.Equal(t, http.Header{
+		"X-Ratelimit-Limit":     []string{"10"},
+		"X-Ratelimit-Remaining": []string{"2"},
+		"X-Ratelimit-Reset":     []string{"4"},
+		"Retry-After":           []string{"2"},
+	}, result.Header())
+
+	result.Allowed = true
+	assert.Equal(t, "", result.Header().Get(RateLimitHeaderRetry))
+}
+
+func TestRateLimitKeyFromClientIP(t *testing.T) {
+	header := http.Header{"X-Forwarded-For": []string{"203.0.113.1, 10.0.0.1"}}
+
+	assert.Equal(t, "192.0.2.1", RateLimitKeyFromClientIP(false)(header, "192.0.2.1:1234"))
+	assert.Equal(t, "203.0.113.1", RateLimitKeyFromClientIP(true)(header, "192.0.2.1:1234"))
+	assert.Equal(t, "192.0.2.1", RateLimitKeyFromClientIP(true)(nil, "192.0.2.1:1234"))
+}
+
+func TestExecutionEngineV2_RateLimit(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		directive @listSize(assumedSize: Int, slicingArguments: [String!], sizedFields: [String!], requireOneSlicingArgument: Boolean = true) on FIELD_DEFINITION
+
+		type Query {
+			users(first: Int): [User] @listSize(slicingArguments: ["first"])
+		}
+
+		type User {
+			name: String
+		}
+	`)
+	require.NoError(t, err)
+
+	engineConf := NewEngineV2Configuration(schema)
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"users"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"name"}},
+			},
+			Factory: &staticdatasource.Factory{},
+			Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+				Data: `[{"name":"Jens"}]`,
+			}),
+		},
+	})
+	engineConf.SetFieldConfigurations([]plan.FieldConfiguration{
+		{TypeName: "Query", FieldName: "users", DisableDefaultMapping: true},
+	})
+	engineConf.SetRateLimitConfiguration(RateLimitConfiguration{
+		RateLimitBucket: RateLimitBucket{Capacity: 15, RefillAmount: 1, RefillInterval: time.Hour},
+		Key:             RateLimitKeyFromHeader("X-Api-Key"),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	execute := func(apiKey string) (*Request, string, error) {
+		request := &Request{Query: `{ users(first: 10) { name } }`}
+		request.SetHeader(http.Header{"X-Api-Key": []string{apiKey}})
+
+		resultWriter := NewEngineResultWriter()
+		err := engine.Execute(context.Background(), request, &resultWriter)
+		return request, resultWriter.String(), err
+	}
+
+	request, response, err := execute("a")
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"users":[{"name":"Jens"}]}}`, response)
+	result, exists := request.RateLimitResult()
+	require.True(t, exists)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 10, result.Cost)
+	assert.Equal(t, 5, result.Remaining)
+
+	request, response, err = execute("a")
+	require.Error(t, err)
+	assert.Equal(t, "", response)
+	rateLimitErr, ok := err.(RateLimitError)
+	require.True(t, ok)
+	assert.Equal(t, 5, rateLimitErr.Result.Remaining)
+	assert.Equal(t, "operation cost of 10 exceeds the remaining rate limit budget of 5", RequestErrorsFromError(err)[0].Message)
+	result, exists = request.RateLimitResult()
+	require.True(t, exists)
+	assert.False(t, result.Allowed)
+
+	_, _, err = execute("b")
+	assert.NoError(t, err)
+}
@@ -7,6 +7,8 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/tidwall/gjson"
+
 	"github.com/pvormste/graphql-go-tools/pkg/ast"
 	"github.com/pvormste/graphql-go-tools/pkg/astparser"
 	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
@@ -37,11 +39,16 @@ type Request struct {
 	OperationName string          `json:"operationName"`
 	Variables     json.RawMessage `json:"variables"`
 	Query         string          `json:"query"`
+	Extensions    json.RawMessage `json:"extensions,omitempty"`
 
-	document     ast.Document
-	isParsed     bool
-	isNormalized bool
-	request      resolve.Request
+	document               ast.Document
+	isParsed               bool
+	isNormalized           bool
+	isPersistedQueryLoaded bool
+	request                resolve.Request
+	cachePolicy            *CachePolicy
+	remoteAddr             string
+	rateLimitResult        *RateLimitResult
 
 	validForSchema map[uint64]ValidationResult
 }
@@ -61,6 +68,7 @@ func UnmarshalRequest(reader io.Reader, request *Request) error {
 
 func UnmarshalHttpRequest(r *http.Request, request *Request) error {
 	request.request.Header = r.Header
+	request.remoteAddr = r.RemoteAddr
 	return UnmarshalRequest(r.Body, request)
 }
 
@@ -68,6 +76,11 @@ func (r *Request) SetHeader(header http.Header) {
 	r.request.Header = header
 }
 
+// SetRemoteAddr sets the network address of the client, e.g. to identify callers for rate limiting.
+func (r *Request) SetRemoteAddr(remoteAddr string) {
+	r.remoteAddr = remoteAddr
+}
+
 func (r *Request) CalculateComplexity(complexityCalculator ComplexityCalculator, schema *Schema) (ComplexityResult, error) {
 	if schema == nil {
 		return ComplexityResult{}, ErrNilSchema
@@ -108,6 +121,51 @@ func (r *Request) parseQueryOnce() (report operationreport.Report) {
 	return report
 }
 
+// CachePolicy returns the cache policy of the executed operation.
+// It only exists if the response cache of the ExecutionEngineV2 is enabled.
+func (r *Request) CachePolicy() (policy CachePolicy, exists bool) {
+	if r.cachePolicy == nil {
+		return CachePolicy{}, false
+	}
+	return *r.cachePolicy, true
+}
+
+// RateLimitResult returns the state of the rate limit bucket of the caller after charging the operation.
+// It only exists if rate limiting of the ExecutionEngineV2 is enabled, also for operations rejected with a RateLimitError.
+func (r *Request) RateLimitResult() (result RateLimitResult, exists bool) {
+	if r.rateLimitResult == nil {
+		return RateLimitResult{}, false
+	}
+	return *r.rateLimitResult, true
+}
+
+// IsPersistedQuery returns true if the request uses the automatic persisted queries extension.
+func (r *Request) IsPersistedQuery() bool {
+	_, isPersistedQuery, _ := r.persistedQueryHash()
+	return isPersistedQuery
+}
+
+func (r *Request) persistedQueryHash() (hash string, isPersistedQuery bool, err error) {
+	if len(r.Extensions) == 0 {
+		return "", false, nil
+	}
+
+	persistedQuery := gjson.GetBytes(r.Extensions, "persistedQuery")
+	if !persistedQuery.IsObject() {
+		return "", false, nil
+	}
+
+	if version := persistedQuery.Get("version"); version.Exists() && version.Int() != persistedQueryVersion {
+		return "", true, ErrPersistedQueryVersion
+	}
+
+	hash = persistedQuery.Get("sha256Hash").String()
+	if hash == "" {
+		return "", false, nil
+	}
+	return hash, true, nil
+}
+
 func (r *Request) IsIntrospectionQuery() (result bool, err error) {
 	report := r.parseQueryOnce()
 	if report.HasErrors() {
@@ -174,6 +232,72 @@ func (r *Request) IsIntrospectionQuery() (result bool, err error) {
 	return true, nil
 }
 
+// UsesIncrementalDelivery returns true if the selected operation uses @defer or @stream,
+// which means its result is delivered as a streaming response.
+func (r *Request) UsesIncrementalDelivery() (bool, error) {
+	report := r.parseQueryOnce()
+	if report.HasErrors() {
+		return false, report
+	}
+
+	for _, rootNode := range r.document.RootNodes {
+		if rootNode.Kind != ast.NodeKindOperationDefinition {
+			continue
+		}
+
+		if r.OperationName != "" && r.document.OperationDefinitionNameString(rootNode.Ref) != r.OperationName {
+			continue
+		}
+
+		operationDef := r.document.OperationDefinitions[rootNode.Ref]
+		if !operationDef.HasSelections {
+			return false, nil
+		}
+		return r.selectionSetUsesIncrementalDelivery(operationDef.SelectionSet, map[int]struct{}{}), nil
+	}
+
+	return false, nil
+}
+
+func (r *Request) selectionSetUsesIncrementalDelivery(selectionSet int, visitedFragments map[int]struct{}) bool {
+	for _, selectionRef := range r.document.SelectionSets[selectionSet].SelectionRefs {
+		selection := r.document.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			field := r.document.Fields[selection.Ref]
+			if field.HasDirectives {
+				for _, directive := range field.Directives.Refs {
+					switch r.document.DirectiveNameString(directive) {
+					case "defer", "stream":
+						return true
+					}
+				}
+			}
+			if field.HasSelections && r.selectionSetUsesIncrementalDelivery(field.SelectionSet, visitedFragments) {
+				return true
+			}
+		case ast.SelectionKindInlineFragment:
+			inlineFragment := r.document.InlineFragments[selection.Ref]
+			if inlineFragment.HasSelections && r.selectionSetUsesIncrementalDelivery(inlineFragment.SelectionSet, visitedFragments) {
+				return true
+			}
+		case ast.SelectionKindFragmentSpread:
+			fragment, exists := r.document.FragmentDefinitionRef(r.document.FragmentSpreadNameBytes(selection.Ref))
+			if !exists {
+				continue
+			}
+			if _, visited := visitedFragments[fragment]; visited {
+				continue
+			}
+			visitedFragments[fragment] = struct{}{}
+			if r.document.FragmentDefinitions[fragment].HasSelections && r.selectionSetUsesIncrementalDelivery(r.document.FragmentDefinitions[fragment].SelectionSet, visitedFragments) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (r *Request) OperationType() (OperationType, error) {
 	report := r.parseQueryOnce()
 	if report.HasErrors() {
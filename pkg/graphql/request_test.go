@@ -157,6 +157,30 @@ func TestRequest_IsIntrospectionQuery(t *testing.T) {
 	})
 }
 
+func TestRequest_UsesIncrementalDelivery(t *testing.T) {
+	run := func(operationName, query string, expected bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			request := Request{OperationName: operationName, Query: query}
+			usesIncrementalDelivery, err := request.UsesIncrementalDelivery()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, usesIncrementalDelivery)
+		}
+	}
+
+	t.Run("without directives", run("", `{ hero { name } }`, false))
+	t.Run("with defer", run("", `{ hero { name friends @defer { name } } }`, true))
+	t.Run("with stream in an inline fragment", run("", `{ hero { ... on Hero { friends @stream { name } } } }`, true))
+	t.Run("with defer in a fragment", run("", `{ hero { ...HeroFields } } fragment HeroFields on Hero { friends @defer { name } }`, true))
+	t.Run("with defer in another operation", run("Hero", `query Hero { hero { name } } query Friends { hero { friends @defer { name } } }`, false))
+	t.Run("with defer in the selected operation", run("Friends", `query Hero { hero { name } } query Friends { hero { friends @defer { name } } }`, true))
+
+	t.Run("should return an error for a broken query", func(t *testing.T) {
+		request := Request{Query: "Broken Query"}
+		_, err := request.UsesIncrementalDelivery()
+		assert.Error(t, err)
+	})
+}
+
 func TestRequest_OperationType(t *testing.T) {
 	request := Request{
 		OperationName: "",
@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const DefaultResponseCacheSize = 1024
+
+// ResponseCacheStore stores complete responses of query operations.
+type ResponseCacheStore interface {
+	Get(ctx context.Context, key string) (response []byte, found bool, err error)
+	Set(ctx context.Context, key string, response []byte, ttl time.Duration) error
+}
+
+// ResponseCacheConfiguration enables the calculation of a CachePolicy for every operation
+// and caching of query responses.
+type ResponseCacheConfiguration struct {
+	// Store holds the cached responses, use NewInMemoryResponseCacheStore for a LRU cache.
+	// Without a Store, the CachePolicy is calculated but responses are not cached.
+	Store ResponseCacheStore
+	// DefaultMaxAge applies to root fields and fields returning composite types without cache hint.
+	// It defaults to 0, so responses are only cached if all of these fields have a cache hint.
+	DefaultMaxAge time.Duration
+	// VaryHeaders are the request headers which are part of the cache key, e.g. Authorization.
+	// Responses with scope PRIVATE are only cached if at least one of these headers is set.
+	VaryHeaders []string
+}
+
+type inMemoryResponseCacheEntry struct {
+	response  []byte
+	expiresAt time.Time
+}
+
+// InMemoryResponseCacheStore keeps the most recently used responses in memory until they expire.
+type InMemoryResponseCacheStore struct {
+	cache *lru.Cache
+	now   func() time.Time
+}
+
+func NewInMemoryResponseCacheStore(size int) (*InMemoryResponseCacheStore, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &InMemoryResponseCacheStore{
+		cache: cache,
+		now:   time.Now,
+	}, nil
+}
+
+func (i *InMemoryResponseCacheStore) Get(_ context.Context, key string) (response []byte, found bool, err error) {
+	cached, ok := i.cache.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry, ok := cached.(inMemoryResponseCacheEntry)
+	if !ok || !i.now().Before(entry.expiresAt) {
+		i.cache.Remove(key)
+		return nil, false, nil
+	}
+	return entry.response, true, nil
+}
+
+func (i *InMemoryResponseCacheStore) Set(_ context.Context, key string, response []byte, ttl time.Duration) error {
+	i.cache.Add(key, inMemoryResponseCacheEntry{
+		response:  response,
+		expiresAt: i.now().Add(ttl),
+	})
+	return nil
+}
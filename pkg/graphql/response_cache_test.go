@@ -0,0 +1,232 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/rest_datasource"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
+)
+
+func TestInMemoryResponseCacheStore(t *testing.T) {
+	store, err := NewInMemoryResponseCacheStore(DefaultResponseCacheSize)
+	require.NoError(t, err)
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Set(context.Background(), "key", []byte(`{"data":{}}`), time.Minute))
+
+	response, found, err := store.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, `{"data":{}}`, string(response))
+
+	now = now.Add(time.Minute)
+	_, found, err = store.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestExecutionEngineV2_ResponseCache(t *testing.T) {
+	var upstreamCalls int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls := atomic.AddInt64(&upstreamCalls, 1)
+		if r.URL.Query().Get("name") == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `"%s-%d"`, r.URL.Query().Get("name"), calls)
+	}))
+	defer upstream.Close()
+
+	schema, err := NewSchemaFromString(`
+		enum CacheControlScope { PUBLIC PRIVATE }
+		directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+
+		schema { query: Query mutation: Mutation }
+		type Query {
+			hello(name: String): String @cacheControl(maxAge: 60)
+			me: String @cacheControl(maxAge: 60, scope: PRIVATE)
+			uncached: String
+		}
+		type Mutation {
+			hello(name: String): String @cacheControl(maxAge: 60)
+		}
+	`)
+	require.NoError(t, err)
+
+	restDataSource := func(typeName, fieldName string) plan.DataSourceConfiguration {
+		return plan.DataSourceConfiguration{
+			RootNodes: []plan.TypeField{
+				{TypeName: typeName, FieldNames: []string{fieldName}},
+			},
+			Factory: &rest_datasource.Factory{Client: http.DefaultClient},
+			Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+				Fetch: rest_datasource.FetchConfiguration{
+					URL:    upstream.URL,
+					Method: http.MethodGet,
+					Query: []rest_datasource.QueryConfiguration{
+						{Name: "name", Value: "{{ .arguments.name }}"},
+					},
+				},
+			}),
+		}
+	}
+
+	newEngine := func(t *testing.T, config ResponseCacheConfiguration) *ExecutionEngineV2 {
+		engineConf := NewEngineV2Configuration(schema)
+		engineConf.SetDataSources([]plan.DataSourceConfiguration{
+			restDataSource("Query", "hello"),
+			restDataSource("Query", "me"),
+			restDataSource("Query", "uncached"),
+			restDataSource("Mutation", "hello"),
+		})
+		engineConf.SetFieldConfigurations([]plan.FieldConfiguration{
+			{TypeName: "Query", FieldName: "hello", DisableDefaultMapping: true, Arguments: []plan.ArgumentConfiguration{{Name: "name", SourceType: plan.FieldArgumentSource}}},
+			{TypeName: "Query", FieldName: "me", DisableDefaultMapping: true},
+			{TypeName: "Query", FieldName: "uncached", DisableDefaultMapping: true},
+			{TypeName: "Mutation", FieldName: "hello", DisableDefaultMapping: true, Arguments: []plan.ArgumentConfiguration{{Name: "name", SourceType: plan.FieldArgumentSource}}},
+		})
+		engineConf.SetResponseCacheConfiguration(config)
+
+		engine, err := NewExecutionEngineV2(context.Background(), abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+		return engine
+	}
+
+	newStore := func(t *testing.T) *InMemoryResponseCacheStore {
+		store, err := NewInMemoryResponseCacheStore(DefaultResponseCacheSize)
+		require.NoError(t, err)
+		return store
+	}
+
+	execute := func(t *testing.T, engine *ExecutionEngineV2, request *Request, header http.Header) string {
+		t.Helper()
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), request, &resultWriter, WithAdditionalHttpHeaders(header)))
+		return resultWriter.String()
+	}
+
+	t.Run("should serve identical queries from cache", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		engine := newEngine(t, ResponseCacheConfiguration{Store: newStore(t)})
+
+		request := Request{Query: `query Hello($name: String) { hello(name: $name) }`, Variables: []byte(`{"name":"a"}`)}
+		assert.Equal(t, `{"data":{"hello":"a-1"}}`, execute(t, engine, &request, nil))
+		policy, exists := request.CachePolicy()
+		assert.True(t, exists)
+		assert.Equal(t, time.Minute, policy.MaxAge)
+
+		request = Request{Query: `query Hello($name: String) { hello(name: $name) }`, Variables: []byte(`{"name":"a"}`)}
+		assert.Equal(t, `{"data":{"hello":"a-1"}}`, execute(t, engine, &request, nil))
+
+		request = Request{Query: `query Hello($name: String) { hello(name: $name) }`, Variables: []byte(`{"name":"b"}`)}
+		assert.Equal(t, `{"data":{"hello":"b-2"}}`, execute(t, engine, &request, nil))
+		assert.Equal(t, int64(2), atomic.LoadInt64(&upstreamCalls))
+	})
+
+	t.Run("should vary by configured headers", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		engine := newEngine(t, ResponseCacheConfiguration{Store: newStore(t), VaryHeaders: []string{"Authorization"}})
+
+		assert.Equal(t, `{"data":{"hello":"a-1"}}`, execute(t, engine, &Request{Query: `{ hello(name: "a") }`}, http.Header{"Authorization": []string{"1"}}))
+		assert.Equal(t, `{"data":{"hello":"a-1"}}`, execute(t, engine, &Request{Query: `{ hello(name: "a") }`}, http.Header{"Authorization": []string{"1"}}))
+		assert.Equal(t, `{"data":{"hello":"a-2"}}`, execute(t, engine, &Request{Query: `{ hello(name: "a") }`}, http.Header{"Authorization": []string{"2"}}))
+	})
+
+	t.Run("should only store private responses with vary header", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		engine := newEngine(t, ResponseCacheConfiguration{Store: newStore(t), VaryHeaders: []string{"Authorization"}})
+
+		request := Request{Query: `{ me }`}
+		assert.Equal(t, `{"data":{"me":"-1"}}`, execute(t, engine, &request, nil))
+		policy, _ := request.CachePolicy()
+		assert.Equal(t, "max-age=60, private", policy.HeaderValue())
+		assert.Equal(t, `{"data":{"me":"-2"}}`, execute(t, engine, &Request{Query: `{ me }`}, nil))
+
+		assert.Equal(t, `{"data":{"me":"-3"}}`, execute(t, engine, &Request{Query: `{ me }`}, http.Header{"Authorization": []string{"1"}}))
+		assert.Equal(t, `{"data":{"me":"-3"}}`, execute(t, engine, &Request{Query: `{ me }`}, http.Header{"Authorization": []string{"1"}}))
+	})
+
+	t.Run("should not cache responses with uncacheable fields", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		engine := newEngine(t, ResponseCacheConfiguration{Store: newStore(t)})
+
+		request := Request{Query: `{ hello(name: "a") uncached }`}
+		execute(t, engine, &request, nil)
+		execute(t, engine, &Request{Query: `{ hello(name: "a") uncached }`}, nil)
+		assert.Equal(t, int64(4), atomic.LoadInt64(&upstreamCalls))
+
+		policy, exists := request.CachePolicy()
+		assert.True(t, exists)
+		assert.False(t, policy.Cacheable())
+	})
+
+	t.Run("should not cache mutations", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		engine := newEngine(t, ResponseCacheConfiguration{Store: newStore(t)})
+
+		request := Request{Query: `mutation { hello(name: "a") }`}
+		assert.Equal(t, `{"data":{"hello":"a-1"}}`, execute(t, engine, &request, nil))
+		assert.Equal(t, `{"data":{"hello":"a-2"}}`, execute(t, engine, &Request{Query: `mutation { hello(name: "a") }`}, nil))
+
+		policy, _ := request.CachePolicy()
+		assert.False(t, policy.Cacheable())
+	})
+
+	t.Run("should not cache responses with errors", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		engine := newEngine(t, ResponseCacheConfiguration{Store: newStore(t)})
+
+		request := Request{Query: `{ hello(name: "fail") }`}
+		response := execute(t, engine, &request, nil)
+		assert.Contains(t, response, `"errors"`)
+		execute(t, engine, &Request{Query: `{ hello(name: "fail") }`}, nil)
+		assert.Equal(t, int64(2), atomic.LoadInt64(&upstreamCalls))
+
+		policy, exists := request.CachePolicy()
+		assert.True(t, exists)
+		assert.False(t, policy.Cacheable())
+	})
+
+	t.Run("should bypass the cache for traced responses", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		engine := newEngine(t, ResponseCacheConfiguration{Store: newStore(t)})
+
+		executeTraced := func() string {
+			resultWriter := NewEngineResultWriter()
+			require.NoError(t, engine.Execute(context.Background(), &Request{Query: `{ hello(name: "a") }`}, &resultWriter, WithExecutionTracing(resolve.TraceFormatJSON)))
+			return resultWriter.String()
+		}
+
+		assert.Contains(t, executeTraced(), `"tracing"`)
+		assert.Equal(t, `{"data":{"hello":"a-2"}}`, execute(t, engine, &Request{Query: `{ hello(name: "a") }`}, nil))
+		assert.Contains(t, executeTraced(), `"tracing"`)
+		assert.Equal(t, `{"data":{"hello":"a-2"}}`, execute(t, engine, &Request{Query: `{ hello(name: "a") }`}, nil))
+		assert.Equal(t, int64(3), atomic.LoadInt64(&upstreamCalls))
+	})
+
+	t.Run("should calculate cache policy without store", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		engine := newEngine(t, ResponseCacheConfiguration{})
+
+		request := Request{Query: `{ hello(name: "a") }`}
+		assert.Equal(t, `{"data":{"hello":"a-1"}}`, execute(t, engine, &request, nil))
+		assert.Equal(t, `{"data":{"hello":"a-2"}}`, execute(t, engine, &Request{Query: `{ hello(name: "a") }`}, nil))
+
+		policy, exists := request.CachePolicy()
+		assert.True(t, exists)
+		assert.Equal(t, time.Minute, policy.MaxAge)
+	})
+}
@@ -1,14 +1,17 @@
 package http
 
 import (
+	"bufio"
 	"encoding/json"
 	"io"
+	"net"
 	"net/http"
 
 	"github.com/gobwas/ws"
 	log "github.com/jensneuse/abstractlogger"
 
 	"github.com/pvormste/graphql-go-tools/pkg/execution"
+	"github.com/pvormste/graphql-go-tools/pkg/subscription"
 )
 
 const (
@@ -45,15 +48,29 @@ func (g *GraphQLHTTPRequestHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 }
 
 func (g *GraphQLHTTPRequestHandler) upgradeWithNewGoroutine(w http.ResponseWriter, r *http.Request) error {
-	conn, _, _, err := g.wsUpgrader.Upgrade(r, w)
+	conn, _, handshake, err := UpgradeWithSubscriptionProtocol(g.wsUpgrader, r, w)
 	if err != nil {
 		return err
 	}
-	g.handleWebsocket(conn)
+	g.handleWebsocket(conn, subscription.ProtocolFromString(handshake.Protocol))
 	return nil
 }
 
+// UpgradeWithSubscriptionProtocol upgrades the connection and negotiates the subscription protocol
+// from the Sec-WebSocket-Protocol header. A custom protocol selection of the upgrader takes precedence.
+func UpgradeWithSubscriptionProtocol(upgrader *ws.HTTPUpgrader, r *http.Request, w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, ws.Handshake, error) {
+	negotiatingUpgrader := *upgrader
+	if negotiatingUpgrader.Protocol == nil {
+		negotiatingUpgrader.Protocol = subscription.IsSupportedProtocol
+	}
+	return negotiatingUpgrader.Upgrade(r, w)
+}
+
 func (g *GraphQLHTTPRequestHandler) isWebsocketUpgrade(r *http.Request) bool {
+	return isWebsocketUpgrade(r)
+}
+
+func isWebsocketUpgrade(r *http.Request) bool {
 	for _, header := range r.Header[httpHeaderUpgrade] {
 		if header == "websocket" {
 			return true
@@ -153,6 +153,34 @@ func TestGraphQLHTTPRequestHandler_ServeHTTP(t *testing.T) {
 		cancelFunc()
 	})
 
+	t.Run("websockets with graphql-transport-ws protocol", func(t *testing.T) {
+		dialer := ws.Dialer{
+			Protocols: []string{string(subscription.ProtocolGraphQLTransportWS)},
+		}
+		clientConn, _, handshake, err := dialer.Dial(context.Background(), wsAddr)
+		require.NoError(t, err)
+		defer func() {
+			err := clientConn.Close()
+			require.NoError(t, err)
+		}()
+
+		assert.Equal(t, string(subscription.ProtocolGraphQLTransportWS), handshake.Protocol)
+
+		sendMessageToServer(t, clientConn, subscription.Message{
+			Type: subscription.MessageTypeConnectionInit,
+		})
+		serverMessage := readMessageFromServer(t, clientConn)
+		assert.Equal(t, `{"id":"","type":"connection_ack","payload":null}`, string(serverMessage))
+
+		sendMessageToServer(t, clientConn, subscription.Message{
+			Id:      "1",
+			Type:    subscription.MessageTypeSubscribe,
+			Payload: starwars.LoadQuery(t, starwars.FileRemainingJedisSubscription, nil),
+		})
+		serverMessage = readMessageFromServer(t, clientConn)
+		assert.Equal(t, `{"id":"1","type":"next","payload":{"data":null}}`, string(serverMessage))
+	})
+
 }
 
 func TestGraphQLHTTPRequestHandler_IsWebsocketUpgrade(t *testing.T) {
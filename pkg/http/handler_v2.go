@@ -0,0 +1,520 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gobwas/ws"
+	log "github.com/jensneuse/abstractlogger"
+
+	"github.com/pvormste/graphql-go-tools/pkg/graphql"
+	"github.com/pvormste/graphql-go-tools/pkg/operationreport"
+	"github.com/pvormste/graphql-go-tools/pkg/subscription"
+)
+
+const (
+	httpHeaderAllow string = "Allow"
+
+	queryParamQuery         string = "query"
+	queryParamOperationName string = "operationName"
+	queryParamVariables     string = "variables"
+	queryParamExtensions    string = "extensions"
+)
+
+var (
+	errMissingQuery       = errors.New("missing query")
+	errEmptyBatch         = errors.New("batch contains no operations")
+	errMutationNotAllowed = errors.New("mutations are not allowed with GET requests")
+
+	errIncrementalDeliveryNotAccepted = errors.New("the operation uses @defer or @stream but the client does not accept multipart/mixed responses")
+	errSubscriptionNotAccepted        = errors.New("subscriptions require the client to accept text/event-stream or multipart/mixed responses")
+)
+
+type graphqlHTTPHandlerV2Options struct {
+	wsUpgrader         *ws.HTTPUpgrader
+	excludedHeaderKeys []string
+	disableGet         bool
+	disableBatching    bool
+	maxBatchSize       int
+}
+
+type GraphQLHTTPHandlerV2Option func(options *graphqlHTTPHandlerV2Options)
+
+// WithWebsocketUpgrader enables websocket upgrades for subscriptions.
+// The subscription protocol (graphql-ws or graphql-transport-ws) is negotiated from the Sec-WebSocket-Protocol header.
+func WithWebsocketUpgrader(upgrader *ws.HTTPUpgrader) GraphQLHTTPHandlerV2Option {
+	return func(options *graphqlHTTPHandlerV2Options) {
+		options.wsUpgrader = upgrader
+	}
+}
+
+// WithExcludedHeaderKeys prevents the given request headers from being forwarded to the data sources.
+func WithExcludedHeaderKeys(keys ...string) GraphQLHTTPHandlerV2Option {
+	return func(options *graphqlHTTPHandlerV2Options) {
+		options.excludedHeaderKeys = append(options.excludedHeaderKeys, keys...)
+	}
+}
+
+// WithDisabledGetRequests rejects GET requests with 405 Method Not Allowed.
+func WithDisabledGetRequests() GraphQLHTTPHandlerV2Option {
+	return func(options *graphqlHTTPHandlerV2Options) {
+		options.disableGet = true
+	}
+}
+
+// WithDisabledBatching rejects array-batched POST requests with 400 Bad Request.
+func WithDisabledBatching() GraphQLHTTPHandlerV2Option {
+	return func(options *graphqlHTTPHandlerV2Options) {
+		options.disableBatching = true
+	}
+}
+
+// WithMaxBatchSize limits the amount of operations in a single batched request.
+// A value of 0 means no limit.
+func WithMaxBatchSize(size int) GraphQLHTTPHandlerV2Option {
+	return func(options *graphqlHTTPHandlerV2Options) {
+		options.maxBatchSize = size
+	}
+}
+
+// NewGraphqlHTTPHandlerV2 creates a http.Handler which executes GraphQL requests with the ExecutionEngineV2.
+// It serves POST requests with a JSON body, GET requests with query string parameters and array-batched POST requests.
+// Operations using @defer or @stream are answered with multipart/mixed responses if the client accepts them.
+// Clients accepting text/event-stream get subscriptions and streaming responses delivered as Server-Sent Events.
+func NewGraphqlHTTPHandlerV2(engine *graphql.ExecutionEngineV2, logger log.Logger, opts ...GraphQLHTTPHandlerV2Option) *GraphQLHTTPRequestHandlerV2 {
+	options := graphqlHTTPHandlerV2Options{}
+	for _, optFunc := range opts {
+		optFunc(&options)
+	}
+
+	return &GraphQLHTTPRequestHandlerV2{
+		log:     logger,
+		engine:  engine,
+		options: options,
+	}
+}
+
+type GraphQLHTTPRequestHandlerV2 struct {
+	log     log.Logger
+	engine  *graphql.ExecutionEngineV2
+	options graphqlHTTPHandlerV2Options
+}
+
+func (g *GraphQLHTTPRequestHandlerV2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if g.options.wsUpgrader != nil && isWebsocketUpgrade(r) {
+		if err := g.upgradeWebsocket(w, r); err != nil {
+			g.log.Error("GraphQLHTTPRequestHandlerV2.ServeHTTP",
+				log.Error(err),
+			)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if g.options.disableGet {
+			g.writeMethodNotAllowed(w, http.MethodPost)
+			return
+		}
+		g.handleGet(w, r)
+	case http.MethodPost:
+		g.handlePost(w, r)
+	default:
+		if g.options.disableGet {
+			g.writeMethodNotAllowed(w, http.MethodPost)
+			return
+		}
+		g.writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPost)
+	}
+}
+
+func (g *GraphQLHTTPRequestHandlerV2) upgradeWebsocket(w http.ResponseWriter, r *http.Request) error {
+	conn, _, handshake, err := UpgradeWithSubscriptionProtocol(g.options.wsUpgrader, r, w)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan bool)
+	errChan := make(chan error)
+
+	executorPool := subscription.NewExecutorV2Pool(g.engine, subscription.NewInitialHttpRequestContext(r))
+	go HandleWebsocketWithProtocol(done, errChan, conn, executorPool, g.log, subscription.ProtocolFromString(handshake.Protocol))
+	select {
+	case err := <-errChan:
+		g.log.Error("http.GraphQLHTTPRequestHandlerV2.upgradeWebsocket()",
+			log.Error(err),
+		)
+	case <-done:
+	}
+	return nil
+}
+
+func (g *GraphQLHTTPRequestHandlerV2) handleGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	gqlRequest := graphql.Request{
+		Query:         query.Get(queryParamQuery),
+		OperationName: query.Get(queryParamOperationName),
+	}
+
+	if variables := query.Get(queryParamVariables); variables != "" {
+		if !json.Valid([]byte(variables)) {
+			g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(errors.New("variables must be valid JSON")))
+			return
+		}
+		gqlRequest.Variables = json.RawMessage(variables)
+	}
+
+	if extensions := query.Get(queryParamExtensions); extensions != "" {
+		if !json.Valid([]byte(extensions)) {
+			g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(errors.New("extensions must be valid JSON")))
+			return
+		}
+		gqlRequest.Extensions = json.RawMessage(extensions)
+	}
+
+	if !g.loadPersistedQuery(w, r, &gqlRequest) {
+		return
+	}
+
+	if gqlRequest.Query == "" {
+		g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(errMissingQuery))
+		return
+	}
+
+	operationType, err := gqlRequest.OperationType()
+	if err != nil {
+		g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(err))
+		return
+	}
+
+	if operationType == graphql.OperationTypeMutation {
+		w.Header().Set(httpHeaderAllow, http.MethodPost)
+		g.writeRequestErrors(w, http.StatusMethodNotAllowed, graphql.RequestErrorsFromError(errMutationNotAllowed))
+		return
+	}
+
+	g.executeAndWrite(w, r, &gqlRequest)
+}
+
+func (g *GraphQLHTTPRequestHandlerV2) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		g.log.Error("GraphQLHTTPRequestHandlerV2.handlePost", log.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(graphql.ErrEmptyRequest))
+		return
+	}
+
+	if body[0] == '[' {
+		g.handleBatch(w, r, body)
+		return
+	}
+
+	var gqlRequest graphql.Request
+	if err = json.Unmarshal(body, &gqlRequest); err != nil {
+		g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(err))
+		return
+	}
+
+	if !g.loadPersistedQuery(w, r, &gqlRequest) {
+		return
+	}
+
+	if gqlRequest.Query == "" {
+		g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(errMissingQuery))
+		return
+	}
+
+	g.executeAndWrite(w, r, &gqlRequest)
+}
+
+// loadPersistedQuery resolves the query of requests using automatic persisted queries.
+// It returns false if the request can't be executed, in which case the error response has been written already.
+func (g *GraphQLHTTPRequestHandlerV2) loadPersistedQuery(w http.ResponseWriter, r *http.Request, gqlRequest *graphql.Request) bool {
+	err := g.engine.LoadPersistedQuery(r.Context(), gqlRequest)
+	if err == nil {
+		return true
+	}
+
+	buf := &bytes.Buffer{}
+	status := g.writeExecutionResult(buf, err)
+	g.writeResponse(w, status, buf.Bytes())
+	return false
+}
+
+// handleBatch executes each operation of an array-batched request in order.
+// Errors of single operations are reported inside the respective result, so the batch itself is answered with 200 OK.
+func (g *GraphQLHTTPRequestHandlerV2) handleBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+	if g.options.disableBatching {
+		g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(errors.New("batched requests are not supported")))
+		return
+	}
+
+	var gqlRequests []graphql.Request
+	if err := json.Unmarshal(body, &gqlRequests); err != nil {
+		g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(err))
+		return
+	}
+
+	if len(gqlRequests) == 0 {
+		g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(errEmptyBatch))
+		return
+	}
+
+	if g.options.maxBatchSize > 0 && len(gqlRequests) > g.options.maxBatchSize {
+		g.writeRequestErrors(w, http.StatusBadRequest, graphql.RequestErrorsFromError(errors.New("batch size exceeds the allowed maximum")))
+		return
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, 4096*len(gqlRequests)))
+	buf := bytes.NewBuffer(make([]byte, 0, 4096))
+	out.WriteByte('[')
+	for i := range gqlRequests {
+		if i != 0 {
+			out.WriteByte(',')
+		}
+
+		buf.Reset()
+		if err := g.engine.LoadPersistedQuery(r.Context(), &gqlRequests[i]); err != nil {
+			_ = g.writeExecutionResult(buf, err)
+		} else if gqlRequests[i].Query == "" {
+			_, _ = graphql.RequestErrorsFromError(errMissingQuery).WriteResponse(buf)
+		} else {
+			gqlRequests[i].SetRemoteAddr(r.RemoteAddr)
+			_ = g.execute(r, &gqlRequests[i], buf)
+		}
+		_, _ = buf.WriteTo(out)
+	}
+	out.WriteByte(']')
+
+	// the bucket state after charging the last operation describes the remaining budget of the caller
+	for i := len(gqlRequests) - 1; i >= 0; i-- {
+		if _, exists := gqlRequests[i].RateLimitResult(); exists {
+			writeRateLimit(w.Header(), &gqlRequests[i])
+			break
+		}
+	}
+	g.writeResponse(w, http.StatusOK, out.Bytes())
+}
+
+// executeAndWrite runs a single operation and writes the result to w.
+// Streaming responses are delivered as Server-Sent Events or multipart/mixed if the client accepts it.
+func (g *GraphQLHTTPRequestHandlerV2) executeAndWrite(w http.ResponseWriter, r *http.Request, gqlRequest *graphql.Request) {
+	gqlRequest.SetRemoteAddr(r.RemoteAddr)
+	w = &rateLimitResponseWriter{ResponseWriter: w, gqlRequest: gqlRequest}
+
+	if acceptsEventStream(r) {
+		g.executeEventStream(w, r, gqlRequest)
+		return
+	}
+
+	if !acceptsMultipartMixed(r) {
+		buf := bytes.NewBuffer(make([]byte, 0, 4096))
+		status := g.execute(r, gqlRequest, buf)
+		g.writeCacheControl(w, status, gqlRequest)
+		g.writeResponse(w, status, buf.Bytes())
+		return
+	}
+
+	multipartWriter := NewMultipartMixedWriter(w)
+	err := g.engine.Execute(r.Context(), gqlRequest, multipartWriter,
+		graphql.WithAdditionalHttpHeaders(r.Header, g.options.excludedHeaderKeys...),
+	)
+	if multipartWriter.Flushed() {
+		if err != nil {
+			g.log.Error("engine.Execute", log.Error(err))
+		}
+		if err = multipartWriter.Complete(); err != nil {
+			g.log.Error("MultipartMixedWriter.Complete", log.Error(err))
+		}
+		return
+	}
+
+	buf := bytes.NewBuffer(multipartWriter.Bytes())
+	status := g.writeExecutionResult(buf, err)
+	g.writeCacheControl(w, status, gqlRequest)
+	g.writeResponse(w, status, buf.Bytes())
+}
+
+// writeCacheControl sets the Cache-Control header of successful responses if the engine calculated a cache policy.
+func (g *GraphQLHTTPRequestHandlerV2) writeCacheControl(w http.ResponseWriter, status int, gqlRequest *graphql.Request) {
+	if status != http.StatusOK {
+		return
+	}
+	if policy, exists := gqlRequest.CachePolicy(); exists {
+		w.Header().Set(httpHeaderCacheControl, policy.HeaderValue())
+	}
+}
+
+// writeRateLimit sets the headers describing the remaining rate limit budget if the engine charged the operation.
+func writeRateLimit(header http.Header, gqlRequest *graphql.Request) {
+	result, exists := gqlRequest.RateLimitResult()
+	if !exists {
+		return
+	}
+	for key, values := range result.Header() {
+		header[key] = values
+	}
+}
+
+// rateLimitResponseWriter sets the rate limit headers of the operation right before the status code is written.
+// The engine charges an operation before anything is flushed, so streamed responses carry the headers as well.
+type rateLimitResponseWriter struct {
+	http.ResponseWriter
+	gqlRequest  *graphql.Request
+	wroteHeader bool
+}
+
+func (r *rateLimitResponseWriter) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		writeRateLimit(r.Header(), r.gqlRequest)
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *rateLimitResponseWriter) Write(data []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *rateLimitResponseWriter) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// executeEventStream runs a single operation and sends every flushed result as Server-Sent Event.
+// The operation is executed with the request context, so a disconnecting client stops a running subscription.
+// Errors which occur before anything has been flushed are answered with a regular JSON response.
+func (g *GraphQLHTTPRequestHandlerV2) executeEventStream(w http.ResponseWriter, r *http.Request, gqlRequest *graphql.Request) {
+	eventStreamWriter := NewEventStreamWriter(w)
+	err := g.engine.Execute(r.Context(), gqlRequest, eventStreamWriter,
+		graphql.WithAdditionalHttpHeaders(r.Header, g.options.excludedHeaderKeys...),
+	)
+	if err != nil && !eventStreamWriter.Flushed() {
+		buf := bytes.NewBuffer(eventStreamWriter.Bytes())
+		status := g.writeExecutionResult(buf, err)
+		g.writeResponse(w, status, buf.Bytes())
+		return
+	}
+	if err != nil {
+		g.log.Error("engine.Execute", log.Error(err))
+	}
+
+	if r.Context().Err() != nil {
+		// the client is gone, there is no one left to receive the complete event
+		return
+	}
+	if err = eventStreamWriter.Complete(); err != nil {
+		g.log.Error("EventStreamWriter.Complete", log.Error(err))
+	}
+}
+
+// execute runs a single operation and writes either the result or the errors to buf.
+// It returns the http status code which describes the outcome of the execution.
+func (g *GraphQLHTTPRequestHandlerV2) execute(r *http.Request, gqlRequest *graphql.Request, buf *bytes.Buffer) (status int) {
+	if operationType, err := gqlRequest.OperationType(); err == nil && operationType == graphql.OperationTypeSubscription {
+		// a subscription would never end, so it can't be answered with a single JSON response
+		_, _ = graphql.RequestErrorsFromError(errSubscriptionNotAccepted).WriteResponse(buf)
+		return http.StatusNotAcceptable
+	}
+	if usesIncrementalDelivery, err := gqlRequest.UsesIncrementalDelivery(); err == nil && usesIncrementalDelivery {
+		// the operation must not be executed, e.g. a mutation would have side effects although its result is rejected
+		_, _ = graphql.RequestErrorsFromError(errIncrementalDeliveryNotAccepted).WriteResponse(buf)
+		return http.StatusNotAcceptable
+	}
+
+	resultWriter := &bufferedResultWriter{Buffer: buf}
+	err := g.engine.Execute(r.Context(), gqlRequest, resultWriter,
+		graphql.WithAdditionalHttpHeaders(r.Header, g.options.excludedHeaderKeys...),
+	)
+	if err == nil && resultWriter.flushed {
+		buf.Reset()
+		_, _ = graphql.RequestErrorsFromError(errIncrementalDeliveryNotAccepted).WriteResponse(buf)
+		return http.StatusNotAcceptable
+	}
+	return g.writeExecutionResult(buf, err)
+}
+
+// writeExecutionResult replaces the content of buf with the errors if the execution failed.
+// It returns the http status code which describes the outcome of the execution.
+func (g *GraphQLHTTPRequestHandlerV2) writeExecutionResult(buf *bytes.Buffer, err error) (status int) {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	buf.Reset()
+	status = statusCodeFromExecutionError(err)
+	if status == http.StatusInternalServerError {
+		g.log.Error("engine.Execute", log.Error(err))
+		_, _ = graphql.RequestErrors{{Message: "Internal Error"}}.WriteResponse(buf)
+		return status
+	}
+
+	_, _ = graphql.RequestErrorsFromError(err).WriteResponse(buf)
+	return status
+}
+
+func (g *GraphQLHTTPRequestHandlerV2) writeRequestErrors(w http.ResponseWriter, status int, requestErrors graphql.RequestErrors) {
+	buf := &bytes.Buffer{}
+	if _, err := requestErrors.WriteResponse(buf); err != nil {
+		g.log.Error("RequestErrors.WriteResponse", log.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	g.writeResponse(w, status, buf.Bytes())
+}
+
+func (g *GraphQLHTTPRequestHandlerV2) writeResponse(w http.ResponseWriter, status int, data []byte) {
+	w.Header().Set(httpHeaderContentType, httpContentTypeApplicationJson)
+	w.WriteHeader(status)
+	if _, err := w.Write(data); err != nil {
+		g.log.Error("GraphQLHTTPRequestHandlerV2.writeResponse", log.Error(err))
+	}
+}
+
+func (g *GraphQLHTTPRequestHandlerV2) writeMethodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set(httpHeaderAllow, allow)
+	w.WriteHeader(http.StatusMethodNotAllowed)
+}
+
+// statusCodeFromExecutionError maps errors returned by ExecutionEngineV2.Execute to http status codes.
+// Errors caused by the operation itself (parsing, normalization, validation) result in 400 Bad Request,
+// operations exceeding the rate limit in 429 Too Many Requests and everything else is considered to be an internal error.
+func statusCodeFromExecutionError(err error) int {
+	switch e := err.(type) {
+	case graphql.RateLimitError:
+		return http.StatusTooManyRequests
+	case graphql.Errors:
+		return http.StatusBadRequest
+	case operationreport.Report:
+		if len(e.ExternalErrors) > 0 {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// bufferedResultWriter collects the result of an operation which is written as a single JSON response.
+// Streaming responses can't be delivered this way, so a call to Flush is only recorded.
+type bufferedResultWriter struct {
+	*bytes.Buffer
+	flushed bool
+}
+
+func (b *bufferedResultWriter) Flush() {
+	b.flushed = true
+}
@@ -0,0 +1,552 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/rest_datasource"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/datasource/staticdatasource"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/plan"
+	"github.com/pvormste/graphql-go-tools/pkg/graphql"
+	"github.com/pvormste/graphql-go-tools/pkg/subscription"
+)
+
+func newTestExecutionEngineV2(t *testing.T, upstreamURL string, configure ...func(engineConf *graphql.EngineV2Configuration)) *graphql.ExecutionEngineV2 {
+	t.Helper()
+
+	schema, err := graphql.NewSchemaFromString(`
+		directive @defer on FIELD
+		schema { query: Query mutation: Mutation subscription: Subscription }
+		type Query { hello: String header: String hero: Hero }
+		type Mutation { greet: String }
+		type Subscription { counter: Int }
+		type Hero { name: String friends: [String] }
+	`)
+	require.NoError(t, err)
+
+	engineConf := graphql.NewEngineV2Configuration(schema)
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"hello"}},
+			},
+			Factory: &staticdatasource.Factory{},
+			Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+				Data: `"world"`,
+			}),
+		},
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"hero"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "Hero", FieldNames: []string{"name", "friends"}},
+			},
+			Factory: &staticdatasource.Factory{},
+			Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+				Data: `{"name":"Luke","friends":["Han","Leia"]}`,
+			}),
+		},
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Mutation", FieldNames: []string{"greet"}},
+			},
+			Factory: &staticdatasource.Factory{},
+			Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+				Data: `"hi"`,
+			}),
+		},
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"header"}},
+			},
+			Factory: &rest_datasource.Factory{Client: http.DefaultClient},
+			Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+				Fetch: rest_datasource.FetchConfiguration{
+					URL:    upstreamURL,
+					Method: http.MethodGet,
+					Header: http.Header{
+						"X-Upstream": []string{"{{ .request.headers.X-Test }}"},
+					},
+				},
+			}),
+		},
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Subscription", FieldNames: []string{"counter"}},
+			},
+			Factory: &graphql_datasource.Factory{HTTPClient: http.DefaultClient},
+			Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL: upstreamURL,
+				},
+				Subscription: graphql_datasource.SubscriptionConfiguration{
+					URL:      upstreamURL,
+					Protocol: graphql_datasource.ProtocolSSE,
+				},
+			}),
+		},
+	})
+	engineConf.SetFieldConfigurations([]plan.FieldConfiguration{
+		{TypeName: "Query", FieldName: "hello", DisableDefaultMapping: true},
+		{TypeName: "Query", FieldName: "header", DisableDefaultMapping: true},
+		{TypeName: "Query", FieldName: "hero", DisableDefaultMapping: true},
+		{TypeName: "Mutation", FieldName: "greet", DisableDefaultMapping: true},
+	})
+	for _, configureFn := range configure {
+		configureFn(&engineConf)
+	}
+
+	engine, err := graphql.NewExecutionEngineV2(context.Background(), abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+	return engine
+}
+
+func TestGraphQLHTTPRequestHandlerV2_ServeHTTP(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(httpHeaderAccept) == httpContentTypeEventStream {
+			w.Header().Set(httpHeaderContentType, httpContentTypeEventStream)
+			for i := 1; i <= 2; i++ {
+				_, _ = fmt.Fprintf(w, "event: next\ndata: {\"data\":{\"counter\":%d}}\n\n", i)
+				w.(http.Flusher).Flush()
+			}
+			_, _ = fmt.Fprint(w, "event: complete\ndata: \n\n")
+			return
+		}
+		_, _ = fmt.Fprintf(w, `"%s"`, r.Header.Get("X-Upstream"))
+	}))
+	defer upstream.Close()
+
+	engine := newTestExecutionEngineV2(t, upstream.URL)
+
+	serve := func(t *testing.T, handler http.Handler, method, target string, body string, header http.Header) (int, http.Header, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		res := rec.Result()
+		defer res.Body.Close()
+		data, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, res.Header, string(data)
+	}
+
+	handler := NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger)
+
+	t.Run("POST", func(t *testing.T) {
+		t.Run("should execute query", func(t *testing.T) {
+			status, header, body := serve(t, handler, http.MethodPost, "/", `{"query":"{hello}"}`, nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, httpContentTypeApplicationJson, header.Get(httpHeaderContentType))
+			assert.Equal(t, `{"data":{"hello":"world"}}`, body)
+		})
+
+		t.Run("should execute mutation", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodPost, "/", `{"query":"mutation {greet}"}`, nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, `{"data":{"greet":"hi"}}`, body)
+		})
+
+		t.Run("should forward request headers", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodPost, "/", `{"query":"{header}"}`, http.Header{"X-Test": []string{"forwarded"}})
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, `{"data":{"header":"forwarded"}}`, body)
+		})
+
+		t.Run("should not forward excluded request headers", func(t *testing.T) {
+			handler := NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger, WithExcludedHeaderKeys("X-Test"))
+			status, _, body := serve(t, handler, http.MethodPost, "/", `{"query":"{header}"}`, http.Header{"X-Test": []string{"forwarded"}})
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, `{"data":{"header":""}}`, body)
+		})
+
+		t.Run("should return 400 for invalid json", func(t *testing.T) {
+			status, _, _ := serve(t, handler, http.MethodPost, "/", `{"query":`, nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+
+		t.Run("should return 400 for empty body", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodPost, "/", ``, nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, `{"errors":[{"message":"the provided request is empty"}]}`, body)
+		})
+
+		t.Run("should return 400 with errors when validation fails", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodPost, "/", `{"query":"{unknown}"}`, nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Contains(t, body, `"errors":[{"message":`)
+			assert.Contains(t, body, `unknown`)
+		})
+
+		t.Run("should respond with multipart/mixed for deferred fields", func(t *testing.T) {
+			status, header, body := serve(t, handler, http.MethodPost, "/", `{"query":"{hero {name friends @defer}}"}`, http.Header{httpHeaderAccept: []string{"multipart/mixed, application/json"}})
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, multipartContentType, header.Get(httpHeaderContentType))
+			assert.Equal(t, "\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"+
+				`{"data":{"hero":{"name":"Luke","friends":null}},"hasNext":true}`+
+				"\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"+
+				`{"incremental":[{"data":{"friends":["Han","Leia"]},"path":["hero"]}],"hasNext":true}`+
+				"\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"+
+				`{"hasNext":false}`+
+				"\r\n-----\r\n", body)
+		})
+
+		t.Run("should respond with json when multipart/mixed is accepted but the operation is not streamed", func(t *testing.T) {
+			status, header, body := serve(t, handler, http.MethodPost, "/", `{"query":"{hello}"}`, http.Header{httpHeaderAccept: []string{"multipart/mixed"}})
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, httpContentTypeApplicationJson, header.Get(httpHeaderContentType))
+			assert.Equal(t, `{"data":{"hello":"world"}}`, body)
+		})
+
+		t.Run("should return 406 for deferred fields when multipart/mixed is not accepted", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodPost, "/", `{"query":"{hero {name friends @defer}}"}`, nil)
+			assert.Equal(t, http.StatusNotAcceptable, status)
+			assert.Contains(t, body, "multipart/mixed")
+		})
+
+		t.Run("should not execute deferred operations when multipart/mixed is not accepted", func(t *testing.T) {
+			var requests int32
+			countingUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				_, _ = w.Write([]byte(`"upstream"`))
+			}))
+			defer countingUpstream.Close()
+
+			handler := NewGraphqlHTTPHandlerV2(newTestExecutionEngineV2(t, countingUpstream.URL), abstractlogger.NoopLogger)
+			status, _, _ := serve(t, handler, http.MethodPost, "/", `{"query":"{header hero {name friends @defer}}"}`, nil)
+			assert.Equal(t, http.StatusNotAcceptable, status)
+			assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+		})
+
+		t.Run("should execute batched requests", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodPost, "/", `[{"query":"{hello}"},{"query":"{unknown}"},{"query":"mutation {greet}"}]`, nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Regexp(t, `^\[\{"data":\{"hello":"world"\}\},\{"errors":\[.+\]\},\{"data":\{"greet":"hi"\}\}\]$`, body)
+		})
+
+		t.Run("should return 400 for empty batch", func(t *testing.T) {
+			status, _, _ := serve(t, handler, http.MethodPost, "/", `[]`, nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+
+		t.Run("should reject batches when batching is disabled", func(t *testing.T) {
+			handler := NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger, WithDisabledBatching())
+			status, _, _ := serve(t, handler, http.MethodPost, "/", `[{"query":"{hello}"}]`, nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+
+		t.Run("should reject batches exceeding the max batch size", func(t *testing.T) {
+			handler := NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger, WithMaxBatchSize(1))
+			status, _, _ := serve(t, handler, http.MethodPost, "/", `[{"query":"{hello}"},{"query":"{hello}"}]`, nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+	})
+
+	t.Run("SSE", func(t *testing.T) {
+		eventStreamHeader := http.Header{httpHeaderAccept: []string{httpContentTypeEventStream}}
+
+		t.Run("should send every subscription update as next event", func(t *testing.T) {
+			status, header, body := serve(t, handler, http.MethodPost, "/", `{"query":"subscription {counter}"}`, eventStreamHeader)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, httpContentTypeEventStream, header.Get(httpHeaderContentType))
+			assert.Equal(t, "no-cache", header.Get(httpHeaderCacheControl))
+			assert.Equal(t, "event: next\ndata: {\"data\":{\"counter\":1}}\n\n"+
+				"event: next\ndata: {\"data\":{\"counter\":2}}\n\n"+
+				"event: complete\ndata: \n\n", body)
+		})
+
+		t.Run("should execute subscription from query string", func(t *testing.T) {
+			target := "/?" + url.Values{"query": []string{"subscription {counter}"}}.Encode()
+			status, _, body := serve(t, handler, http.MethodGet, target, "", eventStreamHeader)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "event: next\ndata: {\"data\":{\"counter\":1}}\n\n"+
+				"event: next\ndata: {\"data\":{\"counter\":2}}\n\n"+
+				"event: complete\ndata: \n\n", body)
+		})
+
+		t.Run("should send deferred fields as next events", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodPost, "/", `{"query":"{hero {name friends @defer}}"}`, eventStreamHeader)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "event: next\ndata: {\"data\":{\"hero\":{\"name\":\"Luke\",\"friends\":null}}}\n\n"+
+				"event: next\ndata: {\"incremental\":[{\"data\":{\"friends\":[\"Han\",\"Leia\"]},\"path\":[\"hero\"]}],\"hasNext\":true}\n\n"+
+				"event: complete\ndata: \n\n", body)
+		})
+
+		t.Run("should send query result as single next event", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodPost, "/", `{"query":"{hello}"}`, eventStreamHeader)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "event: next\ndata: {\"data\":{\"hello\":\"world\"}}\n\n"+
+				"event: complete\ndata: \n\n", body)
+		})
+
+		t.Run("should respond with json when validation fails", func(t *testing.T) {
+			status, header, body := serve(t, handler, http.MethodPost, "/", `{"query":"subscription {unknown}"}`, eventStreamHeader)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, httpContentTypeApplicationJson, header.Get(httpHeaderContentType))
+			assert.Contains(t, body, `"errors":[{"message":`)
+		})
+
+		t.Run("should return 406 for subscriptions when no streaming response is accepted", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodPost, "/", `{"query":"subscription {counter}"}`, nil)
+			assert.Equal(t, http.StatusNotAcceptable, status)
+			assert.Contains(t, body, "text/event-stream")
+		})
+
+		t.Run("should stop the subscription when the client disconnects", func(t *testing.T) {
+			upstreamDone := make(chan struct{})
+			endlessUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(httpHeaderContentType, httpContentTypeEventStream)
+				_, _ = fmt.Fprint(w, "event: next\ndata: {\"data\":{\"counter\":1}}\n\n")
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+				close(upstreamDone)
+			}))
+			defer endlessUpstream.Close()
+
+			server := httptest.NewServer(NewGraphqlHTTPHandlerV2(newTestExecutionEngineV2(t, endlessUpstream.URL), abstractlogger.NoopLogger))
+			defer server.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, bytes.NewBufferString(`{"query":"subscription {counter}"}`))
+			require.NoError(t, err)
+			req.Header = eventStreamHeader
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, httpContentTypeEventStream, res.Header.Get(httpHeaderContentType))
+
+			event := make([]byte, len("event: next\ndata: {\"data\":{\"counter\":1}}\n\n"))
+			_, err = io.ReadFull(res.Body, event)
+			require.NoError(t, err)
+			assert.Equal(t, "event: next\ndata: {\"data\":{\"counter\":1}}\n\n", string(event))
+
+			cancel()
+			select {
+			case <-upstreamDone:
+			case <-time.After(time.Second):
+				t.Fatal("upstream subscription has not been stopped")
+			}
+		})
+	})
+
+	t.Run("GET", func(t *testing.T) {
+		t.Run("should execute query from query string", func(t *testing.T) {
+			target := "/?" + url.Values{"query": []string{"query Hello { hello }"}, "operationName": []string{"Hello"}}.Encode()
+			status, _, body := serve(t, handler, http.MethodGet, target, "", nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, `{"data":{"hello":"world"}}`, body)
+		})
+
+		t.Run("should return 400 for missing query", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodGet, "/", "", nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, `{"errors":[{"message":"missing query"}]}`, body)
+		})
+
+		t.Run("should return 400 for invalid variables", func(t *testing.T) {
+			target := "/?" + url.Values{"query": []string{"{hello}"}, "variables": []string{"{"}}.Encode()
+			status, _, _ := serve(t, handler, http.MethodGet, target, "", nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+
+		t.Run("should return 405 for mutations", func(t *testing.T) {
+			target := "/?" + url.Values{"query": []string{"mutation { greet }"}}.Encode()
+			status, header, _ := serve(t, handler, http.MethodGet, target, "", nil)
+			assert.Equal(t, http.StatusMethodNotAllowed, status)
+			assert.Equal(t, http.MethodPost, header.Get(httpHeaderAllow))
+		})
+
+		t.Run("should return 405 when get requests are disabled", func(t *testing.T) {
+			handler := NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger, WithDisabledGetRequests())
+			target := "/?" + url.Values{"query": []string{"{hello}"}}.Encode()
+			status, _, _ := serve(t, handler, http.MethodGet, target, "", nil)
+			assert.Equal(t, http.StatusMethodNotAllowed, status)
+		})
+	})
+
+	t.Run("websocket", func(t *testing.T) {
+		server := httptest.NewServer(NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger, WithWebsocketUpgrader(&ws.DefaultHTTPUpgrader)))
+		defer server.Close()
+
+		dialer := ws.Dialer{
+			Protocols: []string{string(subscription.ProtocolGraphQLTransportWS)},
+		}
+		clientConn, _, handshake, err := dialer.Dial(context.Background(), "ws://"+server.Listener.Addr().String())
+		require.NoError(t, err)
+		defer clientConn.Close()
+		assert.Equal(t, string(subscription.ProtocolGraphQLTransportWS), handshake.Protocol)
+
+		sendMessageToServer(t, clientConn, subscription.Message{Type: subscription.MessageTypeConnectionInit})
+		assert.Equal(t, `{"id":"","type":"connection_ack","payload":null}`, string(readMessageFromServer(t, clientConn)))
+
+		sendMessageToServer(t, clientConn, subscription.Message{Id: "1", Type: subscription.MessageTypeSubscribe, Payload: []byte(`{"query":"{hello}"}`)})
+		assert.Equal(t, `{"id":"1","type":"next","payload":{"data":{"hello":"world"}}}`, string(readMessageFromServer(t, clientConn)))
+		assert.Equal(t, `{"id":"1","type":"complete","payload":null}`, string(readMessageFromServer(t, clientConn)))
+	})
+
+	t.Run("persisted queries", func(t *testing.T) {
+		store, err := graphql.NewInMemoryPersistedQueryStore(graphql.DefaultPersistedQueryCacheSize)
+		require.NoError(t, err)
+		engine := newTestExecutionEngineV2(t, upstream.URL, func(engineConf *graphql.EngineV2Configuration) {
+			engineConf.SetPersistedQueryConfiguration(graphql.PersistedQueryConfiguration{Store: store})
+		})
+		handler := NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger)
+
+		query := "query Hello { hello }"
+		extensions := fmt.Sprintf(`{"persistedQuery":{"version":1,"sha256Hash":"%s"}}`, graphql.PersistedQueryHash(query))
+		getTarget := "/?" + url.Values{"operationName": []string{"Hello"}, "extensions": []string{extensions}}.Encode()
+
+		t.Run("should return PersistedQueryNotFound for unknown hash", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodGet, getTarget, "", nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`, body)
+		})
+
+		t.Run("should register query and execute it by hash", func(t *testing.T) {
+			status, _, body := serve(t, handler, http.MethodPost, "/", fmt.Sprintf(`{"query":"%s","extensions":%s}`, query, extensions), nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, `{"data":{"hello":"world"}}`, body)
+
+			status, _, body = serve(t, handler, http.MethodGet, getTarget, "", nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, `{"data":{"hello":"world"}}`, body)
+
+			status, _, body = serve(t, handler, http.MethodPost, "/", fmt.Sprintf(`[{"extensions":%s},{"query":"{hello}"}]`, extensions), nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, `[{"data":{"hello":"world"}},{"data":{"hello":"world"}}]`, body)
+		})
+
+		t.Run("should reject persisted query mutations with GET", func(t *testing.T) {
+			mutation := "mutation { greet }"
+			mutationExtensions := fmt.Sprintf(`{"persistedQuery":{"version":1,"sha256Hash":"%s"}}`, graphql.PersistedQueryHash(mutation))
+			require.NoError(t, store.Set(context.Background(), graphql.PersistedQueryHash(mutation), mutation))
+
+			status, _, _ := serve(t, handler, http.MethodGet, "/?"+url.Values{"extensions": []string{mutationExtensions}}.Encode(), "", nil)
+			assert.Equal(t, http.StatusMethodNotAllowed, status)
+		})
+
+		t.Run("should reject operations not in the allow-list", func(t *testing.T) {
+			engine := newTestExecutionEngineV2(t, upstream.URL, func(engineConf *graphql.EngineV2Configuration) {
+				engineConf.SetPersistedQueryConfiguration(graphql.PersistedQueryConfiguration{
+					Store:         graphql.NewStaticPersistedQueryStore(map[string]string{graphql.PersistedQueryHash(query): query}),
+					AllowListOnly: true,
+				})
+			})
+			handler := NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger)
+
+			status, _, body := serve(t, handler, http.MethodGet, getTarget, "", nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, `{"data":{"hello":"world"}}`, body)
+
+			status, _, body = serve(t, handler, http.MethodPost, "/", `{"query":"{hello}"}`, nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, `{"errors":[{"message":"PersistedQueryNotInList","extensions":{"code":"PERSISTED_QUERY_NOT_IN_LIST"}}]}`, body)
+		})
+	})
+
+	t.Run("should set cache-control header", func(t *testing.T) {
+		engine := newTestExecutionEngineV2(t, upstream.URL, func(engineConf *graphql.EngineV2Configuration) {
+			fields := engineConf.FieldConfigurations()
+			fields.ForTypeField("Query", "hello").CacheControl = &plan.CacheControl{MaxAge: time.Minute}
+			engineConf.SetResponseCacheConfiguration(graphql.ResponseCacheConfiguration{})
+		})
+		handler := NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger)
+
+		status, header, body := serve(t, handler, http.MethodGet, "/?"+url.Values{"query": []string{"{hello}"}}.Encode(), "", nil)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "max-age=60, public", header.Get(httpHeaderCacheControl))
+		assert.Equal(t, `{"data":{"hello":"world"}}`, body)
+
+		status, header, _ = serve(t, handler, http.MethodPost, "/", `{"query":"{hello header}"}`, nil)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "no-store", header.Get(httpHeaderCacheControl))
+
+		status, header, _ = serve(t, handler, http.MethodPost, "/", `{"query":"{unknown}"}`, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "", header.Get(httpHeaderCacheControl))
+	})
+
+	t.Run("should set rate limit headers", func(t *testing.T) {
+		engine := newTestExecutionEngineV2(t, upstream.URL, func(engineConf *graphql.EngineV2Configuration) {
+			engineConf.SetRateLimitConfiguration(graphql.RateLimitConfiguration{
+				RateLimitBucket: graphql.RateLimitBucket{Capacity: 1, RefillAmount: 1, RefillInterval: time.Hour},
+				Key:             graphql.RateLimitKeyFromHeader("X-Api-Key"),
+			})
+		})
+		handler := NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger)
+
+		status, header, _ := serve(t, handler, http.MethodPost, "/", `{"query":"{hero {name}}"}`, http.Header{"X-Api-Key": []string{"a"}})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "1", header.Get(graphql.RateLimitHeaderLimit))
+		assert.Equal(t, "0", header.Get(graphql.RateLimitHeaderRemaining))
+		assert.Equal(t, "", header.Get(graphql.RateLimitHeaderRetry))
+
+		status, header, body := serve(t, handler, http.MethodPost, "/", `{"query":"{hero {name}}"}`, http.Header{"X-Api-Key": []string{"a"}})
+		assert.Equal(t, http.StatusTooManyRequests, status)
+		assert.Equal(t, "0", header.Get(graphql.RateLimitHeaderRemaining))
+		assert.Equal(t, "3600", header.Get(graphql.RateLimitHeaderRetry))
+		assert.Equal(t, `{"errors":[{"message":"operation cost of 1 exceeds the remaining rate limit budget of 0","extensions":{"code":"RATE_LIMITED","cost":1,"limit":1,"remaining":0,"retryAfter":3600}}]}`, body)
+
+		status, header, _ = serve(t, handler, http.MethodPost, "/", `{"query":"{hero {name}}"}`, http.Header{"X-Api-Key": []string{"b"}})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "0", header.Get(graphql.RateLimitHeaderRemaining))
+	})
+
+	t.Run("should set rate limit headers for streamed and batched responses", func(t *testing.T) {
+		engine := newTestExecutionEngineV2(t, upstream.URL, func(engineConf *graphql.EngineV2Configuration) {
+			engineConf.SetRateLimitConfiguration(graphql.RateLimitConfiguration{
+				RateLimitBucket: graphql.RateLimitBucket{Capacity: 10, RefillAmount: 1, RefillInterval: time.Hour},
+				Key:             graphql.RateLimitKeyFromHeader("X-Api-Key"),
+			})
+		})
+		handler := NewGraphqlHTTPHandlerV2(engine, abstractlogger.NoopLogger)
+
+		status, header, _ := serve(t, handler, http.MethodPost, "/", `{"query":"{hero {name friends @defer}}"}`, http.Header{
+			"X-Api-Key":      []string{"multipart"},
+			httpHeaderAccept: []string{"multipart/mixed"},
+		})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, multipartContentType, header.Get(httpHeaderContentType))
+		assert.Equal(t, "10", header.Get(graphql.RateLimitHeaderLimit))
+		assert.NotEmpty(t, header.Get(graphql.RateLimitHeaderRemaining))
+
+		status, header, _ = serve(t, handler, http.MethodPost, "/", `{"query":"subscription {counter}"}`, http.Header{
+			"X-Api-Key":      []string{"sse"},
+			httpHeaderAccept: []string{httpContentTypeEventStream},
+		})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, httpContentTypeEventStream, header.Get(httpHeaderContentType))
+		assert.Equal(t, "10", header.Get(graphql.RateLimitHeaderLimit))
+		assert.NotEmpty(t, header.Get(graphql.RateLimitHeaderRemaining))
+
+		status, header, _ = serve(t, handler, http.MethodPost, "/", `[{"query":"{hello}"},{"query":"{hello}"}]`, http.Header{"X-Api-Key": []string{"batch"}})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "10", header.Get(graphql.RateLimitHeaderLimit))
+		assert.NotEmpty(t, header.Get(graphql.RateLimitHeaderRemaining))
+	})
+
+	t.Run("should return 405 for unsupported methods", func(t *testing.T) {
+		status, header, _ := serve(t, handler, http.MethodPut, "/", "", nil)
+		assert.Equal(t, http.StatusMethodNotAllowed, status)
+		assert.Equal(t, "GET, POST", header.Get(httpHeaderAllow))
+	})
+}
@@ -0,0 +1,243 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+)
+
+const (
+	httpHeaderAccept string = "Accept"
+
+	httpContentTypeMultipartMixed string = "multipart/mixed"
+
+	multipartContentType   = `multipart/mixed; boundary="-"; deferSpec=20220824`
+	multipartPartHeader    = "\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"
+	multipartTerminator    = "\r\n-----\r\n"
+	multipartLastPartBody  = `{"hasNext":false}`
+	multipartHasNextSuffix = `,"hasNext":true}`
+)
+
+var (
+	errInvalidPatch = errors.New("invalid patch")
+)
+
+// acceptsMultipartMixed returns true if the client signals that it understands multipart/mixed responses.
+func acceptsMultipartMixed(r *http.Request) bool {
+	for _, accept := range r.Header.Values(httpHeaderAccept) {
+		if strings.Contains(accept, httpContentTypeMultipartMixed) {
+			return true
+		}
+	}
+	return false
+}
+
+// MultipartMixedWriter is a resolve.FlushWriter which delivers streaming responses (@defer, @stream)
+// as multipart/mixed http responses using the GraphQL incremental delivery format.
+//
+// The first flushed payload is the initial response which gets extended by "hasNext": true.
+// Every following payload is expected to be a list of JSON patches as produced by
+// resolve.Resolver.ResolveGraphQLStreamingResponse and is transformed into an "incremental" payload.
+// Payloads which are no list of patches (e.g. subscription updates) are written as they are.
+//
+// Nothing is written to the http.ResponseWriter until the first call to Flush,
+// so a response which never gets flushed can still be written as a regular JSON response.
+type MultipartMixedWriter struct {
+	w       http.ResponseWriter
+	buf     *bytes.Buffer
+	part    *bytes.Buffer
+	flushed bool
+	err     error
+}
+
+func NewMultipartMixedWriter(w http.ResponseWriter) *MultipartMixedWriter {
+	return &MultipartMixedWriter{
+		w:    w,
+		buf:  bytes.NewBuffer(make([]byte, 0, 4096)),
+		part: bytes.NewBuffer(make([]byte, 0, 4096)),
+	}
+}
+
+func (m *MultipartMixedWriter) Write(p []byte) (n int, err error) {
+	return m.buf.Write(p)
+}
+
+// Flush writes the buffered payload as a new part to the http response.
+func (m *MultipartMixedWriter) Flush() {
+	if m.err != nil || m.buf.Len() == 0 {
+		return
+	}
+
+	payload := bytes.TrimSpace(m.buf.Bytes())
+	m.part.Reset()
+
+	if len(payload) > 0 && payload[0] == '[' {
+		m.err = writeIncrementalPayload(m.part, payload)
+	} else {
+		m.err = writeHasNextPayload(m.part, payload)
+	}
+	m.buf.Reset()
+	if m.err != nil {
+		return
+	}
+
+	if !m.flushed {
+		m.flushed = true
+		m.w.Header().Set(httpHeaderContentType, multipartContentType)
+		m.w.WriteHeader(http.StatusOK)
+	}
+
+	m.writePart(m.part.Bytes())
+}
+
+// Flushed returns true if at least one part has been written to the http response.
+func (m *MultipartMixedWriter) Flushed() bool {
+	return m.flushed
+}
+
+// Bytes returns the payload which has been written but not yet flushed.
+func (m *MultipartMixedWriter) Bytes() []byte {
+	return m.buf.Bytes()
+}
+
+// Complete flushes remaining data, writes the final part with "hasNext": false and terminates the multipart response.
+func (m *MultipartMixedWriter) Complete() error {
+	m.Flush()
+	if m.err != nil {
+		return m.err
+	}
+	if !m.flushed {
+		return nil
+	}
+
+	m.writePart([]byte(multipartLastPartBody))
+	if m.err != nil {
+		return m.err
+	}
+	_, m.err = m.w.Write([]byte(multipartTerminator))
+	m.flushResponseWriter()
+	return m.err
+}
+
+func (m *MultipartMixedWriter) writePart(body []byte) {
+	if m.err != nil {
+		return
+	}
+	if _, m.err = m.w.Write([]byte(multipartPartHeader)); m.err != nil {
+		return
+	}
+	if _, m.err = m.w.Write(body); m.err != nil {
+		return
+	}
+	m.flushResponseWriter()
+}
+
+func (m *MultipartMixedWriter) flushResponseWriter() {
+	if flusher, ok := m.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func writeHasNextPayload(out *bytes.Buffer, payload []byte) error {
+	end := bytes.LastIndexByte(payload, '}')
+	if end == -1 {
+		return errInvalidPatch
+	}
+	out.Write(payload[:end])
+	out.WriteString(multipartHasNextSuffix)
+	return nil
+}
+
+// writeIncrementalPayload transforms a list of JSON patches into an incremental delivery payload.
+// "add" operations on list items are produced by @stream and become "items" entries,
+// all other operations replace a deferred field and become "data" entries on the parent path.
+// The errors of a patch are kept on its entry.
+func writeIncrementalPayload(out *bytes.Buffer, patches []byte) (err error) {
+	out.WriteString(`{"incremental":[`)
+	first := true
+	_, arrayErr := jsonparser.ArrayEach(patches, func(patch []byte, dataType jsonparser.ValueType, offset int, _ error) {
+		if err != nil {
+			return
+		}
+		if dataType != jsonparser.Object {
+			err = errInvalidPatch
+			return
+		}
+		op, opErr := jsonparser.GetString(patch, "op")
+		path, pathErr := jsonparser.GetString(patch, "path")
+		value, _, _, valueErr := jsonparser.Get(patch, "value")
+		if opErr != nil || pathErr != nil || valueErr != nil {
+			err = errInvalidPatch
+			return
+		}
+		patchErrors, _, _, _ := jsonparser.Get(patch, "errors")
+
+		segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		if len(segments) < 2 || segments[0] != "data" {
+			err = errInvalidPatch
+			return
+		}
+		segments = segments[1:]
+
+		if !first {
+			out.WriteByte(',')
+		}
+		first = false
+
+		last := segments[len(segments)-1]
+		if _, indexErr := strconv.Atoi(last); op == "add" && indexErr == nil {
+			out.WriteString(`{"items":[`)
+			out.Write(value)
+			out.WriteString(`],"path":`)
+			writeResponsePath(out, segments)
+			writePatchErrors(out, patchErrors)
+			out.WriteByte('}')
+			return
+		}
+
+		out.WriteString(`{"data":{`)
+		out.WriteString(strconv.Quote(last))
+		out.WriteByte(':')
+		out.Write(value)
+		out.WriteString(`},"path":`)
+		writeResponsePath(out, segments[:len(segments)-1])
+		writePatchErrors(out, patchErrors)
+		out.WriteByte('}')
+	})
+	if arrayErr != nil {
+		return arrayErr
+	}
+	if err != nil {
+		return err
+	}
+	out.WriteString(`],"hasNext":true}`)
+	return nil
+}
+
+func writePatchErrors(out *bytes.Buffer, patchErrors []byte) {
+	if len(patchErrors) == 0 {
+		return
+	}
+	out.WriteString(`,"errors":`)
+	out.Write(patchErrors)
+}
+
+// writeResponsePath writes the segments of a JSON pointer as GraphQL response path with integer list indices.
+func writeResponsePath(out *bytes.Buffer, segments []string) {
+	out.WriteByte('[')
+	for i, segment := range segments {
+		if i != 0 {
+			out.WriteByte(',')
+		}
+		if _, err := strconv.Atoi(segment); err == nil {
+			out.WriteString(segment)
+			continue
+		}
+		out.WriteString(strconv.Quote(segment))
+	}
+	out.WriteByte(']')
+}
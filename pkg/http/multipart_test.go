@@ -0,0 +1,83 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipartMixedWriter(t *testing.T) {
+	t.Run("should write nothing when never flushed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writer := NewMultipartMixedWriter(rec)
+		_, err := writer.Write([]byte(`{"data":{"hello":"world"}}`))
+		require.NoError(t, err)
+
+		assert.False(t, writer.Flushed())
+		assert.Equal(t, `{"data":{"hello":"world"}}`, string(writer.Bytes()))
+		assert.Equal(t, 0, rec.Body.Len())
+	})
+
+	t.Run("should write initial response and patches as incremental payloads", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writer := NewMultipartMixedWriter(rec)
+
+		_, _ = writer.Write([]byte(`{"data":{"users":[{"id":1,"posts":null}]}}`))
+		writer.Flush()
+		_, _ = writer.Write([]byte(`[{"op":"add","path":"/data/users/1","value":{"id":2}},{"op":"replace","path":"/data/users/0/posts","value":[{"title":"a"}]}]`))
+		writer.Flush()
+		require.NoError(t, writer.Complete())
+
+		assert.True(t, writer.Flushed())
+		assert.True(t, rec.Flushed)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, multipartContentType, rec.Header().Get(httpHeaderContentType))
+
+		expected := multipartPartHeader + `{"data":{"users":[{"id":1,"posts":null}]},"hasNext":true}` +
+			multipartPartHeader + `{"incremental":[{"items":[{"id":2}],"path":["users",1]},{"data":{"posts":[{"title":"a"}]},"path":["users",0]}],"hasNext":true}` +
+			multipartPartHeader + `{"hasNext":false}` +
+			multipartTerminator
+		assert.Equal(t, expected, rec.Body.String())
+	})
+
+	t.Run("should keep the errors of patches", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writer := NewMultipartMixedWriter(rec)
+
+		_, _ = writer.Write([]byte(`{"data":{"users":[]}}`))
+		writer.Flush()
+		_, _ = writer.Write([]byte(`[{"op":"add","path":"/data/users/0","value":null,"errors":[{"message":"Cannot return null for non-nullable field name.","path":["users",0,"name"]}]}]`))
+		writer.Flush()
+		require.NoError(t, writer.Complete())
+
+		expected := multipartPartHeader + `{"data":{"users":[]},"hasNext":true}` +
+			multipartPartHeader + `{"incremental":[{"items":[null],"path":["users",0],"errors":[{"message":"Cannot return null for non-nullable field name.","path":["users",0,"name"]}]}],"hasNext":true}` +
+			multipartPartHeader + `{"hasNext":false}` +
+			multipartTerminator
+		assert.Equal(t, expected, rec.Body.String())
+	})
+
+	t.Run("should return error for invalid patches", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writer := NewMultipartMixedWriter(rec)
+
+		_, _ = writer.Write([]byte(`{"data":{}}`))
+		writer.Flush()
+		_, _ = writer.Write([]byte(`[{"op":"add","path":"/errors/0","value":{}}]`))
+		writer.Flush()
+
+		assert.Equal(t, errInvalidPatch, writer.Complete())
+	})
+}
+
+func TestAcceptsMultipartMixed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", &bytes.Buffer{})
+	assert.False(t, acceptsMultipartMixed(req))
+
+	req.Header.Set(httpHeaderAccept, "multipart/mixed;deferSpec=20220824, application/json")
+	assert.True(t, acceptsMultipartMixed(req))
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+const (
+	httpContentTypeEventStream string = "text/event-stream"
+
+	httpHeaderCacheControl string = "Cache-Control"
+	httpHeaderConnection   string = "Connection"
+
+	sseEventNext     = "next"
+	sseEventComplete = "complete"
+)
+
+// acceptsEventStream returns true if the client signals that it understands Server-Sent Events.
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values(httpHeaderAccept) {
+		if strings.Contains(accept, httpContentTypeEventStream) {
+			return true
+		}
+	}
+	return false
+}
+
+// EventStreamWriter is a resolve.FlushWriter which delivers subscriptions and streaming responses (@defer, @stream)
+// as Server-Sent Events following the GraphQL over SSE protocol ("distinct connections mode").
+//
+// Every call to Flush sends the buffered payload as one "next" event, Complete sends the final "complete" event.
+// Lists of JSON patches produced by resolve.Resolver.ResolveGraphQLStreamingResponse are transformed into
+// "incremental" payloads, all other payloads are sent as they are.
+//
+// Nothing is written to the http.ResponseWriter until the first call to Flush,
+// so a response which never gets flushed can still be written as a regular JSON response.
+type EventStreamWriter struct {
+	w       http.ResponseWriter
+	buf     *bytes.Buffer
+	event   *bytes.Buffer
+	flushed bool
+	err     error
+}
+
+func NewEventStreamWriter(w http.ResponseWriter) *EventStreamWriter {
+	return &EventStreamWriter{
+		w:     w,
+		buf:   bytes.NewBuffer(make([]byte, 0, 4096)),
+		event: bytes.NewBuffer(make([]byte, 0, 4096)),
+	}
+}
+
+func (e *EventStreamWriter) Write(p []byte) (n int, err error) {
+	return e.buf.Write(p)
+}
+
+// Flush sends the buffered payload as a "next" event.
+func (e *EventStreamWriter) Flush() {
+	if e.err != nil || e.buf.Len() == 0 {
+		return
+	}
+
+	payload := bytes.TrimSpace(e.buf.Bytes())
+	e.event.Reset()
+
+	if len(payload) > 0 && payload[0] == '[' {
+		e.err = writeIncrementalPayload(e.event, payload)
+	} else {
+		e.event.Write(payload)
+	}
+	e.buf.Reset()
+	if e.err != nil {
+		return
+	}
+
+	e.writeHeader()
+	e.writeEvent(sseEventNext, e.event.Bytes())
+}
+
+// Flushed returns true if at least one event has been written to the http response.
+func (e *EventStreamWriter) Flushed() bool {
+	return e.flushed
+}
+
+// Bytes returns the payload which has been written but not yet flushed.
+func (e *EventStreamWriter) Bytes() []byte {
+	return e.buf.Bytes()
+}
+
+// Complete sends remaining data as "next" event followed by the "complete" event which ends the stream.
+func (e *EventStreamWriter) Complete() error {
+	e.Flush()
+	if e.err != nil {
+		return e.err
+	}
+
+	e.writeHeader()
+	e.writeEvent(sseEventComplete, nil)
+	return e.err
+}
+
+func (e *EventStreamWriter) writeHeader() {
+	if e.flushed {
+		return
+	}
+	e.flushed = true
+	e.w.Header().Set(httpHeaderContentType, httpContentTypeEventStream)
+	e.w.Header().Set(httpHeaderCacheControl, "no-cache")
+	e.w.Header().Set(httpHeaderConnection, "keep-alive")
+	e.w.WriteHeader(http.StatusOK)
+}
+
+// writeEvent writes a single event, multi line data is split into multiple data fields.
+func (e *EventStreamWriter) writeEvent(event string, data []byte) {
+	if e.err != nil {
+		return
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)+32))
+	out.WriteString("event: ")
+	out.WriteString(event)
+	out.WriteString("\ndata: ")
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if i != 0 {
+			out.WriteString("\ndata: ")
+		}
+		out.Write(bytes.TrimSuffix(line, []byte("\r")))
+	}
+	out.WriteString("\n\n")
+
+	if _, e.err = e.w.Write(out.Bytes()); e.err != nil {
+		return
+	}
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventStreamWriter(t *testing.T) {
+	t.Run("should write nothing when never flushed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writer := NewEventStreamWriter(rec)
+		_, err := writer.Write([]byte(`{"data":{"hello":"world"}}`))
+		require.NoError(t, err)
+
+		assert.False(t, writer.Flushed())
+		assert.Equal(t, `{"data":{"hello":"world"}}`, string(writer.Bytes()))
+		assert.Equal(t, 0, rec.Body.Len())
+	})
+
+	t.Run("should write every flush as next event and complete the stream", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writer := NewEventStreamWriter(rec)
+
+		_, _ = writer.Write([]byte(`{"data":{"counter":1}}`))
+		writer.Flush()
+		writer.Flush()
+		_, _ = writer.Write([]byte("{\"data\":\n{\"counter\":2}}"))
+		writer.Flush()
+		require.NoError(t, writer.Complete())
+
+		assert.True(t, writer.Flushed())
+		assert.True(t, rec.Flushed)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, httpContentTypeEventStream, rec.Header().Get(httpHeaderContentType))
+		assert.Equal(t, "event: next\ndata: {\"data\":{\"counter\":1}}\n\n"+
+			"event: next\ndata: {\"data\":\ndata: {\"counter\":2}}\n\n"+
+			"event: complete\ndata: \n\n", rec.Body.String())
+	})
+
+	t.Run("should write patches as incremental payload", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writer := NewEventStreamWriter(rec)
+
+		_, _ = writer.Write([]byte(`[{"op":"add","path":"/data/users/1","value":{"id":2}}]`))
+		writer.Flush()
+
+		assert.Equal(t, "event: next\ndata: {\"incremental\":[{\"items\":[{\"id\":2}],\"path\":[\"users\",1]}],\"hasNext\":true}\n\n", rec.Body.String())
+	})
+
+	t.Run("should return error for invalid patches", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writer := NewEventStreamWriter(rec)
+
+		_, _ = writer.Write([]byte(`[{"op":"add"}]`))
+		assert.Equal(t, errInvalidPatch, writer.Complete())
+		assert.False(t, writer.Flushed())
+	})
+}
+
+func TestAcceptsEventStream(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.False(t, acceptsEventStream(req))
+
+	req.Header.Set(httpHeaderAccept, "application/json, text/event-stream")
+	assert.True(t, acceptsEventStream(req))
+}
@@ -108,6 +108,24 @@ func (w *WebsocketSubscriptionClient) Disconnect() error {
 	return w.clientConn.Close()
 }
 
+// DisconnectWithReason will send a close frame with the given status code and reason and close the websocket connection.
+func (w *WebsocketSubscriptionClient) DisconnectWithReason(code int, reason string) error {
+	if w.isClosedConnection {
+		return nil
+	}
+
+	err := wsutil.WriteServerMessage(w.clientConn, ws.OpClose, ws.NewCloseFrameBody(ws.StatusCode(code), reason))
+	if err != nil {
+		w.logger.Error("http.WebsocketSubscriptionClient.DisconnectWithReason()",
+			abstractlogger.Error(err),
+			abstractlogger.Int("code", code),
+			abstractlogger.String("reason", reason),
+		)
+	}
+
+	return w.Disconnect()
+}
+
 // isClosedConnectionError will indicate if the given error is a conenction closed error.
 func (w *WebsocketSubscriptionClient) isClosedConnectionError(err error) bool {
 	if _, ok := err.(wsutil.ClosedError); ok {
@@ -118,6 +136,11 @@ func (w *WebsocketSubscriptionClient) isClosedConnectionError(err error) bool {
 }
 
 func HandleWebsocket(done chan bool, errChan chan error, conn net.Conn, executorPool subscription.ExecutorPool, logger abstractlogger.Logger) {
+	HandleWebsocketWithProtocol(done, errChan, conn, executorPool, logger, subscription.ProtocolGraphQLWS)
+}
+
+// HandleWebsocketWithProtocol will handle the websocket connection with the negotiated subscription protocol.
+func HandleWebsocketWithProtocol(done chan bool, errChan chan error, conn net.Conn, executorPool subscription.ExecutorPool, logger abstractlogger.Logger, protocol subscription.Protocol) {
 	defer func() {
 		if err := conn.Close(); err != nil {
 			logger.Error("http.HandleWebsocket()",
@@ -128,7 +151,7 @@ func HandleWebsocket(done chan bool, errChan chan error, conn net.Conn, executor
 	}()
 
 	websocketClient := NewWebsocketSubscriptionClient(logger, conn)
-	subscriptionHandler, err := subscription.NewHandler(logger, websocketClient, executorPool)
+	subscriptionHandler, err := subscription.NewHandlerWithProtocol(logger, websocketClient, executorPool, protocol)
 	if err != nil {
 		logger.Error("http.HandleWebsocket()",
 			abstractlogger.String("message", "could not create subscriptionHandler"),
@@ -144,12 +167,12 @@ func HandleWebsocket(done chan bool, errChan chan error, conn net.Conn, executor
 }
 
 // handleWebsocket will handle the websocket connection.
-func (g *GraphQLHTTPRequestHandler) handleWebsocket(conn net.Conn) {
+func (g *GraphQLHTTPRequestHandler) handleWebsocket(conn net.Conn, protocol subscription.Protocol) {
 	done := make(chan bool)
 	errChan := make(chan error)
 
 	executorPool := subscription.NewExecutorV1Pool(g.executionHandler)
-	go HandleWebsocket(done, errChan, conn, executorPool, g.log)
+	go HandleWebsocketWithProtocol(done, errChan, conn, executorPool, g.log, protocol)
 	select {
 	case err := <-errChan:
 		g.log.Error("http.GraphQLHTTPRequestHandler.handleWebsocket()",
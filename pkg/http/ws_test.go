@@ -115,6 +115,33 @@ func TestWebsocketSubscriptionClient_Disconnect(t *testing.T) {
 	})
 }
 
+func TestWebsocketSubscriptionClient_DisconnectWithReason(t *testing.T) {
+	connToServer, connToClient := net.Pipe()
+	websocketClient := NewWebsocketSubscriptionClient(abstractlogger.NoopLogger, connToClient)
+
+	disconnected := make(chan struct{})
+	go func() {
+		err := websocketClient.DisconnectWithReason(subscription.CloseCodeUnauthorized, "Unauthorized")
+		assert.NoError(t, err)
+		close(disconnected)
+	}()
+
+	frame, err := ws.ReadFrame(connToServer)
+	require.NoError(t, err)
+	assert.Equal(t, ws.OpClose, frame.Header.OpCode)
+
+	code, reason := ws.ParseCloseFrameData(frame.Payload)
+	assert.Equal(t, ws.StatusCode(subscription.CloseCodeUnauthorized), code)
+	assert.Equal(t, "Unauthorized", reason)
+
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Fatal("client has not been disconnected")
+	}
+	assert.False(t, websocketClient.IsConnected())
+}
+
 func TestWebsocketSubscriptionClient_isClosedConnectionError(t *testing.T) {
 	_, connToClient := net.Pipe()
 	websocketClient := NewWebsocketSubscriptionClient(abstractlogger.NoopLogger, connToClient)
@@ -66,6 +66,7 @@ var (
 	SUBSCRIPTION                  = []byte("subscription")
 	IMPLEMENTS                    = []byte("implements")
 	ON                            = []byte("on")
+	REPEATABLE                    = []byte("repeatable")
 	FRAGMENT                      = []byte("fragment")
 	NULL                          = []byte("null")
 	OBJECT                        = []byte("object")
@@ -0,0 +1,358 @@
+package operation_complexity
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/astvisitor"
+	"github.com/pvormste/graphql-go-tools/pkg/operationreport"
+)
+
+// DefaultListSize is the size assumed for lists which neither have slicing arguments nor an assumed size.
+const DefaultListSize = 1
+
+const (
+	maxCost = int(^uint(0) >> 1)
+	minCost = -maxCost - 1
+)
+
+var (
+	costDirective                     = []byte("cost")
+	listSizeDirective                 = []byte("listSize")
+	weightArgument                    = []byte("weight")
+	assumedSizeArgument               = []byte("assumedSize")
+	slicingArgumentsArgument          = []byte("slicingArguments")
+	sizedFieldsArgument               = []byte("sizedFields")
+	requireOneSlicingArgumentArgument = []byte("requireOneSlicingArgument")
+)
+
+// OperationCostEstimator calculates the cost of an operation using the @cost and @listSize directives of the schema:
+//
+//	directive @cost(weight: String!) on ARGUMENT_DEFINITION | ENUM | FIELD_DEFINITION | INPUT_FIELD_DEFINITION | OBJECT | SCALAR
+//	directive @listSize(assumedSize: Int, slicingArguments: [String!], sizedFields: [String!], requireOneSlicingArgument: Boolean = true) on FIELD_DEFINITION
+//
+// The cost of a field is the weight of its definition plus the weights of the provided arguments
+// plus the weight of its type and the cost of its selections, multiplied by the size of the list the field returns.
+// Types default to a weight of 1 for objects, interfaces and unions, and 0 for scalars and enums.
+//
+// The size of a list is the largest value of the provided slicing arguments, the assumed size or the default list size.
+// If sized fields are defined, the size applies to these fields of the returned type instead of the field itself, e.g. for connections.
+// The operation must be normalized, values of slicing arguments are read from the variables of the operation.
+type OperationCostEstimator struct {
+	walker  *astvisitor.Walker
+	visitor *costVisitor
+}
+
+// NewOperationCostEstimator returns an OperationCostEstimator, defaultListSize is used for lists without @listSize.
+// If defaultListSize isn't positive, DefaultListSize is used.
+func NewOperationCostEstimator(defaultListSize int) *OperationCostEstimator {
+	if defaultListSize <= 0 {
+		defaultListSize = DefaultListSize
+	}
+
+	walker := astvisitor.NewWalker(48)
+	visitor := &costVisitor{
+		Walker:          &walker,
+		defaultListSize: defaultListSize,
+		fields:          make([]fieldCost, 0, 16),
+	}
+
+	walker.RegisterEnterDocumentVisitor(visitor)
+	walker.RegisterEnterFieldVisitor(visitor)
+	walker.RegisterLeaveFieldVisitor(visitor)
+	walker.RegisterEnterFragmentDefinitionVisitor(visitor)
+
+	return &OperationCostEstimator{
+		walker:  &walker,
+		visitor: visitor,
+	}
+}
+
+// Do returns the cost of the operation.
+func (e *OperationCostEstimator) Do(operation, definition *ast.Document, report *operationreport.Report) int {
+	e.visitor.cost = 0
+	e.visitor.fields = e.visitor.fields[:0]
+
+	e.walker.Walk(operation, definition, report)
+
+	return e.visitor.cost
+}
+
+func CalculateOperationCost(operation, definition *ast.Document, defaultListSize int, report *operationreport.Report) int {
+	estimator := NewOperationCostEstimator(defaultListSize)
+	return estimator.Do(operation, definition, report)
+}
+
+type costVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	defaultListSize       int
+	cost                  int
+	fields                []fieldCost
+}
+
+// fieldCost holds the cost of a field while its selections get walked
+type fieldCost struct {
+	// weight is the weight of the field definition and its provided arguments
+	weight int
+	// typeWeight is the weight of the type of the field
+	typeWeight int
+	// multiplier is the size of the list returned by the field, 1 if it isn't a list
+	multiplier int
+	// selectionsCost is the cost of the selections of a single item
+	selectionsCost int
+	// sizedFields are the fields of the returned type the slicing size applies to
+	sizedFields []string
+	// slicingSize is the list size of the sized fields
+	slicingSize int
+}
+
+func (c *costVisitor) EnterDocument(operation, definition *ast.Document) {
+	c.operation = operation
+	c.definition = definition
+}
+
+func (c *costVisitor) EnterFragmentDefinition(ref int) {
+	c.SkipNode()
+}
+
+func (c *costVisitor) EnterField(ref int) {
+	current := fieldCost{
+		multiplier: 1,
+	}
+
+	definition, exists := c.FieldDefinition(ref)
+	if !exists {
+		c.fields = append(c.fields, current)
+		return
+	}
+
+	current.weight = c.directiveWeight(c.definition.FieldDefinitions[definition].Directives.Refs)
+	for _, argumentDefinition := range c.definition.FieldDefinitionArgumentsDefinitions(definition) {
+		if _, provided := c.operation.FieldArgument(ref, c.definition.InputValueDefinitionNameBytes(argumentDefinition)); provided {
+			current.weight += c.directiveWeight(c.definition.InputValueDefinitions[argumentDefinition].Directives.Refs)
+		}
+	}
+
+	current.typeWeight = c.typeWeight(c.definition.FieldDefinitionTypeNode(definition))
+
+	isList := c.definition.TypeIsList(c.definition.FieldDefinitionType(definition))
+	if isList {
+		current.multiplier = c.defaultListSize
+		if size, ok := c.sizeFromParent(definition); ok {
+			current.multiplier = size
+		}
+	}
+
+	if listSize, exists := c.definition.FieldDefinitionDirectiveByName(definition, listSizeDirective); exists {
+		size, ok := c.listSize(ref, definition, listSize)
+		if !ok {
+			size = c.defaultListSize
+		}
+		if sizedFields := c.directiveStringListArgument(listSize, sizedFieldsArgument); len(sizedFields) != 0 {
+			current.sizedFields = sizedFields
+			current.slicingSize = size
+		} else if isList && ok {
+			current.multiplier = size
+		}
+	}
+
+	c.fields = append(c.fields, current)
+}
+
+func (c *costVisitor) LeaveField(ref int) {
+	current := c.fields[len(c.fields)-1]
+	c.fields = c.fields[:len(c.fields)-1]
+
+	cost := addCost(current.weight, mulCost(current.multiplier, addCost(current.typeWeight, current.selectionsCost)))
+	if len(c.fields) == 0 {
+		c.cost = addCost(c.cost, cost)
+		return
+	}
+	parent := &c.fields[len(c.fields)-1]
+	parent.selectionsCost = addCost(parent.selectionsCost, cost)
+}
+
+// addCost adds two costs, the result is capped instead of overflowing.
+func addCost(a, b int) int {
+	if b > 0 && a > maxCost-b {
+		return maxCost
+	}
+	if b < 0 && a < minCost-b {
+		return minCost
+	}
+	return a + b
+}
+
+// mulCost multiplies a cost by a list size, which must not be negative. The result is capped instead of overflowing.
+func mulCost(size, cost int) int {
+	switch {
+	case size == 0 || cost == 0:
+		return 0
+	case cost == minCost:
+		return minCost
+	case cost < 0:
+		return -mulCost(size, -cost)
+	case size > maxCost/cost:
+		return maxCost
+	default:
+		return size * cost
+	}
+}
+
+// sizeFromParent returns the slicing size of the parent field if the field is one of its sized fields.
+func (c *costVisitor) sizeFromParent(definition int) (int, bool) {
+	if len(c.fields) == 0 {
+		return 0, false
+	}
+	parent := c.fields[len(c.fields)-1]
+	fieldName := c.definition.FieldDefinitionNameString(definition)
+	for i := range parent.sizedFields {
+		if parent.sizedFields[i] == fieldName {
+			return parent.slicingSize, true
+		}
+	}
+	return 0, false
+}
+
+// listSize returns the largest value of the provided slicing arguments or the assumed size of the @listSize directive.
+// Negative sizes are treated as empty lists, so they can't lower the cost of the other fields.
+func (c *costVisitor) listSize(field, definition, listSize int) (size int, ok bool) {
+	slicingArguments := c.directiveStringListArgument(listSize, slicingArgumentsArgument)
+
+	provided := 0
+	for i := range slicingArguments {
+		argument, exists := c.operation.FieldArgument(field, []byte(slicingArguments[i]))
+		if !exists {
+			continue
+		}
+		provided++
+		if value, isInt := c.intValue(c.operation.ArgumentValue(argument)); isInt && (!ok || value > size) {
+			size, ok = nonNegative(value), true
+		}
+	}
+
+	requireOneSlicingArgument := true
+	if value, exists := c.definition.DirectiveArgumentValueByName(listSize, requireOneSlicingArgumentArgument); exists && value.Kind == ast.ValueKindBoolean {
+		requireOneSlicingArgument = bool(c.definition.BooleanValue(value.Ref))
+	}
+	if requireOneSlicingArgument && len(slicingArguments) != 0 && provided != 1 {
+		c.StopWithExternalErr(operationreport.ErrFieldRequiresOneSlicingArgument(c.EnclosingTypeDefinition.NameString(c.definition), c.definition.FieldDefinitionNameString(definition), slicingArguments))
+		return 0, false
+	}
+
+	if ok {
+		return size, true
+	}
+
+	if value, exists := c.definition.DirectiveArgumentValueByName(listSize, assumedSizeArgument); exists && value.Kind == ast.ValueKindInteger {
+		return nonNegative(int(c.definition.IntValueAsInt(value.Ref))), true
+	}
+
+	return 0, false
+}
+
+func nonNegative(size int) int {
+	if size < 0 {
+		return 0
+	}
+	return size
+}
+
+// intValue returns the value of an integer argument of the operation, variables are read from the operation input.
+func (c *costVisitor) intValue(value ast.Value) (int, bool) {
+	switch value.Kind {
+	case ast.ValueKindInteger:
+		return int(c.operation.IntValueAsInt(value.Ref)), true
+	case ast.ValueKindVariable:
+		variable, err := jsonparser.GetInt(c.operation.Input.Variables, c.operation.VariableValueNameString(value.Ref))
+		if err != nil {
+			return 0, false
+		}
+		return int(variable), true
+	default:
+		return 0, false
+	}
+}
+
+// typeWeight returns the weight of the @cost directive of the type,
+// objects, interfaces and unions default to 1, all other types to 0.
+func (c *costVisitor) typeWeight(node ast.Node) int {
+	if directive, exists := c.costDirective(c.definition.NodeDirectives(node)); exists {
+		return c.weight(directive)
+	}
+
+	switch node.Kind {
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition, ast.NodeKindUnionTypeDefinition:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// directiveWeight returns the weight of the @cost directive, if it is one of the directives.
+func (c *costVisitor) directiveWeight(directives []int) int {
+	if directive, exists := c.costDirective(directives); exists {
+		return c.weight(directive)
+	}
+	return 0
+}
+
+func (c *costVisitor) costDirective(directives []int) (int, bool) {
+	for _, directive := range directives {
+		if bytes.Equal(c.definition.DirectiveNameBytes(directive), costDirective) {
+			return directive, true
+		}
+	}
+	return -1, false
+}
+
+// weight returns the weight argument of a @cost directive, fractional weights are rounded up.
+func (c *costVisitor) weight(directive int) int {
+	value, exists := c.definition.DirectiveArgumentValueByName(directive, weightArgument)
+	if !exists {
+		return 0
+	}
+
+	switch value.Kind {
+	case ast.ValueKindInteger:
+		return int(c.definition.IntValueAsInt(value.Ref))
+	case ast.ValueKindFloat:
+		return int(math.Ceil(float64(c.definition.FloatValueAsFloat32(value.Ref))))
+	case ast.ValueKindString:
+		weight, err := strconv.ParseFloat(c.definition.StringValueContentString(value.Ref), 64)
+		if err != nil {
+			return 0
+		}
+		return int(math.Ceil(weight))
+	default:
+		return 0
+	}
+}
+
+func (c *costVisitor) directiveStringListArgument(directive int, name []byte) []string {
+	value, exists := c.definition.DirectiveArgumentValueByName(directive, name)
+	if !exists {
+		return nil
+	}
+
+	switch value.Kind {
+	case ast.ValueKindString:
+		return []string{c.definition.StringValueContentString(value.Ref)}
+	case ast.ValueKindList:
+		values := make([]string, 0, len(c.definition.ListValues[value.Ref].Refs))
+		for _, ref := range c.definition.ListValues[value.Ref].Refs {
+			item := c.definition.Value(ref)
+			if item.Kind == ast.ValueKindString {
+				values = append(values, c.definition.StringValueContentString(item.Ref))
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,143 @@
+package operation_complexity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/pvormste/graphql-go-tools/pkg/astnormalization"
+	"github.com/pvormste/graphql-go-tools/pkg/operationreport"
+)
+
+func TestCalculateOperationCost(t *testing.T) {
+	run := func(t *testing.T, operation, variables string, defaultListSize int, expectedCost int) {
+		t.Helper()
+		def := unsafeparser.ParseGraphqlDocumentString(costDefinition)
+		op := unsafeparser.ParseGraphqlDocumentString(operation)
+		op.Input.Variables = []byte(variables)
+		report := operationreport.Report{}
+
+		astnormalization.NormalizeOperation(&op, &def, &report)
+		require.False(t, report.HasErrors(), report.Error())
+
+		cost := CalculateOperationCost(&op, &def, defaultListSize, &report)
+		require.False(t, report.HasErrors(), report.Error())
+		assert.Equal(t, expectedCost, cost)
+	}
+
+	runWithError := func(t *testing.T, operation string, expectedError string) {
+		t.Helper()
+		def := unsafeparser.ParseGraphqlDocumentString(costDefinition)
+		op := unsafeparser.ParseGraphqlDocumentString(operation)
+		report := operationreport.Report{}
+
+		CalculateOperationCost(&op, &def, 0, &report)
+		require.Len(t, report.ExternalErrors, 1)
+		assert.Equal(t, expectedError, report.ExternalErrors[0].Message)
+	}
+
+	t.Run("field and type weights", func(t *testing.T) {
+		run(t, `{ user(id: "1") { id name } }`, "", 0, 6)
+	})
+	t.Run("weight of a scalar type", func(t *testing.T) {
+		run(t, `{ user(id: "1") { balance } }`, "", 0, 8)
+	})
+	t.Run("list of scalars", func(t *testing.T) {
+		run(t, `{ tags }`, "", 10, 0)
+	})
+	t.Run("slicing argument multiplies the selections", func(t *testing.T) {
+		run(t, `{ users(first: 10) { id friends { id } } }`, "", 0, 20)
+	})
+	t.Run("default list size for lists without @listSize", func(t *testing.T) {
+		run(t, `{ users(first: 10) { id friends { id } } }`, "", 5, 60)
+	})
+	t.Run("slicing argument from variables", func(t *testing.T) {
+		run(t, `query Users($first: Int) { users(first: $first) { id } }`, `{"first":7}`, 0, 7)
+	})
+	t.Run("assumed size and argument weight", func(t *testing.T) {
+		run(t, `{ search(term: "jens") { id } }`, "", 0, 23)
+	})
+	t.Run("sized fields of a connection", func(t *testing.T) {
+		run(t, `{ friends(first: 4) { totalCount edges { cursor node { id } } nodes { id } } }`, "", 0, 13)
+	})
+	t.Run("largest of multiple slicing arguments", func(t *testing.T) {
+		run(t, `{ optionalUsers(first: 3, last: 5) { id } }`, "", 0, 5)
+	})
+	t.Run("default list size without slicing argument", func(t *testing.T) {
+		run(t, `{ optionalUsers { id } }`, "", 2, 2)
+	})
+	t.Run("negative slicing argument doesn't lower the cost of other fields", func(t *testing.T) {
+		run(t, `{ users(first: -1000) { id } search(term: "jens") { id } }`, "", 0, 23)
+	})
+	t.Run("negative slicing argument from variables", func(t *testing.T) {
+		run(t, `query Users($first: Int) { users(first: $first) { id } }`, `{"first":-7}`, 0, 0)
+	})
+	t.Run("negative assumed size", func(t *testing.T) {
+		run(t, `{ negativeSearch { id } }`, "", 0, 0)
+	})
+	t.Run("huge slicing arguments are capped instead of overflowing", func(t *testing.T) {
+		run(t, `query Users($first: Int) { a: users(first: $first) { friends { id } } b: optionalUsers(first: $first) { friends { id } } }`, `{"first":9223372036854775807}`, 5, maxCost)
+	})
+	t.Run("missing slicing argument", func(t *testing.T) {
+		runWithError(t, `{ users { id } }`, "field 'Query.users' requires exactly one of the slicing arguments: first, last")
+	})
+	t.Run("more than one slicing argument", func(t *testing.T) {
+		runWithError(t, `{ users(first: 1, last: 2) { id } }`, "field 'Query.users' requires exactly one of the slicing arguments: first, last")
+	})
+}
+
+const costDefinition = `
+directive @cost(weight: String!) on ARGUMENT_DEFINITION | ENUM | FIELD_DEFINITION | INPUT_FIELD_DEFINITION | OBJECT | SCALAR
+directive @listSize(assumedSize: Int, slicingArguments: [String!], sizedFields: [String!], requireOneSlicingArgument: Boolean = true) on FIELD_DEFINITION
+
+scalar ID
+scalar Int
+scalar String
+scalar Boolean
+scalar Money @cost(weight: "2")
+
+schema {
+	query: Query
+}
+
+type Query {
+	user(id: ID!): User @cost(weight: "5")
+	users(first: Int, last: Int): [User] @listSize(slicingArguments: ["first", "last"])
+	optionalUsers(first: Int, last: Int): [User] @listSize(slicingArguments: ["first", "last"], requireOneSlicingArgument: false)
+	search(term: String! @cost(weight: "3")): [User] @listSize(assumedSize: 20)
+	negativeSearch: [User] @listSize(assumedSize: -10)
+	friends(first: Int): FriendsConnection @listSize(slicingArguments: ["first"], sizedFields: ["edges", "nodes"])
+	tags: [String]
+}
+
+type User {
+	id: ID!
+	name: String
+	balance: Money
+	friends: [User]
+}
+
+type FriendsConnection {
+	edges: [FriendEdge]
+	nodes: [User]
+	totalCount: Int
+}
+
+type FriendEdge {
+	cursor: String
+	node: User
+}
+`
+
+func TestCostArithmetic(t *testing.T) {
+	assert.Equal(t, maxCost, addCost(maxCost, 1))
+	assert.Equal(t, minCost, addCost(minCost, -1))
+	assert.Equal(t, 3, addCost(5, -2))
+	assert.Equal(t, maxCost, mulCost(maxCost, 2))
+	assert.Equal(t, -maxCost, mulCost(maxCost, -2))
+	assert.Equal(t, minCost, mulCost(2, minCost))
+	assert.Equal(t, 0, mulCost(0, maxCost))
+	assert.Equal(t, 12, mulCost(3, 4))
+}
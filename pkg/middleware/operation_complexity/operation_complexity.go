@@ -18,6 +18,9 @@
 
 	nodeCountSkip:
 	Indicates that the algorithm should skip this Node. This is useful to whitelist certain query paths, e.g. for introspection.
+
+	Additionally, OperationCostEstimator calculates the cost of a query following the IBM GraphQL cost specification.
+	The cost is based on the weights of fields, arguments and types defined with the @cost directive and on list sizes defined with the @listSize directive.
 */
 package operation_complexity
 
@@ -2,6 +2,7 @@ package operationreport
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pvormste/graphql-go-tools/pkg/ast"
 	"github.com/pvormste/graphql-go-tools/pkg/graphqlerrors"
@@ -303,3 +304,18 @@ func ErrExtensionWithKeyDirectiveMustExtendEntity(typeName string) (err External
 	err.Message = fmt.Sprintf("the extension named '%s' has a key directive but there is no entity of the same name", typeName)
 	return err
 }
+
+func ErrSharedFieldMustBeShareable(typeName, fieldName string) (err ExternalError) {
+	err.Message = fmt.Sprintf("the field '%s.%s' is defined in several subgraphs and must be marked @shareable", typeName, fieldName)
+	return err
+}
+
+func ErrInaccessibleTypeMustNotBeReferenced(typeName, fieldName, inaccessibleTypeName string) (err ExternalError) {
+	err.Message = fmt.Sprintf("the field '%s.%s' references the @inaccessible type '%s' and must be marked @inaccessible", typeName, fieldName, inaccessibleTypeName)
+	return err
+}
+
+func ErrFieldRequiresOneSlicingArgument(typeName, fieldName string, slicingArguments []string) (err ExternalError) {
+	err.Message = fmt.Sprintf("field '%s.%s' requires exactly one of the slicing arguments: %s", typeName, fieldName, strings.Join(slicingArguments, ", "))
+	return err
+}
@@ -36,6 +36,10 @@ func (e *ExecutorV2Pool) Get(payload []byte) (Executor, error) {
 		return nil, err
 	}
 
+	if err = e.engine.LoadPersistedQuery(e.connectionInitReqCtx, &operation); err != nil {
+		return nil, err
+	}
+
 	return &ExecutorV2{
 		engine:    e.engine,
 		operation: &operation,
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jensneuse/abstractlogger"
@@ -68,20 +70,33 @@ type Handler struct {
 	logger abstractlogger.Logger
 	// client will hold the subscription client implementation.
 	client Client
+	// protocol is the websocket sub-protocol which is spoken with the client.
+	protocol Protocol
+	// initialized is set to 1 as soon as the client has sent a connection init message.
+	initialized int32
+	// connectionInitTimeout is the duration in which a graphql-transport-ws client has to send the connection init message.
+	connectionInitTimeout time.Duration
 	// keepAliveInterval is the actual interval on which the server send keep alive messages to the client.
 	keepAliveInterval time.Duration
 	// subscriptionUpdateInterval is the actual interval on which the server sends subscription updates to the client.
 	subscriptionUpdateInterval time.Duration
 	// subCancellations is map containing the cancellation functions to every active subscription.
 	subCancellations subscriptionCancellations
+	// subCancellationsMu guards subCancellations, graphql-transport-ws subscriptions remove themselves when they end.
+	subCancellationsMu sync.Mutex
 	// executorPool is responsible to create and hold executors.
 	executorPool ExecutorPool
 	// bufferPool will hold buffers.
 	bufferPool *sync.Pool
 }
 
-// NewHandler creates a new subscription handler.
+// NewHandler creates a new subscription handler speaking the legacy graphql-ws protocol.
 func NewHandler(logger abstractlogger.Logger, client Client, executorPool ExecutorPool) (*Handler, error) {
+	return NewHandlerWithProtocol(logger, client, executorPool, ProtocolGraphQLWS)
+}
+
+// NewHandlerWithProtocol creates a new subscription handler speaking the given protocol.
+func NewHandlerWithProtocol(logger abstractlogger.Logger, client Client, executorPool ExecutorPool, protocol Protocol) (*Handler, error) {
 	keepAliveInterval, err := time.ParseDuration(DefaultKeepAliveInterval)
 	if err != nil {
 		return nil, err
@@ -92,9 +107,16 @@ func NewHandler(logger abstractlogger.Logger, client Client, executorPool Execut
 		return nil, err
 	}
 
+	connectionInitTimeout, err := time.ParseDuration(DefaultConnectionInitTimeout)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Handler{
 		logger:                     logger,
 		client:                     client,
+		protocol:                   protocol,
+		connectionInitTimeout:      connectionInitTimeout,
 		keepAliveInterval:          keepAliveInterval,
 		subscriptionUpdateInterval: subscriptionUpdateInterval,
 		subCancellations:           subscriptionCancellations{},
@@ -110,10 +132,18 @@ func NewHandler(logger abstractlogger.Logger, client Client, executorPool Execut
 
 // Handle will handle the subscription connection.
 func (h *Handler) Handle(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
 	defer func() {
+		h.subCancellationsMu.Lock()
 		h.subCancellations.CancelAll()
+		h.subCancellationsMu.Unlock()
+		cancel()
 	}()
 
+	if h.protocol == ProtocolGraphQLTransportWS {
+		go h.handleConnectionInitTimeout(ctx)
+	}
+
 	for {
 		if !h.client.IsConnected() {
 			h.logger.Debug("subscription.Handler.Handle()",
@@ -130,18 +160,21 @@ func (h *Handler) Handle(ctx context.Context) {
 				abstractlogger.Any("message", message),
 			)
 
-			h.handleConnectionError("could not read message from client")
+			if h.protocol == ProtocolGraphQLTransportWS {
+				h.closeWithReason(CloseCodeInvalidMessage, "Invalid message received")
+			} else {
+				h.handleConnectionError("could not read message from client")
+			}
 		} else if message != nil {
-			switch message.Type {
-			case MessageTypeConnectionInit:
-				h.handleInit()
-				go h.handleKeepAlive(ctx)
-			case MessageTypeStart:
-				h.handleStart(message.Id, message.Payload)
-			case MessageTypeStop:
-				h.handleStop(message.Id)
-			case MessageTypeConnectionTerminate:
-				h.handleConnectionTerminate()
+			var stop bool
+			switch h.protocol {
+			case ProtocolGraphQLTransportWS:
+				stop = h.handleGraphQLTransportWSMessage(ctx, message)
+			default:
+				stop = h.handleGraphQLWSMessage(ctx, message)
+			}
+
+			if stop {
 				return
 			}
 		}
@@ -155,6 +188,65 @@ func (h *Handler) Handle(ctx context.Context) {
 	}
 }
 
+// handleGraphQLWSMessage will handle a message of the legacy graphql-ws protocol.
+// It returns true when the connection has been terminated.
+func (h *Handler) handleGraphQLWSMessage(ctx context.Context, message *Message) (stop bool) {
+	switch message.Type {
+	case MessageTypeConnectionInit:
+		h.handleInit()
+		go h.handleKeepAlive(ctx)
+	case MessageTypeStart:
+		h.handleStart(message.Id, message.Payload)
+	case MessageTypeStop:
+		h.handleStop(message.Id)
+	case MessageTypeConnectionTerminate:
+		h.handleConnectionTerminate()
+		return true
+	}
+
+	return false
+}
+
+// handleGraphQLTransportWSMessage will handle a message of the graphql-transport-ws protocol.
+// Protocol violations close the connection with the matching close code.
+// It returns true when the connection has been closed.
+func (h *Handler) handleGraphQLTransportWSMessage(ctx context.Context, message *Message) (stop bool) {
+	switch message.Type {
+	case MessageTypeConnectionInit:
+		if !atomic.CompareAndSwapInt32(&h.initialized, 0, 1) {
+			h.closeWithReason(CloseCodeTooManyInitialisationReqs, "Too many initialisation requests")
+			return true
+		}
+		h.handleInit()
+		go h.handleKeepAlive(ctx)
+	case MessageTypePing:
+		h.sendPong(message.Payload)
+	case MessageTypePong:
+		// pong messages are only heartbeats, there is nothing to do
+	case MessageTypeSubscribe:
+		if atomic.LoadInt32(&h.initialized) == 0 {
+			h.closeWithReason(CloseCodeUnauthorized, "Unauthorized")
+			return true
+		}
+		if message.Id == "" {
+			h.closeWithReason(CloseCodeInvalidMessage, "Invalid message received")
+			return true
+		}
+		if h.hasSubscription(message.Id) {
+			h.closeWithReason(CloseCodeSubscriberAlreadyExists, fmt.Sprintf("Subscriber for %s already exists", message.Id))
+			return true
+		}
+		h.handleStart(message.Id, message.Payload)
+	case MessageTypeComplete:
+		h.cancelSubscription(message.Id)
+	default:
+		h.closeWithReason(CloseCodeInvalidMessage, "Invalid message received")
+		return true
+	}
+
+	return false
+}
+
 // ChangeKeepAliveInterval can be used to change the keep alive interval.
 func (h *Handler) ChangeKeepAliveInterval(d time.Duration) {
 	h.keepAliveInterval = d
@@ -165,6 +257,16 @@ func (h *Handler) ChangeSubscriptionUpdateInterval(d time.Duration) {
 	h.subscriptionUpdateInterval = d
 }
 
+// ChangeConnectionInitTimeout can be used to change the connection init timeout of the graphql-transport-ws protocol.
+func (h *Handler) ChangeConnectionInitTimeout(d time.Duration) {
+	h.connectionInitTimeout = d
+}
+
+// Protocol returns the protocol which is spoken with the client.
+func (h *Handler) Protocol() Protocol {
+	return h.protocol
+}
+
 // handleInit will handle an init message.
 func (h *Handler) handleInit() {
 	ackMessage := Message{
@@ -197,7 +299,9 @@ func (h *Handler) handleStart(id string, payload []byte) {
 	}
 
 	if executor.OperationType() == ast.OperationTypeSubscription {
+		h.subCancellationsMu.Lock()
 		ctx := h.subCancellations.Add(id)
+		h.subCancellationsMu.Unlock()
 		go h.startSubscription(ctx, id, executor)
 		return
 	}
@@ -270,7 +374,13 @@ func (h *Handler) startSubscription(ctx context.Context, id string, executor Exe
 
 	defer h.bufferPool.Put(buf)
 
-	h.executeSubscription(buf, id, executor)
+	ok := h.executeSubscription(buf, id, executor)
+
+	// graphql-transport-ws subscriptions end with the upstream subscription instead of being executed again
+	if h.protocol == ProtocolGraphQLTransportWS {
+		h.completeSubscription(ctx, id, ok)
+		return
+	}
 
 	for {
 		buf.Reset()
@@ -284,8 +394,25 @@ func (h *Handler) startSubscription(ctx context.Context, id string, executor Exe
 
 }
 
+// completeSubscription removes a graphql-transport-ws subscription which has ended and sends complete to the client.
+// Subscriptions completed by the client or ended with an error message are not completed again.
+func (h *Handler) completeSubscription(ctx context.Context, id string, ok bool) {
+	h.subCancellationsMu.Lock()
+	if ctx.Err() != nil {
+		h.subCancellationsMu.Unlock()
+		return
+	}
+	h.subCancellations.Cancel(id)
+	h.subCancellationsMu.Unlock()
+
+	if ok {
+		h.sendComplete(id)
+	}
+}
+
 // executeSubscription will keep execution the subscription until it ends.
-func (h *Handler) executeSubscription(buf *graphql.EngineResultWriter, id string, executor Executor) {
+// It returns false if the execution failed and an error has been sent to the client.
+func (h *Handler) executeSubscription(buf *graphql.EngineResultWriter, id string, executor Executor) (ok bool) {
 	buf.SetFlushCallback(func(data []byte) {
 		h.logger.Debug("subscription.Handle.executeSubscription()",
 			abstractlogger.ByteString("execution_result", data),
@@ -301,7 +428,7 @@ func (h *Handler) executeSubscription(buf *graphql.EngineResultWriter, id string
 		)
 
 		h.handleError(id, graphql.RequestErrorsFromError(err))
-		return
+		return false
 	}
 
 	if buf.Len() > 0 {
@@ -311,14 +438,30 @@ func (h *Handler) executeSubscription(buf *graphql.EngineResultWriter, id string
 		)
 		h.sendData(id, data)
 	}
+	return true
 }
 
 // handleStop will handle a stop message,
 func (h *Handler) handleStop(id string) {
-	h.subCancellations.Cancel(id)
+	h.cancelSubscription(id)
 	h.sendComplete(id)
 }
 
+// cancelSubscription cancels the subscription with the given id if it is active.
+func (h *Handler) cancelSubscription(id string) {
+	h.subCancellationsMu.Lock()
+	defer h.subCancellationsMu.Unlock()
+	h.subCancellations.Cancel(id)
+}
+
+// hasSubscription reports whether a subscription with the given id is active.
+func (h *Handler) hasSubscription(id string) bool {
+	h.subCancellationsMu.Lock()
+	defer h.subCancellationsMu.Unlock()
+	_, exists := h.subCancellations[id]
+	return exists
+}
+
 // sendData will send a data message to the client.
 func (h *Handler) sendData(id string, responseData []byte) {
 	dataMessage := Message{
@@ -327,6 +470,10 @@ func (h *Handler) sendData(id string, responseData []byte) {
 		Payload: responseData,
 	}
 
+	if h.protocol == ProtocolGraphQLTransportWS {
+		dataMessage.Type = MessageTypeNext
+	}
+
 	err := h.client.WriteToClient(dataMessage)
 	if err != nil {
 		h.logger.Error("subscription.Handler.sendData()",
@@ -375,11 +522,16 @@ func (h *Handler) handleKeepAlive(ctx context.Context) {
 }
 
 // sendKeepAlive will send a keep alive message to the client.
+// The graphql-transport-ws protocol uses ping messages as keep alive.
 func (h *Handler) sendKeepAlive() {
 	keepAliveMessage := Message{
 		Type: MessageTypeConnectionKeepAlive,
 	}
 
+	if h.protocol == ProtocolGraphQLTransportWS {
+		keepAliveMessage.Type = MessageTypePing
+	}
+
 	err := h.client.WriteToClient(keepAliveMessage)
 	if err != nil {
 		h.logger.Error("subscription.Handler.sendKeepAlive()",
@@ -388,6 +540,53 @@ func (h *Handler) sendKeepAlive() {
 	}
 }
 
+// sendPong will answer a ping message of the client.
+func (h *Handler) sendPong(payload json.RawMessage) {
+	pongMessage := Message{
+		Type:    MessageTypePong,
+		Payload: payload,
+	}
+
+	err := h.client.WriteToClient(pongMessage)
+	if err != nil {
+		h.logger.Error("subscription.Handler.sendPong()",
+			abstractlogger.Error(err),
+		)
+	}
+}
+
+// handleConnectionInitTimeout will close the connection if the client does not initialize it in time.
+func (h *Handler) handleConnectionInitTimeout(ctx context.Context) {
+	timer := time.NewTimer(h.connectionInitTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+		if atomic.LoadInt32(&h.initialized) == 0 {
+			h.closeWithReason(CloseCodeConnectionInitTimeout, "Connection initialisation timeout")
+		}
+	}
+}
+
+// closeWithReason will close the connection with the given close code and reason if the client supports it.
+func (h *Handler) closeWithReason(code int, reason string) {
+	var err error
+	if closer, ok := h.client.(ClientCloser); ok {
+		err = closer.DisconnectWithReason(code, reason)
+	} else {
+		err = h.client.Disconnect()
+	}
+
+	if err != nil {
+		h.logger.Error("subscription.Handler.closeWithReason()",
+			abstractlogger.Error(err),
+			abstractlogger.Int("code", code),
+			abstractlogger.String("reason", reason),
+		)
+	}
+}
+
 // handleConnectionError will handle a connection error message.
 func (h *Handler) handleConnectionError(errorPayload interface{}) {
 	payloadBytes, err := json.Marshal(errorPayload)
@@ -444,5 +643,7 @@ func (h *Handler) handleError(id string, errors graphql.RequestErrors) {
 
 // ActiveSubscriptions will return the actual number of active subscriptions for that client.
 func (h *Handler) ActiveSubscriptions() int {
+	h.subCancellationsMu.Lock()
+	defer h.subCancellationsMu.Unlock()
 	return len(h.subCancellations)
 }
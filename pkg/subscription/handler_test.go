@@ -0,0 +1,283 @@
+package subscription
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pvormste/graphql-go-tools/pkg/ast"
+	"github.com/pvormste/graphql-go-tools/pkg/engine/resolve"
+)
+
+type fakeExecutorPool struct{}
+
+func (f *fakeExecutorPool) Get(payload []byte) (Executor, error) {
+	switch string(payload) {
+	case `{"query":"subscription { counter }"}`:
+		return &fakeExecutor{operationType: ast.OperationTypeSubscription}, nil
+	case `{"query":"subscription { lastCounter }"}`:
+		return &fakeExecutor{operationType: ast.OperationTypeSubscription, ends: true}, nil
+	default:
+		return &fakeExecutor{operationType: ast.OperationTypeQuery}, nil
+	}
+}
+
+func (f *fakeExecutorPool) Put(_ Executor) error {
+	return nil
+}
+
+// fakeExecutor resolves subscriptions until their context is done, unless they end after the first event.
+type fakeExecutor struct {
+	operationType ast.OperationType
+	ends          bool
+	ctx           context.Context
+}
+
+func (f *fakeExecutor) Execute(writer resolve.FlushWriter) error {
+	_, err := writer.Write([]byte(`{"data":{"counter":1}}`))
+	if err != nil {
+		return err
+	}
+	if f.operationType == ast.OperationTypeSubscription {
+		writer.Flush()
+		if !f.ends {
+			<-f.ctx.Done()
+		}
+	}
+	return nil
+}
+
+func (f *fakeExecutor) OperationType() ast.OperationType {
+	return f.operationType
+}
+
+func (f *fakeExecutor) SetContext(ctx context.Context) {
+	f.ctx = ctx
+}
+
+func (f *fakeExecutor) Reset() {}
+
+func TestHandler_Handle_GraphQLTransportWS(t *testing.T) {
+	queryPayload := []byte(`{"query":"{ counter }"}`)
+	subscriptionPayload := []byte(`{"query":"subscription { counter }"}`)
+	endingSubscriptionPayload := []byte(`{"query":"subscription { lastCounter }"}`)
+
+	setup := func(t *testing.T) (*mockClient, context.CancelFunc) {
+		client := newMockClient()
+		handler, err := NewHandlerWithProtocol(abstractlogger.NoopLogger, client, &fakeExecutorPool{}, ProtocolGraphQLTransportWS)
+		require.NoError(t, err)
+		handler.ChangeSubscriptionUpdateInterval(time.Hour)
+		handler.ChangeKeepAliveInterval(time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go handler.Handle(ctx)
+		return client, cancel
+	}
+
+	messageTypes := func(messages []Message) []string {
+		types := make([]string, 0, len(messages))
+		for _, message := range messages {
+			types = append(types, message.Type)
+		}
+		return types
+	}
+
+	t.Run("should acknowledge connection init", func(t *testing.T) {
+		client, cancel := setup(t)
+		defer cancel()
+
+		client.prepareConnectionInitMessage().withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return client.hasMoreMessagesThan(0)
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, []string{MessageTypeConnectionAck}, messageTypes(client.readFromServer()))
+	})
+
+	t.Run("should answer ping with pong", func(t *testing.T) {
+		client, cancel := setup(t)
+		defer cancel()
+
+		client.preparePingMessage([]byte(`{"foo":"bar"}`)).withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return client.hasMoreMessagesThan(0)
+		}, time.Second, time.Millisecond)
+
+		messages := client.readFromServer()
+		assert.Equal(t, MessageTypePong, messages[0].Type)
+		assert.Equal(t, `{"foo":"bar"}`, string(messages[0].Payload))
+	})
+
+	t.Run("should send next and complete for a query", func(t *testing.T) {
+		client, cancel := setup(t)
+		defer cancel()
+
+		client.prepareConnectionInitMessage().withoutError().and().send()
+		client.prepareSubscribeMessage("1", queryPayload).withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return client.hasMoreMessagesThan(2)
+		}, time.Second, time.Millisecond)
+
+		messages := client.readFromServer()
+		assert.Equal(t, []string{MessageTypeConnectionAck, MessageTypeNext, MessageTypeComplete}, messageTypes(messages))
+		assert.Equal(t, "1", messages[1].Id)
+		assert.Equal(t, `{"data":{"counter":1}}`, string(messages[1].Payload))
+	})
+
+	t.Run("should send next for a subscription and stop it on complete", func(t *testing.T) {
+		client, cancel := setup(t)
+		defer cancel()
+
+		client.prepareConnectionInitMessage().withoutError().and().send()
+		client.prepareSubscribeMessage("1", subscriptionPayload).withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return client.hasMoreMessagesThan(1)
+		}, time.Second, time.Millisecond)
+
+		messages := client.readFromServer()
+		assert.Equal(t, []string{MessageTypeConnectionAck, MessageTypeNext}, messageTypes(messages[:2]))
+
+		client.prepareCompleteMessage("1").withoutError().and().send()
+		client.prepareSubscribeMessage("1", subscriptionPayload).withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return client.hasMoreMessagesThan(2)
+		}, time.Second, time.Millisecond)
+
+		code, _ := client.closedWith()
+		assert.Equal(t, 0, code)
+		assert.True(t, client.IsConnected())
+	})
+
+	t.Run("should complete a subscription once it ends", func(t *testing.T) {
+		client, cancel := setup(t)
+		defer cancel()
+
+		client.prepareConnectionInitMessage().withoutError().and().send()
+		client.prepareSubscribeMessage("1", endingSubscriptionPayload).withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return client.hasMoreMessagesThan(2)
+		}, time.Second, time.Millisecond)
+
+		messages := client.readFromServer()
+		assert.Equal(t, []string{MessageTypeConnectionAck, MessageTypeNext, MessageTypeComplete}, messageTypes(messages))
+		assert.Equal(t, "1", messages[2].Id)
+
+		client.prepareSubscribeMessage("1", endingSubscriptionPayload).withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return client.hasMoreMessagesThan(4)
+		}, time.Second, time.Millisecond)
+		assert.True(t, client.IsConnected())
+	})
+
+	t.Run("should close with 4401 when subscribing before connection init", func(t *testing.T) {
+		client, cancel := setup(t)
+		defer cancel()
+
+		client.prepareSubscribeMessage("1", queryPayload).withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return !client.IsConnected()
+		}, time.Second, time.Millisecond)
+
+		code, reason := client.closedWith()
+		assert.Equal(t, CloseCodeUnauthorized, code)
+		assert.Equal(t, "Unauthorized", reason)
+	})
+
+	t.Run("should close with 4409 when subscriber already exists", func(t *testing.T) {
+		client, cancel := setup(t)
+		defer cancel()
+
+		client.prepareConnectionInitMessage().withoutError().and().send()
+		client.prepareSubscribeMessage("1", subscriptionPayload).withoutError().and().send()
+		client.prepareSubscribeMessage("1", subscriptionPayload).withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return !client.IsConnected()
+		}, time.Second, time.Millisecond)
+
+		code, reason := client.closedWith()
+		assert.Equal(t, CloseCodeSubscriberAlreadyExists, code)
+		assert.Equal(t, "Subscriber for 1 already exists", reason)
+	})
+
+	t.Run("should close with 4429 on second connection init", func(t *testing.T) {
+		client, cancel := setup(t)
+		defer cancel()
+
+		client.prepareConnectionInitMessage().withoutError().and().send()
+		client.prepareConnectionInitMessage().withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return !client.IsConnected()
+		}, time.Second, time.Millisecond)
+
+		code, _ := client.closedWith()
+		assert.Equal(t, CloseCodeTooManyInitialisationReqs, code)
+	})
+
+	t.Run("should close with 4400 on unknown message type", func(t *testing.T) {
+		client, cancel := setup(t)
+		defer cancel()
+
+		client.prepareStartMessage("1", queryPayload).withoutError().and().send()
+		assert.Eventually(t, func() bool {
+			return !client.IsConnected()
+		}, time.Second, time.Millisecond)
+
+		code, _ := client.closedWith()
+		assert.Equal(t, CloseCodeInvalidMessage, code)
+	})
+
+	t.Run("should close with 4408 when connection init is missing", func(t *testing.T) {
+		client := newMockClient()
+		handler, err := NewHandlerWithProtocol(abstractlogger.NoopLogger, client, &fakeExecutorPool{}, ProtocolGraphQLTransportWS)
+		require.NoError(t, err)
+		handler.ChangeConnectionInitTimeout(10 * time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go handler.Handle(ctx)
+
+		assert.Eventually(t, func() bool {
+			return !client.IsConnected()
+		}, time.Second, time.Millisecond)
+
+		code, reason := client.closedWith()
+		assert.Equal(t, CloseCodeConnectionInitTimeout, code)
+		assert.Equal(t, "Connection initialisation timeout", reason)
+
+		client.preparePingMessage(nil).withoutError().and().send()
+	})
+}
+
+func TestHandler_Handle_GraphQLWS(t *testing.T) {
+	client := newMockClient()
+	handler, err := NewHandler(abstractlogger.NoopLogger, client, &fakeExecutorPool{})
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolGraphQLWS, handler.Protocol())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.Handle(ctx)
+
+	client.prepareConnectionInitMessage().withoutError().and().send()
+	client.prepareStartMessage("1", []byte(`{"query":"{ counter }"}`)).withoutError().and().send()
+	assert.Eventually(t, func() bool {
+		return client.hasMoreMessagesThan(2)
+	}, time.Second, time.Millisecond)
+
+	messages := client.readFromServer()
+	assert.Equal(t, MessageTypeConnectionAck, messages[0].Type)
+	assert.Equal(t, MessageTypeData, messages[1].Type)
+	assert.Equal(t, MessageTypeComplete, messages[2].Type)
+}
+
+func TestIsSupportedProtocol(t *testing.T) {
+	assert.True(t, IsSupportedProtocol("graphql-ws"))
+	assert.True(t, IsSupportedProtocol("graphql-transport-ws"))
+	assert.False(t, IsSupportedProtocol("mqtt"))
+
+	assert.Equal(t, ProtocolGraphQLTransportWS, ProtocolFromString("graphql-transport-ws"))
+	assert.Equal(t, ProtocolGraphQLWS, ProtocolFromString(""))
+}
@@ -2,10 +2,14 @@ package subscription
 
 import (
 	"errors"
+	"sync"
 )
 
 type mockClient struct {
+	mu                 sync.Mutex
 	messagesFromServer []Message
+	closeCode          int
+	closeReason        string
 	messageToServer    *Message
 	err                error
 	messagePipe        chan *Message
@@ -21,8 +25,10 @@ func newMockClient() *mockClient {
 }
 
 func (c *mockClient) ReadFromClient() (*Message, error) {
-	returnErr := c.err
 	returnMessage := <-c.messagePipe
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	returnErr := c.err
 	if returnErr != nil {
 		return nil, returnErr
 	}
@@ -33,25 +39,49 @@ func (c *mockClient) ReadFromClient() (*Message, error) {
 }
 
 func (c *mockClient) WriteToClient(message Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.messagesFromServer = append(c.messagesFromServer, message)
 	return c.err
 }
 
 func (c *mockClient) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.connected
 }
 
 func (c *mockClient) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.connected = false
 	return nil
 }
 
+func (c *mockClient) DisconnectWithReason(code int, reason string) error {
+	c.mu.Lock()
+	c.closeCode = code
+	c.closeReason = reason
+	c.mu.Unlock()
+	return c.Disconnect()
+}
+
+func (c *mockClient) closedWith() (code int, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeCode, c.closeReason
+}
+
 func (c *mockClient) hasMoreMessagesThan(num int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return len(c.messagesFromServer) > num
 }
 
 func (c *mockClient) readFromServer() []Message {
-	return c.messagesFromServer
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message{}, c.messagesFromServer...)
 }
 
 func (c *mockClient) prepareConnectionInitMessage() *mockClient {
@@ -82,6 +112,34 @@ func (c *mockClient) prepareStopMessage(id string) *mockClient {
 	return c
 }
 
+func (c *mockClient) prepareSubscribeMessage(id string, payload []byte) *mockClient {
+	c.messageToServer = &Message{
+		Id:      id,
+		Type:    MessageTypeSubscribe,
+		Payload: payload,
+	}
+
+	return c
+}
+
+func (c *mockClient) prepareCompleteMessage(id string) *mockClient {
+	c.messageToServer = &Message{
+		Id:   id,
+		Type: MessageTypeComplete,
+	}
+
+	return c
+}
+
+func (c *mockClient) preparePingMessage(payload []byte) *mockClient {
+	c.messageToServer = &Message{
+		Type:    MessageTypePing,
+		Payload: payload,
+	}
+
+	return c
+}
+
 func (c *mockClient) prepareConnectionTerminateMessage() *mockClient {
 	c.messageToServer = &Message{
 		Type: MessageTypeConnectionTerminate,
@@ -97,11 +155,15 @@ func (c *mockClient) send() bool {
 }
 
 func (c *mockClient) withoutError() *mockClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.err = nil
 	return c
 }
 
 func (c *mockClient) withError() *mockClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.err = errors.New("error")
 	return c
 }
@@ -111,12 +173,16 @@ func (c *mockClient) and() *mockClient {
 }
 
 func (c *mockClient) reset() *mockClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.messagesFromServer = []Message{}
 	return c
 }
 
 func (c *mockClient) reconnect() *mockClient {
 	c.reset()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.connected = true
 	return c
 }
@@ -0,0 +1,60 @@
+package subscription
+
+// Protocol is the websocket sub-protocol which is spoken between the subscription handler and the client.
+type Protocol string
+
+const (
+	// ProtocolGraphQLWS is the legacy protocol of subscriptions-transport-ws (start/stop/data/ka).
+	ProtocolGraphQLWS Protocol = "graphql-ws"
+	// ProtocolGraphQLTransportWS is the protocol of graphql-ws (subscribe/next/complete/ping/pong).
+	ProtocolGraphQLTransportWS Protocol = "graphql-transport-ws"
+)
+
+const (
+	MessageTypeSubscribe = "subscribe"
+	MessageTypeNext      = "next"
+	MessageTypePing      = "ping"
+	MessageTypePong      = "pong"
+
+	DefaultConnectionInitTimeout = "15s"
+)
+
+// Close codes defined by the graphql-transport-ws protocol.
+const (
+	CloseCodeInternalServerError       = 4500
+	CloseCodeInvalidMessage            = 4400
+	CloseCodeUnauthorized              = 4401
+	CloseCodeConnectionInitTimeout     = 4408
+	CloseCodeSubscriberAlreadyExists   = 4409
+	CloseCodeTooManyInitialisationReqs = 4429
+)
+
+// SupportedProtocols contains all sub-protocols the Handler is able to speak ordered by preference.
+var SupportedProtocols = []Protocol{ProtocolGraphQLTransportWS, ProtocolGraphQLWS}
+
+// IsSupportedProtocol returns true if the given websocket sub-protocol can be handled by the Handler.
+// It can be used as ws.HTTPUpgrader.Protocol to negotiate the protocol from the Sec-WebSocket-Protocol header.
+func IsSupportedProtocol(protocol string) bool {
+	for _, supported := range SupportedProtocols {
+		if string(supported) == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// ProtocolFromString returns the protocol for a negotiated websocket sub-protocol.
+// Clients which don't send a sub-protocol are treated as legacy clients.
+func ProtocolFromString(protocol string) Protocol {
+	if protocol == string(ProtocolGraphQLTransportWS) {
+		return ProtocolGraphQLTransportWS
+	}
+	return ProtocolGraphQLWS
+}
+
+// ClientCloser can be implemented by a Client which is able to close the connection with a status code and a reason.
+// It's used by the graphql-transport-ws protocol to signal protocol violations to the client.
+// Clients not implementing it are disconnected without a reason.
+type ClientCloser interface {
+	DisconnectWithReason(code int, reason string) error
+}